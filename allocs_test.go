@@ -0,0 +1,38 @@
+//go:build !race
+
+// The race detector allocates on its own, so the allocations are only counted without it.
+
+package snap
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiffAllocs(t *testing.T) {
+	s := snapNoUpdate(t, "usage: tool\n\nflags:\n  -v\n")
+	got := "usage: tool\n\nflags:\n  -v\n"
+	if allocs := testing.AllocsPerRun(100, func() { s.Diff(got) }); allocs != 0 {
+		t.Errorf("expected a matching snapshot without markers to be compared without allocating, got %v allocations", allocs)
+	}
+}
+
+func TestDiffJSONAllocs(t *testing.T) {
+	type user struct {
+		Name  string   `json:"name"`
+		Roles []string `json:"roles"`
+	}
+	value := user{Name: "Ada", Roles: []string{"admin"}}
+	s := snapNoUpdate(t, `{
+  "name": "Ada",
+  "roles": [
+    "admin"
+  ]
+}`)
+	encoding := testing.AllocsPerRun(100, func() { json.MarshalIndent(value, "", "  ") })
+	// Beyond encoding the value, a matching snapshot costs the encoder's buffer and the copy of
+	// the Snapshot marking it as JSON, but not a copy of the value.
+	if allocs := testing.AllocsPerRun(100, func() { s.DiffJSON(value, "  ") }); allocs > encoding+3 {
+		t.Errorf("expected a matching DiffJSON to allocate at most 3 times more than encoding the value (%v), got %v", encoding, allocs)
+	}
+}
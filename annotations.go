@@ -0,0 +1,56 @@
+package snap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Owner tags the snapshot with its owner, like a team handle. The owner is shown when the snapshot
+// differs, and included in reviews, HTML reports and baselines, so that the right people get
+// notified when a shared snapshot changes.
+//
+//	snap.Snap(t, want).Owner("@payments-team").Diff(got)
+func (s *Snapshot) Owner(owner string) *Snapshot {
+	c := *s
+	c.owner = owner
+	return &c
+}
+
+// Note attaches a note to the snapshot, explaining why it looks the way it does. Notes are shown
+// when the snapshot differs, and included in reviews and HTML reports.
+//
+//	snap.Snap(t, want).Note("covers issue #42: pagination off-by-one").Diff(got)
+func (s *Snapshot) Note(note string) *Snapshot {
+	c := *s
+	c.notes = append(s.notes[:len(s.notes):len(s.notes)], note)
+	return &c
+}
+
+// Seed attaches the seed of the random number generator used by the test, like a simulation
+// does, to the snapshot. The seed is shown when the snapshot differs, to reproduce the failing
+// run.
+//
+//	seed := time.Now().UnixNano()
+//	rng := rand.New(rand.NewSource(seed))
+//	snap.Snap(t, want).Seed(seed).Diff(simulate(rng))
+func (s *Snapshot) Seed(seed any) *Snapshot {
+	c := *s
+	c.seed = seed
+	return &c
+}
+
+// annotations returns the lines describing the snapshot appended to its failure messages.
+func (s *Snapshot) annotations() string {
+	var sb strings.Builder
+	if s.owner != "" {
+		fmt.Fprintf(&sb, "snap: Owner: %s\n", s.owner)
+	}
+	if s.seed != nil {
+		fmt.Fprintf(&sb, "snap: Seed: %v\n", s.seed)
+	}
+	for _, note := range s.notes {
+		fmt.Fprintf(&sb, "snap: Note: %s\n", note)
+	}
+	sb.WriteString(s.stampWarning())
+	return sb.String()
+}
@@ -0,0 +1,59 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnnotations(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "report")
+	t.Setenv("SNAP_HTML_REPORT", dir)
+	t.Setenv("CI", "true")
+	var reviews []Review
+	SetReviewer(ReviewerFunc(func(r Review) (string, error) {
+		reviews = append(reviews, r)
+		return "https://review.example.com/1", nil
+	}))
+	t.Cleanup(func() { SetReviewer(nil) })
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "want").Owner("@payments-team").Note("covers issue #42").Diff("got")
+
+	if len(ft.errors) != 1 || !strings.HasSuffix(ft.errors[0], "snap: Owner: @payments-team\nsnap: Note: covers issue #42\n") {
+		t.Errorf("expected the failure to show the owner and notes, got %q", ft.errors)
+	}
+	if len(reviews) != 1 || reviews[0].Owner != "@payments-team" || len(reviews[0].Notes) != 1 {
+		t.Errorf("expected the review to include the owner and notes, got %+v", reviews)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "TestAnnotations-1.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "<p>Owner: @payments-team</p>\n<p>Note: covers issue #42</p>") {
+		t.Errorf("expected the report to include the owner and notes, got:\n%s", b)
+	}
+}
+
+func TestNoteCopies(t *testing.T) {
+	s := snapNoUpdate(t, "").Note("a")
+	b, c := s.Note("b"), s.Note("c")
+	if len(s.notes) != 1 || b.notes[1] != "b" || c.notes[1] != "c" {
+		t.Errorf("expected Note to leave the receiver unchanged, got %q, %q and %q", s.notes, b.notes, c.notes)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	var failures []FailureInfo
+	onFailure(t, func(f FailureInfo) { failures = append(failures, f) })
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "want").Seed(int64(1234)).Diff("got")
+	if len(ft.errors) != 1 || !strings.HasSuffix(ft.errors[0], "snap: Seed: 1234\n") {
+		t.Errorf("expected the failure to show the seed, got %q", ft.errors)
+	}
+	if len(failures) != 1 || failures[0].Seed != int64(1234) {
+		t.Errorf("expected the failure hooks to get the seed, got %+v", failures)
+	}
+}
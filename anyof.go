@@ -0,0 +1,53 @@
+package snap
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// AnyOf creates a snapshot that matches a value equal to any of texts, for values with a few
+// legitimate outcomes, like the two orders in which two concurrent events can be logged, that
+// markers would match too loosely:
+//
+//	snap.AnyOf(t, "ping\npong", "pong\nping").Diff(events)
+//
+// Failures show the diff with the closest snapshot. AnyOf snapshots are not updated
+// automatically, since the value doesn't tell which snapshot to update.
+func AnyOf(t testing.TB, texts ...string) *Snapshot {
+	if len(texts) == 0 {
+		t.Errorf("snap: AnyOf needs at least one snapshot")
+		texts = []string{""}
+	}
+	s := newSnapshot(t, texts[0], 0)
+	s.candidates = texts
+	return s
+}
+
+// diffAnyOf is [Snapshot.Diff] for [AnyOf] snapshots.
+func (s *Snapshot) diffAnyOf(got string) {
+	s.t.Helper()
+	closest, closestWant, closestGot, closestLines := 0, "", "", -1
+	for i, text := range s.candidates {
+		c := *s
+		c.text = text
+		want, prepared, _, ok := c.prepare(got)
+		if !ok {
+			return
+		}
+		if equal, _ := c.compare(prepared, want); equal || (c.ignoreOrder && sameLines(want, prepared)) {
+			c.runChecks(prepared, want)
+			return
+		}
+		stats := ComputeDiff(want, prepared).Stats
+		if lines := stats.Added + stats.Removed; closestLines < 0 || lines < closestLines {
+			closest, closestWant, closestGot, closestLines = i, want, prepared, lines
+		}
+	}
+	s.mismatch(closestWant, closestGot, "snap: Value at %s matches none of the %d snapshots, the closest is snapshot %d: (-want +got):\n%s%s",
+		s.findLiteral(), len(s.candidates), closest+1, cmp.Diff(closestWant, closestGot), s.annotations())
+	if s.shouldUpdate() {
+		s.logf(slog.LevelDebug, "AnyOf snapshots are not updated automatically, edit them by hand.")
+	}
+}
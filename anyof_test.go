@@ -0,0 +1,18 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnyOf(t *testing.T) {
+	AnyOf(t, "ping\npong", "pong\nping").Diff("pong\nping")
+	AnyOf(t, "took <snap:ignore>ms", "cached").Diff("took 12ms")
+
+	ft := newFakeT(t)
+	AnyOf(ft, "a\nb\nc\nd", "w\nx\ny\nz").Diff("w\nx\ny\nq")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "matches none of the 2 snapshots, the closest is snapshot 2") ||
+		!strings.Contains(ft.errors[0], `"w\nx\ny\nq"`) {
+		t.Errorf("expected a diff with the closest snapshot, got %q", ft.errors)
+	}
+}
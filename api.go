@@ -0,0 +1,175 @@
+package snap
+
+import (
+	"bytes"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// PackageAPI renders the exported API of the package in dir: its exported constants, variables,
+// types, with their exported fields and methods, and functions, without documentation and sorted
+// by name. Snapshotting it makes accidental changes to the API fail the tests before a release:
+//
+//	snap.Snap(t, want).Diff(snap.PackageAPI(t, "."))
+//
+// The files of the package are the ones built for the current platform, without tests.
+func PackageAPI(t testing.TB, dir string) string {
+	t.Helper()
+	pkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		t.Errorf("snap: %v", err)
+		return ""
+	}
+
+	fset := token.NewFileSet()
+	var decls []apiDecl
+	for _, name := range pkg.GoFiles {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			t.Errorf("snap: %v", err)
+			return ""
+		}
+		decls = append(decls, exportedDecls(fset, f)...)
+	}
+	sort.SliceStable(decls, func(i, j int) bool { return decls[i].key < decls[j].key })
+
+	var sb strings.Builder
+	sb.WriteString("package " + pkg.Name + "\n")
+	for _, d := range decls {
+		sb.WriteString("\n" + d.text + "\n")
+	}
+	return sb.String()
+}
+
+// apiDecl is an exported declaration rendered by PackageAPI.
+type apiDecl struct {
+	key  string // Sorts methods after their type.
+	text string
+}
+
+// exportedDecls returns the exported declarations of f.
+func exportedDecls(fset *token.FileSet, f *ast.File) []apiDecl {
+	var decls []apiDecl
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			key := d.Name.Name
+			if d.Recv != nil {
+				recv := receiverType(d.Recv.List[0].Type)
+				if !ast.IsExported(recv) {
+					continue
+				}
+				key = recv + "." + d.Name.Name
+				for _, field := range d.Recv.List {
+					field.Names = nil
+				}
+			}
+			d.Doc, d.Body = nil, nil
+			decls = append(decls, apiDecl{key: key, text: render(fset, d)})
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if !s.Name.IsExported() {
+						continue
+					}
+					s.Doc, s.Comment = nil, nil
+					removeUnexported(s.Type)
+					decls = append(decls, apiDecl{key: s.Name.Name, text: "type " + render(fset, s)})
+				case *ast.ValueSpec:
+					for i, name := range s.Names {
+						if !name.IsExported() {
+							continue
+						}
+						v := &ast.ValueSpec{Names: []*ast.Ident{name}, Type: s.Type}
+						if i < len(s.Values) {
+							v.Values = []ast.Expr{s.Values[i]}
+						}
+						decls = append(decls, apiDecl{key: name.Name, text: d.Tok.String() + " " + render(fset, v)})
+					}
+				}
+			}
+		}
+	}
+	return decls
+}
+
+// receiverType returns the name of the type of a method receiver.
+func receiverType(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(e.X)
+	case *ast.IndexExpr:
+		return receiverType(e.X)
+	case *ast.IndexListExpr:
+		return receiverType(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+// removeUnexported removes the unexported fields and methods, and all comments, from the struct
+// and interface types in expr.
+func removeUnexported(expr ast.Expr) {
+	ast.Inspect(expr, func(n ast.Node) bool {
+		var fields *ast.FieldList
+		switch n := n.(type) {
+		case *ast.StructType:
+			fields = n.Fields
+		case *ast.InterfaceType:
+			fields = n.Methods
+		default:
+			return true
+		}
+		kept := fields.List[:0]
+		for _, field := range fields.List {
+			field.Doc, field.Comment = nil, nil
+			var names []*ast.Ident
+			for _, name := range field.Names {
+				if name.IsExported() {
+					names = append(names, name)
+				}
+			}
+			if len(field.Names) > 0 && len(names) == 0 {
+				continue
+			}
+			if len(field.Names) == 0 && !ast.IsExported(receiverType(field.Type)) {
+				continue // Unexported embedded type.
+			}
+			field.Names = names
+			kept = append(kept, field)
+		}
+		fields.List = kept
+		return true
+	})
+}
+
+// render prints node without the comments of its file, and without the blank lines left by the
+// removed comments and fields.
+func render(fset *token.FileSet, node any) string {
+	var buf bytes.Buffer
+	// Printing the node alone, rather than the file, leaves out the comments.
+	if err := (&printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}).Fprint(&buf, fset, node); err != nil {
+		return err.Error()
+	}
+	var lines []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
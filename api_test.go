@@ -0,0 +1,26 @@
+package snap
+
+import "testing"
+
+func TestPackageAPI(t *testing.T) {
+	Snap(t, `package api
+
+type Client struct {
+	Name string
+	io.Reader
+	B int
+}
+
+func (*Client) Do(req string) (string, error)
+
+var Default = New()
+
+type Handler interface {
+	Handle(string) error
+}
+
+func New() *Client
+
+const Version = "1.0"
+`).Diff(PackageAPI(t, "testdata/api"))
+}
@@ -0,0 +1,96 @@
+package snap
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CompatibilityCheck reports whether got is a backwards-compatible change of the snapshot want,
+// returning an error describing the incompatibility if it isn't.
+type CompatibilityCheck func(want string, got string) error
+
+// Compatible puts the snapshot in approval mode: instead of having to be equal, the value may
+// change in the ways check accepts, like an API response gaining new fields. A compatible change
+// passes and is logged, and SNAP_UPDATE=1 records it in the source code. An incompatible change
+// fails like a mismatch does, with the error of check, and is never updated automatically.
+//
+//	snap.Snap(t, `{"id": 1}`).Compatible(snap.JSONCompatible).Diff(response)
+func (s *Snapshot) Compatible(check CompatibilityCheck) *Snapshot {
+	c := *s
+	c.compatible = check
+	return &c
+}
+
+// JSONCompatible is a [CompatibilityCheck] for JSON documents, like the responses of a public API.
+// Fields can be added to the objects of want, but all of its fields must be kept with the same
+// JSON type, recursively. Arrays must keep their length. The values of strings, numbers and
+// booleans can change.
+func JSONCompatible(want string, got string) error {
+	var wantValue, gotValue any
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		return fmt.Errorf("the snapshot is not valid JSON: %w", err)
+	}
+	if err := json.Unmarshal([]byte(got), &gotValue); err != nil {
+		return fmt.Errorf("the value is not valid JSON: %w", err)
+	}
+	return jsonCompatible("$", wantValue, gotValue)
+}
+
+// jsonCompatible is [JSONCompatible] for the decoded values at path.
+func jsonCompatible(path string, want any, got any) error {
+	if jsonType(want) != jsonType(got) {
+		return fmt.Errorf("%s changed from %s to %s", path, jsonType(want), jsonType(got))
+	}
+
+	switch want := want.(type) {
+	case map[string]any:
+		got := got.(map[string]any)
+		var errs []string
+		for key, value := range want {
+			keyPath := path + "." + key
+			gotValue, ok := got[key]
+			if !ok {
+				errs = append(errs, keyPath+" was removed")
+				continue
+			}
+			if err := jsonCompatible(keyPath, value, gotValue); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		if len(errs) > 0 {
+			sort.Strings(errs)
+			return fmt.Errorf("%s", strings.Join(errs, "; "))
+		}
+	case []any:
+		got := got.([]any)
+		if len(want) != len(got) {
+			return fmt.Errorf("%s changed length from %d to %d", path, len(want), len(got))
+		}
+		for i := range want {
+			if err := jsonCompatible(fmt.Sprintf("%s[%d]", path, i), want[i], got[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonType returns the name of the JSON type of a value decoded by encoding/json.
+func jsonType(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
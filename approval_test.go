@@ -0,0 +1,55 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONCompatible(t *testing.T) {
+	cases := []struct {
+		want, got string
+		err       string
+	}{
+		{want: `{"id": 1}`, got: `{"id": 2, "name": "gopher"}`},
+		{want: `{"user": {"id": 1}, "tags": ["a"]}`, got: `{"user": {"id": 1, "admin": false}, "tags": ["b"]}`},
+		{want: `{"id": 1, "name": "gopher"}`, got: `{"id": 1}`, err: "$.name was removed"},
+		{want: `{"id": 1}`, got: `{"id": "1"}`, err: "$.id changed from number to string"},
+		{want: `{"tags": ["a"]}`, got: `{"tags": ["a", "b"]}`, err: "$.tags changed length from 1 to 2"},
+		{want: `{"tags": [{"id": 1}]}`, got: `{"tags": [{}]}`, err: "$.tags[0].id was removed"},
+		{want: `{"id": 1}`, got: `{"id": 1`, err: "the value is not valid JSON"},
+	}
+
+	for _, tc := range cases {
+		err := JSONCompatible(tc.want, tc.got)
+		if tc.err == "" && err != nil {
+			t.Errorf("JSONCompatible(%s, %s): unexpected error %v", tc.want, tc.got, err)
+		}
+		if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("JSONCompatible(%s, %s): expected error %q, got %v", tc.want, tc.got, tc.err, err)
+		}
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	ft := newFakeT(t)
+	snapNoUpdate(ft, `{"id": 1}`).Compatible(JSONCompatible).Diff(`{"id": 1, "name": "gopher"}`)
+	if len(ft.errors) != 0 || !containsLog(ft, "changed compatibly") {
+		t.Errorf("expected a compatible change to pass, got errors %q and logs %q", ft.errors, ft.logs)
+	}
+
+	ft = newFakeT(t)
+	snapNoUpdate(ft, `{"id": 1}`).Compatible(JSONCompatible).Diff(`{}`)
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "changed incompatibly: $.id was removed: (-want +got):") {
+		t.Errorf("expected an incompatible change error with the diff, got %q", ft.errors)
+	}
+
+	ft = newFakeT(t)
+	s, path := snapInFile(t, ft, "package x\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestX(t *testing.T) {\n\tsnap.Snap(t, `{\"id\": 1}`)\n}\n", `{"id": 1}`)
+	s.Compatible(JSONCompatible).Diff(`{}`)
+	if source := readFile(t, path); !strings.Contains(source, "`{\"id\": 1}`") {
+		t.Errorf("expected an incompatible change not to be updated, got:\n%s", source)
+	}
+	if len(ft.errors) != 1 {
+		t.Errorf("expected one error, got %q", ft.errors)
+	}
+}
@@ -0,0 +1,48 @@
+package snap
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// writeArtifacts writes want.txt, got.txt and diff.patch files of a mismatching snapshot to the
+// directory set with the SNAP_ARTIFACTS_DIR environment variable, for CI systems to upload and to
+// inspect full values the terminal truncated. The files of each snapshot go in a directory named
+// after its test and position, like TestLogin/login_test.go-42. If SNAP_ARTIFACTS_DIR is set but
+// empty, they're written under the test's temporary directory. The path of the files is logged.
+func (s *Snapshot) writeArtifacts(lit literal, want string, got string) {
+	s.t.Helper()
+
+	root, ok := os.LookupEnv("SNAP_ARTIFACTS_DIR")
+	if !ok {
+		return
+	}
+	if root == "" {
+		root = s.t.TempDir()
+	}
+
+	position := fmt.Sprintf("%s-%d", filepath.Base(lit.pos.Filename), lit.pos.Line)
+	dir := filepath.Join(root,
+		unsafeFileChars.ReplaceAllString(s.testName(), "_"),
+		unsafeFileChars.ReplaceAllString(position, "_"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		s.logf(slog.LevelWarn, "Failed to create artifacts directory: %s", err)
+		return
+	}
+
+	patch := "--- want.txt\n+++ got.txt\n" + ComputeDiff(want, got).Text
+	files := []struct{ name, data string }{
+		{"want.txt", want},
+		{"got.txt", got},
+		{"diff.patch", patch},
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, f.name), []byte(f.data), 0644); err != nil {
+			s.logf(slog.LevelWarn, "Failed to write artifacts: %s", err)
+			return
+		}
+	}
+	s.logf(slog.LevelInfo, "Wrote want.txt, got.txt and diff.patch to %s", dir)
+}
@@ -0,0 +1,57 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArtifacts(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "artifacts")
+	t.Setenv("SNAP_ARTIFACTS_DIR", dir)
+
+	ft := newFakeT(t)
+	s := snapNoUpdate(ft, "a\nwant")
+	s.Diff("a\ngot")
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "TestArtifacts", "*"))
+	if len(matches) != 1 {
+		t.Fatalf("expected one artifacts directory, got %q", matches)
+	}
+	if !containsLog(ft, "snap: Wrote want.txt, got.txt and diff.patch to "+matches[0]) {
+		t.Errorf("expected artifacts path to be logged, got %q", ft.logs)
+	}
+	for name, want := range map[string]string{
+		"want.txt":   "a\nwant",
+		"got.txt":    "a\ngot",
+		"diff.patch": "--- want.txt\n+++ got.txt\n@@ -1,2 +1,2 @@\n a\n-want\n+got\n",
+	} {
+		b, err := os.ReadFile(filepath.Join(matches[0], name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s: expected %q, got %q", name, want, b)
+		}
+	}
+}
+
+func TestArtifactsTempDir(t *testing.T) {
+	t.Setenv("SNAP_ARTIFACTS_DIR", "")
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "want").Diff("got")
+	if !containsLog(ft, "snap: Wrote want.txt, got.txt and diff.patch to ") {
+		t.Errorf("expected artifacts path to be logged, got %q", ft.logs)
+	}
+
+	ft = newFakeT(t)
+	os.Unsetenv("SNAP_ARTIFACTS_DIR")
+	snapNoUpdate(ft, "want").Diff("got")
+	for _, log := range ft.logs {
+		if strings.Contains(log, "want.txt") {
+			t.Errorf("expected no artifacts without SNAP_ARTIFACTS_DIR, got %q", log)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAt(t *testing.T) {
+	src := "package a\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestA(t *testing.T) {\n\tsnap.Snap(t, \"old\").Diff(run())\n}\n"
+	path := filepath.Join(t.TempDir(), "testdata", "src", "a", "a_test.go")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	At(t, path, 6, "old").Diff("old")
+
+	ft := newFakeT(t)
+	At(ft, path, 6, "old").Update().Diff("new")
+	if got := readFile(t, path); got != strings.Replace(src, `"old"`, `"new"`, 1) {
+		t.Errorf("expected the snapshot in the original file to be updated, got:\n%s", got)
+	}
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "a_test.go:6:15 differs") {
+		t.Errorf("expected the failure to point at the original file, got %q", ft.errors)
+	}
+}
@@ -0,0 +1,62 @@
+package snap
+
+import (
+	"os"
+	"sync"
+)
+
+var (
+	backupsMu sync.Mutex
+	// backups holds the source files backed up by this test binary.
+	backups = make(map[string]bool)
+)
+
+// backupFile copies the file at path to path.orig, before it's first rewritten by this test binary.
+// Later rewrites keep that backup, so it holds the source as it was before the tests ran.
+func backupFile(path string, perm os.FileMode) error {
+	backupsMu.Lock()
+	defer backupsMu.Unlock()
+	if backups[path] {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := writeFileInPlace(path+".orig", data, perm, true); err != nil {
+		return err
+	}
+	backups[path] = true
+	return nil
+}
+
+// writeFileInPlace is like [os.WriteFile], but syncs the file to disk if durable is set.
+func writeFileInPlace(path string, data []byte, perm os.FileMode, durable bool) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if durable {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// syncDir syncs the directory dir to disk, to persist a rename in it. It's best effort: directories
+// can't be synced on every platform.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	_ = d.Sync()
+}
@@ -0,0 +1,54 @@
+package snap
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// base64LineLength is the length of the lines of base64 snapshots, as in MIME.
+const base64LineLength = 76
+
+// Base64 makes the snapshot store the value encoded in base64, wrapped in lines of 76 characters,
+// for values that aren't printable text, like images or binary protocols, but should still be kept
+// inline. The snapshot preserves the exact bytes of the value, and failures report the first byte
+// that differs.
+//
+//	snap.Snap(t, "AAECAw==").Base64().Diff(string([]byte{0, 1, 2, 3}))
+func (s *Snapshot) Base64() *Snapshot {
+	c := *s
+	c.base64 = true
+	return &c
+}
+
+// encodeBase64 encodes value in base64, wrapped in lines of base64LineLength characters.
+func encodeBase64(value string) string {
+	encoded := base64.StdEncoding.EncodeToString([]byte(value))
+	var lines []string
+	for len(encoded) > base64LineLength {
+		lines = append(lines, encoded[:base64LineLength])
+		encoded = encoded[base64LineLength:]
+	}
+	return strings.Join(append(lines, encoded), "\n")
+}
+
+// changedBytes reports the first byte at which the base64 snapshot want and the encoded value got
+// differ once decoded, which the diff of their encodings doesn't show.
+func changedBytes(want string, got string) string {
+	decode := func(s string) ([]byte, error) {
+		return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+	}
+	wantBytes, err := decode(want)
+	if err != nil {
+		return fmt.Sprintf("snap: The snapshot is not valid base64: %s\n", err)
+	}
+	gotBytes, err := decode(got)
+	if err != nil {
+		return ""
+	}
+	i := 0
+	for i < len(wantBytes) && i < len(gotBytes) && wantBytes[i] == gotBytes[i] {
+		i++
+	}
+	return fmt.Sprintf("snap: Decoded, the values differ from byte %d, want %d bytes, got %d\n", i, len(wantBytes), len(gotBytes))
+}
@@ -0,0 +1,21 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBase64(t *testing.T) {
+	Snap(t, "AAECAw==").Base64().Diff(string([]byte{0, 1, 2, 3}))
+
+	value := strings.Repeat("\x00\xff", 60)
+	Snap(t, `AP8A/wD/AP8A/wD/AP8A/wD/AP8A/wD/AP8A/wD/AP8A/wD/AP8A/wD/AP8A/wD/AP8A/wD/AP8A
+/wD/AP8A/wD/AP8A/wD/AP8A/wD/AP8A/wD/AP8A/wD/AP8A/wD/AP8A/wD/AP8A/wD/AP8A/wD/
+AP8A/wD/`).Base64().Diff(value)
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "AAECAw==").Base64().Diff("\x00\x01\x09\x03\x04")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "snap: Decoded, the values differ from byte 2, want 4 bytes, got 5\n") {
+		t.Errorf("expected the first differing byte to be reported, got %q", ft.errors)
+	}
+}
@@ -0,0 +1,75 @@
+package snap
+
+import (
+	"log/slog"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/KasonBraley/snap/internal/baseline"
+)
+
+var (
+	baselineMu sync.Mutex
+	// baselineSeq counts the compared snapshots of each running test, so that several snapshots in
+	// one test get distinct keys.
+	baselineSeq = make(map[testing.TB]int)
+)
+
+// baselinePath returns the baseline file set with the SNAP_BASELINE environment variable.
+//
+// When set, mismatching snapshots are recorded to that file instead of failing the test. Use
+// `go run github.com/KasonBraley/snap/cmd/snap baseline old new` to compare the baselines of two
+// branches.
+func baselinePath() (string, bool) {
+	path := os.Getenv("SNAP_BASELINE")
+	return path, path != ""
+}
+
+func (s *Snapshot) recordBaseline(path string, want string, got string) {
+	s.t.Helper()
+
+	test := s.testName()
+	baselineMu.Lock()
+	if baselineSeq[s.t] == 0 {
+		s.t.Cleanup(func() {
+			baselineMu.Lock()
+			delete(baselineSeq, s.t)
+			baselineMu.Unlock()
+		})
+	}
+	baselineSeq[s.t]++
+	index := baselineSeq[s.t]
+	baselineMu.Unlock()
+
+	if equalExcludingIgnored(got, want) {
+		return
+	}
+
+	err := baseline.Append(path, baseline.Entry{
+		Package: testPackage(),
+		Test:    test,
+		Index:   index,
+		File:    relativePath(s.location.file),
+		Line:    s.location.line,
+		Want:    want,
+		Got:     got,
+		Owner:   s.owner,
+	})
+	if err != nil {
+		s.t.Errorf("snap: Failed to record baseline to %q: %s", path, err)
+		return
+	}
+	s.logf(slog.LevelInfo, "Snapshot differs, recorded to baseline %s", path)
+}
+
+// testPackage returns the import path of the package under test.
+func testPackage() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	return strings.TrimSuffix(info.Path, ".test")
+}
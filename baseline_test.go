@@ -0,0 +1,38 @@
+package snap
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/KasonBraley/snap/internal/baseline"
+)
+
+func TestBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.jsonl")
+	t.Setenv("SNAP_BASELINE", path)
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "same").Diff("same")
+	snapNoUpdate(ft, "want 1").Diff("got 1")
+	snapNoUpdate(ft, "want 2").Diff("got 2")
+
+	if len(ft.errors) != 0 {
+		t.Errorf("expected no errors in baseline mode, got %q", ft.errors)
+	}
+
+	entries, err := baseline.Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", entries)
+	}
+	for i, e := range entries {
+		if e.Package != "github.com/KasonBraley/snap" || e.Test != t.Name() || e.Index != i+2 {
+			t.Errorf("unexpected entry key: %+v", e)
+		}
+	}
+	if entries[1].Want != "want 2" || entries[1].Got != "got 2" {
+		t.Errorf("unexpected entry: %+v", entries[1])
+	}
+}
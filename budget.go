@@ -0,0 +1,36 @@
+package snap
+
+import (
+	"log/slog"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// AllowDiffLines makes the snapshot pass when at most n lines of the value differ from it, logging
+// the remaining diff instead of failing. A changed line counts once, and added or removed lines
+// count one each. It's meant to migrate legacy output towards an exact snapshot step by step
+// without blocking CI, lowering n as the differences are fixed:
+//
+//	snap.Snap(t, want).AllowDiffLines(12).Diff(legacyReport())
+func (s *Snapshot) AllowDiffLines(n int) *Snapshot {
+	c := *s
+	c.allowedLines = n
+	return &c
+}
+
+// withinDiffBudget reports whether got differs from want in at most the allowed number of lines,
+// logging the diff if it does.
+func (s *Snapshot) withinDiffBudget(want string, got string) bool {
+	s.t.Helper()
+	if s.allowedLines <= 0 {
+		return false
+	}
+	stats := ComputeDiff(want, got).Stats
+	changed := max(stats.Added, stats.Removed)
+	if changed > s.allowedLines {
+		return false
+	}
+	s.logf(slog.LevelInfo, "Snapshot at %s differs in %d of the %d allowed %s: (-want +got):\n%s",
+		s.findLiteral(), changed, s.allowedLines, plural(s.allowedLines, "line", "lines"), cmp.Diff(want, got))
+	return true
+}
@@ -0,0 +1,23 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAllowDiffLines(t *testing.T) {
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "a\nb\nc\nd").AllowDiffLines(2).Diff("a\nB\nc\nd\ne")
+	if len(ft.errors) != 0 {
+		t.Errorf("expected 2 differing lines to pass, got %q", ft.errors)
+	}
+	if !containsLog(ft, "differs in 2 of the 2 allowed lines: (-want +got):") {
+		t.Errorf("expected the remaining diff to be logged, got %q", ft.logs)
+	}
+
+	ft = newFakeT(t)
+	snapNoUpdate(ft, "a\nb\nc\nd").AllowDiffLines(1).Diff("a\nB\nc\nD")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "differs, 2 lines added, 2 removed") {
+		t.Errorf("expected 2 differing lines to fail with 1 allowed, got %q", ft.errors)
+	}
+}
@@ -0,0 +1,41 @@
+package snap
+
+import (
+	"runtime/debug"
+	"strings"
+)
+
+// scrubbed replaces the volatile values of build information.
+const scrubbed = "(scrubbed)"
+
+// volatileBuildSettings are the build settings that change with every commit or toolchain.
+var volatileBuildSettings = map[string]bool{
+	"vcs.revision": true,
+	"vcs.time":     true,
+	"vcs.modified": true,
+}
+
+// BuildInfo renders the build information of a binary, like [debug.ReadBuildInfo] returns, in the
+// format of `go version -m`, with the values that change with every commit or toolchain scrubbed:
+// the Go version, the version of the main module and the vcs.revision, vcs.time and vcs.modified
+// settings. Tools embedding their build information in their output, like version commands, can
+// snapshot the rest of it.
+//
+//	info, _ := debug.ReadBuildInfo()
+//	snap.Snap(t, want).Diff(snap.BuildInfo(info))
+func BuildInfo(info *debug.BuildInfo) string {
+	c := *info
+	c.GoVersion = scrubbed
+	if c.Main.Version != "" {
+		c.Main.Version = scrubbed
+		c.Main.Sum = ""
+	}
+	c.Settings = make([]debug.BuildSetting, len(info.Settings))
+	for i, s := range info.Settings {
+		if volatileBuildSettings[s.Key] {
+			s.Value = scrubbed
+		}
+		c.Settings[i] = s
+	}
+	return strings.TrimSuffix(c.String(), "\n")
+}
@@ -0,0 +1,36 @@
+package snap
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestBuildInfo(t *testing.T) {
+	info := &debug.BuildInfo{
+		GoVersion: "go1.22.3",
+		Path:      "example.com/tool",
+		Main:      debug.Module{Path: "example.com/tool", Version: "v1.2.4-0.20240102150405-abcdef012345+dirty"},
+		Deps:      []*debug.Module{{Path: "github.com/google/go-cmp", Version: "v0.6.0", Sum: "h1:abc="}},
+		Settings: []debug.BuildSetting{
+			{Key: "-compiler", Value: "gc"},
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "abcdef0123456789"},
+			{Key: "vcs.time", Value: "2024-01-02T15:04:05Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	Snap(t, `go	(scrubbed)
+path	example.com/tool
+mod	example.com/tool	(scrubbed)	
+dep	github.com/google/go-cmp	v0.6.0	h1:abc=
+build	-compiler=gc
+build	vcs=git
+build	vcs.revision=(scrubbed)
+build	vcs.time=(scrubbed)
+build	vcs.modified=(scrubbed)`).Diff(BuildInfo(info))
+
+	if info.Settings[2].Value != "abcdef0123456789" {
+		t.Error("expected BuildInfo to leave its argument unchanged")
+	}
+}
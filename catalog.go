@@ -0,0 +1,63 @@
+package snap
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiffCatalog compares the snapshot with a table of the message catalog translations, which maps
+// locales to the translations of each message key, like the messages loaded from the files of a
+// go-i18n bundle or the dictionaries of a golang.org/x/text catalog. The keys are listed in order,
+// each with its translation in every locale, also in order:
+//
+//	snap.Snap(t, `checkout.title
+//	  de: Kasse
+//	  en: Checkout
+//	  fr: <missing>`).DiffCatalog(catalog)
+//
+// A key missing from a locale shows as <missing>, so translation regressions and keys deleted by
+// accident fail the test. Translations with line breaks, or leading or trailing spaces, are quoted
+// like Go strings, to keep the table unambiguous.
+// It calls [testing.T.Error] when the snapshot is not equal to the table.
+func (s *Snapshot) DiffCatalog(translations map[string]map[string]string) {
+	s.t.Helper()
+	locales := make([]string, 0, len(translations))
+	keys := make(map[string]bool)
+	for locale, messages := range translations {
+		locales = append(locales, locale)
+		for key := range messages {
+			keys[key] = true
+		}
+	}
+	sort.Strings(locales)
+
+	var sb strings.Builder
+	for i, key := range sortedStrings(keys) {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(key)
+		for _, locale := range locales {
+			text, ok := translations[locale][key]
+			switch {
+			case !ok:
+				text = "<missing>"
+			case text == "" || strings.TrimSpace(text) != text || strings.ContainsAny(text, "\n\r"):
+				text = strconv.Quote(text)
+			}
+			sb.WriteString("\n  " + locale + ": " + text)
+		}
+	}
+	s.Diff(sb.String())
+}
+
+// sortedStrings returns the keys of m in order.
+func sortedStrings(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,36 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffCatalog(t *testing.T) {
+	catalog := map[string]map[string]string{
+		"en": {"checkout.title": "Checkout", "cart.items": "{count} items", "footer": "Thanks!\nThe team"},
+		"fr": {"checkout.title": "Paiement", "cart.items": "{count} articles", "footer": " Merci !"},
+		"de": {"checkout.title": "Kasse", "footer": ""},
+	}
+	Snap(t, `cart.items
+  de: <missing>
+  en: {count} items
+  fr: {count} articles
+checkout.title
+  de: Kasse
+  en: Checkout
+  fr: Paiement
+footer
+  de: ""
+  en: "Thanks!\nThe team"
+  fr: " Merci !"`).DiffCatalog(catalog)
+
+	delete(catalog["fr"], "checkout.title")
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "checkout.title\n  en: Checkout\n  fr: Paiement").DiffCatalog(map[string]map[string]string{
+		"en": {"checkout.title": catalog["en"]["checkout.title"]},
+		"fr": catalog["fr"],
+	})
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "fr: <missing>") {
+		t.Errorf("expected the deleted key in the diff, got %q", ft.errors)
+	}
+}
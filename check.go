@@ -0,0 +1,134 @@
+package snap
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// checkMarker matches the markers whose text is validated: <snap:check:name> markers, validated by
+// a registered check, with the name as the first submatch, <snap:recent:duration> markers, with
+// the duration as the second submatch, <snap:seq> markers, with "seq" as the third, and the
+// markers of typedMarker, with their type as the fourth submatch, or the pattern of
+// <snap:regexp:pattern> as the fifth.
+var checkMarker = regexp.MustCompile(`<snap:check:([A-Za-z0-9_]+)>|<snap:recent:([0-9][0-9a-zµ.]*)>|<snap:(seq)>|` +
+	`<snap:(uuid|timestamp|url|ignore-lines)>|<snap:regexp:([^>\n]+)>`)
+
+// matchingMarker matches the markers that match part of the input: <snap:ignore> and the markers
+// of checkMarker, with the same submatches.
+var matchingMarker = regexp.MustCompile(checkMarker.String() + "|" + regexp.QuoteMeta(ignoreFmt))
+
+// checkName matches valid check names.
+var checkName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+var (
+	checksMu sync.Mutex
+	checks   = make(map[string]func(string) error)
+)
+
+// RegisterCheck registers fn to validate the text matched by `<snap:check:name>` markers. The
+// marker matches like `<snap:ignore>`, so the text is not compared byte for byte, but the
+// snapshot fails when fn returns an error for it:
+//
+//	snap.RegisterCheck("isRFC3339", func(s string) error {
+//		_, err := time.Parse(time.RFC3339, s)
+//		return err
+//	})
+//
+//	snap.Snap(t, `created at <snap:check:isRFC3339>.`).Diff(got)
+//
+// Typically called from TestMain or an init function. RegisterCheck panics if name is not made
+// of letters, digits and underscores, or if a check with the same name is already registered.
+func RegisterCheck(name string, fn func(string) error) {
+	if !checkName.MatchString(name) {
+		panic(fmt.Sprintf("snap: invalid check name %q", name))
+	}
+
+	checksMu.Lock()
+	defer checksMu.Unlock()
+	if _, ok := checks[name]; ok {
+		panic(fmt.Sprintf("snap: check %q is already registered", name))
+	}
+	checks[name] = fn
+}
+
+// runChecks runs the registered checks of the <snap:check:name> markers of want, and the checks of
+// the <snap:recent:duration> and <snap:seq> markers, on the text of got they matched. got must
+// match want.
+func (s *Snapshot) runChecks(got string, want string) {
+	s.t.Helper()
+	if !checkMarker.MatchString(want) {
+		return
+	}
+
+	matched, ok := matchMarkers(got, want)
+	if !ok {
+		return
+	}
+	markers := matchingMarker.FindAllStringSubmatch(want, -1)
+	if len(markers) != len(matched) {
+		return
+	}
+
+	var seq []string // The text matched by the <snap:seq> markers.
+	for i, m := range markers {
+		if m[3] != "" {
+			seq = append(seq, matched[i])
+			continue
+		}
+		if m[2] != "" {
+			s.checkRecent(want, got, m[0], m[2], matched[i])
+			continue
+		}
+		name := m[1]
+		if name == "" {
+			continue // <snap:ignore>
+		}
+		checksMu.Lock()
+		fn, ok := checks[name]
+		checksMu.Unlock()
+		if !ok {
+			s.t.Errorf("snap: Check %q is not registered, use snap.RegisterCheck", name)
+			continue
+		}
+		if err := fn(matched[i]); err != nil {
+			s.mismatch(want, got, "snap: Check %q failed for %q: %v", name, matched[i], err)
+		}
+	}
+	if err := checkIncreasing(seq); err != nil {
+		s.mismatch(want, got, "snap: Check <snap:seq> failed: %v", err)
+	}
+}
+
+// checkRecent fails the test unless text, matched by the <snap:recent:duration> marker, is a
+// timestamp within duration of the start of the test.
+func (s *Snapshot) checkRecent(want string, got string, marker string, duration string, text string) {
+	s.t.Helper()
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		s.t.Errorf("snap: Invalid duration in %s: %s", marker, err)
+		return
+	}
+	if err := checkRecent(text, d, s.started); err != nil {
+		s.mismatch(want, got, "snap: Check %s failed for %q: %v", marker, text, err)
+	}
+}
+
+// checkIncreasing returns an error unless values, matched by <snap:seq> markers, are numbers in
+// strictly increasing order.
+func checkIncreasing(values []string) error {
+	var prev *big.Float
+	for i, v := range values {
+		n, ok := new(big.Float).SetString(v)
+		if !ok {
+			return fmt.Errorf("%q is not a number", v)
+		}
+		if prev != nil && n.Cmp(prev) <= 0 {
+			return fmt.Errorf("%q is not greater than %q", v, values[i-1])
+		}
+		prev = n
+	}
+	return nil
+}
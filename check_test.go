@@ -0,0 +1,73 @@
+package snap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func registerCheck(t *testing.T, name string, fn func(string) error) {
+	t.Helper()
+	RegisterCheck(name, fn)
+	t.Cleanup(func() {
+		checksMu.Lock()
+		defer checksMu.Unlock()
+		delete(checks, name)
+	})
+}
+
+func TestCheck(t *testing.T) {
+	registerCheck(t, "isNumber", func(s string) error {
+		if strings.Trim(s, "0123456789") != "" {
+			return errors.New("not a number")
+		}
+		return nil
+	})
+
+	Snap(t, "id <snap:check:isNumber> at <snap:ignore>.").Diff("id 42 at noon.")
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "id <snap:check:isNumber> at <snap:ignore>.").Diff("id 4x at noon.")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `Check "isNumber" failed for "4x": not a number`) {
+		t.Errorf("expected a failed check, got %q", ft.errors)
+	}
+}
+
+func TestCheckNotRegistered(t *testing.T) {
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "id <snap:check:unknown>.").Diff("id 1.")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `Check "unknown" is not registered`) {
+		t.Errorf("expected an unregistered check error, got %q", ft.errors)
+	}
+}
+
+func TestRegisterCheckPanics(t *testing.T) {
+	registerCheck(t, "dup", func(string) error { return nil })
+	for _, name := range []string{"dup", "with space", ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected RegisterCheck(%q) to panic", name)
+				}
+			}()
+			RegisterCheck(name, func(string) error { return nil })
+		}()
+	}
+}
+
+func TestSeqMarker(t *testing.T) {
+	Snap(t, "id=<snap:seq>, id=<snap:seq>, id=<snap:seq>.").Diff("id=7, id=8, id=120.")
+	Snap(t, "at <snap:seq>: start\nat <snap:ignore>: skipped\nat <snap:seq>: end\n").Diff("at 0.5: start\nat 99: skipped\nat 1.25: end\n")
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "id=<snap:seq>, id=<snap:seq>, id=<snap:seq>.").Diff("id=7, id=9, id=8.")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `snap: Check <snap:seq> failed: "8" is not greater than "9"`) {
+		t.Errorf("expected the decreasing values to fail, got %q", ft.errors)
+	}
+
+	ft = newFakeT(t)
+	snapNoUpdate(ft, "id=<snap:seq>, id=<snap:seq>.").Diff("id=7, id=x.")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `snap: Check <snap:seq> failed: "x" is not a number`) {
+		t.Errorf("expected the non-number to fail, got %q", ft.errors)
+	}
+}
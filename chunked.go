@@ -0,0 +1,56 @@
+package snap
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// chunkedDiffSize is the size, in bytes, above which values are compared in chunks rather than
+	// diffed, as a diff of hundreds of megabytes takes too long and too much memory to be useful.
+	chunkedDiffSize = 16 << 20
+	diffChunkSize   = 4096
+	// maxDiffChunks is the number of differing chunks reported, after which the comparison stops.
+	maxDiffChunks = 3
+	// chunkContext is the number of bytes shown around the first difference of a chunk.
+	chunkContext = 32
+)
+
+// isLarge reports whether want or got is too large to be diffed, see chunkedDiffSize.
+func isLarge(want string, got string) bool {
+	return len(want) > chunkedDiffSize || len(got) > chunkedDiffSize
+}
+
+// differingChunks compares want and got in chunks of diffChunkSize bytes, and describes the first
+// maxDiffChunks chunks that differ, with their offset and the bytes around their first difference.
+func differingChunks(want string, got string) string {
+	var sb strings.Builder
+	found := 0
+	for off := 0; off < len(want) || off < len(got); off += diffChunkSize {
+		w, g := chunkAt(want, off), chunkAt(got, off)
+		if w == g {
+			continue
+		}
+		if found == maxDiffChunks {
+			sb.WriteString("... more chunks may differ, they weren't compared\n")
+			break
+		}
+		found++
+		i := 0
+		for i < len(w) && i < len(g) && w[i] == g[i] {
+			i++
+		}
+		start := max(i-chunkContext, 0)
+		fmt.Fprintf(&sb, "chunk at offset %d, first difference at offset %d:\n-\t%q\n+\t%q\n",
+			off, off+i, w[start:min(i+chunkContext, len(w))], g[start:min(i+chunkContext, len(g))])
+	}
+	return sb.String()
+}
+
+// chunkAt returns the chunk of s at offset off, which is empty past the end of s.
+func chunkAt(s string, off int) string {
+	if off >= len(s) {
+		return ""
+	}
+	return s[off:min(off+diffChunkSize, len(s))]
+}
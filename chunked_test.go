@@ -0,0 +1,37 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDifferingChunks(t *testing.T) {
+	want := strings.Repeat("a", 3*diffChunkSize)
+	got := want[:diffChunkSize+10] + "b" + want[diffChunkSize+11:]
+	Snap(t, `chunk at offset 4096, first difference at offset 4106:
+-	"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
++	"aaaaaaaaaabaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+`).Diff(differingChunks(want, got))
+
+	got = "x" + want[1:] + "more"
+	want = strings.Replace(want, "a", "c", -1)
+	if report := differingChunks(want, got); strings.Count(report, "chunk at offset") != maxDiffChunks ||
+		!strings.HasSuffix(report, "... more chunks may differ, they weren't compared\n") {
+		t.Errorf("expected the report to stop after %d chunks, got:\n%s", maxDiffChunks, report)
+	}
+	if report := differingChunks("abc", "abcdef"); !strings.Contains(report, "first difference at offset 3:\n-\t\"abc\"\n+\t\"abcdef\"") {
+		t.Errorf("expected the added bytes to be reported, got:\n%s", report)
+	}
+}
+
+func TestDiffLargeValue(t *testing.T) {
+	want := strings.Repeat("0123456789\n", chunkedDiffSize/10)
+	got := strings.Replace(want, "0123456789", "0123456780", 1)
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, want).Diff(got)
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "compared in chunks of 4096 bytes") ||
+		!strings.Contains(ft.errors[0], "chunk at offset 0, first difference at offset 9:") {
+		t.Errorf("expected a chunked report, got %.500q", ft.errors)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/KasonBraley/snap/internal/baseline"
+	"github.com/google/go-cmp/cmp"
+)
+
+// runBaseline prints the snapshots whose mismatches differ between two baseline files.
+func runBaseline(args []string, stdout io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf("expected two baseline files, got %d arguments", len(args))
+	}
+
+	old, err := baseline.Read(args[0])
+	if err != nil {
+		return err
+	}
+	new, err := baseline.Read(args[1])
+	if err != nil {
+		return err
+	}
+
+	changes := baseline.Compare(old, new)
+	for _, c := range changes {
+		switch {
+		case c.Old == nil:
+			fmt.Fprintf(stdout, "mismatch added: %s (%s)\n%s\n", c.Key, location(c.New),
+				cmp.Diff(c.New.Want, c.New.Got))
+		case c.New == nil:
+			fmt.Fprintf(stdout, "mismatch removed: %s\n", c.Key)
+		default:
+			fmt.Fprintf(stdout, "mismatch changed: %s (%s) (-old got +new got):\n%s\n", c.Key, location(c.New),
+				cmp.Diff(c.Old.Got, c.New.Got))
+		}
+	}
+
+	if len(changes) > 0 {
+		return errChanged
+	}
+	return nil
+}
+
+// location describes where the snapshot of e is, and who owns it.
+func location(e *baseline.Entry) string {
+	if e.Owner == "" {
+		return fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+	return fmt.Sprintf("%s:%d, owned by %s", e.File, e.Line, e.Owner)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/KasonBraley/snap"
+)
+
+// runConvert moves the snapshot of the call at file.go:line between the source and a snapshot
+// file: the inline snapshot of a snap.Snap call to the file given with -o, or the file of a
+// snap.SnapFile call back inline without -o.
+func runConvert(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	out := flags.String("o", "", "move the inline snapshot to this file, relative to the directory of the source file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected one file.go:line argument, got %d", flags.NArg())
+	}
+	// The file can contain colons, like Windows drive letters.
+	i := strings.LastIndexByte(flags.Arg(0), ':')
+	file := flags.Arg(0)[:max(i, 0)]
+	line, err := strconv.Atoi(flags.Arg(0)[i+1:])
+	if i < 0 || err != nil || line < 1 {
+		return fmt.Errorf("expected a file.go:line argument, got %q", flags.Arg(0))
+	}
+
+	if *out != "" {
+		if err := snap.InlineToFile(file, line, *out); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "moved the snapshot at %s:%d to %s\n", file, line, *out)
+		return nil
+	}
+	if err := snap.FileToInline(file, line); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "moved the snapshot file of %s:%d inline\n", file, line)
+	return nil
+}
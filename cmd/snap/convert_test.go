@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunConvert(t *testing.T) {
+	t.Setenv("SNAP_UNDO_DIR", t.TempDir())
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a_test.go")
+	original := "package a\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestA(t *testing.T) {\n\tsnap.Snap(t, \"text\").Diff(\"text\")\n}\n"
+	writeFile(t, src, original)
+
+	var stdout, stderr strings.Builder
+	if code := run([]string{"convert", "-o", "a.golden", src + ":6"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected the snapshot to be moved, got exit code %d and stderr: %s", code, stderr.String())
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "a.golden")); string(b) != "text" {
+		t.Errorf("expected the snapshot in the file, got %q", b)
+	}
+	if code := run([]string{"convert", src + ":6"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected the snapshot to be moved back, got exit code %d and stderr: %s", code, stderr.String())
+	}
+	if b, _ := os.ReadFile(src); string(b) != original {
+		t.Errorf("expected the source to be restored, got:\n%s", b)
+	}
+	if want := "moved the snapshot at " + src + ":6 to a.golden\nmoved the snapshot file of " + src + ":6 inline\n"; stdout.String() != want {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"convert", src}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "expected a file.go:line argument") {
+		t.Errorf("expected the missing line to be reported, got exit code %d and stderr: %s", code, stderr.String())
+	}
+}
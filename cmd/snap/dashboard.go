@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/KasonBraley/snap"
+	"github.com/KasonBraley/snap/internal/baseline"
+	"github.com/KasonBraley/snap/internal/flakes"
+)
+
+// runDashboard writes a static HTML dashboard of the health of the snapshots of a suite, from the
+// machine-readable outputs of CI runs given as arguments, oldest first. Each run is a JSON lines
+// file, or a directory of them, of the events written with SNAP_EVENTS, the mismatches recorded
+// with SNAP_BASELINE, or the history recorded with SNAP_FLAKES. The dashboard shows the failures
+// of each run, the snapshots failing the most, the largest ones, the ones mostly matched by
+// markers, and the flaky ones.
+func runDashboard(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("dashboard", flag.ContinueOnError)
+	out := flags.String("o", "", "write the dashboard to this file instead of the standard output")
+	title := flags.String("title", "Snapshot health", "title of the dashboard")
+	top := flags.Int("top", 20, "number of snapshots listed in each table")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("expected the outputs of at least one run")
+	}
+
+	d := newDashboard(*title, *top)
+	for _, run := range flags.Args() {
+		if err := d.addRun(run); err != nil {
+			return err
+		}
+	}
+	if d.records == 0 {
+		return errors.New("no snapshot events, baselines or flake histories in the runs")
+	}
+
+	w := stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return dashboardTemplate.Execute(w, d.data())
+}
+
+// dashboard aggregates the outputs of runs.
+type dashboard struct {
+	title     string
+	top       int
+	records   int
+	runs      []*runStats
+	snapshots map[string]*snapshotStats
+	results   []flakes.Result
+}
+
+// runStats are the statistics of the snapshots of one run.
+type runStats struct {
+	Name     string
+	Compared int // Snapshots matched or mismatched.
+	Failures int
+	Created  int
+	Updated  int
+	Size     int // Bytes of the compared values.
+	Ignored  int // Bytes of the matched values matched by markers.
+	Matched  int // Bytes of the matched values.
+}
+
+// snapshotStats are the statistics of one snapshot across runs.
+type snapshotStats struct {
+	Snapshot string // Position of the Snap call, relative to the module root.
+	Golden   string
+	Tests    []string
+	Failures int    // Runs in which the snapshot mismatched.
+	LastFail string // Last run in which the snapshot mismatched.
+	Size     int    // Bytes of the last compared value.
+	Ignored  int    // Bytes of the last matched value matched by markers.
+	Matched  int    // Bytes of the last matched value.
+	failed   *runStats
+}
+
+func newDashboard(title string, top int) *dashboard {
+	return &dashboard{title: title, top: top, snapshots: make(map[string]*snapshotStats)}
+}
+
+// addRun adds the outputs of the run at path, a file or a directory of .jsonl files.
+func (d *dashboard) addRun(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	run := &runStats{Name: path}
+	d.runs = append(d.runs, run)
+	if !info.IsDir() {
+		return d.addFile(run, path)
+	}
+	return filepath.WalkDir(path, func(p string, e fs.DirEntry, err error) error {
+		if err != nil || e.IsDir() || filepath.Ext(p) != ".jsonl" {
+			return err
+		}
+		return d.addFile(run, p)
+	})
+}
+
+// addFile adds the records of the JSON lines file at path to run.
+func (d *dashboard) addFile(run *runStats, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20) // Baseline entries have whole snapshots.
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := d.addRecord(run, line); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// addRecord adds the event, baseline entry or flake result encoded in line to run.
+func (d *dashboard) addRecord(run *runStats, line []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return err
+	}
+	d.records++
+	switch {
+	case fields["kind"] != nil:
+		var e snap.Event
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
+		}
+		d.addEvent(run, e)
+	case fields["want"] != nil:
+		var e baseline.Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
+		}
+		run.Compared++
+		d.fail(run, d.snapshot(fmt.Sprintf("%s:%d", e.File, e.Line), e.Test))
+	case fields["commit"] != nil:
+		var r flakes.Result
+		if err := json.Unmarshal(line, &r); err != nil {
+			return err
+		}
+		d.snapshot(r.Snapshot, r.Test)
+		d.results = append(d.results, r)
+	default:
+		return errors.New("not a snapshot event, baseline entry or flake result")
+	}
+	return nil
+}
+
+func (d *dashboard) addEvent(run *runStats, e snap.Event) {
+	s := d.snapshot(fmt.Sprintf("%s:%d", e.File, e.Line), e.Test)
+	if e.Golden != "" {
+		s.Golden = e.Golden
+	}
+	switch e.Kind {
+	case snap.EventCreated:
+		run.Created++
+	case snap.EventUpdated:
+		run.Updated++
+	case snap.EventMatched:
+		run.Compared++
+		run.Size += e.Size
+		run.Ignored += e.Ignored
+		run.Matched += e.Size
+		s.Size, s.Ignored, s.Matched = e.Size, e.Ignored, e.Size
+	case snap.EventMismatched:
+		run.Compared++
+		run.Size += e.Size
+		s.Size = e.Size
+		d.fail(run, s)
+	}
+}
+
+// fail records that the snapshot s mismatched in run, once per run.
+func (d *dashboard) fail(run *runStats, s *snapshotStats) {
+	if s.failed == run {
+		return
+	}
+	run.Failures++
+	s.Failures++
+	s.LastFail = run.Name
+	s.failed = run
+}
+
+// snapshot returns the statistics of the snapshot, compared in test.
+func (d *dashboard) snapshot(pos string, test string) *snapshotStats {
+	s, ok := d.snapshots[pos]
+	if !ok {
+		s = &snapshotStats{Snapshot: pos}
+		d.snapshots[pos] = s
+	}
+	if i := sort.SearchStrings(s.Tests, test); test != "" && (i == len(s.Tests) || s.Tests[i] != test) {
+		s.Tests = append(s.Tests[:i], append([]string{test}, s.Tests[i:]...)...)
+	}
+	return s
+}
+
+// dashboardData is the data of the dashboard template.
+type dashboardData struct {
+	Title   string
+	Runs    []*runStats
+	Failing []*snapshotStats
+	Largest []*snapshotStats
+	Ignored []*snapshotStats
+	Flaky   []flakes.Flake
+}
+
+func (d *dashboard) data() dashboardData {
+	var all []*snapshotStats
+	for _, s := range d.snapshots {
+		all = append(all, s)
+	}
+	// Sorted by position first, for stable tables.
+	sort.Slice(all, func(i, j int) bool { return all[i].Snapshot < all[j].Snapshot })
+
+	data := dashboardData{Title: d.title, Runs: d.runs, Flaky: flakes.Find(d.results)}
+	data.Failing = d.topSnapshots(all, func(s *snapshotStats) int { return s.Failures })
+	data.Largest = d.topSnapshots(all, func(s *snapshotStats) int { return s.Size })
+	data.Ignored = d.topSnapshots(all, func(s *snapshotStats) int {
+		if s.Ignored == 0 {
+			return 0
+		}
+		return 1 + s.Ignored*1000/s.Matched
+	})
+	return data
+}
+
+// topSnapshots returns the snapshots of all with the highest positive scores, highest first.
+func (d *dashboard) topSnapshots(all []*snapshotStats, score func(*snapshotStats) int) []*snapshotStats {
+	var top []*snapshotStats
+	for _, s := range all {
+		if score(s) > 0 {
+			top = append(top, s)
+		}
+	}
+	sort.SliceStable(top, func(i, j int) bool { return score(top[i]) > score(top[j]) })
+	if len(top) > d.top {
+		top = top[:d.top]
+	}
+	return top
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"percent": func(n int, total int) string {
+		if total == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+	},
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+td.n { text-align: right; }
+th { background: #f4f4f4; }
+.bad { color: #b00; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+
+<h2>Runs</h2>
+<table>
+<tr><th>Run</th><th>Compared</th><th>Failures</th><th>Failure rate</th><th>Created</th><th>Updated</th><th>Bytes</th><th>Ignored</th></tr>
+{{range .Runs}}<tr><td>{{.Name}}</td><td class="n">{{.Compared}}</td><td class="n{{if .Failures}} bad{{end}}">{{.Failures}}</td><td class="n">{{percent .Failures .Compared}}</td><td class="n">{{.Created}}</td><td class="n">{{.Updated}}</td><td class="n">{{.Size}}</td><td class="n">{{percent .Ignored .Matched}}</td></tr>
+{{end}}</table>
+
+<h2>Failing snapshots</h2>
+{{with .Failing}}<table>
+<tr><th>Snapshot</th><th>Tests</th><th>Failed runs</th><th>Last failed</th></tr>
+{{range .}}<tr><td>{{.Snapshot}}{{with .Golden}} ({{.}}){{end}}</td><td>{{join .Tests ", "}}</td><td class="n bad">{{.Failures}}</td><td>{{.LastFail}}</td></tr>
+{{end}}</table>
+{{else}}<p>No failures.</p>
+{{end}}
+<h2>Largest snapshots</h2>
+{{with .Largest}}<table>
+<tr><th>Snapshot</th><th>Tests</th><th>Bytes</th></tr>
+{{range .}}<tr><td>{{.Snapshot}}{{with .Golden}} ({{.}}){{end}}</td><td>{{join .Tests ", "}}</td><td class="n">{{.Size}}</td></tr>
+{{end}}</table>
+{{else}}<p>No sizes recorded.</p>
+{{end}}
+<h2>Most ignored snapshots</h2>
+{{with .Ignored}}<table>
+<tr><th>Snapshot</th><th>Tests</th><th>Ignored</th></tr>
+{{range .}}<tr><td>{{.Snapshot}}{{with .Golden}} ({{.}}){{end}}</td><td>{{join .Tests ", "}}</td><td class="n">{{percent .Ignored .Matched}}</td></tr>
+{{end}}</table>
+{{else}}<p>No snapshots matched by markers.</p>
+{{end}}
+<h2>Flaky snapshots</h2>
+{{with .Flaky}}<table>
+<tr><th>Snapshot</th><th>Tests</th><th>Commit</th><th>Matched</th><th>Mismatched</th></tr>
+{{range .}}<tr><td>{{.Snapshot}}</td><td>{{join .Tests ", "}}</td><td>{{.Commit}}</td><td class="n">{{.Passes}}</td><td class="n bad">{{.Failures}}</td></tr>
+{{end}}</table>
+{{else}}<p>No flaky snapshots.</p>
+{{end}}</body>
+</html>
+`))
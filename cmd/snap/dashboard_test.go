@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunDashboard(t *testing.T) {
+	dir := t.TempDir()
+	run1 := filepath.Join(dir, "run1")
+	writeFile(t, filepath.Join(run1, "events.jsonl"),
+		`{"kind":"matched","test":"TestA","file":"a_test.go","line":4,"size":100,"ignored":80,"time":"2026-01-01T00:00:00Z"}
+{"kind":"mismatched","test":"TestB","file":"b_test.go","line":9,"size":5000,"time":"2026-01-01T00:00:00Z"}
+{"kind":"mismatched","test":"TestB","file":"b_test.go","line":9,"size":5000,"time":"2026-01-01T00:00:00Z"}
+`)
+	writeFile(t, filepath.Join(run1, "flakes.jsonl"),
+		`{"snapshot":"b_test.go:9","test":"TestB","commit":"c1","pass":true}
+{"snapshot":"b_test.go:9","test":"TestB","commit":"c1","pass":false}
+`)
+	run2 := filepath.Join(dir, "run2.jsonl")
+	writeFile(t, run2, `{"package":"example.com/m","test":"TestB","index":1,"file":"b_test.go","line":9,"want":"a","got":"b"}
+{"kind":"created","test":"TestC","file":"c_test.go","line":3,"golden":"testdata/c.golden","time":"2026-01-02T00:00:00Z"}
+`)
+
+	out := filepath.Join(dir, "dashboard.html")
+	var stdout, stderr strings.Builder
+	if code := run([]string{"dashboard", "-o", out, "-title", "ACME snapshots", run1, run2}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d, stderr: %s", code, stderr.String())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"<title>ACME snapshots</title>",
+		// Run 1 compared the snapshot of TestB twice, and it failed in both runs.
+		"<tr><td>" + run1 + `</td><td class="n">3</td><td class="n bad">1</td><td class="n">33.3%</td><td class="n">0</td><td class="n">0</td><td class="n">10100</td><td class="n">80.0%</td></tr>`,
+		"<tr><td>" + run2 + `</td><td class="n">1</td><td class="n bad">1</td><td class="n">100.0%</td><td class="n">1</td><td class="n">0</td><td class="n">0</td><td class="n">-</td></tr>`,
+		`<tr><td>b_test.go:9</td><td>TestB</td><td class="n bad">2</td><td>` + run2 + `</td></tr>`,
+		`<tr><td>b_test.go:9</td><td>TestB</td><td class="n">5000</td></tr>`,
+		`<tr><td>a_test.go:4</td><td>TestA</td><td class="n">80.0%</td></tr>`,
+		`<tr><td>b_test.go:9</td><td>TestB</td><td>c1</td><td class="n">1</td><td class="n bad">1</td></tr>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want dashboard containing %q, got:\n%s", want, got)
+		}
+	}
+
+	stderr.Reset()
+	empty := filepath.Join(dir, "empty")
+	if err := os.Mkdir(empty, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if code := run([]string{"dashboard", empty}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "no snapshot events") {
+		t.Errorf("expected an error without outputs, got %d, %s", code, stderr.String())
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/KasonBraley/snap"
+	"github.com/KasonBraley/snap/internal/source"
+)
+
+// runDupes lists groups of byte-identical snapshots in the test files of the given directories.
+// With -consolidate, each group is moved to a section of a shared .snap file in the directory of
+// each package, named after the hash of the snapshot, and its calls read it with snap.FileSection.
+func runDupes(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("dupes", flag.ContinueOnError)
+	minLines := flags.Int("min-lines", 10, "ignore snapshots with fewer lines")
+	minCount := flags.Int("min-count", 2, "only report snapshots duplicated at least this many times")
+	consolidate := flags.String("consolidate", "", "move the duplicated snapshots to sections of this .snap file, relative to each package")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	files, err := testFiles(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	byText := make(map[string][]source.Snapshot)
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		snapshots, err := source.Find(file, src)
+		if err != nil {
+			return err
+		}
+		for _, s := range snapshots {
+			if s.Constant && strings.Count(s.Text, "\n")+1 >= *minLines {
+				byText[s.Text] = append(byText[s.Text], s)
+			}
+		}
+	}
+
+	var groups [][]source.Snapshot
+	for _, group := range byText {
+		if len(group) >= *minCount {
+			groups = append(groups, group)
+		}
+	}
+	// Biggest savings first.
+	sort.Slice(groups, func(i, j int) bool {
+		si, sj := len(groups[i][0].Text)*len(groups[i]), len(groups[j][0].Text)*len(groups[j])
+		if si != sj {
+			return si > sj
+		}
+		return groups[i][0].Pos.String() < groups[j][0].Pos.String()
+	})
+
+	for _, group := range groups {
+		fmt.Fprintf(stdout, "%d identical snapshots (%d lines, %d bytes):\n", len(group),
+			strings.Count(group[0].Text, "\n")+1, len(group[0].Text))
+		for _, s := range group {
+			fmt.Fprintf(stdout, "\t%s\n", s.Pos)
+		}
+	}
+
+	if len(groups) == 0 {
+		return nil
+	}
+	if *consolidate == "" {
+		return errChanged
+	}
+	return consolidateDupes(groups, *consolidate, stdout)
+}
+
+// consolidateDupes moves each group of identical snapshots to the section named after its hash of
+// the .snap file at path, relative to the directory of each of their files.
+func consolidateDupes(groups [][]source.Snapshot, path string, stdout io.Writer) error {
+	var all []source.Snapshot
+	sections := make(map[string]string)
+	for _, group := range groups {
+		sum := sha256.Sum256([]byte(group[0].Text))
+		for _, s := range group {
+			sections[s.Pos.String()] = "shared-" + hex.EncodeToString(sum[:4])
+		}
+		all = append(all, group...)
+	}
+	// Moving a snapshot shifts the lines after it, so the snapshots of a file are moved from the
+	// last.
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Pos.Filename != all[j].Pos.Filename {
+			return all[i].Pos.Filename < all[j].Pos.Filename
+		}
+		return all[i].Pos.Offset > all[j].Pos.Offset
+	})
+
+	var errs []error
+	for _, s := range all {
+		name := sections[s.Pos.String()]
+		if err := snap.InlineToSection(s.Pos.Filename, s.Line, path, name); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Pos, err))
+			continue
+		}
+		fmt.Fprintf(stdout, "moved %s to section %s of %s\n", s.Pos, name, filepath.Join(filepath.Dir(s.Pos.Filename), path))
+	}
+	return errors.Join(errs...)
+}
+
+// testFiles returns the Go test files in dirs and their subdirectories, skipping testdata, vendor
+// and hidden directories. A trailing "/..." is accepted for consistency with the go command.
+func testFiles(dirs []string) ([]string, error) {
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	var files []string
+	for _, dir := range dirs {
+		dir = strings.TrimSuffix(dir, "/...")
+		if dir == "" {
+			dir = "."
+		}
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				name := d.Name()
+				if path != dir && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(path, "_test.go") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunDupes(t *testing.T) {
+	dir := t.TempDir()
+	big := "`1\n2\n3`"
+	writeFile(t, filepath.Join(dir, "a", "a_test.go"), "package a\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestA(t *testing.T) {\n\tsnap.Snap(t, "+big+")\n\tsnap.Snap(t, `x`)\n}\n")
+	writeFile(t, filepath.Join(dir, "b", "b_test.go"), "package b\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestB(t *testing.T) {\n\tsnap.Snap(t, \"1\\n2\\n3\")\n\tsnap.Snap(t, `x`)\n}\n")
+	writeFile(t, filepath.Join(dir, "b", "testdata", "c_test.go"), "package c\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestC(t *testing.T) {\n\tsnap.Snap(t, "+big+")\n}\n")
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"dupes", "-min-lines", "3", dir + "/..."}, &stdout, &stderr)
+	if code != 1 {
+		t.Errorf("expected exit code 1, got %d, stderr: %s", code, stderr.String())
+	}
+
+	want := "2 identical snapshots (3 lines, 5 bytes):\n" +
+		"\t" + filepath.Join(dir, "a", "a_test.go") + ":6:15\n" +
+		"\t" + filepath.Join(dir, "b", "b_test.go") + ":6:15\n"
+	if stdout.String() != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, stdout.String())
+	}
+}
+
+func TestRunDupesConsolidate(t *testing.T) {
+	t.Setenv("SNAP_UNDO_DIR", t.TempDir())
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a_test.go")
+	writeFile(t, a, "package a\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestA(t *testing.T) {\n\tsnap.Snap(t, `1\n2\n3`).Diff(one())\n\tsnap.Snap(t, `x`)\n\tsnap.Snap(t, \"1\\n2\\n3\").Diff(two())\n}\n")
+
+	var stdout, stderr strings.Builder
+	if code := run([]string{"dupes", "-min-lines", "3", "-consolidate", "testdata/shared.snap", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected the snapshots to be consolidated, got exit code %d, stderr: %s", code, stderr.String())
+	}
+	want := "package a\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestA(t *testing.T) {\n" +
+		"\tsnap.FileSection(t, \"testdata/shared.snap\", \"shared-ad53e880\").Diff(one())\n" +
+		"\tsnap.Snap(t, `x`)\n" +
+		"\tsnap.FileSection(t, \"testdata/shared.snap\", \"shared-ad53e880\").Diff(two())\n}\n"
+	if b, _ := os.ReadFile(a); string(b) != want {
+		t.Errorf("expected the calls to read the shared section, want:\n%s\ngot:\n%s", want, b)
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, "testdata", "shared.snap"))
+	if !strings.HasSuffix(string(b), "\n-- shared-ad53e880 --\n1\n2\n3\n") || strings.Count(string(b), "-- shared") != 1 {
+		t.Errorf("expected one shared section, got:\n%s", b)
+	}
+	if !strings.Contains(stdout.String(), "moved "+a+":6:15 to section shared-ad53e880 of "+filepath.Join(dir, "testdata", "shared.snap")) {
+		t.Errorf("unexpected output:\n%s", stdout.String())
+	}
+}
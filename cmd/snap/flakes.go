@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/KasonBraley/snap/internal/flakes"
+)
+
+// runFlakes lists the snapshots of the module that both matched and mismatched at the same commit,
+// from the results recorded by test runs with SNAP_FLAKES set, and compacts the history to the
+// last results of each snapshot.
+func runFlakes(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("flakes", flag.ContinueOnError)
+	dir := flags.String("C", ".", "run in this directory of the module")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("expected no arguments, got %d", flags.NArg())
+	}
+
+	root, err := findModuleRoot(*dir)
+	if err != nil {
+		return err
+	}
+	path, err := flakes.File(root)
+	if err != nil {
+		return err
+	}
+	results, err := flakes.Read(path)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("no snapshot results recorded for %s, run the tests with SNAP_FLAKES=1", root)
+	}
+	found := flakes.Find(results)
+	if err := flakes.Compact(path, results); err != nil {
+		return err
+	}
+
+	for _, f := range found {
+		fmt.Fprintf(stdout, "%s: matched %d times and mismatched %d times at %s, in %s\n",
+			f.Snapshot, f.Passes, f.Failures, f.Commit, strings.Join(f.Tests, ", "))
+	}
+	if len(found) > 0 {
+		return errChanged
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KasonBraley/snap/internal/flakes"
+)
+
+func TestRunFlakes(t *testing.T) {
+	t.Setenv("GOCACHE", t.TempDir())
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/m\n")
+
+	var stdout, stderr strings.Builder
+	if code := run([]string{"flakes", "-C", root}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "no snapshot results recorded") {
+		t.Errorf("expected an error without results, got %d, %s", code, stderr.String())
+	}
+
+	path, err := flakes.File(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []flakes.Result{
+		{Snapshot: "a_test.go:4", Test: "TestA", Commit: "c1", Pass: true},
+		{Snapshot: "a_test.go:4", Test: "TestA", Commit: "c1", Pass: false},
+		{Snapshot: "b_test.go:9", Test: "TestB", Commit: "c1", Pass: true},
+	} {
+		if err := flakes.Append(path, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stderr.Reset()
+	if code := run([]string{"flakes", "-C", root}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d, stderr: %s", code, stderr.String())
+	}
+	if want := "a_test.go:4: matched 1 times and mismatched 1 times at c1, in TestA\n"; stdout.String() != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, stdout.String())
+	}
+}
@@ -0,0 +1,92 @@
+// Command snap provides tooling around snapshots created with the
+// github.com/KasonBraley/snap package.
+//
+// Usage:
+//
+//	go run github.com/KasonBraley/snap/cmd/snap <command> [arguments]
+//
+// The commands are:
+//
+//	accept-all  apply all the snapshot changes recorded with SNAP_PENDING
+//	baseline    compare two baseline files recorded with SNAP_BASELINE
+//	convert     move a snapshot from the source to a snapshot file, or back
+//	dashboard   write an HTML dashboard of the snapshot health across CI runs
+//	dupes       list large snapshots duplicated across tests, or move them to shared .snap files
+//	flakes      list the snapshots that both matched and mismatched at a commit, with SNAP_FLAKES
+//	overlap     list tests whose coverage is mostly covered by another test
+//	reject-all  discard all the snapshot changes recorded with SNAP_PENDING
+//	review      accept or reject each snapshot change recorded with SNAP_PENDING
+//	seed        fill in the empty and TODO snapshots of the tests, printing the patch
+//	since       list the snapshots that changed since a git revision, without running tests
+//	undo        restore the source files rewritten by the last run updating snapshots
+//	update      update the snapshots of the tests affected by changed files
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string, stdout io.Writer) error
+}
+
+var commands = []command{
+	{name: "accept-all", usage: "accept-all [-C dir]", run: runAcceptAll},
+	{name: "baseline", usage: "baseline old.jsonl new.jsonl", run: runBaseline},
+	{name: "convert", usage: "convert [-o file] file.go:line", run: runConvert},
+	{name: "dashboard", usage: "dashboard [-o file] [-title title] [-top n] run...", run: runDashboard},
+	{name: "dupes", usage: "dupes [-min-lines n] [-min-count n] [-consolidate file] [dir/...]", run: runDupes},
+	{name: "flakes", usage: "flakes [-C dir]", run: runFlakes},
+	{name: "overlap", usage: "overlap [-min percent] profile...", run: runOverlap},
+	{name: "reject-all", usage: "reject-all [-C dir]", run: runRejectAll},
+	{name: "review", usage: "review [-C dir]", run: runReview},
+	{name: "seed", usage: "seed [-C dir] [-o patch] [packages]", run: runSeed},
+	{name: "since", usage: "since [-C dir] <git revision>", run: runSince},
+	{name: "undo", usage: "undo [-C dir]", run: runUndo},
+	{name: "update", usage: "update [-C dir] [-n] path...", run: runUpdate},
+}
+
+// errChanged is returned by commands that found differences, to exit with status 1 without
+// printing an additional error.
+var errChanged = fmt.Errorf("changed")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		err := cmd.run(args[1:], stdout)
+		if err == errChanged {
+			return 1
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "snap %s: %s\n", cmd.name, err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "snap: unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  snap %s\n", cmd.usage)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KasonBraley/snap/internal/baseline"
+)
+
+func TestRunBaseline(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.jsonl")
+	newPath := filepath.Join(dir, "new.jsonl")
+
+	mustAppend := func(path string, e baseline.Entry) {
+		t.Helper()
+		if err := baseline.Append(path, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustAppend(oldPath, baseline.Entry{Package: "p", Test: "TestA", Index: 1, Want: "1", Got: "2"})
+	mustAppend(oldPath, baseline.Entry{Package: "p", Test: "TestB", Index: 1, Want: "1", Got: "2"})
+	mustAppend(newPath, baseline.Entry{Package: "p", Test: "TestA", Index: 1, Want: "1", Got: "2"})
+
+	var stdout, stderr strings.Builder
+	if code := run([]string{"baseline", oldPath, newPath}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d, stderr: %s", code, stderr.String())
+	}
+	if got, want := stdout.String(), "mismatch removed: p TestB#1\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"baseline", oldPath, oldPath}, &stdout, &stderr); code != 0 {
+		t.Errorf("expected exit code 0 for identical baselines, got %d", code)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected no output, got %q", stdout.String())
+	}
+}
+
+func TestRunUnknownCommand(t *testing.T) {
+	var stdout, stderr strings.Builder
+	if code := run([]string{"nope"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `unknown command "nope"`) {
+		t.Errorf("unexpected stderr: %q", stderr.String())
+	}
+}
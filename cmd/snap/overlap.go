@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// coverage is the code covered by a test, read from its coverage profile.
+type coverage struct {
+	test string
+	// blocks holds the number of statements of each covered block, by position, like
+	// "example.com/auth/login.go:10.2,12.3".
+	blocks     map[string]int
+	statements int
+	packages   map[string]bool
+}
+
+// runOverlap reports the pairs of tests whose coverage mostly overlaps, from coverage profiles
+// written for each test, to find redundant snapshot tests. The profiles are named after their
+// test, like TestLogin.out, and written with:
+//
+//	for test in $(go test -list . ./pkg); do
+//		go test -run "^$test\$" -coverpkg=./... -coverprofile=cover/$test.out ./pkg
+//	done
+func runOverlap(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("overlap", flag.ContinueOnError)
+	minPercent := flags.Int("min", 90, "only report tests whose covered statements are at least this percent covered by another test")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		return fmt.Errorf("expected at least two coverage profiles")
+	}
+
+	var tests []*coverage
+	for _, file := range flags.Args() {
+		c, err := readCoverage(file)
+		if err != nil {
+			return err
+		}
+		if c.statements > 0 {
+			tests = append(tests, c)
+		}
+	}
+	sort.Slice(tests, func(i, j int) bool { return tests[i].test < tests[j].test })
+
+	type overlap struct {
+		test, other *coverage
+		percent     int
+	}
+	var overlaps []overlap
+	for _, c := range tests {
+		for _, other := range tests {
+			// Compare each test with the larger ones, and tests of the same size once.
+			if other == c || other.statements < c.statements || other.statements == c.statements && other.test < c.test {
+				continue
+			}
+			shared := 0
+			for block, n := range c.blocks {
+				if _, ok := other.blocks[block]; ok {
+					shared += n
+				}
+			}
+			if percent := shared * 100 / c.statements; percent >= *minPercent {
+				overlaps = append(overlaps, overlap{test: c, other: other, percent: percent})
+			}
+		}
+	}
+	// Most redundant first.
+	sort.SliceStable(overlaps, func(i, j int) bool { return overlaps[i].percent > overlaps[j].percent })
+
+	for _, o := range overlaps {
+		fmt.Fprintf(stdout, "%s: %d%% of its %d covered statements are covered by %s\n",
+			o.test.test, o.percent, o.test.statements, o.other.test)
+		fmt.Fprintf(stdout, "\tpackages: %s\n", strings.Join(sortedKeys(o.test.packages), ", "))
+	}
+
+	if len(overlaps) > 0 {
+		return errChanged
+	}
+	return nil
+}
+
+// readCoverage reads the coverage profile file, written by go test -coverprofile, of the test the
+// file is named after.
+func readCoverage(file string) (*coverage, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	name := filepath.Base(file)
+	c := &coverage{
+		test:     strings.TrimSuffix(name, filepath.Ext(name)),
+		blocks:   make(map[string]int),
+		packages: make(map[string]bool),
+	}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if line == 1 && strings.HasPrefix(text, "mode: ") || text == "" {
+			continue
+		}
+		// Lines are "file:startLine.startCol,endLine.endCol statements count". Profiles written
+		// with -coverpkg can repeat a block, once for each package of tests.
+		fields := strings.Fields(text)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: invalid coverage line %q", file, line, text)
+		}
+		statements, err1 := strconv.Atoi(fields[1])
+		count, err2 := strconv.Atoi(fields[2])
+		i := strings.LastIndexByte(fields[0], ':')
+		if err1 != nil || err2 != nil || i < 0 {
+			return nil, fmt.Errorf("%s:%d: invalid coverage line %q", file, line, text)
+		}
+		block := fields[0]
+		if count == 0 {
+			continue
+		}
+		if _, ok := c.blocks[block]; !ok {
+			c.blocks[block] = statements
+			c.statements += statements
+		}
+		c.packages[path.Dir(block[:i])] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunOverlap(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "TestLogin.out"), "mode: set\n"+
+		"example.com/auth/login.go:10.2,12.3 2 1\n"+
+		"example.com/auth/login.go:14.2,16.3 3 1\n"+
+		"example.com/db/query.go:5.2,6.3 1 1\n"+
+		"example.com/db/query.go:8.2,9.3 4 0\n")
+	writeFile(t, filepath.Join(dir, "TestSignup.out"), "mode: set\n"+
+		"example.com/auth/login.go:10.2,12.3 2 1\n"+
+		"example.com/auth/login.go:14.2,16.3 3 1\n"+
+		"example.com/auth/signup.go:3.2,7.3 5 1\n"+
+		"example.com/db/query.go:5.2,6.3 1 1\n")
+	writeFile(t, filepath.Join(dir, "TestAdmin.out"), "mode: set\n"+
+		"example.com/admin/admin.go:3.2,7.3 5 1\n"+
+		"example.com/auth/login.go:10.2,12.3 2 1\n")
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"overlap", "-min", "50", filepath.Join(dir, "TestAdmin.out"), filepath.Join(dir, "TestLogin.out"), filepath.Join(dir, "TestSignup.out")}, &stdout, &stderr)
+	if code != 1 {
+		t.Errorf("expected exit code 1, got %d, stderr: %s", code, stderr.String())
+	}
+	want := "TestLogin: 100% of its 6 covered statements are covered by TestSignup\n" +
+		"\tpackages: example.com/auth, example.com/db\n"
+	if stdout.String() != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, stdout.String())
+	}
+
+	writeFile(t, filepath.Join(dir, "TestBroken.out"), "mode: set\nexample.com/a.go:1.1,2.2 x 1\n")
+	stderr.Reset()
+	if code := run([]string{"overlap", filepath.Join(dir, "TestLogin.out"), filepath.Join(dir, "TestBroken.out")}, &stdout, &stderr); code != 1 ||
+		!strings.Contains(stderr.String(), `TestBroken.out:2: invalid coverage line "example.com/a.go:1.1,2.2 x 1"`) {
+		t.Errorf("expected an invalid profile error, got %d, %s", code, stderr.String())
+	}
+}
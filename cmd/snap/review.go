@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/KasonBraley/snap/internal/changes"
+)
+
+// stdin is read for the answers of `snap review`, replaced in tests.
+var stdin io.Reader = os.Stdin
+
+// runReview shows each pending change of the module recorded with SNAP_PENDING and asks whether
+// to accept it, applying it to its file, or to reject it, discarding it. Changes of several hunks
+// can be split, to accept some of their hunks only. Quitting leaves the remaining changes pending.
+func runReview(args []string, stdout io.Writer) error {
+	root, pending, err := readPending("review", args)
+	if err != nil {
+		return err
+	}
+
+	answers := bufio.NewScanner(stdin)
+	// ask asks question until it's answered with one of the options, and returns the answer, or
+	// "q" at the end of the input.
+	ask := func(question string, options string, help string) string {
+		for {
+			fmt.Fprintf(stdout, "%s [%s] ", question, options)
+			if !answers.Scan() {
+				fmt.Fprintln(stdout)
+				return "q"
+			}
+			answer := strings.TrimSpace(answers.Text())
+			if slices.Contains(strings.Split(options, ","), answer) {
+				return answer
+			}
+			fmt.Fprintln(stdout, help)
+		}
+	}
+	const quitHelp = "q - quit, leaving the remaining changes pending"
+	const help = "y - accept the change\nn - reject the change\n" + quitHelp
+	const splitHelp = "y - accept the change\nn - reject the change\ns - split the change into hunks, to accept some of them\n" + quitHelp
+	const hunkHelp = "y - accept the hunk\nn - reject the hunk\n" +
+		quitHelp + ", and this one unless some of its hunks were accepted"
+
+	var accepted []changes.Change
+	var errs []error
+review:
+	for i, c := range pending {
+		fmt.Fprintf(stdout, "%s:%d (%s) [%d/%d]\n%s\n", c.File, c.Line, c.Test, i+1, len(pending), strings.TrimSuffix(c.Diff, "\n"))
+		hunks, err := changes.Hunks(root, c)
+		if err != nil {
+			errs = append(errs, err)
+			hunks = []changes.Change{c}
+		}
+		var answer string
+		if len(hunks) > 1 {
+			answer = ask("accept this change?", "y,n,s,q", splitHelp)
+		} else {
+			answer = ask("accept this change?", "y,n,q", help)
+		}
+		switch answer {
+		case "y":
+			accepted = append(accepted, c)
+		case "n":
+			if err := changes.Remove(root, c.File, c.Line); err != nil {
+				errs = append(errs, err)
+			}
+		case "s":
+			kept := 0
+			for j, h := range hunks {
+				fmt.Fprintf(stdout, "hunk %d/%d\n%s\n", j+1, len(hunks), strings.TrimSuffix(h.Diff, "\n"))
+				switch ask("accept this hunk?", "y,n,q", hunkHelp) {
+				case "y":
+					accepted = append(accepted, h)
+					kept++
+				case "q":
+					// The hunks accepted so far are applied, which discards the change.
+					break review
+				}
+			}
+			if kept == 0 {
+				if err := changes.Remove(root, c.File, c.Line); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		case "q":
+			break review
+		}
+	}
+	if err := changes.Apply(root, accepted); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// runAcceptAll applies all the pending changes of the module.
+func runAcceptAll(args []string, stdout io.Writer) error {
+	root, pending, err := readPending("accept-all", args)
+	if err != nil {
+		return err
+	}
+	if err := changes.Apply(root, pending); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "accepted %d changes\n", len(pending))
+	return nil
+}
+
+// runRejectAll discards all the pending changes of the module.
+func runRejectAll(args []string, stdout io.Writer) error {
+	root, pending, err := readPending("reject-all", args)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, c := range pending {
+		if err := changes.Remove(root, c.File, c.Line); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "rejected %d changes\n", len(pending))
+	return nil
+}
+
+// readPending parses the arguments of the commands handling pending changes and returns the root
+// of the module and its pending changes.
+func readPending(name string, args []string) (string, []changes.Change, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	dir := flags.String("C", ".", "run in this directory of the module")
+	if err := flags.Parse(args); err != nil {
+		return "", nil, err
+	}
+	if flags.NArg() != 0 {
+		return "", nil, fmt.Errorf("expected no arguments, got %d", flags.NArg())
+	}
+
+	root, err := findModuleRoot(*dir)
+	if err != nil {
+		return "", nil, err
+	}
+	pending, err := changes.Read(root)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(pending) == 0 {
+		return "", nil, fmt.Errorf("no pending snapshot changes in %s, run the tests with SNAP_PENDING=1 to record them", root)
+	}
+	return root, pending, nil
+}
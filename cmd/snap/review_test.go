@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KasonBraley/snap/internal/changes"
+)
+
+// writePending writes a module with a file for each of contents and a pending change replacing it
+// with its upper case.
+func writePending(t *testing.T, contents ...string) (root string, files []string) {
+	t.Helper()
+	root = t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/m\n")
+	for i, content := range contents {
+		file := string(rune('a'+i)) + "_test.go"
+		writeFile(t, filepath.Join(root, file), content)
+		err := changes.Write(root, changes.Change{
+			Test:   "Test" + strings.ToUpper(file[:1]),
+			File:   file,
+			Line:   1,
+			Diff:   "-" + content + "\n+" + strings.ToUpper(content) + "\n",
+			End:    len(content),
+			New:    strings.ToUpper(content),
+			SHA256: changes.Hash([]byte(content)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, filepath.Join(root, file))
+	}
+	return root, files
+}
+
+func TestRunReview(t *testing.T) {
+	root, files := writePending(t, "one", "two", "three")
+	stdin = strings.NewReader("y\nmaybe\nn\nq\n")
+	t.Cleanup(func() { stdin = os.Stdin })
+
+	var stdout, stderr strings.Builder
+	if code := run([]string{"review", "-C", root}, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %d, stderr: %s", code, stderr.String())
+	}
+	for _, want := range []string{"a_test.go:1 (TestA) [1/3]\n-one\n+ONE\naccept this change?", "n - reject the change", "c_test.go:1 (TestC) [3/3]"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("expected the output to contain %q, got:\n%s", want, stdout.String())
+		}
+	}
+	for i, want := range []string{"ONE", "two", "three"} {
+		if b, _ := os.ReadFile(files[i]); string(b) != want {
+			t.Errorf("expected %s to contain %q, got %q", files[i], want, b)
+		}
+	}
+	pending, _ := changes.Read(root)
+	if len(pending) != 1 || pending[0].File != "c_test.go" {
+		t.Errorf("expected only the change left by quitting to stay pending, got %+v", pending)
+	}
+}
+
+func TestRunReviewHunks(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/m\n")
+	golden := filepath.Join(root, "testdata", "a.golden")
+	writeFile(t, golden, "one\ntwo\nthree\n")
+	err := changes.Write(root, changes.Change{
+		Test:   "TestA",
+		File:   "testdata/a.golden",
+		Line:   1,
+		Diff:   "-one\n+ONE\n two\n-three\n+THREE\n",
+		End:    len("one\ntwo\nthree\n"),
+		New:    "ONE\ntwo\nTHREE\n",
+		SHA256: changes.Hash([]byte("one\ntwo\nthree\n")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin = strings.NewReader("s\nn\ny\n")
+	t.Cleanup(func() { stdin = os.Stdin })
+
+	var stdout, stderr strings.Builder
+	if code := run([]string{"review", "-C", root}, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %d, stderr: %s", code, stderr.String())
+	}
+	for _, want := range []string{"accept this change? [y,n,s,q]", "hunk 1/2\n-one\n+ONE\n two\naccept this hunk? [y,n,q]", "hunk 2/2\n two\n-three\n+THREE\n"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("expected the output to contain %q, got:\n%s", want, stdout.String())
+		}
+	}
+	if b, _ := os.ReadFile(golden); string(b) != "one\ntwo\nTHREE\n" {
+		t.Errorf("expected only the accepted hunk to be applied, got %q", b)
+	}
+	if pending, _ := changes.Read(root); len(pending) != 0 {
+		t.Errorf("expected the change to be removed, got %+v", pending)
+	}
+}
+
+func TestRunAcceptAll(t *testing.T) {
+	root, files := writePending(t, "one", "two")
+	var stdout, stderr strings.Builder
+	if code := run([]string{"accept-all", "-C", root}, &stdout, &stderr); code != 0 || stdout.String() != "accepted 2 changes\n" {
+		t.Fatalf("unexpected exit code %d, stdout: %s, stderr: %s", code, stdout.String(), stderr.String())
+	}
+	for i, want := range []string{"ONE", "TWO"} {
+		if b, _ := os.ReadFile(files[i]); string(b) != want {
+			t.Errorf("expected %s to contain %q, got %q", files[i], want, b)
+		}
+	}
+
+	stderr.Reset()
+	if code := run([]string{"accept-all", "-C", root}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "no pending snapshot changes") {
+		t.Errorf("expected no pending changes to be an error, got exit code %d, stderr: %s", code, stderr.String())
+	}
+}
+
+func TestRunRejectAll(t *testing.T) {
+	root, files := writePending(t, "one")
+	var stdout, stderr strings.Builder
+	if code := run([]string{"reject-all", "-C", root}, &stdout, &stderr); code != 0 || stdout.String() != "rejected 1 changes\n" {
+		t.Fatalf("unexpected exit code %d, stdout: %s, stderr: %s", code, stdout.String(), stderr.String())
+	}
+	if b, _ := os.ReadFile(files[0]); string(b) != "one" {
+		t.Errorf("expected the file not to change, got %q", b)
+	}
+	if pending, _ := changes.Read(root); len(pending) != 0 {
+		t.Errorf("expected the changes to be removed, got %+v", pending)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/KasonBraley/snap"
+	"github.com/KasonBraley/snap/internal/changes"
+)
+
+// goTest runs go test with args in dir, with the environment variables env added, writing its
+// output to w. It's replaced in tests.
+var goTest = func(dir string, args []string, env []string, w io.Writer) error {
+	cmd := exec.Command("go", append([]string{"test"}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	return cmd.Run()
+}
+
+// runSeed runs the tests of the packages given as arguments, ./... by default, with SNAP_SEED=1,
+// which records the snapshots not written yet, empty ones, ones reading TODO and missing files, as
+// pending changes. It then applies all of them, and writes one patch of the changes to review.
+func runSeed(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	dir := flags.String("C", ".", "run in this directory of the module")
+	out := flags.String("o", "", "write the patch to this file instead of the standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	pkgs := flags.Args()
+	if len(pkgs) == 0 {
+		pkgs = []string{"./..."}
+	}
+
+	root, err := findModuleRoot(*dir)
+	if err != nil {
+		return err
+	}
+	// The changes must all come from this run, to be applied together.
+	if pending, err := changes.Read(root); err != nil {
+		return err
+	} else if len(pending) > 0 {
+		return fmt.Errorf("%d snapshot changes are already pending, review them with snap review first", len(pending))
+	}
+
+	// The tests of the snapshots to seed fail, so a failure of go test is only reported if there's
+	// nothing to seed.
+	testErr := goTest(*dir, pkgs, []string{"SNAP_SEED=1"}, os.Stderr)
+	pending, err := changes.Read(root)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		if testErr != nil {
+			return fmt.Errorf("go test: %w", testErr)
+		}
+		return errors.New("no snapshots to seed")
+	}
+
+	var files []string
+	before := make(map[string][]byte)
+	for _, c := range pending {
+		if _, ok := before[c.File]; ok {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(c.File)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		before[c.File] = data
+		files = append(files, c.File)
+	}
+	sort.Strings(files)
+	if err := changes.Apply(root, pending); err != nil {
+		return err
+	}
+
+	w := stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	for _, file := range files {
+		after, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil {
+			return err
+		}
+		writePatch(w, file, before[file], after)
+	}
+	return nil
+}
+
+// writePatch writes the unified diff of the file from old to new to w, in the format of git diff.
+// Files that didn't exist have a nil old.
+func writePatch(w io.Writer, file string, old []byte, new []byte) {
+	if old == nil {
+		lines := strings.SplitAfter(strings.TrimSuffix(string(new), "\n"), "\n")
+		fmt.Fprintf(w, "--- /dev/null\n+++ b/%s\n@@ -0,0 +1,%d @@\n+%s\n", file, len(lines), strings.Join(lines, "+"))
+		return
+	}
+	oldText, newText := string(old), string(new)
+	// Both texts end with a newline, which isn't a line of its own.
+	if strings.HasSuffix(oldText, "\n") && strings.HasSuffix(newText, "\n") {
+		oldText, newText = oldText[:len(oldText)-1], newText[:len(newText)-1]
+	}
+	fmt.Fprintf(w, "--- a/%s\n+++ b/%s\n%s", file, file, snap.ComputeDiff(oldText, newText, snap.ExactLines()).Text)
+}
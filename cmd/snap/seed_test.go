@@ -0,0 +1,61 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KasonBraley/snap/internal/changes"
+)
+
+func TestRunSeed(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/m\n")
+	src := "package m\n\nfunc TestHelp(t *testing.T) {\n\tsnap.Snap(t, `TODO`).Diff(help())\n}\n"
+	writeFile(t, filepath.Join(root, "m_test.go"), src)
+
+	// The tests record a change of the TODO snapshot and of a missing golden file.
+	var env []string
+	defer func(run func(string, []string, []string, io.Writer) error) { goTest = run }(goTest)
+	goTest = func(dir string, args []string, testEnv []string, w io.Writer) error {
+		env = append(testEnv, args...)
+		start := strings.Index(src, "`TODO`")
+		err := changes.Write(root, changes.Change{
+			File: "m_test.go", Line: 4, Start: start, End: start + len("`TODO`"), New: "`usage: tool`",
+			SHA256: changes.Hash([]byte(src)),
+		})
+		if err == nil {
+			err = changes.Write(root, changes.Change{File: "testdata/help.golden", Line: 1, New: "usage: tool\nflags:\n"})
+		}
+		return err
+	}
+
+	var stdout, stderr strings.Builder
+	if code := run([]string{"seed", "-C", root}, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %d, stderr: %s", code, stderr.String())
+	}
+	if strings.Join(env, " ") != "SNAP_SEED=1 ./..." {
+		t.Errorf("expected the tests of all packages to run with SNAP_SEED=1, got %q", env)
+	}
+	want := "--- a/m_test.go\n+++ b/m_test.go\n" +
+		"@@ -1,5 +1,5 @@\n package m\n \n func TestHelp(t *testing.T) {\n-\tsnap.Snap(t, `TODO`).Diff(help())\n+\tsnap.Snap(t, `usage: tool`).Diff(help())\n }\n" +
+		"--- /dev/null\n+++ b/testdata/help.golden\n@@ -0,0 +1,2 @@\n+usage: tool\n+flags:\n"
+	if stdout.String() != want {
+		t.Errorf("want patch:\n%s\ngot:\n%s", want, stdout.String())
+	}
+	if b, _ := os.ReadFile(filepath.Join(root, "testdata", "help.golden")); string(b) != "usage: tool\nflags:\n" {
+		t.Errorf("expected the golden file to be created, got %q", b)
+	}
+	if pending, _ := changes.Read(root); len(pending) != 0 {
+		t.Errorf("expected no pending changes left, got %+v", pending)
+	}
+
+	// Nothing is left to seed.
+	goTest = func(string, []string, []string, io.Writer) error { return nil }
+	stderr.Reset()
+	if code := run([]string{"seed", "-C", root}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "no snapshots to seed") {
+		t.Errorf("expected an error without snapshots to seed, got exit code %d, stderr: %s", code, stderr.String())
+	}
+}
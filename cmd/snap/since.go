@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/KasonBraley/snap/internal/diff"
+	"github.com/KasonBraley/snap/internal/source"
+	"github.com/google/go-cmp/cmp"
+)
+
+// runSince prints the snapshots of the test files of the git repository that changed since a
+// revision, by comparing the snapshot literals at the revision with the ones in the working tree.
+// Tests are not run.
+func runSince(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("since", flag.ContinueOnError)
+	dir := flags.String("C", ".", "run in this directory of the repository")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a git revision, got %d arguments", flags.NArg())
+	}
+	rev := flags.Arg(0)
+
+	root, err := git(*dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return err
+	}
+	root = strings.TrimSpace(root)
+
+	oldFiles, err := git(root, "ls-tree", "-r", "-z", "--name-only", rev)
+	if err != nil {
+		return err
+	}
+	newFiles, err := git(root, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return err
+	}
+	files := make(map[string]bool)
+	for _, list := range []string{oldFiles, newFiles} {
+		for _, file := range strings.Split(list, "\x00") {
+			if strings.HasSuffix(file, "_test.go") {
+				files[file] = true
+			}
+		}
+	}
+	sorted := make([]string, 0, len(files))
+	for file := range files {
+		sorted = append(sorted, file)
+	}
+	sort.Strings(sorted)
+
+	changed := false
+	for _, file := range sorted {
+		// A missing file has no snapshots.
+		oldSrc, _ := git(root, "show", rev+":"+file)
+		newSrc, _ := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+		oldSnapshots, err := constantSnapshots(file, []byte(oldSrc))
+		if err != nil {
+			return fmt.Errorf("%s at %s: %w", file, rev, err)
+		}
+		newSnapshots, err := constantSnapshots(file, newSrc)
+		if err != nil {
+			return err
+		}
+		if printChanges(stdout, oldSnapshots, newSnapshots) {
+			changed = true
+		}
+	}
+
+	if changed {
+		return errChanged
+	}
+	return nil
+}
+
+// constantSnapshots returns the snapshots of src whose text is known without running the tests.
+func constantSnapshots(filename string, src []byte) ([]source.Snapshot, error) {
+	if len(src) == 0 {
+		return nil, nil
+	}
+	snapshots, err := source.Find(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	var constant []source.Snapshot
+	for _, s := range snapshots {
+		if s.Constant {
+			constant = append(constant, s)
+		}
+	}
+	return constant, nil
+}
+
+// printChanges prints the snapshots added, removed and changed between the snapshots of a file at
+// two revisions, and reports whether there were any. Snapshots are paired up in order, like the
+// lines of a diff: removed snapshots directly followed by added ones are changes.
+func printChanges(w io.Writer, old []source.Snapshot, new []source.Snapshot) bool {
+	oldTexts, newTexts := make([]string, len(old)), make([]string, len(new))
+	for i, s := range old {
+		oldTexts[i] = s.Text
+	}
+	for i, s := range new {
+		newTexts[i] = s.Text
+	}
+
+	var removed, added []source.Snapshot
+	changed := false
+	flush := func() {
+		for len(removed) > 0 && len(added) > 0 {
+			fmt.Fprintf(w, "snapshot changed: %s (-old +new):\n%s\n", added[0].Pos, cmp.Diff(removed[0].Text, added[0].Text))
+			removed, added = removed[1:], added[1:]
+		}
+		for _, s := range removed {
+			fmt.Fprintf(w, "snapshot removed: %s\n", s.Pos)
+		}
+		for _, s := range added {
+			fmt.Fprintf(w, "snapshot added: %s\n", s.Pos)
+		}
+		removed, added = nil, nil
+	}
+	for _, e := range diff.Lines(oldTexts, newTexts, func(a, b string) bool { return a == b }) {
+		switch e.Op {
+		case diff.Equal:
+			flush()
+		case diff.Delete:
+			removed = append(removed, old[e.A])
+			changed = true
+		case diff.Insert:
+			added = append(added, new[e.B])
+			changed = true
+		}
+	}
+	flush()
+	return changed
+}
+
+// git runs git in dir and returns its output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSince(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	gitRun := func(args ...string) {
+		t.Helper()
+		if _, err := git(dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitRun("init", "-q")
+	gitRun("config", "user.email", "test@example.com")
+	gitRun("config", "user.name", "test")
+
+	writeFile(t, filepath.Join(dir, "a", "a_test.go"), "package a\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestA(t *testing.T) {\n"+
+		"\tsnap.Snap(t, `same`)\n\tsnap.Snap(t, `old`)\n\tsnap.Snap(t, `gone`)\n}\n")
+	writeFile(t, filepath.Join(dir, "b_test.go"), "package b\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestB(t *testing.T) {\n\tsnap.Snap(t, `b`)\n}\n")
+	gitRun("add", "-A")
+	gitRun("commit", "-q", "-m", "initial")
+
+	writeFile(t, filepath.Join(dir, "a", "a_test.go"), "package a\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestA(t *testing.T) {\n"+
+		"\tsnap.Snap(t, `same`)\n\tsnap.Snap(t, `new`)\n}\n")
+	writeFile(t, filepath.Join(dir, "c_test.go"), "package c\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestC(t *testing.T) {\n\tsnap.Snap(t, `c`)\n}\n")
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"since", "-C", dir, "HEAD"}, &stdout, &stderr)
+	if code != 1 {
+		t.Errorf("expected exit code 1, got %d, stderr: %s", code, stderr.String())
+	}
+
+	got := stdout.String()
+	for _, want := range []string{"snapshot changed: a/a_test.go:7:15", `"old"`, `"new"`, "snapshot removed: a/a_test.go:8:15", "snapshot added: c_test.go:6:15"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "b_test.go") {
+		t.Errorf("expected unchanged files not to be listed, got:\n%s", got)
+	}
+}
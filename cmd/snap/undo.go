@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/KasonBraley/snap/internal/undo"
+)
+
+// runUndo restores the source files of the module rewritten by the last run updating snapshots,
+// from the undo journals recorded by the test binaries. Files changed since the run are left as
+// they are.
+func runUndo(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("undo", flag.ContinueOnError)
+	dir := flags.String("C", ".", "run in this directory of the module")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("expected no arguments, got %d", flags.NArg())
+	}
+
+	root, err := findModuleRoot(*dir)
+	if err != nil {
+		return err
+	}
+	journalDir, err := undo.Dir()
+	if err != nil {
+		return err
+	}
+	journals, paths, err := undo.Last(journalDir, root)
+	if err != nil {
+		return err
+	}
+	if len(journals) == 0 {
+		return fmt.Errorf("no snapshot updates to undo in %s", root)
+	}
+
+	var errs []error
+	for _, j := range journals {
+		for _, f := range j.Files {
+			if err := restore(f); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			fmt.Fprintf(stdout, "restored %s\n", relative(root, f.Path))
+		}
+	}
+	// The files that weren't restored changed since the run, the journals can't restore them later
+	// either.
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// restore writes the original content of f back from its copy, if the file still has the content written by the
+// run.
+func restore(f undo.File) error {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return err
+	}
+	if undo.Hash(data) != f.SHA256 {
+		return fmt.Errorf("%s changed since the snapshots were updated, not restoring it", f.Path)
+	}
+	original, err := os.ReadFile(f.Copy)
+	if err != nil {
+		return fmt.Errorf("%s: reading the copy of its original content: %w", f.Path, err)
+	}
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(f.Path, original, info.Mode().Perm())
+}
+
+// findModuleRoot returns the absolute path of the directory containing the go.mod file of dir.
+func findModuleRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d, nil
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return "", fmt.Errorf("no go.mod found in %s or its parents", dir)
+		}
+		d = parent
+	}
+}
+
+// relative returns path relative to root if it's inside it.
+func relative(root string, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil && filepath.IsLocal(rel) {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KasonBraley/snap/internal/undo"
+)
+
+func TestRunUndo(t *testing.T) {
+	journalDir := t.TempDir()
+	t.Setenv("SNAP_UNDO_DIR", journalDir)
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/m\n")
+	updated := filepath.Join(root, "a_test.go")
+	edited := filepath.Join(root, "b_test.go")
+	writeFile(t, updated, "updated")
+	writeFile(t, edited, "edited after the update")
+
+	write := func(name string, root string, run string, path string, original string, written string) {
+		t.Helper()
+		f := undo.File{Path: path, SHA256: undo.Hash([]byte(written))}
+		var err error
+		if f.Copy, err = undo.SaveCopy(journalDir, []byte(original)); err != nil {
+			t.Fatal(err)
+		}
+		if err := undo.Append(journalDir, name, root, run, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("1-2.jsonl", root, "1", updated, "older", "before")
+	hourAgo := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(journalDir, "1-2.jsonl"), hourAgo, hourAgo); err != nil {
+		t.Fatal(err)
+	}
+	write("3-4.jsonl", root, "3", updated, "original", "updated")
+	write("3-5.jsonl", root, "3", edited, "original", "updated")
+	write("6-7.jsonl", t.TempDir(), "6", filepath.Join(root, "other"), "other", "other")
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"undo", "-C", root}, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stderr.String(), "b_test.go changed since the snapshots were updated") {
+		t.Errorf("expected the edited file not to be restored, got exit code %d and stderr: %s", code, stderr.String())
+	}
+	if stdout.String() != "restored a_test.go\n" {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+	for path, want := range map[string]string{updated: "original", edited: "edited after the update"} {
+		if b, _ := os.ReadFile(path); string(b) != want {
+			t.Errorf("expected %s to contain %q, got %q", path, want, b)
+		}
+	}
+
+	entries, _ := filepath.Glob(filepath.Join(journalDir, "*.jsonl"))
+	if len(entries) != 2 {
+		t.Errorf("expected the journals of the last run to be removed, got %v", entries)
+	}
+}
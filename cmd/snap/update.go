@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// listedPackage is a package of the module, as printed by go list -json.
+type listedPackage struct {
+	ImportPath   string
+	Dir          string
+	Imports      []string
+	TestImports  []string
+	XTestImports []string
+}
+
+// runUpdate runs the tests that the changed files or directories given as arguments may affect
+// with SNAP_UPDATE=1, rather than all the tests of the module. The tests of a package are affected
+// if the package, or any package of the module it imports directly or indirectly, including from
+// its tests, has changed. Files and directories outside of any package, like testdata, belong to
+// the package of the nearest parent directory. A trailing "/..." also includes the subdirectories.
+func runUpdate(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("update", flag.ContinueOnError)
+	dir := flags.String("C", ".", "run in this directory of the module")
+	dryRun := flags.Bool("n", false, "print the affected packages without running their tests")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("expected changed files or directories")
+	}
+
+	var changed []string
+	for _, arg := range flags.Args() {
+		path := arg
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(*dir, path)
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		// Files, including removed ones, change the package of their directory.
+		if info, err := os.Stat(abs); !strings.HasSuffix(abs, "...") && (err != nil || !info.IsDir()) {
+			abs = filepath.Dir(abs)
+		}
+		changed = append(changed, abs)
+	}
+
+	pkgs, err := listPackages(*dir)
+	if err != nil {
+		return err
+	}
+	for i, path := range changed {
+		if !strings.HasSuffix(path, "...") {
+			changed[i] = packageDir(pkgs, path)
+		}
+	}
+	affected := affectedTests(pkgs, changed)
+	if *dryRun || len(affected) == 0 {
+		for _, pkg := range affected {
+			fmt.Fprintln(stdout, pkg)
+		}
+		return nil
+	}
+
+	cmd := exec.Command("go", append([]string{"test"}, affected...)...)
+	cmd.Dir = *dir
+	cmd.Env = append(os.Environ(), "SNAP_UPDATE=1")
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go test: %w", err)
+	}
+	return nil
+}
+
+// listPackages returns the packages of the module in dir.
+func listPackages(dir string) ([]listedPackage, error) {
+	cmd := exec.Command("go", "list", "-json", "./...")
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	var pkgs []listedPackage
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for dec.More() {
+		var pkg listedPackage
+		if err := dec.Decode(&pkg); err != nil {
+			return nil, fmt.Errorf("go list: %w", err)
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
+// packageDir returns the directory of the package of pkgs containing the directory path, the
+// nearest of path and its parents, or path if there's none.
+func packageDir(pkgs []listedPackage, path string) string {
+	dirs := make(map[string]bool)
+	for _, pkg := range pkgs {
+		dirs[pkg.Dir] = true
+	}
+	for dir := path; ; {
+		if dirs[dir] {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return path
+		}
+		dir = parent
+	}
+}
+
+// affectedTests returns the import paths of the packages of pkgs whose tests the changes of the
+// directories changed may affect, in order. A directory ending in "/..." includes its
+// subdirectories.
+func affectedTests(pkgs []listedPackage, changed []string) []string {
+	affected := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, path := range changed {
+			if containsDir(path, pkg.Dir) {
+				affected[pkg.ImportPath] = true
+			}
+		}
+	}
+	// Propagate the changes to the importers, until no more packages are affected.
+	for more := true; more; {
+		more = false
+		for _, pkg := range pkgs {
+			if !affected[pkg.ImportPath] && importsAny(pkg.Imports, affected) {
+				affected[pkg.ImportPath] = true
+				more = true
+			}
+		}
+	}
+
+	var tests []string
+	for _, pkg := range pkgs {
+		if affected[pkg.ImportPath] || importsAny(pkg.TestImports, affected) || importsAny(pkg.XTestImports, affected) {
+			tests = append(tests, pkg.ImportPath)
+		}
+	}
+	sort.Strings(tests)
+	return tests
+}
+
+// containsDir reports whether the changed directory path is the package directory dir or, with a
+// trailing "/...", dir or one of its parents.
+func containsDir(path string, dir string) bool {
+	if tree, ok := strings.CutSuffix(path, string(filepath.Separator)+"..."); ok {
+		rel, err := filepath.Rel(tree, dir)
+		return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+	}
+	return path == dir
+}
+
+// importsAny reports whether any of the import paths is in pkgs.
+func importsAny(imports []string, pkgs map[string]bool) bool {
+	for _, path := range imports {
+		if pkgs[path] {
+			return true
+		}
+	}
+	return false
+}
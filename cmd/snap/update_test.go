@@ -0,0 +1,41 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunUpdate(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/m\n\ngo 1.21\n")
+	writeFile(t, filepath.Join(dir, "a", "a.go"), "package a\n")
+	writeFile(t, filepath.Join(dir, "b", "b.go"), "package b\n\nimport _ \"example.com/m/a\"\n")
+	writeFile(t, filepath.Join(dir, "c", "c.go"), "package c\n")
+	writeFile(t, filepath.Join(dir, "c", "c_test.go"), "package c_test\n\nimport _ \"example.com/m/b\"\n")
+	writeFile(t, filepath.Join(dir, "c", "testdata", "input.txt"), "")
+	writeFile(t, filepath.Join(dir, "d", "d.go"), "package d\n")
+	writeFile(t, filepath.Join(dir, "d", "e", "e.go"), "package e\n")
+
+	for _, tc := range []struct {
+		changed []string
+		want    string
+	}{
+		{changed: []string{"a/a.go"}, want: "example.com/m/a\nexample.com/m/b\nexample.com/m/c\n"},
+		{changed: []string{"b"}, want: "example.com/m/b\nexample.com/m/c\n"},
+		{changed: []string{"d/..."}, want: "example.com/m/d\nexample.com/m/d/e\n"},
+		{changed: []string{"d/e/removed.go"}, want: "example.com/m/d/e\n"},
+		{changed: []string{"d/e/testdata/x.golden"}, want: "example.com/m/d/e\n"},
+		{changed: []string{"c/testdata"}, want: "example.com/m/c\n"},
+		{changed: []string{"README.md"}, want: ""},
+	} {
+		var stdout, stderr strings.Builder
+		args := append([]string{"update", "-C", dir, "-n"}, tc.changed...)
+		if code := run(args, &stdout, &stderr); code != 0 {
+			t.Fatalf("%q: exit code %d, stderr: %s", tc.changed, code, stderr.String())
+		}
+		if stdout.String() != tc.want {
+			t.Errorf("%q: want:\n%s\ngot:\n%s", tc.changed, tc.want, stdout.String())
+		}
+	}
+}
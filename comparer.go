@@ -0,0 +1,28 @@
+package snap
+
+// Comparer reports whether the value got equals the snapshot want and, if it doesn't, explains
+// why, like which version component of a semver string differs.
+type Comparer func(want string, got string) (equal bool, explanation string)
+
+// WithComparer replaces the comparison of the snapshot with the value by compare, for values
+// with a domain-specific equality, like URLs whose query parameters may come in any order. The
+// value is normalized and the snapshot expanded as usual, but markers are passed to compare as
+// they are. Mismatches are still reported with a diff, along with the explanation, and updated
+// with SNAP_UPDATE=1.
+//
+//	snap.Snap(t, "v1.2.0").WithComparer(sameMinorVersion).Diff(version)
+func (s *Snapshot) WithComparer(compare Comparer) *Snapshot {
+	c := *s
+	c.comparer = compare
+	return &c
+}
+
+// compare reports whether got matches the snapshot want, with the [Comparer] of the snapshot if
+// it has one, and its explanation if got doesn't.
+func (s *Snapshot) compare(got string, want string) (equal bool, explanation string) {
+	s.t.Helper()
+	if s.comparer != nil {
+		return s.comparer(want, got)
+	}
+	return s.equal(got, want), ""
+}
@@ -0,0 +1,62 @@
+package snap
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// sameQuery is a Comparer for URLs whose query parameters may come in any order.
+func sameQuery(want string, got string) (bool, string) {
+	wantURL, err := url.Parse(want)
+	if err != nil {
+		return false, err.Error()
+	}
+	gotURL, err := url.Parse(got)
+	if err != nil {
+		return false, err.Error()
+	}
+	if wantURL.Path != gotURL.Path {
+		return false, "the paths differ"
+	}
+	if wantURL.Query().Encode() != gotURL.Query().Encode() {
+		return false, "the query parameters differ"
+	}
+	return true, ""
+}
+
+func TestWithComparer(t *testing.T) {
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "/search?q=go&page=2").WithComparer(sameQuery).Diff("/search?page=2&q=go")
+	if len(ft.errors) != 0 {
+		t.Errorf("expected the comparer to match, got %q", ft.errors)
+	}
+
+	ft = newFakeT(t)
+	snapNoUpdate(ft, "/search?q=go&page=2").WithComparer(sameQuery).Diff("/search?page=3&q=go")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "snap: Comparer: the query parameters differ") ||
+		!strings.Contains(ft.errors[0], "(-want +got)") {
+		t.Errorf("expected a diff with the explanation, got %q", ft.errors)
+	}
+
+	ft = newFakeT(t)
+	never := func(want, got string) (bool, string) { return false, "never equal" }
+	snapNoUpdate(ft, "same").WithComparer(never).Diff("same")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "its comparer doesn't match them: never equal") {
+		t.Errorf("expected the comparer to reject an equal value, got %q", ft.errors)
+	}
+}
+
+func TestWithComparerUpdate(t *testing.T) {
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `/a?x=1&y=2`).Diff(got)\n}\n", "/a?x=1&y=2")
+	s.WithComparer(sameQuery).Diff("/a?y=2&x=1")
+	if src := readFile(t, path); !strings.Contains(src, "`/a?x=1&y=2`") {
+		t.Errorf("expected an equal value not to update the snapshot, got:\n%s", src)
+	}
+
+	s.WithComparer(sameQuery).Diff("/a?y=3&x=1")
+	if src := readFile(t, path); !strings.Contains(src, "`/a?y=3&x=1`") {
+		t.Errorf("expected a mismatching value to update the snapshot, got:\n%s", src)
+	}
+}
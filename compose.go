@@ -0,0 +1,108 @@
+package snap
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Part is a part of a value composed with [Compose], rendered and normalized on its own, like the
+// output of one subsystem in an end-to-end test.
+type Part struct {
+	Name string
+	// Render returns the text of the part. An error is rendered in place of the text, so that it
+	// shows in the diff.
+	Render func() (string, error)
+	// Normalizers are applied to the text of the part only.
+	Normalizers []Normalizer
+}
+
+// TextPart returns a [Part] rendering text.
+func TextPart(name string, text string, normalizers ...Normalizer) Part {
+	return Part{Name: name, Render: func() (string, error) { return text, nil }, Normalizers: normalizers}
+}
+
+// JSONPart returns a [Part] rendering value as JSON indented with two spaces, like
+// [Snapshot.DiffJSON].
+func JSONPart(name string, value any, normalizers ...Normalizer) Part {
+	render := func() (string, error) { return encodeJSON(value, "  ", "") }
+	return Part{Name: name, Render: render, Normalizers: normalizers}
+}
+
+// partHeader matches the header lines starting the parts of a composed value.
+var partHeader = regexp.MustCompile(`(?m)^=== (.+) ===$`)
+
+// Compose renders parts into one value to snapshot, each part starting with a "=== name ===" line.
+// When the snapshot of a composed value differs, the failure lists the parts that changed, to tell
+// which subsystem's output diverged:
+//
+//	snap.Snap(t, want).Diff(snap.Compose(
+//		snap.TextPart("http", resp, snap.DropLines(regexp.MustCompile(`^Date:`))),
+//		snap.JSONPart("db", rows),
+//	))
+func Compose(parts ...Part) string {
+	var sb strings.Builder
+	for i, p := range parts {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		text, err := p.Render()
+		if err != nil {
+			text = fmt.Sprintf("error: %v", err)
+		}
+		for _, n := range p.Normalizers {
+			text = n(text)
+		}
+		fmt.Fprintf(&sb, "=== %s ===\n%s", p.Name, text)
+	}
+	return sb.String()
+}
+
+// splitParts splits a composed value into its parts, in order, or returns nil if value is not
+// composed.
+func splitParts(value string) (names []string, texts map[string]string) {
+	headers := partHeader.FindAllStringSubmatchIndex(value, -1)
+	if len(headers) == 0 || headers[0][0] != 0 {
+		return nil, nil
+	}
+	texts = make(map[string]string, len(headers))
+	for i, h := range headers {
+		end := len(value)
+		if i+1 < len(headers) {
+			end = headers[i+1][0] - 1 // Without the newline before the next header.
+		}
+		name := value[h[2]:h[3]]
+		names = append(names, name)
+		texts[name] = value[min(h[1]+1, end):end]
+	}
+	return names, texts
+}
+
+// changedParts lists the parts of the composed value got that differ from the snapshot want, or
+// returns an empty string if got is not composed.
+func changedParts(want string, got string) string {
+	gotNames, gotTexts := splitParts(got)
+	if gotNames == nil {
+		return ""
+	}
+	wantNames, wantTexts := splitParts(want)
+	var changed []string
+	for _, name := range gotNames {
+		text, ok := wantTexts[name]
+		switch {
+		case !ok:
+			changed = append(changed, name+" (added)")
+		case !matchIgnored("\n"+gotTexts[name]+"\n", "\n"+text+"\n"): // Markers can end a part.
+			changed = append(changed, name)
+		}
+	}
+	for _, name := range wantNames {
+		if _, ok := gotTexts[name]; !ok {
+			changed = append(changed, name+" (removed)")
+		}
+	}
+	if len(changed) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("snap: Changed parts: %s\n", strings.Join(changed, ", "))
+}
@@ -0,0 +1,38 @@
+package snap
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCompose(t *testing.T) {
+	got := Compose(
+		TextPart("http", "HTTP/1.1 200 OK\nDate: Mon, 01 Jan 2024\nContent-Type: text/plain", DropLines(regexp.MustCompile(`^Date:`))),
+		JSONPart("db", map[string]int{"users": 2}),
+		Part{Name: "queue", Render: func() (string, error) { return "", errors.New("connection refused") }},
+	)
+	Snap(t, `=== http ===
+HTTP/1.1 200 OK
+Content-Type: text/plain
+=== db ===
+{
+  "users": 2
+}
+=== queue ===
+error: connection refused`).Diff(got)
+}
+
+func TestComposeChangedParts(t *testing.T) {
+	want := "=== http ===\n200 OK\n=== db ===\nusers: <snap:ignore>\n=== cache ===\nhit"
+	ft := newFakeT(t)
+	snapNoUpdate(ft, want).Diff(Compose(
+		TextPart("http", "500 Internal Server Error"),
+		TextPart("db", "users: 3"),
+		TextPart("search", "ok"),
+	))
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "snap: Changed parts: http, search (added), cache (removed)\n") {
+		t.Errorf("expected the changed parts to be listed, got %q", ft.errors)
+	}
+}
@@ -0,0 +1,189 @@
+package snap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/KasonBraley/snap/internal/diff"
+)
+
+// Diff is the line diff between a snapshot and a value, computed by [ComputeDiff].
+type Diff struct {
+	Hunks []Hunk
+	Stats DiffStats
+	// Text is the diff rendered in the unified format, without file headers. It's empty when
+	// there are no changes.
+	Text string
+}
+
+// Hunk is a group of changed lines with the unchanged lines around them.
+type Hunk struct {
+	WantStart int // Line of the hunk in want, starting at 1.
+	WantLines int // Number of lines of want in the hunk.
+	GotStart  int // Line of the hunk in got, starting at 1.
+	GotLines  int // Number of lines of got in the hunk.
+	Lines     []DiffLine
+}
+
+// DiffLine is a line of a [Hunk].
+type DiffLine struct {
+	Op   LineOp
+	Text string
+}
+
+// LineOp is the kind of a [DiffLine].
+type LineOp int
+
+const (
+	LineEqual   LineOp = iota // The line is in want and got.
+	LineRemoved               // The line is only in want.
+	LineAdded                 // The line is only in got.
+)
+
+// DiffStats counts the changed lines of a [Diff].
+type DiffStats struct {
+	Added   int
+	Removed int
+	Ignored int // Markers on unchanged lines.
+}
+
+// String summarizes the stats in one line, like "2 lines added, 1 removed, 3 ignored regions
+// matched".
+func (s DiffStats) String() string {
+	return fmt.Sprintf("%d %s added, %d removed, %d ignored %s matched",
+		s.Added, plural(s.Added, "line", "lines"), s.Removed, s.Ignored, plural(s.Ignored, "region", "regions"))
+}
+
+// DiffOption configures [ComputeDiff].
+type DiffOption func(*diffOptions)
+
+type diffOptions struct {
+	context int
+	markers bool
+}
+
+// ContextLines sets the number of unchanged lines around the changes of each hunk, 3 by default.
+func ContextLines(n int) DiffOption {
+	return func(o *diffOptions) { o.context = n }
+}
+
+// ExactLines compares the lines exactly, without matching the markers of want, like
+// <snap:ignore>, against got.
+func ExactLines() DiffOption {
+	return func(o *diffOptions) { o.markers = false }
+}
+
+// ComputeDiff computes the line diff from the snapshot text want to got, as snapshots report it,
+// for programs post-processing diffs independently of tests. Lines of want with markers, like
+// <snap:ignore>, are equal to the lines of got they match, unless [ExactLines] is given.
+func ComputeDiff(want string, got string, opts ...DiffOption) Diff {
+	o := diffOptions{context: 3, markers: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	var equal func(string, string) bool
+	if o.markers {
+		equal = lineMatches
+	}
+	edits := diff.Lines(wantLines, gotLines, equal)
+
+	var d Diff
+	for _, e := range edits {
+		switch e.Op {
+		case diff.Equal:
+			if o.markers {
+				d.Stats.Ignored += len(matchingMarker.FindAllStringIndex(wantLines[e.A], -1))
+			}
+		case diff.Delete:
+			d.Stats.Removed++
+		case diff.Insert:
+			d.Stats.Added++
+		}
+	}
+	d.Hunks = hunks(edits, wantLines, gotLines, o.context)
+
+	var sb strings.Builder
+	for _, h := range d.Hunks {
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", h.WantStart, h.WantLines, h.GotStart, h.GotLines)
+		for _, l := range h.Lines {
+			sb.WriteString([]string{" ", "-", "+"}[l.Op])
+			sb.WriteString(l.Text)
+			sb.WriteString("\n")
+		}
+	}
+	d.Text = sb.String()
+	return d
+}
+
+// hunks groups the changes of edits with context unchanged lines around them, merging the groups
+// whose context overlaps.
+func hunks(edits []diff.Edit, wantLines []string, gotLines []string, context int) []Hunk {
+	// wantPos[i] and gotPos[i] are the number of lines of want and got before edits[i].
+	wantPos := make([]int, len(edits)+1)
+	gotPos := make([]int, len(edits)+1)
+	for i, e := range edits {
+		wantPos[i+1], gotPos[i+1] = wantPos[i], gotPos[i]
+		if e.Op != diff.Insert {
+			wantPos[i+1]++
+		}
+		if e.Op != diff.Delete {
+			gotPos[i+1]++
+		}
+	}
+
+	var result []Hunk
+	for i := 0; i < len(edits); {
+		if edits[i].Op == diff.Equal {
+			i++
+			continue
+		}
+
+		// Extend the hunk over changes separated by at most 2*context unchanged lines.
+		start := max(i-context, 0)
+		end := i
+		for end < len(edits) {
+			if edits[end].Op != diff.Equal {
+				end++
+				continue
+			}
+			next := end
+			for next < len(edits) && edits[next].Op == diff.Equal {
+				next++
+			}
+			if next == len(edits) || next-end > 2*context {
+				break
+			}
+			end = next
+		}
+		stop := min(end+context, len(edits))
+
+		h := Hunk{WantStart: wantPos[start] + 1, GotStart: gotPos[start] + 1}
+		for _, e := range edits[start:stop] {
+			switch e.Op {
+			case diff.Equal:
+				h.Lines = append(h.Lines, DiffLine{Op: LineEqual, Text: wantLines[e.A]})
+				h.WantLines++
+				h.GotLines++
+			case diff.Delete:
+				h.Lines = append(h.Lines, DiffLine{Op: LineRemoved, Text: wantLines[e.A]})
+				h.WantLines++
+			case diff.Insert:
+				h.Lines = append(h.Lines, DiffLine{Op: LineAdded, Text: gotLines[e.B]})
+				h.GotLines++
+			}
+		}
+		// Like diff -u, empty sides start at the line before them.
+		if h.WantLines == 0 {
+			h.WantStart--
+		}
+		if h.GotLines == 0 {
+			h.GotStart--
+		}
+		result = append(result, h)
+		i = stop
+	}
+	return result
+}
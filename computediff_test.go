@@ -0,0 +1,41 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComputeDiff(t *testing.T) {
+	want := strings.Join([]string{"a", "b", "id <snap:ignore>", "c", "d", "e", "f", "g", "h", "i", "j"}, "\n")
+	got := strings.Join([]string{"a", "B", "id 42", "c", "d", "e", "f", "g", "h", "i", "j", "k"}, "\n")
+
+	d := ComputeDiff(want, got, ContextLines(1))
+	if d.Stats != (DiffStats{Added: 2, Removed: 1, Ignored: 1}) {
+		t.Errorf("unexpected stats %+v", d.Stats)
+	}
+	Snap(t, `@@ -1,3 +1,3 @@
+ a
+-b
++B
+ id <snap:ignore>
+@@ -11,1 +11,2 @@
+ j
++k
+`).Diff(d.Text)
+	if len(d.Hunks) != 2 || d.Hunks[1].Lines[1] != (DiffLine{Op: LineAdded, Text: "k"}) {
+		t.Errorf("unexpected hunks %+v", d.Hunks)
+	}
+
+	d = ComputeDiff(want, got, ExactLines())
+	if d.Stats != (DiffStats{Added: 3, Removed: 2}) || len(d.Hunks) != 2 {
+		t.Errorf("expected the markers to be compared exactly, got %+v", d)
+	}
+
+	if d := ComputeDiff("a\nb", "b", ContextLines(0)); d.Text != "@@ -1,1 +0,0 @@\n-a\n" {
+		t.Errorf("expected an empty side to start at the line before it, got:\n%s", d.Text)
+	}
+
+	if d := ComputeDiff("same", "same"); d.Text != "" || d.Hunks != nil {
+		t.Errorf("expected no changes, got %+v", d)
+	}
+}
@@ -0,0 +1,260 @@
+package snap
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/KasonBraley/snap/internal/source"
+)
+
+// InlineToFile moves the snapshot of the snap.Snap call at line of the Go source file srcFile to
+// the snapshot file at path, relative to the directory of srcFile like the paths of [SnapFile],
+// and rewrites the call to snap.SnapFile(t, path), keeping the options chained to it. Markers are
+// moved verbatim, and .snap files are written in the format of snap. The file must not exist yet.
+//
+//	err := snap.InlineToFile("handler_test.go", 42, "testdata/help.golden")
+//
+// It's the library side of `snap convert`, for snapshots that outgrew the source.
+func InlineToFile(srcFile string, line int, path string) error {
+	src, err := os.ReadFile(srcFile)
+	if err != nil {
+		return err
+	}
+	call, err := findConvertCall(srcFile, src, line, "Snap")
+	if err != nil {
+		return err
+	}
+	text, ok := source.Constant(call.arg)
+	if !ok {
+		return fmt.Errorf("%s:%d: the snapshot is not a constant string", relativePath(srcFile), line)
+	}
+
+	dst := path
+	if !filepath.IsAbs(dst) {
+		dst = filepath.Join(filepath.Dir(srcFile), path)
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("%s already exists", relativePath(dst))
+	}
+	data := []byte(text)
+	if isSnapFile(dst) {
+		var f snapFile
+		f.setText("", text)
+		f.setStamp(currentStamp())
+		data = []byte(f.String())
+	}
+	if isGzipFile(dst) {
+		if data, err = compressGzip(data); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return err
+	}
+
+	updated, err := call.rewrite(src, "SnapFile", strconv.Quote(filepath.ToSlash(path)))
+	if err == nil {
+		err = writeFile(srcFile, updated)
+	}
+	if err != nil {
+		// The snapshot stays inline.
+		os.Remove(dst)
+		return err
+	}
+	return nil
+}
+
+// FileToInline moves the snapshot of the snap.SnapFile call at line of the Go source file srcFile
+// back inline, rewriting the call to snap.Snap(t, `...`) and removing the file. It's the reverse of
+// [InlineToFile], for snapshots that shrank. The comments and sections of .snap files can't be kept
+// inline, so files that have them are refused.
+func FileToInline(srcFile string, line int) error {
+	src, err := os.ReadFile(srcFile)
+	if err != nil {
+		return err
+	}
+	call, err := findConvertCall(srcFile, src, line, "SnapFile")
+	if err != nil {
+		return err
+	}
+	path, ok := source.Constant(call.arg)
+	if !ok {
+		return fmt.Errorf("%s:%d: the snapshot file is not a constant string", relativePath(srcFile), line)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(srcFile), filepath.FromSlash(path))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	text := string(data)
+	if isGzipFile(path) {
+		if text, err = decompressGzip(text); err != nil {
+			return err
+		}
+	}
+	if isSnapFile(path) {
+		f := parseSnapFile(text)
+		for _, l := range f.lines {
+			_, isHeader := sectionName(l)
+			if isHeader || isComment(l) && !strings.HasPrefix(l, stampPrefix) {
+				return fmt.Errorf("%s has comments or sections, which can't be kept inline", relativePath(path))
+			}
+		}
+		text, _ = f.text("")
+	}
+
+	updated, err := call.rewrite(src, "Snap", GoStringLiteral(text))
+	if err != nil {
+		return err
+	}
+	if err := writeFile(srcFile, updated); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// InlineToSection moves the snapshot of the snap.Snap call at line of the Go source file srcFile
+// to the section name of the .snap file at path, relative to the directory of srcFile, and
+// rewrites the call to snap.FileSection(t, path, name). Snapshots moved to the same section share
+// it, so the section may already exist, but only with the same snapshot.
+//
+// It's the library side of `snap dupes -consolidate`, for identical snapshots carried by many
+// tests.
+func InlineToSection(srcFile string, line int, path string, name string) error {
+	src, err := os.ReadFile(srcFile)
+	if err != nil {
+		return err
+	}
+	call, err := findConvertCall(srcFile, src, line, "Snap")
+	if err != nil {
+		return err
+	}
+	text, ok := source.Constant(call.arg)
+	if !ok {
+		return fmt.Errorf("%s:%d: the snapshot is not a constant string", relativePath(srcFile), line)
+	}
+	dst := path
+	if !filepath.IsAbs(dst) {
+		dst = filepath.Join(filepath.Dir(srcFile), path)
+	}
+	if !isSnapFile(dst) {
+		return fmt.Errorf("%s is not a %s file", relativePath(dst), snapFileExt)
+	}
+
+	unlock := lockFile(dst)
+	defer unlock()
+	data, err := os.ReadFile(dst)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	f := parseSnapFile(string(data))
+	if current, ok := f.text(name); ok && current != text {
+		return fmt.Errorf("%s already has a different section %q", relativePath(dst), name)
+	} else if !ok {
+		f.setText(name, text)
+		f.setStamp(currentStamp())
+		if exists {
+			err = writeFile(dst, []byte(f.String()))
+		} else if err = os.MkdirAll(filepath.Dir(dst), 0755); err == nil {
+			err = os.WriteFile(dst, []byte(f.String()), 0644)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	updated, err := call.rewrite(src, "FileSection", strconv.Quote(filepath.ToSlash(path))+", "+strconv.Quote(name))
+	if err != nil {
+		return err
+	}
+	return writeFile(srcFile, updated)
+}
+
+// convertCall is a call converted by [InlineToFile] or [FileToInline].
+type convertCall struct {
+	fset *token.FileSet
+	fun  *ast.Ident // Name of the called function.
+	arg  ast.Expr   // Argument holding the snapshot or the path of its file.
+}
+
+// findConvertCall returns the call to the snap function name at line of the Go source file
+// filename, with src as content.
+func findConvertCall(filename string, src []byte, line int, name string) (convertCall, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return convertCall{}, err
+	}
+	dotImport := false
+	for _, imp := range f.Imports {
+		if imp.Name != nil && imp.Name.Name == "." && strings.HasSuffix(imp.Path.Value, `/snap"`) {
+			dotImport = true
+		}
+	}
+
+	var found []convertCall
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 || fset.Position(call.Pos()).Line != line {
+			return true
+		}
+		var fun *ast.Ident
+		switch e := call.Fun.(type) {
+		case *ast.SelectorExpr:
+			if _, ok := e.X.(*ast.Ident); ok {
+				fun = e.Sel
+			}
+		case *ast.Ident:
+			if dotImport {
+				fun = e
+			}
+		}
+		if fun != nil && fun.Name == name {
+			found = append(found, convertCall{fset: fset, fun: fun, arg: call.Args[1]})
+		}
+		return true
+	})
+	switch len(found) {
+	case 0:
+		return convertCall{}, fmt.Errorf("%s:%d: no snap.%s call", relativePath(filename), line, name)
+	case 1:
+		return found[0], nil
+	}
+	return convertCall{}, fmt.Errorf("%s:%d: more than one snap.%s call", relativePath(filename), line, name)
+}
+
+// rewrite returns src with the call calling name with arg instead. Files formatted with gofmt stay
+// formatted.
+func (c convertCall) rewrite(src []byte, name string, arg string) ([]byte, error) {
+	offset := func(pos token.Pos) int { return c.fset.Position(pos).Offset }
+	var b []byte
+	b = append(b, src[:offset(c.fun.Pos())]...)
+	b = append(b, name...)
+	b = append(b, src[offset(c.fun.End()):offset(c.arg.Pos())]...)
+	b = append(b, arg...)
+	b = append(b, src[offset(c.arg.End()):]...)
+	if !gofmtClean(src) {
+		return b, nil
+	}
+	formatted, err := format.Source(b)
+	if err != nil {
+		return nil, fmt.Errorf("the rewritten file doesn't parse: %w", err)
+	}
+	return formatted, nil
+}
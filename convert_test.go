@@ -0,0 +1,75 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a_test.go")
+	original := "package a\n\nimport (\n\t\"testing\"\n\n\t\"github.com/KasonBraley/snap\"\n)\n\nfunc TestA(t *testing.T) {\n\tsnap.Snap(t, `line\n<snap:ignore-line>\n`).Diff(got())\n}\n"
+	if err := os.WriteFile(src, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := InlineToFile(src, 10, "testdata/a.golden"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, src); !strings.Contains(got, "\tsnap.SnapFile(t, \"testdata/a.golden\").Diff(got())\n") {
+		t.Errorf("expected the call to be rewritten, got:\n%s", got)
+	}
+	if got := readFile(t, filepath.Join(dir, "testdata", "a.golden")); got != "line\n<snap:ignore-line>\n" {
+		t.Errorf("expected the markers to be moved verbatim, got %q", got)
+	}
+	if err := InlineToFile(src, 10, "testdata/b.golden"); err == nil || !strings.Contains(err.Error(), "no snap.Snap call") {
+		t.Errorf("expected the converted call not to be found, got %v", err)
+	}
+
+	if err := FileToInline(src, 10); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, src); got != original {
+		t.Errorf("expected the snapshot to be moved back, got:\n%s", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "testdata", "a.golden")); !os.IsNotExist(err) {
+		t.Errorf("expected the file to be removed, got %v", err)
+	}
+
+	// .snap files are written in the format of snap, and read back unless they have comments.
+	if err := InlineToFile(src, 10, "a.snap"); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "a.snap")
+	if got, want := readFile(t, path), currentStamp().String()+"\nline\n<snap:ignore-line>\n\n"; got != want {
+		t.Errorf("expected the file in the format of snap, want %q, got %q", want, got)
+	}
+	if err := os.WriteFile(path, []byte("# Reviewed.\nline\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := FileToInline(src, 10); err == nil || !strings.Contains(err.Error(), "has comments or sections") {
+		t.Errorf("expected the comments to be kept, got %v", err)
+	}
+}
+
+func TestInlineToSection(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a_test.go")
+	if err := os.WriteFile(src, []byte("package a\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestA(t *testing.T) {\n\tsnap.Snap(t, \"-- a --\")\n\tsnap.Snap(t, \"other\")\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := InlineToSection(src, 6, "shared.snap", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readFile(t, filepath.Join(dir, "shared.snap")), currentStamp().String()+"\n-- a --\n\\-- a --\n"; got != want {
+		t.Errorf("expected the section to be added, want %q, got %q", want, got)
+	}
+	if got := readFile(t, src); !strings.Contains(got, "\tsnap.FileSection(t, \"shared.snap\", \"a\")\n") {
+		t.Errorf("expected the call to be rewritten, got:\n%s", got)
+	}
+	if err := InlineToSection(src, 7, "shared.snap", "a"); err == nil || !strings.Contains(err.Error(), `already has a different section "a"`) {
+		t.Errorf("expected the section not to be replaced, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package snap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// pendingUpdate is a snapshot update delayed by [Run].
+type pendingUpdate struct {
+	rewrite rewrite
+	test    string // Name of the test that requested it.
+}
+
+var (
+	pendingMu sync.Mutex
+	// pending holds the updates delayed until the tests run by [Run] finish, by source file. It's
+	// nil outside of Run, where snapshots are updated right away.
+	pending map[string][]pendingUpdate
+)
+
+// Run runs the tests like m.Run, and returns the exit code to pass to os.Exit. Call it from
+// TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(snap.Run(m))
+//	}
+//
+// Snapshot updates are then delayed until all tests have finished, and applied file by file in a
+// stable order. Repeated SNAP_UPDATE runs produce the same source changes regardless of how
+// parallel tests are scheduled, and updating a snapshot can't move the lines of the other
+// snapshots of its file before they're updated. Conflicting updates of the same snapshot, from
+// tests sharing it, are reported instead of applied. With SNAP_PRUNE=1, the unused files of the
+// [SnapTree] directories are removed after a complete run that passed. With SNAP_TRACE set, the
+// time spent comparing, parsing and writing is printed after the tests.
+func Run(m *testing.M) int {
+	pendingMu.Lock()
+	pending = make(map[string][]pendingUpdate)
+	pendingMu.Unlock()
+
+	code := m.Run()
+
+	pendingMu.Lock()
+	updates := pending
+	pending = nil
+	pendingMu.Unlock()
+
+	if err := applyUpdates(updates, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "snap: %s\n", err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	if traceMode() {
+		printSpans(os.Stdout)
+	}
+	if code == 0 && pruneMode() && completeRun() {
+		if err := pruneTrees(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "snap: %s\n", err)
+			code = 1
+		}
+	}
+	return code
+}
+
+// queueUpdate delays the update r of the source file path if the tests run with [Run], and
+// reports whether it did.
+func queueUpdate(path string, r rewrite, test string) bool {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	if pending == nil {
+		return false
+	}
+	pending[path] = append(pending[path], pendingUpdate{rewrite: r, test: test})
+	return true
+}
+
+// applyUpdates applies the delayed updates, and prints the updated snapshots to w.
+func applyUpdates(updates map[string][]pendingUpdate, w io.Writer) error {
+	paths := make([]string, 0, len(updates))
+	for path := range updates {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var errs []error
+	for _, path := range paths {
+		if err := applyFileUpdates(path, updates[path], w); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyFileUpdates applies the delayed updates of the source file path.
+func applyFileUpdates(path string, updates []pendingUpdate, w io.Writer) error {
+	// Update from the bottom of the file up, so that changing the number of lines of a snapshot
+	// doesn't move the snapshots that are still to be updated.
+	sort.SliceStable(updates, func(i, j int) bool {
+		if updates[i].rewrite.line != updates[j].rewrite.line {
+			return updates[i].rewrite.line > updates[j].rewrite.line
+		}
+		return updates[i].test < updates[j].test
+	})
+
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	src := original
+	var errs []error
+	for len(updates) > 0 {
+		u := updates[0]
+		n := 1
+		for n < len(updates) && updates[n].rewrite.line == u.rewrite.line {
+			n++
+		}
+		conflict := false
+		for _, other := range updates[1:n] {
+			if other.rewrite != u.rewrite {
+				errs = append(errs, fmt.Errorf("%s:%d: %s and %s update the snapshot differently, not updating it",
+					relativePath(path), u.rewrite.line, u.test, other.test))
+				conflict = true
+				break
+			}
+		}
+		if !conflict {
+			out, err := u.rewrite.apply(path, src)
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				src = out.src
+				out.pos.Filename = relativePath(out.pos.Filename)
+				fmt.Fprintf(w, "snap: Updated %s for %s\n", out.pos, u.test)
+			}
+		}
+		updates = updates[n:]
+	}
+
+	final, err := finalizeSource(path, original, src)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	if err := writeFile(path, final); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,85 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// coordinate makes the updates of the test delayed, like [Run] does, and returns a function
+// applying them.
+func coordinate(t *testing.T) (apply func() (string, error)) {
+	t.Helper()
+	pendingMu.Lock()
+	pending = make(map[string][]pendingUpdate)
+	pendingMu.Unlock()
+	t.Cleanup(func() {
+		pendingMu.Lock()
+		pending = nil
+		pendingMu.Unlock()
+	})
+
+	return func() (string, error) {
+		pendingMu.Lock()
+		updates := pending
+		pending = make(map[string][]pendingUpdate)
+		pendingMu.Unlock()
+		var out strings.Builder
+		err := applyUpdates(updates, &out)
+		return out.String(), err
+	}
+}
+
+func TestRunDelaysUpdates(t *testing.T) {
+	apply := coordinate(t)
+	src := "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `a`).Diff(got)\n\tsnap.Snap(t, `b`).Diff(got)\n}\n"
+	path := filepath.Join(t.TempDir(), "example_test.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ft := newFakeT(t)
+	first := At(ft, path, 6, "a").Update()
+	second := At(ft, path, 7, "b").Update()
+	// The first update adds lines, which would move the second snapshot if written right away.
+	first.Diff("a\n1\n2")
+	second.Diff("b\n1")
+
+	if got := readFile(t, path); got != src {
+		t.Errorf("expected the source to be unchanged until the tests finish, got:\n%s", got)
+	}
+	out, err := apply()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `a\n1\n2`).Diff(got)\n\tsnap.Snap(t, `b\n1`).Diff(got)\n}\n"
+	if got := readFile(t, path); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+	if strings.Count(out, "snap: Updated") != 2 {
+		t.Errorf("expected two updates to be printed, got:\n%s", out)
+	}
+}
+
+func TestRunConflictingUpdates(t *testing.T) {
+	apply := coordinate(t)
+	src := "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `a`).Diff(got)\n}\n"
+	path := filepath.Join(t.TempDir(), "example_test.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, got := range []string{"x", "y"} {
+		ft := newFakeT(t)
+		s := At(ft, path, 6, "a").Update()
+		s.Diff(got)
+	}
+	if _, err := apply(); err == nil || !strings.Contains(err.Error(), "update the snapshot differently") {
+		t.Errorf("expected a conflict error, got %v", err)
+	}
+	if got := readFile(t, path); got != src {
+		t.Errorf("expected the conflicting snapshot not to be updated, got:\n%s", got)
+	}
+}
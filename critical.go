@@ -0,0 +1,134 @@
+package snap
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/KasonBraley/snap/internal/diff"
+)
+
+const (
+	criticalStart = "<snap:critical>"
+	criticalEnd   = "</snap:critical>"
+)
+
+// cutCritical removes the <snap:critical> and </snap:critical> lines from the snapshot text, and
+// reports for each remaining line whether it's in a critical section. critical is nil if text has
+// no critical sections.
+func cutCritical(text string) (plain string, critical []bool, err error) {
+	if !strings.Contains(text, criticalStart) && !strings.Contains(text, criticalEnd) {
+		return text, nil, nil
+	}
+	var lines []string
+	in := false
+	for _, line := range strings.Split(text, "\n") {
+		switch strings.TrimSpace(line) {
+		case criticalStart:
+			if in {
+				return "", nil, errors.New("<snap:critical> sections can't be nested")
+			}
+			in = true
+		case criticalEnd:
+			if !in {
+				return "", nil, errors.New("</snap:critical> without <snap:critical>")
+			}
+			in = false
+		default:
+			if strings.Contains(line, criticalStart) || strings.Contains(line, criticalEnd) {
+				return "", nil, errors.New("<snap:critical> and </snap:critical> must be on their own lines")
+			}
+			lines = append(lines, line)
+			critical = append(critical, in)
+		}
+	}
+	if in {
+		return "", nil, errors.New("<snap:critical> without </snap:critical>")
+	}
+	return strings.Join(lines, "\n"), critical, nil
+}
+
+// criticalChanged reports whether got differs from want in a critical section. Lines added
+// between two critical lines are in the section, and lines added at the edges of a section are
+// not.
+func criticalChanged(want string, critical []bool, got string) bool {
+	wantLines := strings.Split(want, "\n")
+	next := 0 // The want line after the current edit.
+	for _, e := range diff.Lines(wantLines, strings.Split(got, "\n"), lineMatches) {
+		switch e.Op {
+		case diff.Equal:
+			next++
+		case diff.Delete:
+			if critical[e.A] {
+				return true
+			}
+			next++
+		case diff.Insert:
+			if next > 0 && next < len(wantLines) && critical[next-1] && critical[next] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// restoreCritical adds the <snap:critical> and </snap:critical> lines of the snapshot text to the
+// text it's updated to, around the same lines. Like for criticalChanged, lines added at the edges
+// of a section are left out of it.
+func restoreCritical(snapshot string, text string) string {
+	if !strings.Contains(snapshot, criticalStart) {
+		return text
+	}
+	// tags[i] are the tag lines before the i-th line of the snapshot without them.
+	var lines []string
+	tags := [][]string{nil}
+	for _, line := range strings.Split(snapshot, "\n") {
+		if t := strings.TrimSpace(line); t == criticalStart || t == criticalEnd {
+			tags[len(lines)] = append(tags[len(lines)], line)
+			continue
+		}
+		lines = append(lines, line)
+		tags = append(tags, nil)
+	}
+
+	textLines := strings.Split(text, "\n")
+	edits := diff.Lines(lines, textLines, nil)
+	var result []string
+	next := 0 // The snapshot line after the current edit.
+	for i := 0; i < len(edits); {
+		if e := edits[i]; e.Op == diff.Equal {
+			result = append(append(result, tags[e.A]...), textLines[e.B])
+			tags[e.A] = nil
+			next++
+			i++
+			continue
+		}
+
+		// The lines added by a block of changes replace the removed lines in order, and take their
+		// place in the sections.
+		var removed, added []int
+		for ; i < len(edits) && edits[i].Op != diff.Equal; i++ {
+			if edits[i].Op == diff.Delete {
+				removed = append(removed, edits[i].A)
+			} else {
+				added = append(added, edits[i].B)
+			}
+		}
+		next += len(removed)
+		for j := 0; j < max(len(removed), len(added)); j++ {
+			if j < len(removed) {
+				result = append(result, tags[removed[j]]...)
+				tags[removed[j]] = nil
+			}
+			if j >= len(added) {
+				continue
+			}
+			// Close the section ending before the extra added lines.
+			if j >= len(removed) && len(tags[next]) > 0 && strings.TrimSpace(tags[next][0]) == criticalEnd {
+				result = append(result, tags[next][0])
+				tags[next] = tags[next][1:]
+			}
+			result = append(result, textLines[added[j]])
+		}
+	}
+	return strings.Join(append(result, tags[len(lines)]...), "\n")
+}
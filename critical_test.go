@@ -0,0 +1,88 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCriticalSections(t *testing.T) {
+	snapshot := "Request took 12ms\n<snap:critical>\nstatus: 200\nbody: ok\n</snap:critical>\nserved by node-1"
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, snapshot).Diff("Request took 15ms\nstatus: 200\nbody: ok\nserved by node-2\ncache: miss")
+	if len(ft.errors) != 0 {
+		t.Errorf("expected informational changes to pass, got %q", ft.errors)
+	}
+	if !containsLog(ft, "snap: Warning: Snapshot at critical_test.go:") || !containsLog(ft, "differs outside of its critical sections") {
+		t.Errorf("expected a warning, got %q", ft.logs)
+	}
+
+	for _, got := range []string{
+		"Request took 12ms\nstatus: 500\nbody: ok\nserved by node-1",
+		"Request took 12ms\nstatus: 200\nheader: x\nbody: ok\nserved by node-1",
+		"Request took 12ms\nstatus: 200\nserved by node-1",
+	} {
+		ft := newFakeT(t)
+		snapNoUpdate(ft, snapshot).Diff(got)
+		if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "differs") {
+			t.Errorf("expected a critical change in %q to fail, got %q", got, ft.errors)
+		}
+	}
+
+	for _, snapshot := range []string{"a\n<snap:critical>\nb", "a\n</snap:critical>\nb", "a <snap:critical>\nb\n</snap:critical>"} {
+		ft := newFakeT(t)
+		snapNoUpdate(ft, snapshot).Diff("a\nb")
+		if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "snap:critical>") {
+			t.Errorf("expected an error for %q, got %q", snapshot, ft.errors)
+		}
+	}
+}
+
+func TestRestoreCritical(t *testing.T) {
+	snapshot := "took 12ms\n<snap:critical>\nstatus: 200\nbody: ok\n</snap:critical>\nnode-1"
+	cases := []struct {
+		text string
+		want string
+	}{
+		{
+			text: "took 15ms\nstatus: 500\nbody: ok\nnode-1",
+			want: "took 15ms\n<snap:critical>\nstatus: 500\nbody: ok\n</snap:critical>\nnode-1",
+		},
+		{
+			text: "took 12ms\nstatus: 200\nheader: x\nbody: ok\ncache: miss\nnode-1",
+			want: "took 12ms\n<snap:critical>\nstatus: 200\nheader: x\nbody: ok\n</snap:critical>\ncache: miss\nnode-1",
+		},
+		{
+			text: "took 12ms\nnode-1\nnode-2",
+			want: "took 12ms\n<snap:critical>\n</snap:critical>\nnode-1\nnode-2",
+		},
+	}
+	for _, tc := range cases {
+		if got := restoreCritical(snapshot, tc.text); got != tc.want {
+			t.Errorf("expected %q, got %q", tc.want, got)
+		}
+	}
+}
+
+func TestUpdateKeepsCriticalSections(t *testing.T) {
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `took 12ms\n<snap:critical>\nstatus: 200\n</snap:critical>`).Diff(got)\n}\n",
+		"took 12ms\n<snap:critical>\nstatus: 200\n</snap:critical>")
+	s.Diff("took 15ms\nstatus: 500")
+	if src := readFile(t, path); !strings.Contains(src, "snap.Snap(t, `took 15ms\n<snap:critical>\nstatus: 500\n</snap:critical>`)") {
+		t.Errorf("expected the critical section to be kept, got:\n%s", src)
+	}
+}
+
+func TestUpdateInformationalLines(t *testing.T) {
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `took 12ms\n<snap:critical>\nstatus: 200\n</snap:critical>`).Diff(got)\n}\n",
+		"took 12ms\n<snap:critical>\nstatus: 200\n</snap:critical>")
+	s.Diff("took 15ms\nstatus: 200")
+	if len(ft.errors) != 0 {
+		t.Errorf("expected no errors, got %q", ft.errors)
+	}
+	if src := readFile(t, path); !strings.Contains(src, "snap.Snap(t, `took 15ms\n<snap:critical>\nstatus: 200\n</snap:critical>`)") {
+		t.Errorf("expected the informational line to be updated, got:\n%s", src)
+	}
+}
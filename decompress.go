@@ -0,0 +1,100 @@
+package snap
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// decompressor decompresses the values starting with magic.
+type decompressor struct {
+	name  string
+	magic string
+	fn    func(io.Reader) (io.Reader, error)
+}
+
+var (
+	decompressorsMu sync.Mutex
+	// decompressors are tried in order. zstd has no decompressor in the standard library, it's
+	// only listed to tell users to register one.
+	decompressors = []decompressor{
+		{name: "gzip", magic: "\x1f\x8b", fn: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{name: "zstd", magic: "\x28\xb5\x2f\xfd"},
+	}
+)
+
+// RegisterDecompressor registers fn to decompress the values starting with magic for
+// [Snapshot.Decompress], like zstd with github.com/klauspost/compress/zstd:
+//
+//	snap.RegisterDecompressor("zstd", "\x28\xb5\x2f\xfd", func(r io.Reader) (io.Reader, error) {
+//		return zstd.NewReader(r)
+//	})
+//
+// Typically called from TestMain or an init function. RegisterDecompressor replaces the
+// decompressor of a format without one, like zstd, and panics if a decompressor with the same
+// name is already registered.
+func RegisterDecompressor(name string, magic string, fn func(io.Reader) (io.Reader, error)) {
+	decompressorsMu.Lock()
+	defer decompressorsMu.Unlock()
+	for i, d := range decompressors {
+		if d.name != name {
+			continue
+		}
+		if d.fn != nil {
+			panic(fmt.Sprintf("snap: decompressor %q is already registered", name))
+		}
+		decompressors[i] = decompressor{name: name, magic: magic, fn: fn}
+		return
+	}
+	decompressors = append(decompressors, decompressor{name: name, magic: magic, fn: fn})
+}
+
+// Decompress makes the snapshot decompress values compressed with gzip, or a format registered
+// with [RegisterDecompressor], before comparing them, so that compressed artifacts and HTTP
+// responses with a Content-Encoding are stored as readable text. The format is detected from the
+// first bytes of the value, and values that aren't compressed are compared as they are.
+//
+//	snap.Snap(t, want).Decompress().Diff(string(body))
+func (s *Snapshot) Decompress() *Snapshot {
+	c := *s
+	c.decompress = true
+	return &c
+}
+
+// decompress decompresses value until it doesn't start with the magic of a known format.
+func decompress(value string) (string, error) {
+	decompressorsMu.Lock()
+	formats := slices.Clone(decompressors)
+	decompressorsMu.Unlock()
+
+	for {
+		var d *decompressor
+		for i := range formats {
+			if formats[i].magic != "" && strings.HasPrefix(value, formats[i].magic) {
+				d = &formats[i]
+				break
+			}
+		}
+		if d == nil {
+			return value, nil
+		}
+		if d.fn == nil {
+			return "", fmt.Errorf("the value is compressed with %s, register a decompressor for it with snap.RegisterDecompressor", d.name)
+		}
+		r, err := d.fn(strings.NewReader(value))
+		if err != nil {
+			return "", fmt.Errorf("decompressing %s: %w", d.name, err)
+		}
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return "", fmt.Errorf("decompressing %s: %w", d.name, err)
+		}
+		if c, ok := r.(io.Closer); ok {
+			c.Close()
+		}
+		value = string(b)
+	}
+}
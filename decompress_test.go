@@ -0,0 +1,60 @@
+package snap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+)
+
+func gzipped(t *testing.T, value string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(value)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestDecompress(t *testing.T) {
+	Snap(t, `{"ok":true}`).Decompress().Diff(gzipped(t, `{"ok":true}`))
+	Snap(t, `twice`).Decompress().Diff(gzipped(t, gzipped(t, "twice")))
+	Snap(t, `plain`).Decompress().Diff("plain")
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "").Decompress().Diff("\x28\xb5\x2f\xfd\x00")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "snap: the value is compressed with zstd, register a decompressor") {
+		t.Errorf("expected an error for zstd, got %q", ft.errors)
+	}
+
+	ft = newFakeT(t)
+	snapNoUpdate(ft, "").Decompress().Diff(gzipped(t, "truncated")[:12])
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "snap: decompressing gzip: ") {
+		t.Errorf("expected an error for the truncated value, got %q", ft.errors)
+	}
+}
+
+func TestRegisterDecompressor(t *testing.T) {
+	saved := decompressors
+	t.Cleanup(func() { decompressors = saved })
+	decompressors = append([]decompressor(nil), saved...)
+
+	RegisterDecompressor("zstd", "\x28\xb5\x2f\xfd", func(r io.Reader) (io.Reader, error) {
+		// Not zstd, just enough to check that the registered function is used.
+		b, err := io.ReadAll(r)
+		return strings.NewReader(strings.ToUpper(string(b[4:]))), err
+	})
+	Snap(t, `FAKE`).Decompress().Diff("\x28\xb5\x2f\xfdfake")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering zstd twice to panic")
+		}
+	}()
+	RegisterDecompressor("zstd", "\x28\xb5\x2f\xfd", nil)
+}
@@ -0,0 +1,71 @@
+package snap
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Dependencies renders the dependency graph of the module in dir, as reported by `go mod graph`,
+// as a tree of module@version lines starting from the main module, down to depth levels: 1 lists
+// the direct dependencies, 2 also their dependencies, and so on. Snapshotting it makes unexpected
+// dependencies, or version changes, show up in reviews:
+//
+//	snap.Snap(t, want).Diff(snap.Dependencies(t, ".", 1))
+//
+// Modules already listed are followed by "..." instead of their dependencies. The go and toolchain
+// requirements are left out.
+func Dependencies(t testing.TB, dir string, depth int) string {
+	t.Helper()
+	cmd := exec.Command("go", "mod", "graph")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			err = fmt.Errorf("%w: %s", err, exitErr.Stderr)
+		}
+		t.Errorf("snap: go mod graph: %v", err)
+		return ""
+	}
+
+	graph := make(map[string][]string)
+	var main string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		from, to, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		if main == "" {
+			main = from // The main module comes first.
+		}
+		if strings.HasPrefix(to, "go@") || strings.HasPrefix(to, "toolchain@") {
+			continue
+		}
+		graph[from] = append(graph[from], to)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(main + "\n")
+	seen := map[string]bool{main: true}
+	var walk func(module string, level int)
+	walk = func(module string, level int) {
+		deps := graph[module]
+		sort.Strings(deps)
+		for _, dep := range deps {
+			sb.WriteString(strings.Repeat("\t", level) + dep)
+			if seen[dep] && len(graph[dep]) > 0 && level < depth {
+				sb.WriteString(" ...\n")
+				continue
+			}
+			sb.WriteString("\n")
+			seen[dep] = true
+			if level < depth {
+				walk(dep, level+1)
+			}
+		}
+	}
+	walk(main, 1)
+	return sb.String()
+}
@@ -0,0 +1,59 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDependencies(t *testing.T) {
+	t.Setenv("GOFLAGS", "-mod=mod")
+	t.Setenv("GOPROXY", "off")
+	t.Setenv("GOWORK", "off")
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"app/go.mod": `module example.com/app
+
+go 1.21
+
+require (
+	example.com/a v1.0.0
+	example.com/b v1.1.0
+	example.com/c v1.0.0 // indirect
+)
+
+replace (
+	example.com/a => ../a
+	example.com/b => ../b
+	example.com/c => ../c
+)
+`,
+		"a/go.mod": "module example.com/a\n\ngo 1.21\n\nrequire example.com/b v1.1.0\n",
+		"b/go.mod": "module example.com/b\n\ngo 1.21\n\nrequire example.com/c v1.0.0\n",
+		"c/go.mod": "module example.com/c\n\ngo 1.21\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	app := filepath.Join(dir, "app")
+	Snap(t, `example.com/app
+	example.com/a@v1.0.0
+	example.com/b@v1.1.0
+	example.com/c@v1.0.0
+`).Diff(Dependencies(t, app, 1))
+	Snap(t, `example.com/app
+	example.com/a@v1.0.0
+		example.com/b@v1.1.0
+			example.com/c@v1.0.0
+	example.com/b@v1.1.0 ...
+	example.com/c@v1.0.0
+`).Diff(Dependencies(t, app, 3))
+}
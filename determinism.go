@@ -0,0 +1,74 @@
+package snap
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// DiffFunc compares the snapshot with the value returned by render, like [Snapshot.Diff]. Unlike a
+// value passed to Diff, the value can be rendered again, to check that it's deterministic with
+// SNAP_VERIFY_DETERMINISM:
+//
+//	snap.Snap(t, want).DiffFunc(func() string { return report.Render() })
+func (s *Snapshot) DiffFunc(render func() string) {
+	s.t.Helper()
+	got, ok := s.render(func() (string, error) { return render(), nil })
+	if !ok {
+		return
+	}
+	s.Diff(got)
+}
+
+// renderCount returns the number of times values are rendered, set by SNAP_VERIFY_DETERMINISM.
+func renderCount() (int, error) {
+	value := os.Getenv("SNAP_VERIFY_DETERMINISM")
+	if value == "" {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid SNAP_VERIFY_DETERMINISM %q, expected a positive number of renders", value)
+	}
+	return n, nil
+}
+
+// render returns the value rendered by render, calling it as many times as SNAP_VERIFY_DETERMINISM
+// sets. It fails the test and returns false if render fails, or if the renders differ, which
+// points at the nondeterministic serialization, like map iteration, times or random numbers,
+// before it turns into a flaky snapshot.
+func (s *Snapshot) render(render func() (string, error)) (string, bool) {
+	s.t.Helper()
+	got, err := s.renderValue(render)
+	if err != nil {
+		s.t.Errorf("snap: %v", err)
+		return "", false
+	}
+	return got, true
+}
+
+// renderValue is like render, but returns the error instead of failing the test, so that it can be
+// called from other goroutines.
+func (s *Snapshot) renderValue(render func() (string, error)) (string, error) {
+	n, err := renderCount()
+	if err != nil {
+		return "", err
+	}
+	got, err := render()
+	if err != nil {
+		return "", err
+	}
+	for i := 2; i <= n; i++ {
+		again, err := render()
+		if err != nil {
+			return "", err
+		}
+		if again != got {
+			return "", fmt.Errorf("Value at %s rendered differently on render %d of %d, it's not deterministic: (-render 1 +render %d):\n%s",
+				s.findLiteral(), i, n, i, cmp.Diff(got, again))
+		}
+	}
+	return got, nil
+}
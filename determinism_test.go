@@ -0,0 +1,30 @@
+package snap
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestVerifyDeterminism(t *testing.T) {
+	t.Setenv("SNAP_VERIFY_DETERMINISM", "3")
+	Snap(t, "stable").DiffFunc(func() string { return "stable" })
+	Snap(t, `{"a":1,"b":2}`).DiffJSON(map[string]int{"b": 2, "a": 1}, "")
+
+	renders := 0
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "render 1").DiffFunc(func() string {
+		renders++
+		return "render " + strconv.Itoa(renders)
+	})
+	if renders != 2 || len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "rendered differently on render 2 of 3, it's not deterministic") {
+		t.Errorf("expected a nondeterminism error after 2 renders, got %d renders and errors %q", renders, ft.errors)
+	}
+
+	t.Setenv("SNAP_VERIFY_DETERMINISM", "zero")
+	ft = newFakeT(t)
+	snapNoUpdate(ft, "a").DiffValue("a")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `invalid SNAP_VERIFY_DETERMINISM "zero"`) {
+		t.Errorf("expected an invalid SNAP_VERIFY_DETERMINISM error, got %q", ft.errors)
+	}
+}
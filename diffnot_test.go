@@ -0,0 +1,24 @@
+package snap
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDiffNot(t *testing.T) {
+	Snap(t, "v1 output").DiffNot("v2 output")
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "id <snap:ignore>.").DiffNot("id 42.")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "Value matches the snapshot at") {
+		t.Errorf("expected the matching value to fail, got %q", ft.errors)
+	}
+
+	// The value is normalized like with Diff.
+	ft = newFakeT(t)
+	snapNoUpdate(ft, "a\nc").Normalize(DropLines(regexp.MustCompile("^DEBUG"))).IgnoreOrder().DiffNot("c\nDEBUG b\na")
+	if len(ft.errors) != 1 {
+		t.Errorf("expected the normalized value with reordered lines to fail, got %q", ft.errors)
+	}
+}
@@ -0,0 +1,45 @@
+package snap
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// runDiffTool runs the external diff tool set with the SNAP_DIFF_TOOL environment variable, like
+// SNAP_DIFF_TOOL="delta --side-by-side", on files containing want and got. The paths of the two
+// files are appended to the command's arguments, and its output is logged.
+func (s *Snapshot) runDiffTool(want string, got string) {
+	s.t.Helper()
+
+	tool := strings.Fields(os.Getenv("SNAP_DIFF_TOOL"))
+	if len(tool) == 0 {
+		return
+	}
+
+	dir := s.t.TempDir()
+	wantPath := filepath.Join(dir, "want.txt")
+	gotPath := filepath.Join(dir, "got.txt")
+	if err := os.WriteFile(wantPath, []byte(want), 0600); err != nil {
+		s.logf(slog.LevelWarn, "Failed to write snapshot for SNAP_DIFF_TOOL: %s", err)
+		return
+	}
+	if err := os.WriteFile(gotPath, []byte(got), 0600); err != nil {
+		s.logf(slog.LevelWarn, "Failed to write snapshot for SNAP_DIFF_TOOL: %s", err)
+		return
+	}
+
+	args := append(tool[1:], wantPath, gotPath)
+	out, err := exec.Command(tool[0], args...).CombinedOutput() // #nosec G204 -- The command is set by the user running the tests.
+
+	// Diff tools conventionally exit with a non-zero status when the inputs differ, which they do.
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		s.logf(slog.LevelWarn, "Failed to run SNAP_DIFF_TOOL %q: %s", tool[0], err)
+		return
+	}
+	s.logf(slog.LevelInfo, "Output of SNAP_DIFF_TOOL %q:\n%s", tool[0], out)
+}
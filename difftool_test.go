@@ -0,0 +1,53 @@
+package snap
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestDiffTool(t *testing.T) {
+	if _, err := exec.LookPath("diff"); err != nil {
+		t.Skip("diff is not installed")
+	}
+	t.Setenv("SNAP_DIFF_TOOL", "diff -u")
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "a\nb\n").Diff("a\nc\n")
+
+	if !containsLog(ft, "Output of SNAP_DIFF_TOOL \"diff\"") {
+		t.Fatalf("expected output of the diff tool to be logged, got %q", ft.logs)
+	}
+	for _, want := range []string{"-b\n", "+c\n", "want.txt", "got.txt"} {
+		if !containsLog(ft, want) {
+			t.Errorf("expected diff output to contain %q, got %q", want, ft.logs)
+		}
+	}
+}
+
+func TestDiffToolNotFound(t *testing.T) {
+	t.Setenv("SNAP_DIFF_TOOL", "snap-no-such-diff-tool")
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "a").Diff("b")
+
+	if len(ft.errors) != 1 {
+		t.Errorf("expected only the snapshot to fail, got %q", ft.errors)
+	}
+	if !containsLog(ft, "Failed to run SNAP_DIFF_TOOL") {
+		t.Errorf("expected failure to be logged, got %q", ft.logs)
+	}
+}
+
+func TestDiffToolUnset(t *testing.T) {
+	t.Setenv("SNAP_DIFF_TOOL", "")
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "a").Diff("b")
+
+	for _, l := range ft.logs {
+		if strings.Contains(l, "SNAP_DIFF_TOOL") {
+			t.Errorf("expected no diff tool logs, got %q", l)
+		}
+	}
+}
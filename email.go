@@ -0,0 +1,163 @@
+package snap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// scrubbedHeaders are the email headers whose values differ on every send, replaced by markers
+// by [Snapshot.DiffEmail].
+var scrubbedHeaders = map[string]string{
+	"Date":       "<date>",
+	"Message-Id": "<message-id>",
+}
+
+// DiffEmail compares the snapshot with a transcript of the RFC 5322 email message, like one
+// captured by a fake SMTP server, for testing the emails a program sends:
+//
+//	snap.Snap(t, `From: shop@example.com
+//	Subject: Your order
+//	...`).DiffEmail(smtp.Last())
+//
+// The transcript lists the headers sorted by name, with their encoded words decoded, and then the
+// body, with each part of multipart messages under a "--- part 1.2" line. The Date and Message-ID
+// headers are replaced by <date> and <message-id>, and the boundaries of multipart messages by
+// <boundary>, as they differ on every send. Quoted-printable and base64 bodies are decoded, and
+// binary ones, like attachments, are summarized by their size and SHA-256 hash.
+// It calls [testing.T.Error] when the snapshot is not equal to the transcript or when the message
+// can't be parsed.
+func (s *Snapshot) DiffEmail(message []byte) {
+	s.t.Helper()
+	got, ok := s.render(func() (string, error) {
+		got, err := renderEmail(message)
+		if err != nil {
+			return "", fmt.Errorf("invalid email message: %w", err)
+		}
+		return got, nil
+	})
+	if !ok {
+		return
+	}
+	s.Diff(got)
+}
+
+// renderEmail returns the transcript of the email message, as [Snapshot.DiffEmail] compares it.
+func renderEmail(message []byte) (string, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(message))
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := writeEmailEntity(&sb, textproto.MIMEHeader(msg.Header), msg.Body, ""); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// writeEmailEntity writes the headers and the body of the message or part, numbered part, to sb.
+func writeEmailEntity(sb *strings.Builder, header textproto.MIMEHeader, body io.Reader, part string) error {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var decoder mime.WordDecoder
+	for _, key := range keys {
+		for _, value := range header[key] {
+			if marker, ok := scrubbedHeaders[key]; ok {
+				value = marker
+			} else if key == "Content-Type" {
+				value = scrubBoundary(value)
+			} else if decoded, err := decoder.DecodeHeader(value); err == nil {
+				value = decoded
+			}
+			fmt.Fprintf(sb, "%s: %s\n", key, value)
+		}
+	}
+	sb.WriteString("\n")
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		// Messages without a valid Content-Type are plain text.
+		mediaType, params = "text/plain", nil
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if params["boundary"] == "" {
+			return errors.New("multipart message without a boundary")
+		}
+		r := multipart.NewReader(body, params["boundary"])
+		for n := 1; ; n++ {
+			p, err := r.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			number := fmt.Sprint(n)
+			if part != "" {
+				number = part + "." + number
+			}
+			fmt.Fprintf(sb, "--- part %s\n", number)
+			if err := writeEmailEntity(sb, p.Header, p, number); err != nil {
+				return fmt.Errorf("part %s: %w", number, err)
+			}
+		}
+	}
+
+	data, err := decodeEmailBody(body, header.Get("Content-Transfer-Encoding"))
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(mediaType, "text/") && !strings.HasPrefix(mediaType, "message/") || !utf8.Valid(data) {
+		sum := sha256.Sum256(data)
+		fmt.Fprintf(sb, "<%s, %d bytes, sha256 %s>\n", mediaType, len(data), hex.EncodeToString(sum[:8]))
+		return nil
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	sb.WriteString(text)
+	return nil
+}
+
+// decodeEmailBody returns the body decoded from its Content-Transfer-Encoding.
+func decodeEmailBody(body io.Reader, encoding string) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		return io.ReadAll(quotedprintable.NewReader(body))
+	case "base64":
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		data = bytes.Join(bytes.Fields(data), nil)
+		return base64.StdEncoding.DecodeString(string(data))
+	default:
+		return io.ReadAll(body)
+	}
+}
+
+// scrubBoundary replaces the boundary parameter of the Content-Type header value by <boundary>.
+func scrubBoundary(value string) string {
+	mediaType, params, err := mime.ParseMediaType(value)
+	if err != nil || params["boundary"] == "" {
+		return value
+	}
+	params["boundary"] = "<boundary>"
+	return mime.FormatMediaType(mediaType, params)
+}
@@ -0,0 +1,74 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffEmail(t *testing.T) {
+	message := strings.ReplaceAll(`From: Shop <shop@example.com>
+To: ada@example.com
+Subject: =?UTF-8?Q?Your_order_=E2=9C=93?=
+Date: Tue, 14 May 2024 10:00:00 +0000
+Message-ID: <1715680800.123@example.com>
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="b1-5f3a"
+
+--b1-5f3a
+Content-Type: multipart/alternative; boundary=b2-9c1e
+
+--b2-9c1e
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+Thanks for your order, Ada! Total: =E2=82=AC12.50. This line is long enough =
+to be soft-wrapped.
+--b2-9c1e
+Content-Type: text/html; charset=utf-8
+Content-Transfer-Encoding: base64
+
+PHA+VGhhbmtzITwvcD4=
+--b2-9c1e--
+--b1-5f3a
+Content-Type: application/pdf
+Content-Disposition: attachment; filename="invoice.pdf"
+Content-Transfer-Encoding: base64
+
+JVBERi0xLjQK
+--b1-5f3a--
+`, "\n", "\r\n")
+
+	Snap(t, `Content-Type: multipart/mixed; boundary="<boundary>"
+Date: <date>
+From: Shop <shop@example.com>
+Message-Id: <message-id>
+Mime-Version: 1.0
+Subject: Your order ✓
+To: ada@example.com
+
+--- part 1
+Content-Type: multipart/alternative; boundary="<boundary>"
+
+--- part 1.1
+Content-Transfer-Encoding: quoted-printable
+Content-Type: text/plain; charset=utf-8
+
+Thanks for your order, Ada! Total: €12.50. This line is long enough to be soft-wrapped.
+--- part 1.2
+Content-Transfer-Encoding: base64
+Content-Type: text/html; charset=utf-8
+
+<p>Thanks!</p>
+--- part 2
+Content-Disposition: attachment; filename="invoice.pdf"
+Content-Transfer-Encoding: base64
+Content-Type: application/pdf
+
+<application/pdf, 9 bytes, sha256 e5c62df5dab5c87b>`).DiffEmail([]byte(message))
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "").DiffEmail([]byte("Content-Type: multipart/mixed\r\n\r\nbody"))
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "snap: invalid email message: multipart message without a boundary") {
+		t.Errorf("expected an invalid message error, got %q", ft.errors)
+	}
+}
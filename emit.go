@@ -0,0 +1,35 @@
+package snap
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Emit writes to w a subtest named testName with a snapshot of value, for programs generating
+// tests, so that they don't have to reimplement how snapshots are written as Go literals (see
+// [GoStringLiteral]). The subtest compares the snapshot with the variable got, which the generated
+// code must define before it:
+//
+//	got := render(input)
+//	t.Run("login", func(t *testing.T) {
+//		snap.Snap(t, `<h1>Login</h1>
+//	<form>...</form>`).Diff(got)
+//	})
+//
+// Strings and byte slices are compared with [Snapshot.Diff], and other values with
+// [Snapshot.DiffValue]. The output is valid Go, formatted as gofmt would once indented.
+func Emit(w io.Writer, testName string, value any) error {
+	var text, call string
+	switch v := value.(type) {
+	case string:
+		text, call = v, "Diff(got)"
+	case []byte:
+		text, call = string(v), "Diff(string(got))"
+	default:
+		text, call = formatValue(value), "DiffValue(got)"
+	}
+	_, err := fmt.Fprintf(w, "t.Run(%s, func(t *testing.T) {\n\tsnap.Snap(t, %s).%s\n})\n",
+		strconv.Quote(testName), GoStringLiteral(text), call)
+	return err
+}
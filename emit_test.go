@@ -0,0 +1,41 @@
+package snap
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/KasonBraley/snap/internal/source"
+)
+
+func TestEmit(t *testing.T) {
+	for _, value := range []any{"ok", "line 1\nline 2", "back`quote\nline", []byte("bytes"), map[string]int{"a": 1}} {
+		var sb strings.Builder
+		if err := Emit(&sb, "case/one", value); err != nil {
+			t.Fatal(err)
+		}
+		src := "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n" + sb.String() + "}\n"
+		formatted, err := format.Source([]byte(src))
+		if err != nil {
+			t.Fatalf("emitted invalid Go for %#v: %s\n%s", value, err, src)
+		}
+		snapshots, err := source.Find("example_test.go", formatted)
+		if err != nil || len(snapshots) != 1 {
+			t.Fatalf("expected one snapshot in:\n%s", formatted)
+		}
+		want := formatValue(value)
+		switch v := value.(type) {
+		case string:
+			want = v
+		case []byte:
+			want = string(v)
+		}
+		if snapshots[0].Text != want {
+			t.Errorf("expected the snapshot %q, got %q", want, snapshots[0].Text)
+		}
+	}
+
+	var sb strings.Builder
+	Emit(&sb, "login", "<h1>Login</h1>\n<form>")
+	Snap(t, "t.Run(\"login\", func(t *testing.T) {\n\tsnap.Snap(t, `<h1>Login</h1>\n<form>`).Diff(got)\n})\n").Diff(sb.String())
+}
@@ -1,6 +1,12 @@
 package snap
 
-import "testing"
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
 
 func TestEqualExcludingIgnored(t *testing.T) {
 	casesOk := []struct {
@@ -47,3 +53,94 @@ func TestEqualExcludingIgnored(t *testing.T) {
 		})
 	}
 }
+
+// markerPattern returns a regexp matching the values matched by snapshot, with a group for each
+// marker. The groups prefer the shortest match, or the longest one if greedy is set.
+func markerPattern(snapshot string, greedy bool) *regexp.Regexp {
+	region := `([^\n]+?)`
+	if greedy {
+		region = `([^\n]+)`
+	}
+
+	var b strings.Builder
+	b.WriteString(`^`)
+	last := 0
+	for _, loc := range matchingMarker.FindAllStringIndex(snapshot, -1) {
+		b.WriteString(regexp.QuoteMeta(snapshot[last:loc[0]]))
+		b.WriteString(region)
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(snapshot[last:]))
+	b.WriteString(`$`)
+	return regexp.MustCompile(b.String())
+}
+
+// FuzzEqualExcludingIgnored checks the marker matching against the reference regexp of
+// [markerPattern]. The strict matching must agree with it, and every value the legacy matching
+// accepts must be accepted by it too.
+func FuzzEqualExcludingIgnored(f *testing.F) {
+	f.Add("1234", "1<snap:ignore>4")
+	f.Add("12345678", "12<snap:ignore>56<snap:ignore>8")
+	f.Add("a-b-b", "a<snap:ignore>b")
+	f.Add("(x=1, y=2, y=3)", "(x=<snap:ignore>, y=<snap:ignore>)")
+	f.Add("héllo wörld", "h<snap:ignore>llo w<snap:check:any>rld")
+	f.Add("1\n2\n3", "1\n<snap:ignore>\n3")
+	f.Add("1234", "1<snap:ignore><snap:ignore>4")
+
+	f.Fuzz(func(t *testing.T, got string, snapshot string) {
+		// The regexp can only match valid UTF-8.
+		if !utf8.ValidString(got) || !utf8.ValidString(snapshot) {
+			t.Skip()
+		}
+		normalized := checkMarker.ReplaceAllString(snapshot, ignoreFmt)
+		if strings.HasPrefix(normalized, ignoreFmt) || strings.HasSuffix(normalized, ignoreFmt) {
+			t.Skip()
+		}
+
+		for _, greedy := range []bool{false, true} {
+			var want []string
+			if m := markerPattern(snapshot, greedy).FindStringSubmatch(got); m != nil {
+				want = m[1:]
+			}
+			matched, ok := splitAtMarkers(got, snapshot, greedy)
+			if ok != (want != nil) || !slices.Equal(matched, want) {
+				t.Errorf("splitAtMarkers(%q, %q, %v) = %q, %v, the regexp matches %q", got, snapshot, greedy, matched, ok, want)
+			}
+		}
+
+		if _, ok := matchCut(got, snapshot); ok && !markerPattern(snapshot, false).MatchString(got) {
+			t.Errorf("legacy matching accepts %q for %q, the regexp doesn't", got, snapshot)
+		}
+	})
+}
+
+func TestIgnoreTwiceInARow(t *testing.T) {
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "1<snap:ignore><snap:ignore>4").Diff("1234")
+	if len(ft.errors) != 1 || ft.errors[0] != "snap: <snap:ignore> is not allowed twice in a row" {
+		t.Errorf("expected an error for two markers in a row, got %q", ft.errors)
+	}
+}
+
+func TestMarkersAtEdges(t *testing.T) {
+	for _, tc := range []struct{ snapshot, got string }{
+		{"<snap:uuid>", "123e4567-e89b-12d3-a456-426614174000"},
+		{"<snap:timestamp> INFO started", "2024-05-14T10:00:00Z INFO started"},
+		{"<snap:ignore-lines>\ndone", "step 1\nstep 2\ndone"},
+		{"a <snap:uuid><snap:ignore> b", "a 123e4567-e89b-12d3-a456-426614174000! b"},
+	} {
+		ft := newFakeT(t)
+		snapNoUpdate(ft, tc.snapshot).Diff(tc.got)
+		if len(ft.errors) != 0 {
+			t.Errorf("expected %q to match %q, got %q", tc.got, tc.snapshot, ft.errors)
+		}
+	}
+
+	for _, snapshot := range []string{"<snap:ignore> INFO started", "started at <snap:ignore>"} {
+		ft := newFakeT(t)
+		snapNoUpdate(ft, snapshot).Diff("x")
+		if len(ft.errors) != 1 || ft.errors[0] != "snap: <snap:ignore> is not allowed as a prefix or suffix" {
+			t.Errorf("expected an error for %q, got %q", snapshot, ft.errors)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package snap
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventKind is the step of the lifecycle of a snapshot an [Event] reports.
+type EventKind string
+
+const (
+	// EventCreated reports that an empty snapshot, or a missing snapshot file, was written.
+	EventCreated EventKind = "created"
+	// EventMatched reports that a value matched its snapshot.
+	EventMatched EventKind = "matched"
+	// EventMismatched reports that a value didn't match its snapshot, including when it's then
+	// updated.
+	EventMismatched EventKind = "mismatched"
+	// EventUpdated reports that a snapshot was rewritten with its value.
+	EventUpdated EventKind = "updated"
+)
+
+// Event is a step of the lifecycle of a snapshot, for test runners and dashboards following the
+// health of the snapshots of large CI systems. Events are passed to the functions registered with
+// [OnEvent], and written as JSON lines to the file or unix socket set with SNAP_EVENTS.
+type Event struct {
+	Kind EventKind `json:"kind"`
+	Test string    `json:"test"` // Name of the test, as reported by [testing.TB.Name].
+	File string    `json:"file"` // Source file containing the snapshot, relative to the module root.
+	Line int       `json:"line"` // Line of the [Snap] call in File.
+	// Golden is the snapshot file of [SnapFile] snapshots, relative to the module root.
+	Golden string `json:"golden,omitempty"`
+	// Size is the length in bytes of the value compared with the snapshot, for matched and
+	// mismatched events.
+	Size int `json:"size,omitempty"`
+	// Ignored is the length in bytes of the parts of the value matched by the markers of the
+	// snapshot, like <snap:ignore>, for matched events.
+	Ignored int       `json:"ignored,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+var (
+	eventHooksMu sync.Mutex
+	eventHooks   []func(Event)
+
+	eventsMu sync.Mutex
+	// eventsConn is the connection to the unix socket of SNAP_EVENTS, dialed on the first event.
+	eventsConn net.Conn
+)
+
+// OnEvent registers fn to be called on every event of the lifecycle of snapshots, from the
+// goroutine of their test, in the order the functions were registered.
+//
+// Typically called from TestMain.
+func OnEvent(fn func(Event)) {
+	eventHooksMu.Lock()
+	defer eventHooksMu.Unlock()
+	eventHooks = append(eventHooks, fn)
+}
+
+// emit reports the event of kind for the snapshot to the functions registered with [OnEvent] and
+// to SNAP_EVENTS.
+func (s *Snapshot) emit(kind EventKind) {
+	s.t.Helper()
+	s.emitEvent(Event{Kind: kind})
+}
+
+// emitEvent reports e, completed with the snapshot, to the functions registered with [OnEvent] and
+// to SNAP_EVENTS.
+func (s *Snapshot) emitEvent(e Event) {
+	s.t.Helper()
+	eventHooksMu.Lock()
+	hooks := eventHooks
+	eventHooksMu.Unlock()
+	dest := os.Getenv("SNAP_EVENTS")
+	if len(hooks) == 0 && dest == "" {
+		return
+	}
+
+	e.Test = s.testName()
+	e.File = relativePath(s.location.file)
+	e.Line = s.location.line
+	e.Time = time.Now().UTC()
+	if s.file != "" {
+		e.Golden = relativePath(s.file)
+	}
+	for _, fn := range hooks {
+		fn(e)
+	}
+	if dest != "" {
+		if err := writeEvent(dest, e); err != nil {
+			s.logf(slog.LevelWarn, "Warning: Failed to write the snapshot event to SNAP_EVENTS: %s", err)
+		}
+	}
+}
+
+// writeEvent writes the event as a JSON line to dest: the unix socket at the path following
+// "unix:", or else the file at dest, which several test binaries can append to.
+func writeEvent(dest string, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	if socket, ok := strings.CutPrefix(dest, "unix:"); ok {
+		if eventsConn == nil {
+			if eventsConn, err = net.Dial("unix", socket); err != nil {
+				return err
+			}
+		}
+		if _, err := eventsConn.Write(data); err != nil {
+			eventsConn.Close()
+			eventsConn = nil // Dial again for the next event, in case the runner restarted.
+			return err
+		}
+		return nil
+	}
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// emitUpdated reports that the snapshot was written, as created if it was empty or missing.
+func (s *Snapshot) emitUpdated() {
+	s.t.Helper()
+	if s.missingFile || strings.TrimSpace(s.text) == "" {
+		s.emit(EventCreated)
+	} else {
+		s.emit(EventUpdated)
+	}
+}
+
+// emitResult reports that got matched the snapshot text want, unless it mismatched once compared.
+func (s *Snapshot) emitResult(want string, got string) {
+	s.t.Helper()
+	if s.mismatched {
+		return
+	}
+	ignored := 0
+	if matchingMarker.MatchString(want) {
+		matched, _ := matchSegments(got, want)
+		for _, m := range matched {
+			ignored += len(m)
+		}
+	}
+	s.emitEvent(Event{Kind: EventMatched, Size: len(got), Ignored: ignored})
+}
@@ -0,0 +1,124 @@
+package snap
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// onEvent registers fn with [OnEvent] for the events of the test t, until it ends.
+func onEvent(t *testing.T, fn func(Event)) {
+	t.Helper()
+	name := t.Name()
+	OnEvent(func(e Event) {
+		if e.Test == name {
+			fn(e)
+		}
+	})
+	t.Cleanup(func() {
+		eventHooksMu.Lock()
+		defer eventHooksMu.Unlock()
+		eventHooks = eventHooks[:len(eventHooks)-1]
+	})
+}
+
+func TestOnEvent(t *testing.T) {
+	var kinds []string
+	onEvent(t, func(e Event) { kinds = append(kinds, string(e.Kind)) })
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "same").Diff("same")
+	snapNoUpdate(ft, "want").Diff("got")
+
+	path := filepath.Join(t.TempDir(), "help.golden")
+	t.Setenv("SNAP_UPDATE", "1")
+	SnapFile(ft, path).Diff("usage: tool\n")
+	SnapFile(ft, path).Diff("usage: tool [flags]\n")
+	if got, want := strings.Join(kinds, " "), "matched mismatched created mismatched updated"; got != want {
+		t.Errorf("want events %q, got %q", want, got)
+	}
+}
+
+func TestEventsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	t.Setenv("SNAP_EVENTS", path)
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "want").Diff("got")
+
+	var e Event
+	if err := json.Unmarshal([]byte(readFile(t, path)), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Kind != EventMismatched || e.Test != t.Name() || e.File != "events_test.go" || e.Line == 0 || e.Time.IsZero() {
+		t.Errorf("unexpected event %+v", e)
+	}
+}
+
+func TestEventsSocket(t *testing.T) {
+	// Unix socket paths are limited to about 100 bytes, which test temporary directories can exceed.
+	dir, err := os.MkdirTemp("", "snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "events.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets aren't supported: %s", err)
+	}
+	defer l.Close()
+	lines := make(chan string)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			close(lines)
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	t.Cleanup(func() {
+		eventsMu.Lock()
+		defer eventsMu.Unlock()
+		if eventsConn != nil {
+			eventsConn.Close()
+			eventsConn = nil
+		}
+	})
+
+	t.Setenv("SNAP_EVENTS", "unix:"+socket)
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "same").Diff("same")
+	snapNoUpdate(ft, "want").Diff("got")
+	for _, want := range []string{`"kind":"matched"`, `"kind":"mismatched"`} {
+		if line := <-lines; !strings.Contains(line, want) {
+			t.Errorf("expected an event with %s, got %q", want, line)
+		}
+	}
+}
+
+func TestEventSizes(t *testing.T) {
+	var events []Event
+	onEvent(t, func(e Event) { events = append(events, e) })
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "id: <snap:ignore>\nok").Diff("id: 1234\nok")
+	snapNoUpdate(ft, "want").Diff("got!")
+	if len(events) != 2 {
+		t.Fatalf("want 2 events, got %+v", events)
+	}
+	if e := events[0]; e.Kind != EventMatched || e.Size != 11 || e.Ignored != 4 {
+		t.Errorf("want a matched event of 11 bytes with 4 ignored, got %+v", e)
+	}
+	if e := events[1]; e.Kind != EventMismatched || e.Size != 4 || e.Ignored != 0 {
+		t.Errorf("want a mismatched event of 4 bytes, got %+v", e)
+	}
+}
@@ -0,0 +1,48 @@
+package snap
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+)
+
+// Output runs fn, like an Example function, and returns what it wrote to standard output, to
+// compare it with a snapshot. Unlike the Output comments of examples, snapshots can use markers
+// like <snap:ignore> for the volatile parts of the output, and are updated with SNAP_UPDATE:
+//
+//	func TestExampleServer(t *testing.T) {
+//		snap.Snap(t, "listening on <snap:ignore>\n").Diff(snap.Output(t, ExampleServer))
+//	}
+//
+// As it replaces [os.Stdout] while fn runs, it can't be used in parallel tests.
+func Output(t testing.TB, fn func()) (output string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Errorf("snap: %v", err)
+		return ""
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+
+	var buf bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(&buf, r)
+		r.Close()
+		done <- err
+	}()
+
+	// Restore os.Stdout also when fn panics.
+	defer func() {
+		os.Stdout = stdout
+		w.Close()
+		if err := <-done; err != nil {
+			t.Errorf("snap: Reading output: %v", err)
+		}
+		output = buf.String()
+	}()
+	fn()
+	return ""
+}
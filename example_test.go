@@ -0,0 +1,29 @@
+package snap
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func exampleClock() {
+	fmt.Println("started at", time.Now().Format(time.RFC3339))
+	fmt.Println("done")
+}
+
+func TestOutput(t *testing.T) {
+	stdout := os.Stdout
+	Snap(t, "started at <snap:ignore>\ndone\n").Diff(Output(t, exampleClock))
+	if os.Stdout != stdout {
+		t.Error("expected os.Stdout to be restored")
+	}
+
+	func() {
+		defer func() { recover() }()
+		Output(t, func() { panic("boom") })
+	}()
+	if os.Stdout != stdout {
+		t.Error("expected os.Stdout to be restored after a panic")
+	}
+}
@@ -0,0 +1,88 @@
+package snap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeT records failures and logs instead of reporting them, so the failure paths of a Snapshot
+// can be tested without failing the enclosing test.
+type fakeT struct {
+	testing.TB
+	errors []string
+	logs   []string
+}
+
+func newFakeT(t *testing.T) *fakeT {
+	return &fakeT{TB: t}
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Error(args ...any) {
+	f.errors = append(f.errors, fmt.Sprint(args...))
+}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Log(args ...any) {
+	f.logs = append(f.logs, fmt.Sprint(args...))
+}
+
+func (f *fakeT) Logf(format string, args ...any) {
+	f.logs = append(f.logs, fmt.Sprintf(format, args...))
+}
+
+// snapTB is like [Snap], for fake tests.
+func snapTB(t testing.TB, text string) *Snapshot {
+	return newSnapshot(t, text, 0)
+}
+
+// snapNoUpdate is like [Snap], but the returned Snapshot never rewrites its source, even when
+// SNAP_UPDATE is set.
+func snapNoUpdate(t testing.TB, text string) *Snapshot {
+	_, file, line, _ := runtime.Caller(1)
+	return &Snapshot{
+		location: sourceLocation{file: file, line: line},
+		text:     text,
+		t:        t,
+	}
+}
+
+// snapInFile writes src to a temporary Go file and returns a Snapshot bound to the first line of src
+// containing "Snap(" or "Template(", which is updated when it differs.
+func snapInFile(t *testing.T, ft testing.TB, src string, text string) (s *Snapshot, path string) {
+	t.Helper()
+	path = filepath.Join(t.TempDir(), "example_test.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	line := 0
+	for i, l := range strings.Split(src, "\n") {
+		if strings.Contains(l, "Snap(") || strings.Contains(l, "Template(") {
+			line = i + 1
+			break
+		}
+	}
+	if line == 0 {
+		t.Fatalf("no Snap call in source:\n%s", src)
+	}
+
+	return At(ft, path, line, text).Update(), path
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
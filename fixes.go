@@ -0,0 +1,148 @@
+package snap
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// fixesFile is the file of the quick fixes written with SNAP_FIXES, in the directory of the
+// package of the snapshots.
+const fixesFile = ".snap-fixes.json"
+
+// fixesMode reports whether SNAP_FIXES is set: the updates of mismatching snapshots are then
+// written as LSP text edits to the .snap-fixes.json file of their package, for editors to apply
+// them without running the tests again.
+func fixesMode() bool {
+	_, ok := os.LookupEnv("SNAP_FIXES")
+	return ok
+}
+
+// fixes is the content of a .snap-fixes.json file: an LSP WorkspaceEdit, mapping the URIs of the
+// files to edit to their edits.
+type fixes struct {
+	Changes map[string][]textEdit `json:"changes"`
+}
+
+// textEdit is an LSP TextEdit, replacing the text of Range with NewText.
+type textEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+// lspPosition is a position in a text document, with a zero-based line and a character offset in
+// UTF-16 code units, as LSP counts them by default.
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+var (
+	fixesMu sync.Mutex
+	// fixesWritten are the fixes files written by this test binary. The fixes of previous runs are
+	// discarded when it first writes a file, so that the file only has the fixes of the last run.
+	fixesWritten = make(map[string]bool)
+)
+
+// writeFix adds the update of the snapshot to got and version to the fixes file of its package.
+// Snapshot files that don't exist yet have no fix, as a text edit can't create a file.
+func (s *Snapshot) writeFix(want string, got string, version int) {
+	s.t.Helper()
+	if s.missingFile || s.location.file == "" || s.frozen() {
+		return
+	}
+	c, err := s.pendingChange(want, got, version)
+	if err != nil {
+		s.logf(slog.LevelWarn, "Warning: Failed to write the quick fix of the snapshot: %s", err)
+		return
+	}
+	path := filepath.Join(moduleRoot(), filepath.FromSlash(c.File))
+	src, err := os.ReadFile(path)
+	if err == nil {
+		err = addFix(filepath.Join(filepath.Dir(s.location.file), fixesFile), fileURI(path), newTextEdit(src, c.Start, c.End, c.New))
+	}
+	if err != nil {
+		s.logf(slog.LevelWarn, "Warning: Failed to write the quick fix of the snapshot: %s", err)
+	}
+}
+
+// fileURI returns the file URI of the absolute path, like file:///home/me/m_test.go.
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // Like C:/Users on Windows.
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// newTextEdit returns the text edit replacing the bytes start to end of src with text. The range
+// is widened to whole UTF-8 sequences, which can't be split in UTF-16 code units.
+func newTextEdit(src []byte, start int, end int, text string) textEdit {
+	for start > 0 && start < len(src) && !utf8.RuneStart(src[start]) {
+		start--
+		text = string(src[start:start+1]) + text
+	}
+	for end < len(src) && !utf8.RuneStart(src[end]) {
+		text += string(src[end : end+1])
+		end++
+	}
+	return textEdit{Range: lspRange{Start: lspPos(src, start), End: lspPos(src, end)}, NewText: text}
+}
+
+// lspPos returns the LSP position of the byte offset of src.
+func lspPos(src []byte, offset int) lspPosition {
+	var pos lspPosition
+	for _, r := range string(src[:offset]) {
+		if r == '\n' {
+			pos.Line++
+			pos.Character = 0
+			continue
+		}
+		pos.Character++
+		if r >= 0x10000 {
+			pos.Character++ // Encoded as a surrogate pair.
+		}
+	}
+	return pos
+}
+
+// addFix adds the edit of the file at uri to the fixes file at path.
+func addFix(path string, uri string, edit textEdit) error {
+	fixesMu.Lock()
+	defer fixesMu.Unlock()
+
+	f := fixes{Changes: make(map[string][]textEdit)}
+	if fixesWritten[path] {
+		data, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &f); err != nil {
+				return err
+			}
+		}
+	}
+	f.Changes[uri] = append(f.Changes[uri], edit)
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	fixesWritten[path] = true
+	return nil
+}
@@ -0,0 +1,82 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFixes(t *testing.T) {
+	t.Setenv("SNAP_FIXES", "1")
+	t.Setenv("SNAP_UPDATE", "")
+	os.Unsetenv("SNAP_UPDATE")
+
+	// Fixes are computed like pending changes, so the snapshots must be inside the module.
+	dir, err := os.MkdirTemp(filepath.Join(moduleRoot(), "testdata"), "fixes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	src := "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, \"old\").Diff(got)\n\tsnap.Snap(t, \"😀 a\").Diff(got)\n}\n"
+	path := filepath.Join(dir, "example_test.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ft := newFakeT(t)
+	At(ft, path, 6, "old").Diff("new")
+	At(ft, path, 7, "😀 a").Diff("😀 b")
+	At(ft, path, 6, "new").Diff("new")
+	if len(ft.errors) != 2 {
+		t.Fatalf("expected 2 mismatches, got %q", ft.errors)
+	}
+	want := `{
+  "changes": {
+    "` + fileURI(path) + `": [
+      {
+        "range": {
+          "start": {
+            "line": 5,
+            "character": 15
+          },
+          "end": {
+            "line": 5,
+            "character": 18
+          }
+        },
+        "newText": "new"
+      },
+      {
+        "range": {
+          "start": {
+            "line": 6,
+            "character": 18
+          },
+          "end": {
+            "line": 6,
+            "character": 19
+          }
+        },
+        "newText": "b"
+      }
+    ]
+  }
+}
+`
+	if got := readFile(t, filepath.Join(dir, ".snap-fixes.json")); got != want {
+		t.Errorf("want fixes:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestNewTextEdit(t *testing.T) {
+	// The bytes of é and ê only differ in their last byte.
+	src := []byte("x := \"é\"\n")
+	edit := newTextEdit(src, 7, 8, "\xaa")
+	if edit.Range.Start != (lspPosition{0, 6}) || edit.Range.End != (lspPosition{0, 7}) || edit.NewText != "ê" {
+		t.Errorf("expected the edit to replace the whole character, got %+v", edit)
+	}
+	if !strings.HasPrefix(fileURI("/home/me/a b.go"), "file:///home/me/a%20b.go") {
+		t.Errorf("unexpected URI %q", fileURI("/home/me/a b.go"))
+	}
+}
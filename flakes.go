@@ -0,0 +1,67 @@
+package snap
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/KasonBraley/snap/internal/flakes"
+)
+
+// flakesMode reports whether SNAP_FLAKES is set.
+func flakesMode() bool {
+	_, ok := os.LookupEnv("SNAP_FLAKES")
+	return ok
+}
+
+// commit identifies the source code of the module: its git revision, followed by a hash of the
+// uncommitted changes if there are any. It's empty outside of a git repository.
+var commit = sync.OnceValue(func() string {
+	git := func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = moduleRoot()
+		out, err := cmd.Output()
+		return string(out), err
+	}
+	rev, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return ""
+	}
+	rev = strings.TrimSpace(rev)
+	diff, err := git("diff", "HEAD")
+	if err != nil {
+		return ""
+	}
+	if diff != "" {
+		sum := sha256.Sum256([]byte(diff))
+		rev += "+" + hex.EncodeToString(sum[:6])
+	}
+	return rev
+})
+
+// recordResult appends whether the snapshot matched to the flake history of the module, with
+// SNAP_FLAKES set, so that `snap flakes` can find the snapshots that both matched and mismatched
+// at the same commit.
+func (s *Snapshot) recordResult() {
+	s.t.Helper()
+	if !flakesMode() || !s.foundCallerLocation || moduleRoot() == "" || commit() == "" {
+		return
+	}
+	path, err := flakes.File(moduleRoot())
+	if err == nil {
+		err = flakes.Append(path, flakes.Result{
+			Snapshot: fmt.Sprintf("%s:%d", relativePath(s.location.file), s.location.line),
+			Test:     s.testName(),
+			Commit:   commit(),
+			Pass:     !s.mismatched,
+		})
+	}
+	if err != nil {
+		s.logf(slog.LevelWarn, "Warning: Failed to record the result of the snapshot: %s", err)
+	}
+}
@@ -0,0 +1,32 @@
+package snap
+
+import (
+	"testing"
+
+	"github.com/KasonBraley/snap/internal/flakes"
+)
+
+func TestRecordResult(t *testing.T) {
+	if commit() == "" {
+		t.Skip("not in a git repository")
+	}
+	t.Setenv("GOCACHE", t.TempDir())
+	t.Setenv("SNAP_FLAKES", "1")
+
+	Snap(t, "a").Diff("a")
+	ft := newFakeT(t)
+	snapTB(ft, "a").Diff("b")
+
+	path, err := flakes.File(moduleRoot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := flakes.Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || !results[0].Pass || results[1].Pass || results[0].Commit != commit() ||
+		results[0].Test != "TestRecordResult" || results[0].Snapshot != "flakes_test.go:16" {
+		t.Errorf("expected a match and a mismatch to be recorded, got %+v", results)
+	}
+}
@@ -0,0 +1,43 @@
+package snap
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// finalizeSource checks the rewritten source after of the file at path before it's written, so
+// that updates never produce a file that formatting checks in CI reject: if before was formatted
+// with gofmt, after must be as well. If SNAP_FORMATTER is set, after is then piped through that
+// command, like "gofumpt", whose output is written instead.
+func finalizeSource(path string, before []byte, after []byte) ([]byte, error) {
+	if gofmtClean(before) {
+		if !gofmtClean(after) {
+			return nil, fmt.Errorf("%s: the rewritten file is not formatted with gofmt, not writing it", relativePath(path))
+		}
+	}
+
+	formatter := strings.Fields(os.Getenv("SNAP_FORMATTER"))
+	if len(formatter) == 0 {
+		return after, nil
+	}
+	cmd := exec.Command(formatter[0], formatter[1:]...) // #nosec G204 -- The command is set by the user running the tests.
+	cmd.Stdin = bytes.NewReader(after)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: SNAP_FORMATTER %q failed, not writing the file: %w: %s",
+			relativePath(path), strings.Join(formatter, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// gofmtClean reports whether src is formatted with gofmt.
+func gofmtClean(src []byte) bool {
+	formatted, err := format.Source(src)
+	return err == nil && bytes.Equal(formatted, src)
+}
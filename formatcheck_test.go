@@ -0,0 +1,69 @@
+package snap
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestUpdateKeepsGofmt(t *testing.T) {
+	// Changing the length of the literal would misalign the comments, the function is formatted.
+	src := "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tvar (\n\t\ta  = snap.Snap(t, \"x\") // one\n\t\tbb = 1                 // two\n\t)\n}\n"
+	want := "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tvar (\n\t\ta  = snap.Snap(t, \"a longer value\") // one\n\t\tbb = 1                              // two\n\t)\n}\n"
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, src, "x")
+	s.Diff("a longer value")
+	if len(ft.errors) != 1 {
+		t.Errorf("expected only the mismatch, got %q", ft.errors)
+	}
+	if got := readFile(t, path); got != want {
+		t.Errorf("expected the function to be formatted, got:\n%s", got)
+	}
+
+	// Files not formatted with gofmt are only spliced into.
+	unformatted := strings.Replace(src, "bb = 1", "bb =  1", 1)
+	ft = newFakeT(t)
+	s, path = snapInFile(t, ft, unformatted, "x")
+	s.Diff("a longer value")
+	if got, want := readFile(t, path), strings.Replace(unformatted, `"x"`, `"a longer value"`, 1); got != want {
+		t.Errorf("expected only the literal to be replaced, got:\n%s", got)
+	}
+}
+
+func TestFinalizeSourceUnformatted(t *testing.T) {
+	before := []byte("package example\n\nimport \"github.com/KasonBraley/snap\"\n\nvar x = 1\n")
+	if _, err := finalizeSource("x.go", before, []byte("package example\n\nimport \"github.com/KasonBraley/snap\"\n\nvar x =  1\n")); err == nil ||
+		!strings.Contains(err.Error(), "the rewritten file is not formatted with gofmt") {
+		t.Errorf("expected a formatting error, got %v", err)
+	}
+	// Unformatted files stay unformatted.
+	after := []byte("package example\n\nimport \"github.com/KasonBraley/snap\"\n\nvar x =  2\n")
+	if got, err := finalizeSource("x.go", []byte("package example\n\nimport \"github.com/KasonBraley/snap\"\n\nvar x =  1\n"), after); err != nil || string(got) != string(after) {
+		t.Errorf("got %q, %v, want %q", got, err, after)
+	}
+}
+
+func TestFormatter(t *testing.T) {
+	for _, cmd := range []string{"cat", "false"} {
+		if _, err := exec.LookPath(cmd); err != nil {
+			t.Skipf("%s is not installed", cmd)
+		}
+	}
+	src := "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, \"old\").Diff(got)\n}\n"
+
+	t.Setenv("SNAP_FORMATTER", "cat")
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, src, "old")
+	s.Diff("new")
+	if !strings.Contains(readFile(t, path), `snap.Snap(t, "new")`) {
+		t.Errorf("expected the file to be updated, got:\n%s", readFile(t, path))
+	}
+
+	t.Setenv("SNAP_FORMATTER", "false")
+	ft = newFakeT(t)
+	s, path = snapInFile(t, ft, src, "old")
+	s.Diff("new")
+	if len(ft.errors) != 2 || !strings.Contains(ft.errors[1], `SNAP_FORMATTER "false" failed`) || readFile(t, path) != src {
+		t.Errorf("expected the formatter to fail the update, got %q", ft.errors)
+	}
+}
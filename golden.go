@@ -0,0 +1,181 @@
+package snap
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// SnapFile creates a snapshot stored in the file at path, relative to the directory of the
+// package, for values too large to keep in the source, like full HTTP responses or help screens.
+// It supports the same comparisons, markers and updates as [Snap]: with SNAP_UPDATE=1, the file
+// is rewritten with the value, or created with its directories when it doesn't exist yet.
+//
+//	snap.SnapFile(t, "testdata/help.golden").Diff(help)
+//
+// The file is compared byte for byte, so it must not gain a trailing newline unless the value
+// ends with one. Files with a .gz extension are stored compressed with gzip, always to the same
+// bytes for the same snapshot, so that they only change in git when the snapshot does.
+//
+// Files with a .snap extension are in the format of snap, which can hold comments for reviewers:
+// their lines starting with # are comments, ignored when comparing and kept when updating, and
+// the lines of the snapshot starting with # or a backslash are escaped with a backslash. Each
+// line ends with a newline, which isn't part of the snapshot.
+//
+//	# The flags are sorted by name.
+//	usage: tool [flags]
+//	\# not a comment
+//
+// Updates stamp the first line of .snap files with the version of snap and of the format of its
+// renderers, like #snap version=v1.4.0 format=1, so that a snapshot differing from a file written
+// with another format, which may print the same value differently, is reported as such.
+//
+// `snap undo` restores rewritten files, but doesn't remove created ones.
+func SnapFile(t testing.TB, path string) *Snapshot {
+	s := newSnapshot(t, "", 0)
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		t.Errorf("snap: %s", err)
+		abs = path
+	}
+	s.snapFormat = isSnapFile(abs)
+	s.openFile(abs)
+	return s
+}
+
+// FileSection creates a snapshot stored in the section name of the .snap file at path, so that
+// suites with many small snapshots can keep them in a few files rather than one file each. The
+// sections start at headers like in txtar archives, and hold snapshots in the format of the .snap
+// files of [SnapFile], which is the text before the first header:
+//
+//	snap.FileSection(t, "testdata/api.snap", "login").Diff(resp)
+//
+//	# The snapshots of the API responses.
+//	-- login --
+//	{"token": "..."}
+//	-- logout --
+//	{}
+//
+// The lines of the snapshots reading as a header are escaped with a backslash. With
+// SNAP_UPDATE=1, only the section is rewritten, and a missing section is added at the end of the
+// file.
+func FileSection(t testing.TB, path string, name string) *Snapshot {
+	s := newSnapshot(t, "", 0)
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		t.Errorf("snap: %s", err)
+		abs = path
+	}
+	if !isSnapFile(abs) {
+		t.Errorf("snap: FileSection needs a %s file, got %s", snapFileExt, path)
+	}
+	if name == "" || strings.TrimSpace(name) != name || strings.Contains(name, "\n") {
+		t.Errorf("snap: Invalid section name %q", name)
+	}
+	s.snapFormat = true
+	s.section = name
+	s.openFile(abs)
+	return s
+}
+
+// openFile reads the snapshot stored in the file at the absolute path.
+func (s *Snapshot) openFile(path string) {
+	s.file = path
+	text, err := readSnapshotFile(path)
+	if err == nil && isGzipFile(path) {
+		text, err = decompressGzip(text)
+	}
+	found := true
+	if err == nil && s.snapFormat {
+		f := parseSnapFile(text)
+		s.stamp = f.stamp()
+		text, found = f.text(s.section)
+	}
+	switch {
+	case errors.Is(err, fs.ErrNotExist) || !found:
+		s.missingFile = true
+	case err != nil:
+		s.t.Errorf("snap: Failed to read snapshot file: %s", err)
+	}
+	s.text = text
+}
+
+// readSnapshotFile returns the content of the snapshot file at path. Files too large to be diffed
+// are mapped into memory rather than read, so that comparing a value with a golden file of
+// hundreds of megabytes doesn't also take its size in memory.
+func readSnapshotFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.Size() <= chunkedDiffSize {
+		data, err := io.ReadAll(f)
+		return string(data), err
+	}
+	data, err := mapFile(f, info.Size())
+	if err != nil {
+		return "", err
+	}
+	// The mapping is read-only and never released, so the string stays immutable and valid.
+	return unsafe.String(unsafe.SliceData(data), len(data)), nil
+}
+
+// updateFile writes text to the file of a [SnapFile] snapshot, or the difference to it to its
+// overlay.
+func (s *Snapshot) updateFile(text string) {
+	s.t.Helper()
+	if s.overlay != "" && !s.missingFile {
+		s.updateOverlay(text)
+		return
+	}
+	data := []byte(text)
+	var err error
+	if s.snapFormat {
+		// The comments are read back, while parallel tests may update the file.
+		unlock := lockFile(s.file)
+		defer unlock()
+		content, err := s.snapFileContent(text)
+		if err != nil {
+			s.t.Errorf("snap: Failed to read snapshot file %q: %s", relativePath(s.file), err)
+			return
+		}
+		data = []byte(content)
+	}
+	if isGzipFile(s.file) {
+		if data, err = compressGzip(data); err != nil {
+			s.t.Errorf("snap: Failed to compress snapshot file %q: %s", relativePath(s.file), err)
+			return
+		}
+	}
+	// The file of a missing section exists, and is rewritten like the files of other snapshots.
+	if _, statErr := os.Stat(s.file); errors.Is(statErr, fs.ErrNotExist) {
+		if err = os.MkdirAll(filepath.Dir(s.file), 0755); err == nil {
+			err = os.WriteFile(s.file, data, 0644)
+		}
+	} else {
+		if s.history > 0 {
+			if err := s.saveHistory(); err != nil {
+				s.t.Errorf("snap: Failed to keep the history of %q: %s", relativePath(s.file), err)
+				return
+			}
+		}
+		err = writeFile(s.file, data)
+	}
+	if err != nil {
+		s.t.Errorf("snap: Failed to write snapshot file %q: %s", relativePath(s.file), err)
+		return
+	}
+	s.logf(slog.LevelInfo, "Updated %s\n", relativePath(s.file))
+	s.emitUpdated()
+}
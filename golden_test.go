@@ -0,0 +1,124 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSnapFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "help.golden")
+
+	ft := newFakeT(t)
+	SnapFile(ft, path).Diff("usage: tool")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "help.golden does not exist, rerun with SNAP_UPDATE=1 to create it") {
+		t.Errorf("expected an error for the missing file, got %q", ft.errors)
+	}
+
+	t.Setenv("SNAP_UPDATE", "1")
+	ft = newFakeT(t)
+	SnapFile(ft, path).Diff("usage: tool\n\nstarted at 12:00\n")
+	if len(ft.errors) != 0 || readFile(t, path) != "usage: tool\n\nstarted at 12:00\n" {
+		t.Fatalf("expected the file to be created, got errors %q and file %q", ft.errors, readFile(t, path))
+	}
+	os.Unsetenv("SNAP_UPDATE")
+
+	// Markers work in snapshot files.
+	if err := os.WriteFile(path, []byte("usage: tool\n\nstarted at <snap:ignore>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	SnapFile(t, path).Diff("usage: tool\n\nstarted at 12:05\n")
+
+	ft = newFakeT(t)
+	SnapFile(ft, path).Diff("usage: tool [flags]\n\nstarted at 12:05\n")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "help.golden:1: -usage: tool\n") {
+		t.Errorf("expected the changed line of the file to be anchored, got %q", ft.errors)
+	}
+
+	t.Setenv("SNAP_UPDATE", "1")
+	ft = newFakeT(t)
+	SnapFile(ft, path).Diff("usage: tool [flags]\n\nstarted at 12:05\n")
+	if got, want := readFile(t, path), "usage: tool [flags]\n\nstarted at <snap:ignore>\n"; got != want {
+		t.Errorf("expected the file to be updated keeping its marker to %q, got %q", want, got)
+	}
+}
+
+func TestSnapFileLarge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "large.golden")
+	want := strings.Repeat("0123456789\n", chunkedDiffSize/10)
+	if err := os.WriteFile(path, []byte(want), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ft := newFakeT(t)
+	SnapFile(ft, path).Diff(want)
+	if len(ft.errors) != 0 {
+		t.Errorf("expected no errors, got %.500q", ft.errors)
+	}
+
+	ft = newFakeT(t)
+	SnapFile(ft, path).Diff(strings.Replace(want, "0123456789", "0123456780", 1))
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "chunk at offset 0, first difference at offset 9:") {
+		t.Errorf("expected a chunked report, got %.500q", ft.errors)
+	}
+}
+
+func TestSnapFileLargeUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "large.golden")
+	want := strings.Repeat("0123456789\n", chunkedDiffSize/10)
+	if err := os.WriteFile(path, []byte(want), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := SnapFile(t, path)
+	if (runtime.GOOS == "linux" || runtime.GOOS == "darwin") && !isMapped(path) {
+		t.Errorf("expected %s to be mapped", path)
+	}
+
+	ft := newFakeT(t)
+	got := strings.Replace(want, "0123456789", "0123456780", 1)
+	SnapFile(ft, path).Update().Diff(got)
+	if b, _ := os.ReadFile(path); string(b) != got {
+		t.Errorf("expected the file to be updated")
+	}
+	// The file is replaced rather than rewritten, the mapping keeps the old content.
+	if old.String() != want {
+		t.Errorf("expected the mapped snapshot to keep its content")
+	}
+}
+
+// BenchmarkSnapFileLarge compares a 500 MB value with a golden file. The golden file is mapped
+// rather than read, so the bytes allocated per operation stay far below its size. The value is
+// mapped too, like a value streamed from disk, so that the process doesn't hold it either.
+func BenchmarkSnapFileLarge(b *testing.B) {
+	if testing.Short() {
+		b.Skip("writes 1 GB of files")
+	}
+	const size = 500 << 20
+	line := []byte(strings.Repeat("x", 99) + "\n")
+	data := make([]byte, 0, size)
+	for len(data) < size {
+		data = append(data, line...)
+	}
+	dir := b.TempDir()
+	golden := filepath.Join(dir, "large.golden")
+	if err := os.WriteFile(golden, data, 0644); err != nil {
+		b.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "got"), data, 0644); err != nil {
+		b.Fatal(err)
+	}
+	data = nil
+	got, err := readSnapshotFile(filepath.Join(dir, "got"))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(size)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		SnapFile(b, golden).Diff(got)
+	}
+}
@@ -0,0 +1,59 @@
+package snap
+
+import (
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// GoVersion uses text as the snapshot instead when the tests run with the Go release version, like
+// "go1.21", for output that changes across Go releases, like error strings or the usage of the flag
+// package. Suites can then pass with several Go releases at once:
+//
+//	snap.Snap(t, want).GoVersion("go1.21", wantGo121).Diff(got)
+//
+// Snapshots given with GoVersion are not updated automatically, as they're not the literal passed
+// to [Snap].
+func (s *Snapshot) GoVersion(version string, text string) *Snapshot {
+	c := *s
+	if goRelease(runtime.Version()) == version {
+		c.text = text
+		c.variant = version
+	}
+	return &c
+}
+
+// goRelease returns the release of the Go version v, like "go1.22" for "go1.22.3" or for
+// "devel go1.22-5f8b0e1 Tue Jan 2 15:04:05 2024 +0000".
+func goRelease(v string) string {
+	v = strings.TrimPrefix(v, "devel ")
+	v, _, _ = strings.Cut(v, " ")
+	v, _, _ = strings.Cut(v, "-")
+	if major, minor, ok := strings.Cut(v, "."); ok {
+		minor, _, _ = strings.Cut(minor, ".")
+		// Pre-releases, like go1.23rc1, belong to their release.
+		if i := strings.IndexFunc(minor, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+			minor = minor[:i]
+		}
+		return major + "." + minor
+	}
+	return v
+}
+
+var (
+	goroutineHeader = regexp.MustCompile(`(?m)^goroutine \d+ \[`)
+	createdBy       = regexp.MustCompile(`(?m)^(created by .*) in goroutine \d+$`)
+	frameArgs       = regexp.MustCompile(`(?m)^(\S.*)\([^()]*\)$`)
+	frameOffset     = regexp.MustCompile(`(?m)^(\t.*:\d+) \+0x[0-9a-f]+$`)
+)
+
+// GoStackTraces is a [Normalizer] for goroutine stack traces, like the ones of panics, removing
+// what changes across Go releases and runs: goroutine IDs, the goroutine creating a goroutine,
+// which Go 1.21 added, function arguments, whose format changed in Go 1.17, and program counter
+// offsets.
+func GoStackTraces(got string) string {
+	got = goroutineHeader.ReplaceAllString(got, "goroutine N [")
+	got = createdBy.ReplaceAllString(got, "$1")
+	got = frameArgs.ReplaceAllString(got, "$1(...)")
+	return frameOffset.ReplaceAllString(got, "$1")
+}
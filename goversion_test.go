@@ -0,0 +1,62 @@
+package snap
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestGoRelease(t *testing.T) {
+	cases := map[string]string{
+		"go1.22.3":  "go1.22",
+		"go1.21":    "go1.21",
+		"go1.23rc1": "go1.23",
+		"devel go1.24-5f8b0e1 Tue Jan 2 15:04:05 2024 +0000": "go1.24",
+	}
+	for v, want := range cases {
+		if got := goRelease(v); got != want {
+			t.Errorf("goRelease(%q) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestGoVersion(t *testing.T) {
+	release := goRelease(runtime.Version())
+	Snap(t, "other").GoVersion("go1.0", "old").GoVersion(release, "current").Diff("current")
+
+	ft := newFakeT(t)
+	snapTB(ft, "other").GoVersion(release, "current").Update().Diff("changed")
+	if len(ft.errors) != 1 || !containsLog(ft, "is not updated automatically") {
+		t.Errorf("expected a failure without update, got errors %q and logs %q", ft.errors, ft.logs)
+	}
+}
+
+func TestGoStackTraces(t *testing.T) {
+	trace := `panic: boom
+
+goroutine 7 [running]:
+main.(*T).run(0xc000012345, {0x4b2a20?, 0x5a8b30?})
+	/src/main.go:12 +0x1d
+main.main.func1(...)
+	/src/main.go:20
+created by main.main in goroutine 1
+	/src/main.go:19 +0x25
+`
+	got := GoStackTraces(trace)
+	want := `panic: boom
+
+goroutine N [running]:
+main.(*T).run(...)
+	/src/main.go:12
+main.main.func1(...)
+	/src/main.go:20
+created by main.main
+	/src/main.go:19
+`
+	if got != want {
+		t.Errorf("GoStackTraces:\n%s\nwant:\n%s", got, want)
+	}
+	if strings.Contains(GoStackTraces("  -n int (default 1)"), "...") {
+		t.Error("expected lines starting with a space to be kept")
+	}
+}
@@ -0,0 +1,279 @@
+package snap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DiffGraphQLRequest compares the snapshot with the GraphQL request body, a JSON object with the
+// query, and optionally its operationName and variables, as clients send it:
+//
+//	snap.Snap(t, `query User($id: ID!) {
+//	  user(id: $id) {
+//	    name
+//	  }
+//	}
+//
+//	variables: {
+//	  "id": "42"
+//	}`).DiffGraphQLRequest(body)
+//
+// The query is formatted canonically, one field per line, indented by two spaces, without
+// comments or insignificant commas, and the variables are written with sorted keys, so that
+// queries built by code or by different clients compare equal.
+// It calls [testing.T.Error] when the snapshot is not equal to the request or when the request
+// can't be parsed.
+func (s *Snapshot) DiffGraphQLRequest(body []byte) {
+	s.t.Helper()
+	got, err := renderGraphQLRequest(body)
+	if err != nil {
+		s.t.Errorf("snap: Invalid GraphQL request: %v", err)
+		return
+	}
+	s.Diff(got)
+}
+
+// DiffGraphQLResponse compares the snapshot with the GraphQL response body, like
+// [Snapshot.DiffJSONSemantic]: keys are sorted and the diff only shows the values that changed.
+// The errors of the response are also sorted, by path and message, as servers resolving fields
+// concurrently report them in any order.
+// It calls [testing.T.Error] when the snapshot is not equal to the response or when the response
+// isn't valid JSON.
+func (s *Snapshot) DiffGraphQLResponse(body []byte) {
+	s.t.Helper()
+	normalized, err := sortGraphQLErrors(body)
+	if err != nil {
+		s.t.Errorf("snap: Invalid JSON: %v", err)
+		return
+	}
+	s.DiffJSONSemantic(normalized)
+}
+
+// renderGraphQLRequest returns the text of the GraphQL request body, as
+// [Snapshot.DiffGraphQLRequest] compares it.
+func renderGraphQLRequest(body []byte) (string, error) {
+	var req struct {
+		Query         string          `json:"query"`
+		OperationName string          `json:"operationName"`
+		Variables     json.RawMessage `json:"variables"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", err
+	}
+	query, err := formatGraphQL(req.Query)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if req.OperationName != "" {
+		fmt.Fprintf(&sb, "operation: %s\n\n", req.OperationName)
+	}
+	sb.WriteString(query)
+	if v := bytes.TrimSpace(req.Variables); len(v) > 0 && !bytes.Equal(v, []byte("null")) && !bytes.Equal(v, []byte("{}")) {
+		variables, err := canonicalJSON(v)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, "\n\nvariables: %s", variables)
+	}
+	return sb.String(), nil
+}
+
+// sortGraphQLErrors returns the GraphQL response body with its errors sorted by path and message.
+func sortGraphQLErrors(body []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var response map[string]any
+	if err := dec.Decode(&response); err != nil {
+		return nil, err
+	}
+	errs, ok := response["errors"].([]any)
+	if !ok {
+		return body, nil
+	}
+	// Errors sort by the elements of their path, so that the errors of a field come before the
+	// errors of its subfields.
+	key := func(e any) string {
+		m, _ := e.(map[string]any)
+		path, _ := m["path"].([]any)
+		var sb strings.Builder
+		for _, p := range path {
+			fmt.Fprintf(&sb, "%v\x01", p)
+		}
+		fmt.Fprintf(&sb, "\x00%v", m["message"])
+		return sb.String()
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return key(errs[i]) < key(errs[j]) })
+	return json.Marshal(response)
+}
+
+// graphQLToken is a lexical token of a GraphQL document: a punctuator, a name or a value, like a
+// number or a string.
+type graphQLToken struct {
+	text  string
+	punct bool
+}
+
+// lexGraphQL returns the tokens of the GraphQL document, without the ignored ones: whitespace,
+// commas and comments.
+func lexGraphQL(doc string) ([]graphQLToken, error) {
+	var tokens []graphQLToken
+	for i := 0; i < len(doc); {
+		c := doc[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case strings.HasPrefix(doc[i:], "\ufeff"):
+			i += len("\ufeff")
+		case c == '#':
+			for i < len(doc) && doc[i] != '\n' && doc[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(doc[i:], "..."):
+			tokens = append(tokens, graphQLToken{text: "...", punct: true})
+			i += 3
+		case strings.IndexByte("!$&():=@[]{|}", c) >= 0:
+			tokens = append(tokens, graphQLToken{text: doc[i : i+1], punct: true})
+			i++
+		case strings.HasPrefix(doc[i:], `"""`):
+			end := i + 3
+			for end < len(doc) && !strings.HasPrefix(doc[end:], `"""`) {
+				if strings.HasPrefix(doc[end:], `\"""`) {
+					end += 3
+				}
+				end++
+			}
+			if end >= len(doc) {
+				return nil, fmt.Errorf("unterminated block string at offset %d", i)
+			}
+			tokens = append(tokens, graphQLToken{text: doc[i : end+3]})
+			i = end + 3
+		case c == '"':
+			end := i + 1
+			for end < len(doc) && doc[end] != '"' && doc[end] != '\n' {
+				if doc[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(doc) || doc[end] != '"' {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, graphQLToken{text: doc[i : end+1]})
+			i = end + 1
+		case c == '-' || c == '_' || '0' <= c && c <= '9' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z':
+			number := c == '-' || '0' <= c && c <= '9'
+			end := i + 1
+			for end < len(doc) && (isNameChar(doc[end]) ||
+				number && (doc[end] == '.' || (doc[end] == '+' || doc[end] == '-') && (doc[end-1] == 'e' || doc[end-1] == 'E'))) {
+				end++
+			}
+			tokens = append(tokens, graphQLToken{text: doc[i:end]})
+			i = end
+		default:
+			return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+		}
+	}
+	return tokens, nil
+}
+
+// isNameChar reports whether c can continue a GraphQL name or number.
+func isNameChar(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z'
+}
+
+// formatGraphQL returns the GraphQL document doc formatted canonically: the fields of selection
+// sets on their own lines, indented by two spaces, and the items of arguments, lists and input
+// objects separated by ", ".
+func formatGraphQL(doc string) (string, error) {
+	tokens, err := lexGraphQL(doc)
+	if err != nil {
+		return "", err
+	}
+
+	// The open brackets: '{' for selection sets, 'o' for input objects, '(' and '['.
+	var open []byte
+	top := func() byte {
+		if len(open) == 0 {
+			return 0
+		}
+		return open[len(open)-1]
+	}
+	inValue := func() bool { return top() == '(' || top() == '[' || top() == 'o' }
+	// endsItem reports whether the token ends a field, an argument or a value.
+	endsItem := func(t graphQLToken) bool {
+		return !t.punct || t.text == ")" || t.text == "]" || t.text == "}" || t.text == "!"
+	}
+
+	var sb strings.Builder
+	newline := func() { sb.WriteString("\n" + strings.Repeat("  ", strings.Count(string(open), "{"))) }
+	for i, tok := range tokens {
+		var prev, beforePrev graphQLToken
+		if i > 0 {
+			prev = tokens[i-1]
+		}
+		if i > 1 {
+			beforePrev = tokens[i-2]
+		}
+
+		switch {
+		case i == 0:
+		case tok.punct && tok.text == "}":
+			if top() == '{' {
+				open = open[:len(open)-1]
+				newline()
+				sb.WriteString("}")
+				continue
+			}
+		case prev.punct && prev.text == "}" && len(open) == 0:
+			sb.WriteString("\n\n") // A blank line between definitions.
+		case prev.punct && prev.text == "{" && top() == '{':
+			newline()
+		case prev.punct && prev.text == "}" && top() == '{':
+			newline()
+		case tok.punct && strings.Contains(")]!:(", tok.text):
+		case prev.punct && prev.text == "...":
+			if !tok.punct && tok.text != "on" {
+				break
+			}
+			sb.WriteString(" ")
+		case prev.punct && (prev.text == ":" || prev.text == "=") || tok.punct && tok.text == "=":
+			sb.WriteString(" ")
+		case prev.punct && strings.Contains("([$@", prev.text):
+		case prev.punct && prev.text == "{" && top() == 'o':
+		case inValue() && endsItem(prev):
+			sb.WriteString(", ")
+		case top() == '{' && endsItem(prev) && (!tok.punct || tok.text == "...") &&
+			!(beforePrev.punct && beforePrev.text == "..." && prev.text == "on"):
+			newline()
+		default:
+			sb.WriteString(" ")
+		}
+
+		sb.WriteString(tok.text)
+		if tok.punct {
+			switch tok.text {
+			case "{":
+				if inValue() {
+					open = append(open, 'o')
+				} else {
+					open = append(open, '{')
+				}
+			case "(", "[":
+				open = append(open, tok.text[0])
+			case ")", "]", "}":
+				if len(open) == 0 {
+					return "", fmt.Errorf("unbalanced %q", tok.text)
+				}
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	if len(open) > 0 {
+		return "", fmt.Errorf("unclosed %q", open[len(open)-1])
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,79 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffGraphQLRequest(t *testing.T) {
+	body := `{
+		"operationName": "User",
+		"query": "# Fetch a user.\nquery User($id: ID!, $first: Int = 10, $roles: [Role!]) { user(id: $id) { id, name ...Avatar posts(first: $first, filter: {tags: [\"go\", \"test\"], draft: false}) @include(if: true) { edges { node { title } } } ... on Admin { roles } } }\nfragment Avatar on User { avatar(size: 64) }",
+		"variables": {"roles": ["ADMIN"], "id": "42"}
+	}`
+	Snap(t, `operation: User
+
+query User($id: ID!, $first: Int = 10, $roles: [Role!]) {
+  user(id: $id) {
+    id
+    name
+    ...Avatar
+    posts(first: $first, filter: {tags: ["go", "test"], draft: false}) @include(if: true) {
+      edges {
+        node {
+          title
+        }
+      }
+    }
+    ... on Admin {
+      roles
+    }
+  }
+}
+
+fragment Avatar on User {
+  avatar(size: 64)
+}
+
+variables: {
+  "id": "42",
+  "roles": [
+    "ADMIN"
+  ]
+}`).DiffGraphQLRequest([]byte(body))
+
+	Snap(t, `{
+  viewer {
+    login
+  }
+}`).DiffGraphQLRequest([]byte(`{"query": "{viewer{login}}", "variables": null}`))
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "").DiffGraphQLRequest([]byte(`{"query": "{ viewer { login }"}`))
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `snap: Invalid GraphQL request: unclosed '{'`) {
+		t.Errorf("expected an invalid request error, got %q", ft.errors)
+	}
+}
+
+func TestDiffGraphQLResponse(t *testing.T) {
+	Snap(t, `{
+  "data": {
+    "user": null
+  },
+  "errors": [
+    {
+      "message": "not found",
+      "path": [
+        "user"
+      ]
+    },
+    {
+      "message": "forbidden",
+      "path": [
+        "user",
+        "email"
+      ]
+    }
+  ]
+}`).DiffGraphQLResponse([]byte(`{"errors": [{"path": ["user", "email"], "message": "forbidden"}, {"message": "not found", "path": ["user"]}], "data": {"user": null}}`))
+}
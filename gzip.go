@@ -0,0 +1,54 @@
+package snap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+)
+
+// gzipLevel is the compression level of snapshot files, fixed so that a snapshot is always
+// compressed to the same bytes.
+const gzipLevel = gzip.BestCompression
+
+// isGzipFile reports whether the snapshot file at path is stored compressed with gzip, as its .gz
+// extension says.
+func isGzipFile(path string) bool {
+	return strings.HasSuffix(path, ".gz")
+}
+
+// newGzipWriter returns a gzip writer writing the same bytes to w for the same data on every run
+// and machine: the header has no modification time, file name or comment, its OS is unknown
+// rather than the one of the machine, and the compression level is fixed. Otherwise, compressed
+// snapshot files would appear modified in git after every update.
+func newGzipWriter(w io.Writer) *gzip.Writer {
+	zw, _ := gzip.NewWriterLevel(w, gzipLevel) // The level is valid.
+	zw.Header = gzip.Header{OS: 255}
+	return zw
+}
+
+// compressGzip returns data compressed with [newGzipWriter].
+func compressGzip(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := newGzipWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressGzip returns the text compressed with gzip in data.
+func decompressGzip(data string) (string, error) {
+	zr, err := gzip.NewReader(strings.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	text, err := io.ReadAll(zr)
+	if err != nil {
+		return "", err
+	}
+	return string(text), zr.Close()
+}
@@ -0,0 +1,54 @@
+package snap
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressGzip(t *testing.T) {
+	data := bytes.Repeat([]byte("usage: tool [flags]\n"), 100)
+	first, err := compressGzip(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := compressGzip(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Error("expected the same data to be compressed to the same bytes")
+	}
+	// The modification time is zero and the OS unknown.
+	if !bytes.Equal(first[4:8], []byte{0, 0, 0, 0}) || first[9] != 255 {
+		t.Errorf("unexpected gzip header % x", first[:10])
+	}
+	if text, err := decompressGzip(string(first)); err != nil || text != string(data) {
+		t.Errorf("expected the data back, got %.50q and error %v", text, err)
+	}
+}
+
+func TestSnapFileGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "help.golden.gz")
+
+	t.Setenv("SNAP_UPDATE", "1")
+	ft := newFakeT(t)
+	SnapFile(ft, path).Diff("usage: tool\n")
+	compressed := readFile(t, path)
+	if len(ft.errors) != 0 || compressed[:2] != "\x1f\x8b" {
+		t.Fatalf("expected the file to be created compressed, got errors %q and file %q", ft.errors, compressed)
+	}
+	SnapFile(ft, path).Diff("usage: tool [flags]\n")
+	SnapFile(ft, path).Diff("usage: tool\n")
+	if readFile(t, path) != compressed {
+		t.Error("expected the same snapshot to be compressed to the same bytes")
+	}
+
+	os.Unsetenv("SNAP_UPDATE")
+	ft = newFakeT(t)
+	SnapFile(ft, path).Diff("usage: tool\n")
+	if len(ft.errors) != 0 {
+		t.Errorf("expected the compressed file to match, got %q", ft.errors)
+	}
+}
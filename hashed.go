@@ -0,0 +1,126 @@
+package snap
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// hashesFile is the file of the hashes recorded by [Snapshot.DiffHashed], in the testdata
+// directory of the package of the snapshot.
+const hashesFile = "snap-hashes.txt"
+
+var (
+	hashesMu sync.Mutex
+	// hashes maps the paths of the hashes files read by this test binary to their hashes.
+	hashes = make(map[string]map[string]bool)
+)
+
+// DiffHashed compares the snapshot with the value returned by render, like [Snapshot.DiffFunc],
+// but skips rendering it when hash, a cheap hash of what the value is rendered from, is known to
+// render the snapshot. It's meant for expensive renders, like big template executions or database
+// dumps, whose inputs rarely change:
+//
+//	snap.Snap(t, want).DiffHashed(schemaVersion, func() string { return dumpDatabase(db) })
+//
+// When the value matches the snapshot, the hash is recorded with the snapshot text in the
+// testdata/snap-hashes.txt file of the package, to be committed with the snapshot. Later runs with
+// the same hash and snapshot text skip render; runs with a different hash or text, or with
+// SNAP_UPDATE set, render the value and compare it. The file only grows, delete it to start over.
+func (s *Snapshot) DiffHashed(hash string, render func() string) {
+	s.t.Helper()
+	cur := s.current()
+	path := ""
+	if s.location.file != "" {
+		path = filepath.Join(filepath.Dir(s.location.file), "testdata", hashesFile)
+	}
+	key := hashKey(cur.text, hash)
+	if path != "" && !cur.missingFile && !s.shouldUpdate() && hashKnown(path, key) {
+		return
+	}
+
+	c := *s
+	c.DiffFunc(render)
+	if path == "" || c.mismatched || c.t.Failed() {
+		return
+	}
+	if err := recordHash(path, key); err != nil {
+		s.logf(slog.LevelWarn, "Warning: Failed to record the hash of the snapshot: %s", err)
+	}
+}
+
+// hashKey returns the key recording that the value of hash renders the snapshot text.
+func hashKey(text string, hash string) string {
+	sum := sha256.Sum256([]byte(hash + "\x00" + text))
+	return hex.EncodeToString(sum[:16])
+}
+
+// readHashes returns the hashes of the hashes file at path, read once per test binary. It must be
+// called with hashesMu held.
+func readHashes(path string) (map[string]bool, error) {
+	if known, ok := hashes[path]; ok {
+		return known, nil
+	}
+	known := make(map[string]bool)
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		hashes[path] = known
+		return known, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			known[line] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	hashes[path] = known
+	return known, nil
+}
+
+// hashKnown reports whether the hashes file at path contains key.
+func hashKnown(path string, key string) bool {
+	hashesMu.Lock()
+	defer hashesMu.Unlock()
+	known, err := readHashes(path)
+	return err == nil && known[key]
+}
+
+// recordHash adds key to the hashes file at path, which is rewritten sorted, to keep its diffs
+// small.
+func recordHash(path string, key string) error {
+	hashesMu.Lock()
+	defer hashesMu.Unlock()
+	known, err := readHashes(path)
+	if err != nil {
+		return err
+	}
+	if known[key] {
+		return nil
+	}
+	known[key] = true
+
+	keys := make([]string, 0, len(known))
+	for k := range known {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(keys, "\n")+"\n"), 0644)
+}
@@ -0,0 +1,65 @@
+package snap
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffHashed(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "dump_test.go")
+	renders := 0
+	render := func(value string) func() string {
+		return func() string {
+			renders++
+			return value
+		}
+	}
+
+	ft := newFakeT(t)
+	At(ft, source, 10, "users: 2").DiffHashed("v1", render("users: 2"))
+	At(ft, source, 10, "users: 2").DiffHashed("v1", render("users: 2"))
+	if len(ft.errors) != 0 || renders != 1 {
+		t.Fatalf("expected one render and no errors, got %d renders and errors %q", renders, ft.errors)
+	}
+	path := filepath.Join(dir, "testdata", "snap-hashes.txt")
+	if data := readFile(t, path); data != hashKey("users: 2", "v1")+"\n" {
+		t.Errorf("unexpected hashes file %q", data)
+	}
+
+	// The hashes are read back from the file.
+	hashesMu.Lock()
+	delete(hashes, path)
+	hashesMu.Unlock()
+	At(ft, source, 10, "users: 2").DiffHashed("v1", render("users: 2"))
+	if renders != 1 {
+		t.Errorf("expected the recorded hash to skip rendering, got %d renders", renders)
+	}
+
+	// Another hash or another snapshot renders the value again.
+	At(ft, source, 10, "users: 2").DiffHashed("v2", render("users: 3"))
+	At(ft, source, 10, "users: 3").DiffHashed("v1", render("users: 3"))
+	if renders != 3 || len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `"users: 3"`) {
+		t.Errorf("expected 3 renders and one mismatch, got %d renders and errors %q", renders, ft.errors)
+	}
+	if data := readFile(t, path); strings.Count(data, "\n") != 2 || strings.Contains(data, hashKey("users: 2", "v2")) {
+		t.Errorf("expected the hash of the matching snapshot only, got %q", data)
+	}
+}
+
+func TestDiffHashedUpdate(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "dump_test.go")
+	ft := newFakeT(t)
+	At(ft, source, 10, "users: 2").DiffHashed("v1", func() string { return "users: 2" })
+
+	t.Setenv("SNAP_UPDATE", "1")
+	renders := 0
+	At(ft, source, 10, "users: 2").DiffHashed("v1", func() string {
+		renders++
+		return "users: 2"
+	})
+	if renders != 1 {
+		t.Errorf("expected the value to be rendered with SNAP_UPDATE, got %d renders", renders)
+	}
+}
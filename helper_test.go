@@ -0,0 +1,62 @@
+package snap
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// checkOutput is a test helper wrapping Snap.
+func checkOutput(t testing.TB, got string, want string) *Snapshot {
+	s := SnapHelper(t, want, 1)
+	s.Diff(got)
+	return s
+}
+
+func TestSnapHelper(t *testing.T) {
+	_, _, line, _ := runtime.Caller(0)
+	s := checkOutput(t, "ok", "ok")
+	if s.location.line != line+1 || !strings.HasSuffix(s.location.file, "helper_test.go") || !s.wrapped {
+		t.Errorf("expected the snapshot to be located at the helper call, got %+v", s.location)
+	}
+}
+
+func TestUpdateWrapped(t *testing.T) {
+	src := "package example\n\nimport sn \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n" +
+		"\tcheckOutput(t, run(\"a\"), \"old\")\n\tsn.Snap(t, \"other\").Diff(got)\n}\n"
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, src, "old")
+	s.location.line = 6
+	s.wrapped = true
+	s.Diff("new")
+	want := strings.Replace(src, `run("a"), "old")`, `run("a"), "new")`, 1)
+	if got := readFile(t, path); got != want {
+		t.Errorf("expected the helper argument to be updated, got:\n%s", got)
+	}
+	// The helper call is found again after formatting, not the Snap call after it.
+	if !containsLog(ft, "example_test.go:6:27 (bytes") {
+		t.Errorf("expected the update to be logged at the helper argument, got %q", ft.logs)
+	}
+
+	// The argument to update must be the only one equal to the snapshot.
+	src = "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tcheckOutput(t, \"same\", \"same\")\n\tsnap.Snap(t, \"other\").Diff(got)\n}\n"
+	ft = newFakeT(t)
+	s, path = snapInFile(t, ft, src, "same")
+	s.location.line = 6
+	s.wrapped = true
+	s.Diff("new")
+	if readFile(t, path) != src || !strings.Contains(strings.Join(ft.errors, "\n"), "no Snap call found") {
+		t.Errorf("expected an ambiguous helper call not to be updated, got errors %q", ft.errors)
+	}
+
+	// The updated argument is found again after formatting when another argument equals it.
+	src = "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tcheckOutput(t, \"y\", \"z\")\n\tsnap.Snap(t, \"other\").Diff(got)\n}\n"
+	ft = newFakeT(t)
+	s, path = snapInFile(t, ft, src, "z")
+	s.location.line = 6
+	s.wrapped = true
+	s.Diff("y")
+	if got, want := readFile(t, path), strings.Replace(src, `"z"`, `"y"`, 1); got != want || !containsLog(ft, "example_test.go:6:22 (bytes") {
+		t.Errorf("expected the second helper argument to be updated, got errors %q, logs %q and:\n%s", ft.errors, ft.logs, got)
+	}
+}
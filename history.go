@@ -0,0 +1,68 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// KeepHistory makes updates of a [SnapFile] snapshot keep up to n previous versions of the file,
+// so that reviewers can follow how a large golden file evolved without digging through the
+// history of the repository:
+//
+//	snap.SnapFile(t, "testdata/report.golden").KeepHistory(3).Diff(report)
+//
+// Before the file is rewritten with SNAP_UPDATE=1, its content is copied to the
+// report.golden.history directory next to it, in a file named after the time of the update, like
+// 20240514T093000.000000000Z. The oldest versions beyond n are removed.
+func (s *Snapshot) KeepHistory(n int) *Snapshot {
+	s.t.Helper()
+	c := *s
+	if s.file == "" {
+		c.t.Errorf("snap: KeepHistory is only supported for snapshots created with SnapFile")
+		return &c
+	}
+	c.history = n
+	return &c
+}
+
+// historyTimeFormat names the versions of a file kept by [Snapshot.KeepHistory], sorting them by
+// time.
+const historyTimeFormat = "20060102T150405.000000000Z"
+
+// saveHistory copies the current content of the file of the snapshot to its history directory,
+// and removes the versions beyond the number kept.
+func (s *Snapshot) saveHistory() error {
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		return err
+	}
+	dir := s.file + ".history"
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := time.Now().UTC().Format(historyTimeFormat)
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var versions []string
+	for _, e := range entries {
+		if _, err := time.Parse(historyTimeFormat, e.Name()); err == nil && !e.IsDir() {
+			versions = append(versions, e.Name())
+		}
+	}
+	sort.Strings(versions)
+	for len(versions) > s.history {
+		if err := os.Remove(filepath.Join(dir, versions[0])); err != nil {
+			return err
+		}
+		versions = versions[1:]
+	}
+	return nil
+}
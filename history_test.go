@@ -0,0 +1,43 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeepHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.golden")
+	t.Setenv("SNAP_UPDATE", "1")
+	for _, text := range []string{"v1\n", "v2\n", "v3\n", "v4\n"} {
+		ft := newFakeT(t)
+		SnapFile(ft, path).KeepHistory(2).Diff(text)
+		for _, err := range ft.errors {
+			if strings.Contains(err, "Failed") {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		}
+	}
+	if got := readFile(t, path); got != "v4\n" {
+		t.Errorf("expected the file to hold the last version, got %q", got)
+	}
+
+	entries, err := os.ReadDir(path + ".history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []string
+	for _, e := range entries {
+		versions = append(versions, readFile(t, filepath.Join(path+".history", e.Name())))
+	}
+	if got, want := strings.Join(versions, ""), "v2\nv3\n"; got != want {
+		t.Errorf("expected the two previous versions to be kept, got %q", got)
+	}
+
+	ft := newFakeT(t)
+	snapTB(ft, "").KeepHistory(2)
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "only supported for snapshots created with SnapFile") {
+		t.Errorf("expected an error for a snapshot not in a file, got %q", ft.errors)
+	}
+}
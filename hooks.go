@@ -0,0 +1,71 @@
+package snap
+
+import (
+	"sync"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// FailureInfo describes a mismatching snapshot passed to the functions registered with
+// [OnFailure].
+type FailureInfo struct {
+	Test    string // Name of the test, as reported by [testing.TB.Name].
+	File    string // Source file containing the snapshot, relative to the module root.
+	Line    int    // Line of the [Snap] call in File.
+	Want    string // The snapshot text.
+	Got     string // The value the snapshot was compared against.
+	Diff    string // Human readable diff between Want and Got, or the chunks that differ for large values.
+	Message string // The failure reported to the test.
+	// Owner of the snapshot, set with [Snapshot.Owner].
+	Owner string
+	// Notes attached to the snapshot with [Snapshot.Note].
+	Notes []string
+	// Seed attached to the snapshot with [Snapshot.Seed], nil if none.
+	Seed any
+}
+
+var (
+	failureHooksMu sync.Mutex
+	failureHooks   []func(FailureInfo)
+)
+
+// OnFailure registers fn to be called on every mismatch, including failed checks and schemas, for
+// custom logging, metrics, or uploading artifacts. Functions are called in the order they were
+// registered, from the goroutine of the test, also when SNAP_RECORD is set.
+//
+// Typically called from TestMain.
+func OnFailure(fn func(FailureInfo)) {
+	failureHooksMu.Lock()
+	defer failureHooksMu.Unlock()
+	failureHooks = append(failureHooks, fn)
+}
+
+// runFailureHooks calls the functions registered with [OnFailure].
+func (s *Snapshot) runFailureHooks(want string, got string, message string) {
+	failureHooksMu.Lock()
+	hooks := failureHooks
+	failureHooksMu.Unlock()
+
+	if len(hooks) == 0 {
+		return
+	}
+	diff := message
+	if !isLarge(want, got) {
+		diff = cmp.Diff(want, got)
+	}
+	info := FailureInfo{
+		Test:    s.testName(),
+		File:    relativePath(s.location.file),
+		Line:    s.location.line,
+		Want:    want,
+		Got:     got,
+		Diff:    diff,
+		Message: message,
+		Owner:   s.owner,
+		Notes:   s.notes,
+		Seed:    s.seed,
+	}
+	for _, fn := range hooks {
+		fn(info)
+	}
+}
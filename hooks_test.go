@@ -0,0 +1,45 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func onFailure(t *testing.T, fn func(FailureInfo)) {
+	t.Helper()
+	OnFailure(fn)
+	t.Cleanup(func() {
+		failureHooksMu.Lock()
+		defer failureHooksMu.Unlock()
+		failureHooks = failureHooks[:len(failureHooks)-1]
+	})
+}
+
+func TestOnFailure(t *testing.T) {
+	var failures []FailureInfo
+	onFailure(t, func(f FailureInfo) { failures = append(failures, f) })
+
+	Snap(t, "same").Diff("same")
+	if len(failures) != 0 {
+		t.Fatalf("expected no failures, got %+v", failures)
+	}
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "want").Owner("@team").Diff("got")
+	if len(failures) != 1 {
+		t.Fatalf("expected one failure, got %+v", failures)
+	}
+	f := failures[0]
+	if f.Test != t.Name() || f.File != "hooks_test.go" || f.Want != "want" || f.Got != "got" || f.Owner != "@team" {
+		t.Errorf("unexpected failure info %+v", f)
+	}
+	if !strings.Contains(f.Diff, "want") || len(ft.errors) != 1 || f.Message != ft.errors[0] {
+		t.Errorf("expected the diff and the reported message, got %+v", f)
+	}
+
+	t.Setenv("SNAP_RECORD", "1")
+	snapNoUpdate(ft, "want").Diff("got")
+	if len(failures) != 2 {
+		t.Errorf("expected the hooks to run in record mode, got %+v", failures)
+	}
+}
@@ -0,0 +1,354 @@
+package snap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SelectHTML returns a [Normalizer] keeping only the elements of an HTML value matched by the CSS
+// selector, so that a test pins the component under test rather than the whole page:
+//
+//	snap.Snap(t, want).Normalize(snap.SelectHTML("#cart .item")).Diff(page)
+//
+// Selectors are made of simple selectors, "form", "#id", ".class", "[attr]" and "[attr=value]",
+// combined like "form.login", with the descendant and child combinators, like "main > form input",
+// and grouped with commas. The matched elements are kept as they are in the value, from their start
+// tag to their end tag, one per line, in document order, without the elements nested in another
+// matched element. A value without matching elements is replaced by an HTML comment saying so.
+// SelectHTML panics if the selector is invalid.
+func SelectHTML(selector string) Normalizer {
+	groups, err := parseSelector(selector)
+	if err != nil {
+		panic(fmt.Sprintf("snap: invalid selector %q: %v", selector, err))
+	}
+	return func(got string) string {
+		var selected []string
+		var last *htmlElement
+		for _, e := range parseHTML(got) {
+			if last != nil && e.start < last.end {
+				continue // Nested in the last selected element.
+			}
+			for _, g := range groups {
+				if g.matches(e) {
+					selected = append(selected, got[e.start:e.end])
+					last = e
+					break
+				}
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Sprintf("<!-- snap: no element matches %q -->", selector)
+		}
+		return strings.Join(selected, "\n")
+	}
+}
+
+// htmlElement is an element of an HTML document.
+type htmlElement struct {
+	tag        string
+	attrs      map[string]string
+	start, end int // The offsets of the outer HTML of the element.
+	parent     *htmlElement
+}
+
+// voidElements are the HTML elements without content or end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// rawTextElements are the HTML elements whose content is text, not markup.
+var rawTextElements = map[string]bool{"script": true, "style": true, "textarea": true, "title": true}
+
+// siblingClosed are the HTML elements whose end tag can be omitted before a sibling of the same
+// type, like list items.
+var siblingClosed = map[string]bool{"li": true, "p": true, "tr": true, "td": true, "th": true, "option": true, "dt": true, "dd": true}
+
+// parseHTML returns the elements of the HTML document doc, in document order. It tolerates
+// malformed documents: end tags without a start tag are ignored, and elements that aren't closed
+// end where their parent does.
+func parseHTML(doc string) []*htmlElement {
+	var elements, open []*htmlElement
+	closeTo := func(n int, end int) {
+		for _, e := range open[n:] {
+			e.end = end
+		}
+		open = open[:n]
+	}
+	for i := 0; i < len(doc); {
+		lt := strings.IndexByte(doc[i:], '<')
+		if lt < 0 {
+			break
+		}
+		i += lt
+		rest := doc[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				i = len(doc)
+			} else {
+				i += 4 + end + 3
+			}
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			i += tagEnd(rest)
+		case strings.HasPrefix(rest, "</"):
+			name, _ := parseTag(rest[2:])
+			end := i + tagEnd(rest)
+			for n := len(open) - 1; n >= 0; n-- {
+				if open[n].tag == name {
+					closeTo(n+1, i) // Elements left open end before the end tag of their parent.
+					closeTo(n, end)
+					break
+				}
+			}
+			i = end
+		case len(rest) > 1 && isLetter(rest[1]):
+			name, attrs := parseTag(rest[1:])
+			end := i + tagEnd(rest)
+			if siblingClosed[name] && len(open) > 0 && open[len(open)-1].tag == name {
+				closeTo(len(open)-1, i)
+			}
+			e := &htmlElement{tag: name, attrs: attrs, start: i, end: end}
+			if len(open) > 0 {
+				e.parent = open[len(open)-1]
+			}
+			elements = append(elements, e)
+			i = end
+			if voidElements[name] || strings.HasSuffix(doc[e.start:end], "/>") {
+				continue
+			}
+			if rawTextElements[name] {
+				// Skip the content, up to the end tag, which closes the element.
+				if n := strings.Index(strings.ToLower(doc[i:]), "</"+name); n >= 0 {
+					i += n
+				} else {
+					i = len(doc)
+				}
+			}
+			open = append(open, e)
+		default:
+			i++
+		}
+	}
+	closeTo(0, len(doc))
+	return elements
+}
+
+// tagEnd returns the length of the tag at the start of s, up to its closing '>' outside of quoted
+// attribute values, or the length of s if the tag isn't closed.
+func tagEnd(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// parseTag parses the name and the attributes of the tag s, following its '<' or "</". Names are
+// lowercased, as HTML is case-insensitive.
+func parseTag(s string) (name string, attrs map[string]string) {
+	n := 0
+	for n < len(s) && !isTagSpace(s[n]) && s[n] != '>' && s[n] != '/' {
+		n++
+	}
+	name = strings.ToLower(s[:n])
+	attrs = make(map[string]string)
+	for i := n; i < len(s) && s[i] != '>'; {
+		if isTagSpace(s[i]) || s[i] == '/' {
+			i++
+			continue
+		}
+		start := i
+		for i < len(s) && !isTagSpace(s[i]) && s[i] != '>' && s[i] != '=' && s[i] != '/' {
+			i++
+		}
+		key := strings.ToLower(s[start:i])
+		value := ""
+		if i < len(s) && s[i] == '=' {
+			i++
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				quote := s[i]
+				end := strings.IndexByte(s[i+1:], quote)
+				if end < 0 {
+					end = len(s) - i - 1
+				}
+				value = s[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				start := i
+				for i < len(s) && !isTagSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				value = s[start:i]
+			}
+		}
+		if _, ok := attrs[key]; !ok && key != "" {
+			attrs[key] = value
+		}
+	}
+	return name, attrs
+}
+
+func isTagSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// compoundSelector is a sequence of simple selectors matching one element, like "form.login".
+type compoundSelector struct {
+	tag     string   // The element type, or "" for any.
+	id      string   // The id, or "" for any.
+	classes []string // Classes the element must have.
+	attrs   []attrSelector
+	// child is set if the element must be a child of the element matched by the previous compound
+	// selector, rather than a descendant.
+	child bool
+}
+
+// attrSelector is an attribute selector, like "[type=email]".
+type attrSelector struct {
+	name     string
+	value    string
+	hasValue bool
+}
+
+// complexSelector is a list of compound selectors, from the outermost element to the matched one.
+type complexSelector []compoundSelector
+
+// parseSelector parses a group of selectors, separated by commas.
+func parseSelector(selector string) ([]complexSelector, error) {
+	var groups []complexSelector
+	for _, part := range strings.Split(selector, ",") {
+		var sel complexSelector
+		child := false
+		for _, field := range strings.Fields(strings.ReplaceAll(part, ">", " > ")) {
+			if field == ">" {
+				if len(sel) == 0 || child {
+					return nil, fmt.Errorf("unexpected '>'")
+				}
+				child = true
+				continue
+			}
+			c, err := parseCompound(field)
+			if err != nil {
+				return nil, err
+			}
+			c.child = child
+			child = false
+			sel = append(sel, c)
+		}
+		if len(sel) == 0 || child {
+			return nil, fmt.Errorf("empty selector")
+		}
+		groups = append(groups, sel)
+	}
+	return groups, nil
+}
+
+// parseCompound parses a compound selector, like "input.wide[type=email]".
+func parseCompound(s string) (compoundSelector, error) {
+	var c compoundSelector
+	name := func(i int) int {
+		for i < len(s) && (isLetter(s[i]) || '0' <= s[i] && s[i] <= '9' || s[i] == '-' || s[i] == '_') {
+			i++
+		}
+		return i
+	}
+	i := name(0)
+	c.tag = strings.ToLower(s[:i])
+	if s[:i] == "" && strings.HasPrefix(s, "*") {
+		i = 1
+	}
+	for i < len(s) {
+		switch s[i] {
+		case '#', '.':
+			end := name(i + 1)
+			if end == i+1 {
+				return c, fmt.Errorf("expected a name after %q", s[i])
+			}
+			if s[i] == '#' {
+				c.id = s[i+1 : end]
+			} else {
+				c.classes = append(c.classes, s[i+1:end])
+			}
+			i = end
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return c, fmt.Errorf("unclosed '['")
+			}
+			key, value, hasValue := strings.Cut(s[i+1:i+end], "=")
+			if key == "" {
+				return c, fmt.Errorf("expected an attribute name")
+			}
+			c.attrs = append(c.attrs, attrSelector{
+				name:     strings.ToLower(key),
+				value:    strings.Trim(value, `"'`),
+				hasValue: hasValue,
+			})
+			i += end + 1
+		default:
+			return c, fmt.Errorf("unexpected %q", s[i])
+		}
+	}
+	return c, nil
+}
+
+// matches reports whether the element e matches the compound selector.
+func (c compoundSelector) matches(e *htmlElement) bool {
+	if c.tag != "" && c.tag != e.tag || c.id != "" && c.id != e.attrs["id"] {
+		return false
+	}
+	classes := strings.Fields(e.attrs["class"])
+	for _, class := range c.classes {
+		found := false
+		for _, have := range classes {
+			found = found || have == class
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, a := range c.attrs {
+		value, ok := e.attrs[a.name]
+		if !ok || a.hasValue && value != a.value {
+			return false
+		}
+	}
+	return true
+}
+
+// matches reports whether the element e matches the selector, with its ancestors matching the
+// compound selectors before the last one.
+func (s complexSelector) matches(e *htmlElement) bool {
+	last := len(s) - 1
+	if !s[last].matches(e) {
+		return false
+	}
+	if last == 0 {
+		return true
+	}
+	rest := s[:last]
+	for p := e.parent; p != nil; p = p.parent {
+		if rest.matches(p) {
+			return true
+		}
+		if s[last].child {
+			return false
+		}
+	}
+	return false
+}
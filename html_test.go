@@ -0,0 +1,53 @@
+package snap
+
+import "testing"
+
+func TestSelectHTML(t *testing.T) {
+	page := `<!DOCTYPE html>
+<html>
+<head><title>Shop <b></title><style>#cart { color: red }</style></head>
+<body>
+<nav class="top"><a href="/">Home</a></nav>
+<main>
+  <div id="cart" class="panel wide">
+    <ul>
+      <li class="item" data-sku="1">Tea<li class="item sold-out" data-sku="2">Cake
+    </ul>
+    <form action="/checkout"><input type=email name="email"><BUTTON disabled>Pay</BUTTON></form>
+  </div>
+  <!-- <div id="old"></div> -->
+</main>
+</body>
+</html>`
+
+	cases := []struct {
+		selector string
+		want     string
+	}{
+		{"#cart form", `<form action="/checkout"><input type=email name="email"><BUTTON disabled>Pay</BUTTON></form>`},
+		{"ul > li.item", "<li class=\"item\" data-sku=\"1\">Tea\n<li class=\"item sold-out\" data-sku=\"2\">Cake\n    "},
+		{"li[data-sku='2']", "<li class=\"item sold-out\" data-sku=\"2\">Cake\n    "},
+		{"input[type=email], button[disabled]", `<input type=email name="email">` + "\n" + `<BUTTON disabled>Pay</BUTTON>`},
+		{"nav.top a", `<a href="/">Home</a>`},
+		{"main > form", `<!-- snap: no element matches "main > form" -->`},
+		{"#old", `<!-- snap: no element matches "#old" -->`},
+		{"div", "<div id=\"cart\" class=\"panel wide\">\n    <ul>\n      <li class=\"item\" data-sku=\"1\">Tea<li class=\"item sold-out\" data-sku=\"2\">Cake\n    </ul>\n    <form action=\"/checkout\"><input type=email name=\"email\"><BUTTON disabled>Pay</BUTTON></form>\n  </div>"},
+		{"b", `<!-- snap: no element matches "b" -->`},
+	}
+	for _, tc := range cases {
+		if got := SelectHTML(tc.selector)(page); got != tc.want {
+			t.Errorf("SelectHTML(%q):\nexpected %q\ngot      %q", tc.selector, tc.want, got)
+		}
+	}
+
+	for _, selector := range []string{"", "a >", "> a", "a[", "a..b", "a:hover"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected SelectHTML(%q) to panic", selector)
+				}
+			}()
+			SelectHTML(selector)
+		}()
+	}
+}
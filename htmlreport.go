@@ -0,0 +1,164 @@
+package snap
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/KasonBraley/snap/internal/diff"
+)
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Test}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; width: 100%; table-layout: fixed; }
+td { font-family: monospace; white-space: pre-wrap; vertical-align: top; padding: 0 4px; }
+td.num { width: 3em; color: #888; text-align: right; }
+td.delete { background: #ffebe9; }
+td.insert { background: #e6ffec; }
+</style>
+</head>
+<body>
+<h1>{{.Test}}</h1>
+<p>{{.Location}}</p>
+{{- if .Owner}}
+<p>Owner: {{.Owner}}</p>
+{{- end}}
+{{- range .Notes}}
+<p>Note: {{.}}</p>
+{{- end}}
+<table>
+<tr><th></th><th>want</th><th></th><th>got</th></tr>
+{{- range .Rows}}
+<tr><td class="num">{{.WantNum}}</td><td class="{{.WantClass}}">{{.Want}}</td><td class="num">{{.GotNum}}</td><td class="{{.GotClass}}">{{.Got}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+type htmlReport struct {
+	Test     string
+	Location string
+	Owner    string
+	Notes    []string
+	Rows     []htmlRow
+}
+
+// htmlRow is a row of the side-by-side diff. Line numbers are empty when a side has no line.
+type htmlRow struct {
+	Want, Got           string
+	WantNum, GotNum     string
+	WantClass, GotClass string
+}
+
+// sideBySide pairs the lines of want and got for a side-by-side diff. Deleted lines are shown next
+// to the lines that were inserted in their place.
+func sideBySide(want string, got string) []htmlRow {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+
+	var rows []htmlRow
+	var deleted, inserted []int
+	flush := func() {
+		for i := 0; i < max(len(deleted), len(inserted)); i++ {
+			var row htmlRow
+			if i < len(deleted) {
+				row.Want, row.WantNum, row.WantClass = wantLines[deleted[i]], fmt.Sprint(deleted[i]+1), "delete"
+			}
+			if i < len(inserted) {
+				row.Got, row.GotNum, row.GotClass = gotLines[inserted[i]], fmt.Sprint(inserted[i]+1), "insert"
+			}
+			rows = append(rows, row)
+		}
+		deleted, inserted = deleted[:0], inserted[:0]
+	}
+
+	for _, e := range diff.Lines(wantLines, gotLines, lineMatches) {
+		switch e.Op {
+		case diff.Equal:
+			flush()
+			rows = append(rows, htmlRow{
+				Want: wantLines[e.A], WantNum: fmt.Sprint(e.A + 1),
+				Got: gotLines[e.B], GotNum: fmt.Sprint(e.B + 1),
+			})
+		case diff.Delete:
+			deleted = append(deleted, e.A)
+		case diff.Insert:
+			inserted = append(inserted, e.B)
+		}
+	}
+	flush()
+	return rows
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+var (
+	htmlReportMu sync.Mutex
+	// htmlReportCount counts the HTML reports of each running test, to number their pages.
+	htmlReportCount = make(map[testing.TB]int)
+)
+
+// writeHTMLReport writes a side-by-side diff of a mismatching snapshot to the directory set with
+// the SNAP_HTML_REPORT environment variable, one page per snapshot, and logs the path of the page.
+// The page of a snapshot from an earlier run is overwritten.
+func (s *Snapshot) writeHTMLReport(lit literal, want string, got string) {
+	s.t.Helper()
+
+	dir := os.Getenv("SNAP_HTML_REPORT")
+	if dir == "" {
+		return
+	}
+
+	var buf bytes.Buffer
+	report := htmlReport{
+		Test:     s.testName(),
+		Location: lit.String(),
+		Owner:    s.owner,
+		Notes:    s.notes,
+		Rows:     sideBySide(want, got),
+	}
+	if err := htmlReportTemplate.Execute(&buf, report); err != nil {
+		s.logf(slog.LevelWarn, "Failed to render HTML report: %s", err)
+		return
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		s.logf(slog.LevelWarn, "Failed to create HTML report directory: %s", err)
+		return
+	}
+
+	// Several snapshots of one test get numbered pages. The pages are numbered again by every run,
+	// so that reruns overwrite them instead of piling up.
+	htmlReportMu.Lock()
+	if htmlReportCount[s.t] == 0 {
+		s.t.Cleanup(func() {
+			htmlReportMu.Lock()
+			delete(htmlReportCount, s.t)
+			htmlReportMu.Unlock()
+		})
+	}
+	htmlReportCount[s.t]++
+	n := htmlReportCount[s.t]
+	htmlReportMu.Unlock()
+
+	name := unsafeFileChars.ReplaceAllString(s.testName(), "_")
+	path := filepath.Join(dir, fmt.Sprintf("%s-%d.html", name, n))
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		s.logf(slog.LevelWarn, "Failed to write HTML report: %s", err)
+		return
+	}
+	s.logf(slog.LevelInfo, "Wrote HTML report to %s", path)
+}
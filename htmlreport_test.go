@@ -0,0 +1,62 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSideBySide(t *testing.T) {
+	rows := sideBySide("a\nb\nc", "a\nB\nc\nd")
+	want := []htmlRow{
+		{Want: "a", WantNum: "1", Got: "a", GotNum: "1"},
+		{Want: "b", WantNum: "2", WantClass: "delete", Got: "B", GotNum: "2", GotClass: "insert"},
+		{Want: "c", WantNum: "3", Got: "c", GotNum: "3"},
+		{Got: "d", GotNum: "4", GotClass: "insert"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("expected %d rows, got %+v", len(want), rows)
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("row %d: expected %+v, got %+v", i, want[i], rows[i])
+		}
+	}
+}
+
+func TestHTMLReport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "report")
+	t.Setenv("SNAP_HTML_REPORT", dir)
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "<b>want</b>").Diff("got")
+	snapNoUpdate(ft, "want").Diff("got")
+
+	for _, name := range []string{"TestHTMLReport-1.html", "TestHTMLReport-2.html"} {
+		path := filepath.Join(dir, name)
+		if !containsLog(ft, "snap: Wrote HTML report to "+path) {
+			t.Errorf("expected report path to be logged, got %q", ft.logs)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), `<td class="insert">got</td>`) {
+			t.Errorf("expected report to contain the got value, got:\n%s", b)
+		}
+	}
+
+	b, _ := os.ReadFile(filepath.Join(dir, "TestHTMLReport-1.html"))
+	if !strings.Contains(string(b), "&lt;b&gt;want&lt;/b&gt;") {
+		t.Errorf("expected snapshot to be escaped, got:\n%s", b)
+	}
+
+	// A rerun overwrites the pages.
+	ft = newFakeT(t)
+	snapNoUpdate(ft, "rerun").Diff("got")
+	b, _ = os.ReadFile(filepath.Join(dir, "TestHTMLReport-1.html"))
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 || !strings.Contains(string(b), "rerun") {
+		t.Errorf("expected the first page to be overwritten, got %d pages and:\n%s", len(entries), b)
+	}
+}
@@ -0,0 +1,96 @@
+package snap
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// lineShift records that updating the snapshot at line, as compiled in the test binary, moved the
+// following lines of its file by delta lines.
+type lineShift struct {
+	line  int
+	delta int
+}
+
+var (
+	inPlaceMu sync.Mutex
+	// fileLocks serializes the updates of each source file made right away, outside of [Run], so
+	// that parallel tests don't overwrite each other's updates.
+	fileLocks = make(map[string]*sync.Mutex)
+	// lineShifts holds the line shifts of the updates made right away, by source file. The lines of
+	// the Snap calls known to the test binary are those it was compiled from, so the following
+	// updates of a file look for their call at the shifted line.
+	lineShifts = make(map[string][]lineShift)
+
+	writesMu sync.Mutex
+	// writesDone is signaled when a write finishes, to start one waiting for a free slot.
+	writesDone = sync.NewCond(&writesMu)
+	// writes counts the files being written.
+	writes int
+)
+
+// defaultMaxWrites is the number of files written at the same time when SNAP_MAX_WRITES isn't set.
+const defaultMaxWrites = 8
+
+// lockFile locks the source file path for an update, and returns the function unlocking it.
+func lockFile(path string) (unlock func()) {
+	inPlaceMu.Lock()
+	mu, ok := fileLocks[path]
+	if !ok {
+		mu = new(sync.Mutex)
+		fileLocks[path] = mu
+	}
+	inPlaceMu.Unlock()
+	mu.Lock()
+	return mu.Unlock
+}
+
+// shiftedLine returns the line that line of the source file path, as compiled in the test binary,
+// moved to with the updates made to the file.
+func shiftedLine(path string, line int) int {
+	inPlaceMu.Lock()
+	defer inPlaceMu.Unlock()
+	shifted := line
+	for _, s := range lineShifts[path] {
+		if s.line < line {
+			shifted += s.delta
+		}
+	}
+	return shifted
+}
+
+// recordShift records that the update of the snapshot at line of the source file path, as compiled
+// in the test binary, changed the file from before to after.
+func recordShift(path string, line int, before []byte, after []byte) {
+	delta := bytes.Count(after, []byte("\n")) - bytes.Count(before, []byte("\n"))
+	if delta == 0 {
+		return
+	}
+	inPlaceMu.Lock()
+	defer inPlaceMu.Unlock()
+	lineShifts[path] = append(lineShifts[path], lineShift{line: line, delta: delta})
+}
+
+// acquireWrite waits until fewer than SNAP_MAX_WRITES files are being written by the test binary,
+// and returns the function to call when the write is done. This bounds the open files and the
+// load on network file systems of large updates with many parallel tests.
+func acquireWrite() (release func()) {
+	limit, err := strconv.Atoi(os.Getenv("SNAP_MAX_WRITES"))
+	if err != nil || limit < 1 {
+		limit = defaultMaxWrites
+	}
+	writesMu.Lock()
+	for writes >= limit {
+		writesDone.Wait()
+	}
+	writes++
+	writesMu.Unlock()
+	return func() {
+		writesMu.Lock()
+		writes--
+		writesMu.Unlock()
+		writesDone.Signal()
+	}
+}
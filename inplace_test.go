@@ -0,0 +1,72 @@
+package snap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInPlaceUpdates(t *testing.T) {
+	const n = 8
+	var src strings.Builder
+	src.WriteString("package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&src, "\tsnap.Snap(t, `old %d`).Diff(got)\n", i)
+	}
+	src.WriteString("}\n")
+	path := filepath.Join(t.TempDir(), "example_test.go")
+	if err := os.WriteFile(path, []byte(src.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each update turns a snapshot into a raw literal of several lines, moving the snapshots below
+	// it, while the others are updated concurrently.
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s := At(newFakeT(t), path, 6+i, fmt.Sprintf("old %d", i)).Update()
+			s.Diff(fmt.Sprintf("new %d\nsecond line", i))
+		}(i)
+	}
+	wg.Wait()
+
+	got := readFile(t, path)
+	for i := 0; i < n; i++ {
+		if want := fmt.Sprintf("snap.Snap(t, `new %d\nsecond line`).Diff(got)", i); !strings.Contains(got, want) {
+			t.Errorf("expected snapshot %d to be updated, got:\n%s", i, got)
+		}
+	}
+}
+
+func TestAcquireWrite(t *testing.T) {
+	t.Setenv("SNAP_MAX_WRITES", "2")
+	var mu sync.Mutex
+	active, peak := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release := acquireWrite()
+			defer release()
+			mu.Lock()
+			active++
+			peak = max(peak, active)
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			active--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if peak > 2 {
+		t.Errorf("expected at most 2 concurrent writes, got %d", peak)
+	}
+}
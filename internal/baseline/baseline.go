@@ -0,0 +1,121 @@
+// Package baseline reads and writes snapshot baseline files.
+//
+// A baseline file records mismatching snapshots as JSON Lines, one [Entry] per line, so that the
+// mismatches of two test runs (usually on two different branches) can be compared.
+package baseline
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Entry is a single mismatching snapshot.
+type Entry struct {
+	Package string `json:"package"`
+	Test    string `json:"test"`
+	// Index is the 1-based position of the snapshot among the snapshots compared in Test.
+	Index int    `json:"index"`
+	File  string `json:"file"` // Relative to the module root.
+	Line  int    `json:"line"`
+	Want  string `json:"want"`
+	Got   string `json:"got"`
+	Owner string `json:"owner,omitempty"`
+}
+
+// Key identifies the entry independently of source locations, which usually differ between
+// branches.
+func (e Entry) Key() string {
+	return fmt.Sprintf("%s %s#%d", e.Package, e.Test, e.Index)
+}
+
+// Append appends e to the baseline file at path, creating it if needed.
+//
+// The entry is written with a single write to a file opened with O_APPEND, so concurrent test
+// binaries can share a baseline file.
+func Append(path string, e Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read reads all entries of the baseline file at path. A missing file is an empty baseline.
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 64<<20)
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Change is a difference between two baselines for a single snapshot.
+// Old or New is nil when the snapshot only mismatched in one of them.
+type Change struct {
+	Key string
+	Old *Entry
+	New *Entry
+}
+
+// Compare returns the changes between the old and new baselines, sorted by key. Snapshots that
+// mismatched in both baselines with the same got value are not reported.
+func Compare(old []Entry, new []Entry) []Change {
+	byKey := make(map[string]*Change)
+	for i := range old {
+		e := &old[i]
+		byKey[e.Key()] = &Change{Key: e.Key(), Old: e}
+	}
+	for i := range new {
+		e := &new[i]
+		c, ok := byKey[e.Key()]
+		if !ok {
+			c = &Change{Key: e.Key()}
+			byKey[e.Key()] = c
+		}
+		c.New = e
+	}
+
+	var changes []Change
+	for _, c := range byKey {
+		if c.Old != nil && c.New != nil && c.Old.Got == c.New.Got {
+			continue
+		}
+		changes = append(changes, *c)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
@@ -0,0 +1,69 @@
+package baseline
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAppendRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.jsonl")
+
+	entries, err := Read(path)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected an empty baseline, got %v, %v", entries, err)
+	}
+
+	want := []Entry{
+		{Package: "example.com/a", Test: "TestA", Index: 1, File: "a_test.go", Line: 3, Want: "x", Got: "y"},
+		{Package: "example.com/a", Test: "TestA", Index: 2, File: "a_test.go", Line: 4, Want: "1\n2", Got: "1\n3"},
+	}
+	for _, e := range want {
+		if err := Append(path, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d entries, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	old := []Entry{
+		{Package: "p", Test: "TestSame", Index: 1, Got: "a"},
+		{Package: "p", Test: "TestChanged", Index: 1, Got: "a"},
+		{Package: "p", Test: "TestFixed", Index: 1, Got: "a"},
+	}
+	new := []Entry{
+		{Package: "p", Test: "TestSame", Index: 1, Got: "a", Line: 10},
+		{Package: "p", Test: "TestChanged", Index: 1, Got: "b"},
+		{Package: "p", Test: "TestBroken", Index: 1, Got: "a"},
+	}
+
+	changes := Compare(old, new)
+
+	wantKeys := []string{"p TestBroken#1", "p TestChanged#1", "p TestFixed#1"}
+	if len(changes) != len(wantKeys) {
+		t.Fatalf("expected %d changes, got %+v", len(wantKeys), changes)
+	}
+	for i, c := range changes {
+		if c.Key != wantKeys[i] {
+			t.Errorf("change %d: expected key %q, got %q", i, wantKeys[i], c.Key)
+		}
+	}
+	if changes[0].Old != nil || changes[0].New == nil {
+		t.Errorf("expected TestBroken to only be in the new baseline")
+	}
+	if changes[2].Old == nil || changes[2].New != nil {
+		t.Errorf("expected TestFixed to only be in the old baseline")
+	}
+}
@@ -0,0 +1,261 @@
+// Package changes reads, writes and applies the pending snapshot changes reviewed with
+// `snap review`.
+//
+// Tests run with SNAP_PENDING write a change for each mismatching snapshot to the .snap/pending
+// directory of the module, instead of updating it. A change is the edit of the file holding the
+// snapshot, computed by the test binary when it's recorded, so that applying it doesn't need to
+// run the tests again.
+package changes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/KasonBraley/snap/internal/diff"
+)
+
+// Change is a pending change of a snapshot.
+type Change struct {
+	Test string `json:"test"`
+	File string `json:"file"` // Path of the file to edit, relative to the module root.
+	Line int    `json:"line"` // Line of the snapshot.
+	Diff string `json:"diff"` // Diff of the snapshot, for review.
+
+	// The edit replaces the bytes Start to End of the file, whose content has the hash SHA256,
+	// with New.
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+	New    string `json:"new"`
+	SHA256 string `json:"sha256"`
+}
+
+// Dir returns the directory of the pending changes of the module at root.
+func Dir(root string) string {
+	return filepath.Join(root, ".snap", "pending")
+}
+
+// Hash returns the hash of data recorded in [Change].
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// name returns the file name of the pending change of the snapshot at file:line.
+func name(file string, line int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", filepath.ToSlash(file), line)))
+	return hex.EncodeToString(sum[:8]) + ".json"
+}
+
+// Write records c in the pending changes of the module at root, replacing the previous change of
+// the same snapshot.
+func Write(root string, c Change) error {
+	dir := Dir(root)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".change-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name(c.File, c.Line)))
+}
+
+// Remove removes the pending change of the snapshot at file:line, if any.
+func Remove(root string, file string, line int) error {
+	err := os.Remove(filepath.Join(Dir(root), name(file, line)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Read returns the pending changes of the module at root, sorted by file and line.
+func Read(root string) ([]Change, error) {
+	entries, err := os.ReadDir(Dir(root))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(Dir(root), e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var c Change
+		if err := json.Unmarshal(b, &c); err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		changes = append(changes, c)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].File != changes[j].File {
+			return changes[i].File < changes[j].File
+		}
+		return changes[i].Line < changes[j].Line
+	})
+	return changes, nil
+}
+
+// Apply applies changes to the files of the module at root and removes them from the pending
+// changes. The changes of a file must have been recorded against its current content, and must
+// not overlap; otherwise none of the changes of that file are applied, and an error names it.
+func Apply(root string, changes []Change) error {
+	byFile := make(map[string][]Change)
+	var files []string
+	for _, c := range changes {
+		if byFile[c.File] == nil {
+			files = append(files, c.File)
+		}
+		byFile[c.File] = append(byFile[c.File], c)
+	}
+
+	var errs []error
+	for _, file := range files {
+		if err := applyFile(root, file, byFile[file]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file, err))
+			continue
+		}
+		for _, c := range byFile[file] {
+			if err := Remove(root, c.File, c.Line); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyFile applies the changes of one file.
+func applyFile(root string, file string, changes []Change) error {
+	path := filepath.Join(root, filepath.FromSlash(file))
+	// Changes of files that didn't exist, like new golden files, have no hash.
+	perm := fs.FileMode(0o644)
+	var src []byte
+	hash := ""
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+		if src, err = os.ReadFile(path); err != nil {
+			return err
+		}
+		hash = Hash(src)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	// Apply the changes from the end of the file, so that the offsets of the others stay valid.
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Start > changes[j].Start })
+	end := len(src)
+	for _, c := range changes {
+		if c.SHA256 != hash {
+			return errors.New("the file changed since the change was recorded, rerun the tests")
+		}
+		if c.Start < 0 || c.Start > c.End || c.End > end {
+			return errors.New("the changes overlap, accept one of them and rerun the tests")
+		}
+		src = append(src[:c.Start:c.Start], append([]byte(c.New), src[c.End:]...)...)
+		end = c.Start
+	}
+	if hash == "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, src, perm)
+}
+
+// Hunks splits c into the changes of its hunks, the runs of lines its edit changes, so that they
+// can be accepted separately: applying some of them with [Apply] updates the snapshot in those
+// places only, and discards c. It returns c alone if its edit has a single hunk, or if its lines
+// can't be mixed, like those of compressed files or of a string literal changing its quotes.
+func Hunks(root string, c Change) ([]Change, error) {
+	if c.SHA256 == "" || strings.HasSuffix(c.File, ".gz") {
+		return []Change{c}, nil
+	}
+	src, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(c.File)))
+	if err != nil {
+		return nil, err
+	}
+	if Hash(src) != c.SHA256 || c.Start < 0 || c.Start > c.End || c.End > len(src) {
+		// Applying c reports it.
+		return []Change{c}, nil
+	}
+	old := string(src[c.Start:c.End])
+	if strings.HasSuffix(c.File, ".go") && (old == "" || c.New == "" || old[0] != c.New[0] || old[len(old)-1] != c.New[len(c.New)-1]) {
+		return []Change{c}, nil
+	}
+
+	a := strings.SplitAfter(old, "\n")
+	b := strings.SplitAfter(c.New, "\n")
+	// offsets[i] is the offset of the i-th line of a in src.
+	offsets := make([]int, len(a)+1)
+	offsets[0] = c.Start
+	for i, line := range a {
+		offsets[i+1] = offsets[i] + len(line)
+	}
+
+	var hunks []Change
+	edits := diff.Lines(a, b, nil)
+	line := 0 // Index of the next line of a.
+	for i := 0; i < len(edits); {
+		if edits[i].Op == diff.Equal {
+			line++
+			i++
+			continue
+		}
+		h := c
+		h.Start = offsets[line]
+		var sb, newText strings.Builder
+		// The hunk is shown with the lines around it, which don't all end with a newline.
+		show := func(prefix string, text string) {
+			sb.WriteString(prefix + strings.TrimSuffix(text, "\n") + "\n")
+		}
+		if line > 0 {
+			show(" ", a[line-1])
+		}
+		for ; i < len(edits) && edits[i].Op != diff.Equal; i++ {
+			if e := edits[i]; e.Op == diff.Delete {
+				show("-", a[e.A])
+				line++
+			} else {
+				show("+", b[e.B])
+				newText.WriteString(b[e.B])
+			}
+		}
+		if i < len(edits) {
+			show(" ", a[line])
+		}
+		h.End = offsets[line]
+		h.New = newText.String()
+		h.Diff = sb.String()
+		hunks = append(hunks, h)
+	}
+	if len(hunks) < 2 {
+		return []Change{c}, nil
+	}
+	return hunks, nil
+}
@@ -0,0 +1,124 @@
+package changes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	root := t.TempDir()
+	src := "a := \"one\"\nb := \"two\"\n"
+	if err := os.WriteFile(filepath.Join(root, "a_test.go"), []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	hash := Hash([]byte(src))
+	write := func(c Change) {
+		t.Helper()
+		if err := Write(root, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(Change{Test: "TestA", File: "a_test.go", Line: 1, Start: 6, End: 9, New: "uno", SHA256: hash})
+	write(Change{Test: "TestA", File: "a_test.go", Line: 2, Start: 17, End: 20, New: "dos", SHA256: hash})
+	write(Change{Test: "TestB", File: "testdata/b.golden", Line: 1, New: "created"})
+
+	changes, err := Read(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 || changes[0].Line != 1 || changes[2].File != "testdata/b.golden" {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+	if err := Apply(root, changes); err != nil {
+		t.Fatal(err)
+	}
+	for file, want := range map[string]string{"a_test.go": "a := \"uno\"\nb := \"dos\"\n", "testdata/b.golden": "created"} {
+		if b, _ := os.ReadFile(filepath.Join(root, file)); string(b) != want {
+			t.Errorf("expected %s to contain %q, got %q", file, want, b)
+		}
+	}
+	if info, _ := os.Stat(filepath.Join(root, "a_test.go")); info.Mode().Perm() != 0o600 {
+		t.Errorf("expected the permissions to be kept, got %v", info.Mode())
+	}
+	if changes, _ := Read(root); len(changes) != 0 {
+		t.Errorf("expected the applied changes to be removed, got %+v", changes)
+	}
+}
+
+func TestApplyStale(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a_test.go")
+	if err := os.WriteFile(path, []byte("edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stale := Change{File: "a_test.go", Line: 1, Start: 0, End: 3, New: "new", SHA256: Hash([]byte("old"))}
+	if err := Write(root, stale); err != nil {
+		t.Fatal(err)
+	}
+	err := Apply(root, []Change{stale})
+	if err == nil || !strings.Contains(err.Error(), "a_test.go: the file changed since the change was recorded") {
+		t.Errorf("expected a stale change error, got %v", err)
+	}
+
+	hash := Hash([]byte("edited"))
+	overlapping := []Change{
+		{File: "a_test.go", Line: 1, Start: 0, End: 4, New: "x", SHA256: hash},
+		{File: "a_test.go", Line: 2, Start: 2, End: 6, New: "y", SHA256: hash},
+	}
+	if err := Apply(root, overlapping); err == nil || !strings.Contains(err.Error(), "the changes overlap") {
+		t.Errorf("expected an overlap error, got %v", err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "edited" {
+		t.Errorf("expected the file not to change, got %q", b)
+	}
+	if changes, _ := Read(root); len(changes) != 1 {
+		t.Errorf("expected the stale change to stay pending, got %+v", changes)
+	}
+}
+
+func TestHunks(t *testing.T) {
+	root := t.TempDir()
+	src := "func TestA(t *testing.T) {\n\tsnap.Snap(t, `one\ntwo\nthree\nfour`)\n}\n"
+	if err := os.WriteFile(filepath.Join(root, "a_test.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	start := strings.Index(src, "`")
+	end := strings.LastIndex(src, "`") + 1
+	c := Change{Test: "TestA", File: "a_test.go", Line: 2, Start: start, End: end, New: "`ONE\ntwo\nthree\nFOUR\nfive`", SHA256: Hash([]byte(src))}
+	if err := Write(root, c); err != nil {
+		t.Fatal(err)
+	}
+
+	hunks, err := Hunks(root, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %+v", hunks)
+	}
+	if want := "-`one\n+`ONE\n two\n"; hunks[0].Diff != want {
+		t.Errorf("unexpected diff of the first hunk, want:\n%s\ngot:\n%s", want, hunks[0].Diff)
+	}
+	if want := " three\n-four`\n+FOUR\n+five`\n"; hunks[1].Diff != want {
+		t.Errorf("unexpected diff of the second hunk, want:\n%s\ngot:\n%s", want, hunks[1].Diff)
+	}
+	if err := Apply(root, hunks[1:]); err != nil {
+		t.Fatal(err)
+	}
+	want := "func TestA(t *testing.T) {\n\tsnap.Snap(t, `one\ntwo\nthree\nFOUR\nfive`)\n}\n"
+	if b, _ := os.ReadFile(filepath.Join(root, "a_test.go")); string(b) != want {
+		t.Errorf("expected only the second hunk to be applied, got:\n%s", b)
+	}
+	if changes, _ := Read(root); len(changes) != 0 {
+		t.Errorf("expected the change to be removed, got %+v", changes)
+	}
+
+	// Literals changing quotes aren't split.
+	c.SHA256 = Hash([]byte(want))
+	c.New = "\"ONE\\ntwo\\nthree\\nFOUR\\nsix\""
+	if hunks, err := Hunks(root, c); err != nil || len(hunks) != 1 || hunks[0] != c {
+		t.Errorf("expected the change not to be split, got %+v, %v", hunks, err)
+	}
+}
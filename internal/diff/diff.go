@@ -0,0 +1,284 @@
+// Package diff computes line based diffs using Myers' algorithm.
+package diff
+
+// Op is the kind of an [Edit].
+type Op int
+
+const (
+	Equal  Op = iota // The line is in both a and b.
+	Delete           // The line is only in a.
+	Insert           // The line is only in b.
+)
+
+// Edit is a single step of the edit script turning a into b.
+type Edit struct {
+	Op Op
+	A  int // Index of the line in a, -1 for Insert.
+	B  int // Index of the line in b, -1 for Delete.
+}
+
+// maxTraceSteps is the most steps of the search of the shortest edit script kept in a trace to
+// walk it backwards, which needs memory quadratic in the number of steps. Longer scripts are split
+// at their middle snake, with the linear space variant of the algorithm, until their parts are
+// short enough.
+const maxTraceSteps = 256
+
+// Lines returns the shortest edit script turning a into b. Two lines are equal when equal
+// reports true; a nil equal compares lines with ==. In a run of changed lines, the deleted lines
+// come first.
+func Lines(a []string, b []string, equal func(a string, b string) bool) []Edit {
+	if equal == nil {
+		equal = func(a string, b string) bool { return a == b }
+	}
+	d := &differ{a: a, b: b, equal: equal, edits: make([]Edit, 0, max(len(a), len(b)))}
+	d.compare(0, len(a), 0, len(b))
+	return deletesFirst(d.edits)
+}
+
+// differ holds the state of [Lines]: the inputs, the furthest reaching paths of the searches by
+// diagonal, reused by all the parts of the inputs, and the edits found so far.
+type differ struct {
+	a, b   []string
+	equal  func(a string, b string) bool
+	vf, vb []int
+	edits  []Edit
+	// v, buf and trace are the state of [differ.traced], which holds the rows of trace in buf.
+	v, buf []int
+	trace  [][]int
+}
+
+// compare appends the edits turning a[aLo:aHi] into b[bLo:bHi].
+func (d *differ) compare(aLo int, aHi int, bLo int, bHi int) {
+	if d.traced(aLo, aHi, bLo, bHi) {
+		return
+	}
+
+	for aLo < aHi && bLo < bHi && d.equal(d.a[aLo], d.b[bLo]) {
+		d.edits = append(d.edits, Edit{Op: Equal, A: aLo, B: bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi && bLo < bHi && d.equal(d.a[aHi-1], d.b[bHi-1]) {
+		aHi--
+		bHi--
+		suffix++
+	}
+	switch {
+	case aLo == aHi:
+		for ; bLo < bHi; bLo++ {
+			d.edits = append(d.edits, Edit{Op: Insert, A: -1, B: bLo})
+		}
+	case bLo == bHi:
+		for ; aLo < aHi; aLo++ {
+			d.edits = append(d.edits, Edit{Op: Delete, A: aLo, B: -1})
+		}
+	default:
+		// The parts left have different first and last lines, so their shortest edit script
+		// has at least two edits, and the parts of each side of the split fewer.
+		x, y := d.split(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x, bLo, y)
+		d.compare(x, aHi, y, bHi)
+	}
+	for i := 0; i < suffix; i++ {
+		d.edits = append(d.edits, Edit{Op: Equal, A: aHi + i, B: bHi + i})
+	}
+}
+
+// traced appends the edits turning a[aLo:aHi] into b[bLo:bHi] and returns true if their shortest
+// edit script has at most maxTraceSteps edits.
+func (d *differ) traced(aLo int, aHi int, bLo int, bHi int) bool {
+	n, m := aHi-aLo, bHi-bLo
+	if n-m > maxTraceSteps || m-n > maxTraceSteps {
+		return false
+	}
+	steps := min(n+m, maxTraceSteps)
+	offset := steps + 1
+	if len(d.v) < 2*offset+1 {
+		d.v = make([]int, 2*offset+1)
+		// The rows of the trace have 2s+3 diagonals, for s from 0 to steps.
+		d.buf = make([]int, 0, (steps+1)*(steps+3))
+	}
+	v := d.v[:2*offset+1]
+	clear(v)
+	// trace[s] holds the diagonals -s-1 to s+1 of v as it was before step s, to walk the
+	// shortest path backwards.
+	buf, trace := d.buf[:0], d.trace[:0]
+	defer func() { d.trace = trace[:0] }()
+
+	for s := 0; s <= steps; s++ {
+		row := len(buf)
+		buf = append(buf, v[offset-s-1:offset+s+2]...)
+		trace = append(trace, buf[row:])
+		for k := -s; k <= s; k += 2 {
+			var x int
+			if k == -s || (k != s && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Move down, inserting b[y].
+			} else {
+				x = v[offset+k-1] + 1 // Move right, deleting a[x].
+			}
+			y := x - k
+			for x < n && y < m && d.equal(d.a[aLo+x], d.b[bLo+y]) {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				d.backtrack(trace, aLo, bLo, n, m)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// backtrack appends the edits of the shortest path to (n, m) found by [differ.traced].
+func (d *differ) backtrack(trace [][]int, aLo int, bLo int, n int, m int) {
+	start := len(d.edits)
+	x, y := n, m
+	for s := len(trace) - 1; s > 0; s-- {
+		v := trace[s]
+		at := func(k int) int { return v[k+s+1] }
+		k := x - y
+
+		var prevK int
+		if k == -s || (k != s && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			d.edits = append(d.edits, Edit{Op: Equal, A: aLo + x, B: bLo + y})
+		}
+		if x == prevX {
+			y--
+			d.edits = append(d.edits, Edit{Op: Insert, A: -1, B: bLo + y})
+		} else {
+			x--
+			d.edits = append(d.edits, Edit{Op: Delete, A: aLo + x, B: -1})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		d.edits = append(d.edits, Edit{Op: Equal, A: aLo + x, B: bLo + y})
+	}
+
+	edits := d.edits[start:]
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+}
+
+// split returns a point (x, y) on the shortest path turning a[aLo:aHi] into b[bLo:bHi], other
+// than its ends, found by searching the path from both ends at once until they overlap, in the
+// middle of the path. The first and last lines of the parts must differ.
+func (d *differ) split(aLo int, aHi int, bLo int, bHi int) (x int, y int) {
+	n, m := aHi-aLo, bHi-bLo
+	maxSteps := (n + m + 1) / 2
+	offset := maxSteps + 1
+	size := 2*offset + 1
+	if len(d.vf) < size {
+		d.vf, d.vb = make([]int, size), make([]int, size)
+	}
+	// vf holds the furthest x reached from the start on each diagonal k = x-y, and vb the
+	// furthest distance from the end reached on each diagonal of the reversed parts, the diagonal
+	// delta-k, or -1 if they weren't reached.
+	vf, vb := d.vf[:size], d.vb[:size]
+	for i := range vf {
+		vf[i], vb[i] = -1, -1
+	}
+	vf[offset+1], vb[offset+1] = 0, 0
+	delta := n - m
+	odd := delta%2 != 0
+	// The diagonals ending outside of the graph are trimmed from the searches.
+	var fStart, fEnd, rStart, rEnd int
+
+	for s := 0; s <= maxSteps; s++ {
+		for k := -s + fStart; k <= s-fEnd; k += 2 {
+			var x int
+			if k == -s || (k != s && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.equal(d.a[aLo+x], d.b[bLo+y]) {
+				x++
+				y++
+			}
+			vf[offset+k] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				if r := offset + delta - k; r >= 0 && r < size && vb[r] != -1 && x >= n-vb[r] {
+					return aLo + x, bLo + y
+				}
+			}
+		}
+
+		for k := -s + rStart; k <= s-rEnd; k += 2 {
+			var x int
+			if k == -s || (k != s && vb[offset+k-1] < vb[offset+k+1]) {
+				x = vb[offset+k+1]
+			} else {
+				x = vb[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.equal(d.a[aHi-1-x], d.b[bHi-1-y]) {
+				x++
+				y++
+			}
+			vb[offset+k] = x
+			switch {
+			case x > n:
+				rEnd += 2
+			case y > m:
+				rStart += 2
+			case !odd:
+				if f := offset + delta - k; f >= 0 && f < size && vf[f] != -1 && vf[f] >= n-x {
+					fx := vf[f]
+					return aLo + fx, bLo + fx - (delta - k)
+				}
+			}
+		}
+	}
+	panic("unreachable")
+}
+
+// deletesFirst moves the deleted lines of each run of changed lines of edits before the inserted
+// ones, as diffs usually show them.
+func deletesFirst(edits []Edit) []Edit {
+	var inserts []Edit
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Equal {
+			i++
+			continue
+		}
+		j := i
+		inserts = inserts[:0]
+		for ; j < len(edits) && edits[j].Op != Equal; j++ {
+			if edits[j].Op == Insert {
+				inserts = append(inserts, edits[j])
+			}
+		}
+		w := i
+		for _, e := range edits[i:j] {
+			if e.Op == Delete {
+				edits[w] = e
+				w++
+			}
+		}
+		copy(edits[w:j], inserts)
+		i = j
+	}
+	return edits
+}
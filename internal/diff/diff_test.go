@@ -0,0 +1,135 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// render formats edits in the style of a unified diff body.
+func render(a []string, b []string, edits []Edit) string {
+	var sb strings.Builder
+	for _, e := range edits {
+		switch e.Op {
+		case Equal:
+			sb.WriteString(" " + a[e.A] + "\n")
+		case Delete:
+			sb.WriteString("-" + a[e.A] + "\n")
+		case Insert:
+			sb.WriteString("+" + b[e.B] + "\n")
+		}
+	}
+	return sb.String()
+}
+
+func TestLines(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want string
+	}{
+		{a: "", b: "", want: ""},
+		{a: "a", b: "a", want: " a\n"},
+		{a: "a", b: "", want: "-a\n"},
+		{a: "", b: "a", want: "+a\n"},
+		{a: "a b c", b: "a x c", want: " a\n-b\n+x\n c\n"},
+		{a: "a b c a b b a", b: "c b a b a c", want: "-a\n-b\n c\n+b\n a\n b\n-b\n a\n+c\n"},
+		{a: "1 2 3 4 5", b: "1 2 4 5 6", want: " 1\n 2\n-3\n 4\n 5\n+6\n"},
+	}
+
+	for _, tc := range cases {
+		a, b := strings.Fields(tc.a), strings.Fields(tc.b)
+		edits := Lines(a, b, nil)
+		if got := render(a, b, edits); got != tc.want {
+			t.Errorf("Lines(%q, %q):\nwant:\n%s\ngot:\n%s", tc.a, tc.b, tc.want, got)
+		}
+	}
+}
+
+func TestLinesCustomEqual(t *testing.T) {
+	a := []string{"A", "b", "C"}
+	b := []string{"a", "B", "x"}
+	edits := Lines(a, b, strings.EqualFold)
+	if got, want := render(a, b, edits), " A\n b\n-C\n+x\n"; got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+// lcs returns the length of the longest common subsequence of a and b.
+func lcs(a []string, b []string) int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(cur[j], prev[j+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func TestLinesShortest(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	words := func(n int, alphabet int) []string {
+		w := make([]string, n)
+		for i := range w {
+			w[i] = fmt.Sprint(r.Intn(alphabet))
+		}
+		return w
+	}
+	for i := 0; i < 200; i++ {
+		// Long enough for scripts of more than maxTraceSteps edits.
+		a, b := words(r.Intn(400), 1+r.Intn(20)), words(r.Intn(400), 1+r.Intn(20))
+		edits := Lines(a, b, nil)
+
+		var x, y, equal int
+		for _, e := range edits {
+			switch e.Op {
+			case Equal:
+				if e.A != x || e.B != y || a[x] != b[y] {
+					t.Fatalf("Lines(%q, %q): invalid edit %+v at (%d, %d)", a, b, e, x, y)
+				}
+				x, y, equal = x+1, y+1, equal+1
+			case Delete:
+				if e.A != x {
+					t.Fatalf("Lines(%q, %q): invalid edit %+v at (%d, %d)", a, b, e, x, y)
+				}
+				x++
+			case Insert:
+				if e.B != y {
+					t.Fatalf("Lines(%q, %q): invalid edit %+v at (%d, %d)", a, b, e, x, y)
+				}
+				y++
+			}
+		}
+		if x != len(a) || y != len(b) {
+			t.Fatalf("Lines(%q, %q): edits end at (%d, %d)", a, b, x, y)
+		}
+		if want := lcs(a, b); equal != want {
+			t.Fatalf("Lines(%q, %q): %d equal lines, want %d", a, b, equal, want)
+		}
+	}
+}
+
+func TestLinesLinearSpace(t *testing.T) {
+	a, b := make([]string, 4000), make([]string, 4000)
+	for i := range a {
+		a[i], b[i] = fmt.Sprint("a", i), fmt.Sprint("b", i)
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	edits := Lines(a, b, nil)
+	runtime.ReadMemStats(&after)
+
+	if len(edits) != 8000 {
+		t.Errorf("got %d edits, want 8000", len(edits))
+	}
+	// The trace of the whole search would take hundreds of megabytes.
+	if n := after.TotalAlloc - before.TotalAlloc; n > 4<<20 {
+		t.Errorf("Lines allocated %d bytes, want at most 4 MiB", n)
+	}
+}
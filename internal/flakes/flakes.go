@@ -0,0 +1,164 @@
+// Package flakes reads and writes the history of snapshot results recorded with SNAP_FLAKES.
+//
+// Each comparison of a snapshot appends its result to the history file of its module, with the
+// commit it ran at. A snapshot that both matched and mismatched at the same commit is flaky: its
+// value changes between runs of the same code.
+package flakes
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/KasonBraley/snap/internal/gocache"
+)
+
+// Result is the result of comparing a snapshot with a value.
+type Result struct {
+	Snapshot string `json:"snapshot"` // Position of the Snap call, relative to the module root.
+	Test     string `json:"test"`
+	Commit   string `json:"commit"` // The commit the test ran at.
+	Pass     bool   `json:"pass"`
+}
+
+// Keep is the number of results kept for each snapshot when the history is compacted.
+const Keep = 20
+
+// File returns the path of the history file of the module at root, in the snap-flakes directory
+// of the build cache.
+func File(root string) (string, error) {
+	dir, err := gocache.Dir("snap-flakes")
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".jsonl"), nil
+}
+
+// Append appends r to the history file path. Results are written with a single write, so that
+// the test binaries of a run can append to the same file.
+func Append(path string, r Result) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read returns the results of the history file path, oldest first. A missing file has no results.
+func Read(path string) ([]Result, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var results []Result
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var r Result
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		results = append(results, r)
+	}
+	return results, scanner.Err()
+}
+
+// Compact rewrites the history file path with the last [Keep] results of each snapshot, of the
+// results read from it.
+func Compact(path string, results []Result) error {
+	counts := make(map[string]int)
+	var kept []Result
+	for i := len(results) - 1; i >= 0; i-- {
+		r := results[i]
+		if counts[r.Snapshot] < Keep {
+			counts[r.Snapshot]++
+			kept = append(kept, r)
+		}
+	}
+	var data []byte
+	for i := len(kept) - 1; i >= 0; i-- {
+		b, err := json.Marshal(kept[i])
+		if err != nil {
+			return err
+		}
+		data = append(append(data, b...), '\n')
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".flakes-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Flake is a snapshot that both matched and mismatched at a commit.
+type Flake struct {
+	Snapshot string
+	Tests    []string // The tests comparing the snapshot.
+	Commit   string
+	Passes   int
+	Failures int
+}
+
+// Find returns the flaky snapshots of results, by snapshot and commit.
+func Find(results []Result) []Flake {
+	type key struct{ snapshot, commit string }
+	byKey := make(map[key]*Flake)
+	var keys []key
+	for _, r := range results {
+		k := key{r.Snapshot, r.Commit}
+		f, ok := byKey[k]
+		if !ok {
+			f = &Flake{Snapshot: r.Snapshot, Commit: r.Commit}
+			byKey[k] = f
+			keys = append(keys, k)
+		}
+		if r.Pass {
+			f.Passes++
+		} else {
+			f.Failures++
+		}
+		if i := sort.SearchStrings(f.Tests, r.Test); i == len(f.Tests) || f.Tests[i] != r.Test {
+			f.Tests = append(f.Tests[:i], append([]string{r.Test}, f.Tests[i:]...)...)
+		}
+	}
+
+	var flakes []Flake
+	for _, k := range keys {
+		if f := byKey[k]; f.Passes > 0 && f.Failures > 0 {
+			flakes = append(flakes, *f)
+		}
+	}
+	sort.SliceStable(flakes, func(i, j int) bool { return flakes[i].Snapshot < flakes[j].Snapshot })
+	return flakes
+}
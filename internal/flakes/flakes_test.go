@@ -0,0 +1,53 @@
+package flakes
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	results := []Result{
+		{Snapshot: "a_test.go:4", Test: "TestA", Commit: "c1", Pass: true},
+		{Snapshot: "a_test.go:4", Test: "TestA/sub", Commit: "c1", Pass: false},
+		{Snapshot: "a_test.go:4", Test: "TestA", Commit: "c1", Pass: true},
+		// Changing at a new commit isn't flaky.
+		{Snapshot: "b_test.go:9", Test: "TestB", Commit: "c1", Pass: true},
+		{Snapshot: "b_test.go:9", Test: "TestB", Commit: "c2", Pass: false},
+	}
+	want := []Flake{{Snapshot: "a_test.go:4", Tests: []string{"TestA", "TestA/sub"}, Commit: "c1", Passes: 2, Failures: 1}}
+	if got := Find(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("Find() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	var results []Result
+	for i := 0; i < Keep+5; i++ {
+		r := Result{Snapshot: "a_test.go:4", Test: "TestA", Commit: "c1", Pass: i%2 == 0}
+		if err := Append(path, r); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, r)
+	}
+	if err := Append(path, Result{Snapshot: "b_test.go:9", Commit: "c1", Pass: true}); err != nil {
+		t.Fatal(err)
+	}
+	results = append(results, Result{Snapshot: "b_test.go:9", Commit: "c1", Pass: true})
+
+	read, err := Read(path)
+	if err != nil || !reflect.DeepEqual(read, results) {
+		t.Fatalf("Read() = %v, %v, want the appended results", read, err)
+	}
+	if err := Compact(path, read); err != nil {
+		t.Fatal(err)
+	}
+	compacted, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := results[5:]; !reflect.DeepEqual(compacted, want) {
+		t.Errorf("expected the last %d results of each snapshot to be kept, got %v", Keep, compacted)
+	}
+}
@@ -0,0 +1,21 @@
+// Package gocache locates the directories snap keeps in the build cache.
+package gocache
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Dir returns the directory name in the build cache of the go command: GOCACHE, or its default
+// location when GOCACHE isn't set or caching is off.
+func Dir(name string) (string, error) {
+	cache := os.Getenv("GOCACHE")
+	if cache == "" || cache == "off" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		cache = filepath.Join(dir, "go-build")
+	}
+	return filepath.Join(cache, name), nil
+}
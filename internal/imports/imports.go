@@ -0,0 +1,171 @@
+// Package imports adds and removes the imports of Go source files for the rewrites of snapshots,
+// editing the source in place so that everything else in it is preserved byte-for-byte.
+package imports
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Name returns the name under which f imports the package path: its explicit name, or the last
+// element of path, ignoring a major version suffix. ok is false if f doesn't import path, or only
+// imports it for its side effects. The name is "." for dot imports.
+func Name(f *ast.File, importPath string) (name string, ok bool) {
+	for _, spec := range f.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p != importPath {
+			continue
+		}
+		if spec.Name == nil {
+			return defaultName(importPath), true
+		}
+		if spec.Name.Name != "_" {
+			return spec.Name.Name, true
+		}
+	}
+	return "", false
+}
+
+// defaultName guesses the package name of importPath from its last element.
+func defaultName(importPath string) string {
+	base := path.Base(importPath)
+	if len(base) > 1 && base[0] == 'v' && strings.Trim(base[1:], "0123456789") == "" {
+		base = path.Base(path.Dir(importPath))
+	}
+	return base
+}
+
+// Add makes src import the package path, and returns the name under which it's imported. The
+// import is added at its sorted position in the first import declaration, or in a new declaration
+// after the package clause.
+func Add(src []byte, importPath string) ([]byte, string, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return nil, "", err
+	}
+	if name, ok := Name(f, importPath); ok {
+		return src, name, nil
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	quoted := strconv.Quote(importPath)
+
+	var decl *ast.GenDecl
+	for _, d := range f.Decls {
+		if d, ok := d.(*ast.GenDecl); ok && d.Tok == token.IMPORT {
+			decl = d
+			break
+		}
+	}
+	switch {
+	case decl == nil:
+		at := lineEnd(src, offset(f.Name.End()))
+		src = insert(src, at, "\nimport "+quoted+"\n")
+	case !decl.Lparen.IsValid():
+		// Turn the single import into a parenthesized declaration.
+		spec := decl.Specs[0].(*ast.ImportSpec)
+		specs := []string{string(src[offset(spec.Pos()):offset(spec.End())]), quoted}
+		if quoted < spec.Path.Value {
+			specs[0], specs[1] = specs[1], specs[0]
+		}
+		text := "import (\n\t" + specs[0] + "\n\t" + specs[1] + "\n)"
+		src = append(src[:offset(decl.Pos()):offset(decl.Pos())], append([]byte(text), src[offset(decl.End()):]...)...)
+	default:
+		// Insert before the first import of the first group sorting after path.
+		at := lineStart(src, offset(decl.Rparen))
+		for i, s := range decl.Specs {
+			spec := s.(*ast.ImportSpec)
+			if i > 0 && fset.Position(spec.Pos()).Line > fset.Position(decl.Specs[i-1].End()).Line+1 {
+				// A blank line ends the first group.
+				at = lineEnd(src, offset(decl.Specs[i-1].End()))
+				break
+			}
+			if spec.Path.Value > quoted {
+				at = lineStart(src, offset(spec.Pos()))
+				break
+			}
+		}
+		src = insert(src, at, "\t"+quoted+"\n")
+	}
+	return src, defaultName(importPath), nil
+}
+
+// RemoveUnused removes the import of the package path from src if src doesn't use it anymore, like
+// after a rewrite replaced the only call to it.
+func RemoveUnused(src []byte, importPath string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	name, ok := Name(f, importPath)
+	if !ok || name == "." || used(f, name) {
+		return src, nil
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	for _, d := range f.Decls {
+		decl, ok := d.(*ast.GenDecl)
+		if !ok || decl.Tok != token.IMPORT {
+			continue
+		}
+		for _, s := range decl.Specs {
+			spec := s.(*ast.ImportSpec)
+			if p, _ := strconv.Unquote(spec.Path.Value); p != importPath || spec.Name != nil && spec.Name.Name == "_" {
+				continue
+			}
+			start, end := offset(spec.Pos()), offset(spec.End())
+			if !decl.Lparen.IsValid() || len(decl.Specs) == 1 {
+				start, end = offset(decl.Pos()), offset(decl.End())
+			}
+			if spec.Comment != nil && !decl.Lparen.IsValid() {
+				end = offset(spec.Comment.End())
+			}
+			start, end = lineStart(src, start), lineEnd(src, end)
+			if start == offset(decl.Pos()) && end < len(src) && src[end] == '\n' {
+				end++ // Don't leave two blank lines where the declaration was.
+			}
+			return append(src[:start:start], src[end:]...), nil
+		}
+	}
+	return src, nil
+}
+
+// used reports whether the package imported as name is referenced in f.
+func used(f *ast.File, name string) bool {
+	found := false
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Name == name {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// lineStart returns the offset of the start of the line containing offset.
+func lineStart(src []byte, offset int) int {
+	return bytes.LastIndexByte(src[:offset], '\n') + 1
+}
+
+// lineEnd returns the offset after the end of the line containing offset, including its newline.
+func lineEnd(src []byte, offset int) int {
+	if i := bytes.IndexByte(src[offset:], '\n'); i >= 0 {
+		return offset + i + 1
+	}
+	return len(src)
+}
+
+// insert inserts text into src at offset.
+func insert(src []byte, offset int, text string) []byte {
+	out := make([]byte, 0, len(src)+len(text))
+	out = append(out, src[:offset]...)
+	out = append(out, text...)
+	return append(out, src[offset:]...)
+}
@@ -0,0 +1,109 @@
+package imports
+
+import (
+	"testing"
+)
+
+func TestAdd(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		path string
+		want string
+		id   string
+	}{
+		{
+			name: "no imports",
+			src:  "package example\n\nfunc f() {}\n",
+			path: "fmt",
+			want: "package example\n\nimport \"fmt\"\n\nfunc f() {}\n",
+			id:   "fmt",
+		},
+		{
+			name: "single import",
+			src:  "package example\n\nimport \"testing\"\n",
+			path: "fmt",
+			want: "package example\n\nimport (\n\t\"fmt\"\n\t\"testing\"\n)\n",
+			id:   "fmt",
+		},
+		{
+			name: "sorted into the first group",
+			src:  "package example\n\nimport (\n\t\"fmt\"\n\t\"testing\"\n\n\t\"github.com/KasonBraley/snap\"\n)\n",
+			path: "os",
+			want: "package example\n\nimport (\n\t\"fmt\"\n\t\"os\"\n\t\"testing\"\n\n\t\"github.com/KasonBraley/snap\"\n)\n",
+			id:   "os",
+		},
+		{
+			name: "end of the first group",
+			src:  "package example\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/KasonBraley/snap\"\n)\n",
+			path: "testing",
+			want: "package example\n\nimport (\n\t\"fmt\"\n\t\"testing\"\n\n\t\"github.com/KasonBraley/snap\"\n)\n",
+			id:   "testing",
+		},
+		{
+			name: "already imported with a name",
+			src:  "package example\n\nimport s \"github.com/KasonBraley/snap\"\n",
+			path: "github.com/KasonBraley/snap",
+			want: "package example\n\nimport s \"github.com/KasonBraley/snap\"\n",
+			id:   "s",
+		},
+		{
+			name: "major version",
+			src:  "package example\n",
+			path: "example.com/mod/v2",
+			want: "package example\n\nimport \"example.com/mod/v2\"\n",
+			id:   "mod",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, id, err := Add([]byte(tc.src), tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want || id != tc.id {
+				t.Errorf("expected %q imported as %s, got %q imported as %s", tc.want, tc.id, got, id)
+			}
+		})
+	}
+}
+
+func TestRemoveUnused(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "unused in a block",
+			src:  "package example\n\nimport (\n\t\"fmt\" // For Sprintf.\n\t\"os\"\n)\n\nvar _ = os.Args\n",
+			want: "package example\n\nimport (\n\t\"os\"\n)\n\nvar _ = os.Args\n",
+		},
+		{
+			name: "unused single import",
+			src:  "package example\n\nimport \"fmt\"\n\nfunc f() {}\n",
+			want: "package example\n\nfunc f() {}\n",
+		},
+		{
+			name: "used",
+			src:  "package example\n\nimport \"fmt\"\n\nvar _ = fmt.Sprint()\n",
+			want: "package example\n\nimport \"fmt\"\n\nvar _ = fmt.Sprint()\n",
+		},
+		{
+			name: "blank import",
+			src:  "package example\n\nimport _ \"fmt\"\n",
+			want: "package example\n\nimport _ \"fmt\"\n",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := RemoveUnused([]byte(tc.src), "fmt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+// Package rewriter gives the snaptest package access to the source rewriter of the snap package,
+// which registers it, without exporting it from snap.
+package rewriter
+
+// Source returns src, the Go source file filename, with the snapshot of the Snap call at line
+// updated from the text old to the value got, as SNAP_UPDATE=1 would write it. It's set by the
+// snap package.
+var Source func(filename string, src []byte, line int, old string, got string) ([]byte, error)
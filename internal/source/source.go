@@ -0,0 +1,195 @@
+// Package source finds the snapshots in Go source files.
+package source
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"strconv"
+)
+
+// ImportPath is the import path of the snap package.
+const ImportPath = "github.com/KasonBraley/snap"
+
+// constructors are the functions of the snap package that create a snapshot from a literal.
+var constructors = map[string]bool{"Snap": true, "Template": true, "Phases": true}
+
+// Matcher recognizes the calls to snap.Snap, snap.Template and snap.Phases in a file.
+type Matcher struct {
+	// pkgs holds the names the snap package is imported as, like snap or an alias.
+	pkgs map[string]bool
+	// funcs holds the names that refer to the Snap function itself: identifiers assigned from a
+	// Snap selector, like `check := snap.Snap`, and Snap when the package is dot-imported.
+	funcs map[string]bool
+}
+
+// NewMatcher returns a Matcher for the calls in f.
+func NewMatcher(f *ast.File) Matcher {
+	m := Matcher{pkgs: make(map[string]bool), funcs: make(map[string]bool)}
+	for _, imp := range f.Imports {
+		if importPath, err := strconv.Unquote(imp.Path.Value); err != nil || importPath != ImportPath {
+			continue
+		}
+		switch {
+		case imp.Name == nil:
+			m.pkgs[path.Base(ImportPath)] = true
+		case imp.Name.Name == ".":
+			for name := range constructors {
+				m.funcs[name] = true
+			}
+		case imp.Name.Name != "_":
+			m.pkgs[imp.Name.Name] = true
+		}
+	}
+
+	addFuncs := func(names []*ast.Ident, values []ast.Expr) {
+		if len(names) != len(values) {
+			return
+		}
+		for i, v := range values {
+			if m.isSnapSelector(v) {
+				m.funcs[names[i].Name] = true
+			}
+		}
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			addFuncs(n.Names, n.Values)
+		case *ast.AssignStmt:
+			var names []*ast.Ident
+			for _, lhs := range n.Lhs {
+				ident, ok := lhs.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				names = append(names, ident)
+			}
+			addFuncs(names, n.Rhs)
+		}
+		return true
+	})
+	return m
+}
+
+// isSnapSelector reports whether expr is snap.Snap, snap.Template or snap.Snap[T], with one of the
+// names the snap package is imported as.
+func (m Matcher) isSnapSelector(expr ast.Expr) bool {
+	// Unwrap instantiations of generic functions.
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		expr = e.X
+	case *ast.IndexListExpr:
+		expr = e.X
+	}
+
+	selExpr, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := selExpr.X.(*ast.Ident)
+	return ok && m.pkgs[pkg.Name] && constructors[selExpr.Sel.Name]
+}
+
+// IsSnapCall reports whether call looks like snap.Snap(t, "..."), called directly or through a
+// function value.
+func (m Matcher) IsSnapCall(call *ast.CallExpr) bool {
+	if len(call.Args) < 2 {
+		return false
+	}
+	if ident, ok := call.Fun.(*ast.Ident); ok {
+		return m.funcs[ident.Name]
+	}
+	return m.isSnapSelector(call.Fun)
+}
+
+// WrapperArg returns the argument of call, a call to a test helper wrapping Snap, that holds the
+// snapshot text: its only argument that is a constant string equal to text. It returns nil if
+// there is none or more than one.
+func WrapperArg(call *ast.CallExpr, text string) ast.Expr {
+	var found ast.Expr
+	for _, arg := range call.Args {
+		if s, ok := Constant(arg); ok && s == text {
+			if found != nil {
+				return nil
+			}
+			found = arg
+		}
+	}
+	return found
+}
+
+// IsVersionCall reports whether call looks like snap.Snap(t, "...").Version(1).
+func (m Matcher) IsVersionCall(call *ast.CallExpr) bool {
+	selExpr, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || selExpr.Sel.Name != "Version" || len(call.Args) != 1 {
+		return false
+	}
+	inner, ok := selExpr.X.(*ast.CallExpr)
+	return ok && m.IsSnapCall(inner)
+}
+
+// Snapshot is a Snap call found in a file.
+type Snapshot struct {
+	Pos  token.Position // Position of the snapshot argument.
+	Line int            // Line of the Snap call, which can differ from the line of Pos.
+	// Text is the snapshot text. It's only set if the snapshot is a constant, see [Constant].
+	Text     string
+	Constant bool
+}
+
+// Find returns the Snap calls in src, in source order.
+func Find(filename string, src []byte) ([]Snapshot, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	m := NewMatcher(f)
+
+	var snapshots []Snapshot
+	ast.Inspect(f, func(n ast.Node) bool {
+		callExpr, ok := n.(*ast.CallExpr)
+		if !ok || !m.IsSnapCall(callExpr) {
+			return true
+		}
+		arg := callExpr.Args[1]
+		text, isConst := Constant(arg)
+		snapshots = append(snapshots, Snapshot{
+			Pos:      fset.Position(arg.Pos()),
+			Line:     fset.Position(callExpr.Pos()).Line,
+			Text:     text,
+			Constant: isConst,
+		})
+		return true
+	})
+	return snapshots, nil
+}
+
+// Constant returns the value of expr if it's a string literal or a concatenation of them.
+func Constant(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		// Unquote discards the carriage returns of raw string literals, like the compiler.
+		s, err := strconv.Unquote(e.Value)
+		return s, err == nil
+	case *ast.ParenExpr:
+		return Constant(e.X)
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		x, ok := Constant(e.X)
+		if !ok {
+			return "", false
+		}
+		y, ok := Constant(e.Y)
+		return x + y, ok
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,50 @@
+package source
+
+import (
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	src := []byte("package example\n\nimport (\n\tsn \"github.com/KasonBraley/snap\"\n\t\"example.com/other\"\n)\n\nfunc TestFind(t *testing.T) {\n" +
+		"\tsn.Snap(t, \"a\").Diff(got)\n" +
+		"\tcheck := sn.Snap\n" +
+		"\tcheck(t, `b\nc`).Diff(got)\n" +
+		"\tsn.Snap(t, \"d\"+\n\t\t\"e\").Diff(got)\n" +
+		"\tsn.Snap(t, want).Diff(got)\n" +
+		"\tsn.Template(t, \"g\").Bind(\"x\", x).Diff(got)\n" +
+		"\tnotSnap(t, \"f\")\n" +
+		"\tsn.Snap(t, \"x\\r\\nz\").Diff(got)\n" +
+		"\tsn.Snap(t, `x\r\nz`).Diff(got)\n" +
+		"\tother.Snap(t, \"o\")\n" +
+		"\tsnap.Snap(t, \"o\")\n" +
+		"}\n")
+
+	snapshots, err := Find("example_test.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The calls of other packages, and of snap without importing it, aren't snapshots.
+	want := []struct {
+		pos      string
+		text     string
+		constant bool
+	}{
+		{pos: "example_test.go:9:13", text: "a", constant: true},
+		{pos: "example_test.go:11:11", text: "b\nc", constant: true},
+		{pos: "example_test.go:13:13", text: "de", constant: true},
+		{pos: "example_test.go:15:13"},
+		{pos: "example_test.go:16:17", text: "g", constant: true},
+		{pos: "example_test.go:18:13", text: "x\r\nz", constant: true},
+		{pos: "example_test.go:19:13", text: "x\nz", constant: true},
+	}
+	if len(snapshots) != len(want) {
+		t.Fatalf("expected %d snapshots, got %+v", len(want), snapshots)
+	}
+	for i, w := range want {
+		s := snapshots[i]
+		if s.Pos.String() != w.pos || s.Text != w.text || s.Constant != w.constant {
+			t.Errorf("snapshot %d: expected %+v, got %+v", i, w, s)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+// Package undo reads and writes the undo journals of snapshot updates.
+//
+// Each test binary updating snapshots records the files it rewrites in a journal, so that the last
+// update run can be rolled back with `snap undo`. The test binaries of one `go test` invocation
+// share the same parent process, which identifies the run. The original content of the files is
+// copied next to the journals, once per content, and the journal is a JSON lines file that each
+// rewrite appends one line to, so that recording a rewrite costs the same however large the files
+// or however many were already rewritten.
+package undo
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/KasonBraley/snap/internal/gocache"
+)
+
+// File is a source file rewritten by a run.
+type File struct {
+	Path   string `json:"path"`
+	Copy   string `json:"copy,omitempty"` // Path of the copy of the content before the run.
+	SHA256 string `json:"sha256"`         // Hash of the content written by the run.
+}
+
+// Journal lists the files rewritten by a test binary.
+type Journal struct {
+	Root  string // Module root of the tested package.
+	Run   string // Identifies the run, shared by its test binaries.
+	Files []File
+}
+
+// record is a line of a journal file.
+type record struct {
+	Root string `json:"root"`
+	Run  string `json:"run"`
+	File
+}
+
+// copiesDir is the directory of the copies of the original contents, in the directory of the
+// journals.
+const copiesDir = "copies"
+
+// Dir returns the directory of the journals: SNAP_UNDO_DIR if set, and otherwise the snap-undo
+// directory of the build cache.
+func Dir() (string, error) {
+	if dir := os.Getenv("SNAP_UNDO_DIR"); dir != "" {
+		return dir, nil
+	}
+	return gocache.Dir("snap-undo")
+}
+
+// Hash returns the hash of data recorded in [File].
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// SaveCopy copies data to dir, unless a copy of the same content is already there, and returns
+// the path of the copy, to record in [File].
+func SaveCopy(dir string, data []byte) (string, error) {
+	copies := filepath.Join(dir, copiesDir)
+	path := filepath.Join(copies, Hash(data))
+	now := time.Now()
+	// The copy is as recent as its last use, for [Prune].
+	if err := os.Chtimes(path, now, now); err == nil {
+		return path, nil
+	}
+	if err := os.MkdirAll(copies, 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(copies, ".copy-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	return path, os.Rename(tmp.Name(), path)
+}
+
+// Append records in the journal file name in dir, of the test binary of run in the module at root,
+// that f was rewritten. A file rewritten again is recorded with the hash of its new content, and
+// its copy is only needed the first time.
+func Append(dir string, name string, root string, run string, f File) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(record{Root: root, Run: run, File: f})
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(b, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Read reads the journal file at path. Each file is listed once, with its first copy and its last
+// hash.
+func Read(path string) (Journal, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Journal{}, err
+	}
+	defer file.Close()
+
+	var j Journal
+	index := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return Journal{}, err
+		}
+		j.Root, j.Run = r.Root, r.Run
+		if i, ok := index[r.Path]; ok {
+			j.Files[i].SHA256 = r.SHA256
+			continue
+		}
+		index[r.Path] = len(j.Files)
+		j.Files = append(j.Files, r.File)
+	}
+	return j, scanner.Err()
+}
+
+// Last returns the journals of the most recent run in dir for the module at root, and the paths
+// of their files. It returns no journals if there are none.
+func Last(dir string, root string) (journals []Journal, paths []string, err error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type found struct {
+		journal Journal
+		path    string
+	}
+	var all []found
+	var lastRun string
+	var lastTime int64
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".jsonl") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		j, err := Read(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // Pruned or undone meanwhile.
+		}
+		if err != nil || j.Root != root {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, nil, err
+		}
+		t := info.ModTime().UnixNano()
+		all = append(all, found{journal: j, path: path})
+		if t > lastTime {
+			lastRun, lastTime = j.Run, t
+		}
+	}
+	for _, f := range all {
+		if f.journal.Run == lastRun {
+			journals = append(journals, f.journal)
+			paths = append(paths, f.path)
+		}
+	}
+	return journals, paths, nil
+}
+
+// Prune removes the journals in dir last written more than maxAge ago, and the copies last used
+// more than maxAge ago that the remaining journals don't refer to.
+func Prune(dir string, maxAge time.Duration) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-maxAge)
+	var errs []error
+	used := make(map[string]bool)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".jsonl") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if info, err := e.Info(); err == nil && info.ModTime().Before(cutoff) {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		j, err := Read(path)
+		if err != nil {
+			continue
+		}
+		for _, f := range j.Files {
+			used[f.Copy] = true
+		}
+	}
+
+	copies, err := os.ReadDir(filepath.Join(dir, copiesDir))
+	if err != nil && !os.IsNotExist(err) {
+		errs = append(errs, err)
+	}
+	for _, e := range copies {
+		path := filepath.Join(dir, copiesDir, e.Name())
+		info, err := e.Info()
+		if err != nil || used[path] || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
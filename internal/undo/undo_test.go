@@ -0,0 +1,109 @@
+package undo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLast(t *testing.T) {
+	dir := t.TempDir()
+	records := []struct {
+		name, root, run, path string
+	}{
+		{"1-2.jsonl", "/m", "1", "/m/old_test.go"},
+		{"3-4.jsonl", "/m", "3", "/m/a_test.go"},
+		{"3-5.jsonl", "/m", "3", "/m/b/b_test.go"},
+		{"6-7.jsonl", "/other", "6", "/other/a_test.go"},
+	}
+	for _, r := range records {
+		if err := Append(dir, r.name, r.root, r.run, File{Path: r.path}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hourAgo := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "1-2.jsonl"), hourAgo, hourAgo); err != nil {
+		t.Fatal(err)
+	}
+
+	got, paths, err := Last(dir, "/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Run != "3" || got[1].Run != "3" || len(paths) != 2 {
+		t.Errorf("expected the journals of run 3, got %+v", got)
+	}
+
+	if got, _, err := Last(filepath.Join(dir, "missing"), "/m"); err != nil || got != nil {
+		t.Errorf("expected no journals in a missing directory, got %+v, %v", got, err)
+	}
+}
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	copied, err := SaveCopy(dir, []byte("original"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := SaveCopy(dir, []byte("original")); err != nil || again != copied {
+		t.Errorf("expected the same content to be copied once, got %s, %v", again, err)
+	}
+	for _, f := range []File{{Path: "/m/a_test.go", Copy: copied, SHA256: "1"}, {Path: "/m/b_test.go", SHA256: "2"}, {Path: "/m/a_test.go", SHA256: "3"}} {
+		if err := Append(dir, "1-2.jsonl", "/m", "1", f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	j, err := Read(filepath.Join(dir, "1-2.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Root != "/m" || j.Run != "1" || len(j.Files) != 2 || j.Files[0] != (File{Path: "/m/a_test.go", Copy: copied, SHA256: "3"}) {
+		t.Errorf("expected the first copy and the last hash of each file, got %+v", j)
+	}
+	if b, err := os.ReadFile(copied); err != nil || string(b) != "original" {
+		t.Errorf("expected the copy of the original content, got %q, %v", b, err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	weekAgo := time.Now().Add(-7 * 24 * time.Hour)
+	var copies []string
+	for i, name := range []string{"old", "recent", "unused"} {
+		copied, err := SaveCopy(dir, []byte(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		copies = append(copies, copied)
+		if err := os.Chtimes(copied, weekAgo, weekAgo); err != nil {
+			t.Fatal(err)
+		}
+		if name == "unused" {
+			continue
+		}
+		journal := filepath.Join(dir, fmt.Sprintf("%d-1.jsonl", i))
+		if err := Append(dir, filepath.Base(journal), "/m", fmt.Sprint(i), File{Path: "/m/a_test.go", Copy: copied}); err != nil {
+			t.Fatal(err)
+		}
+		if name == "old" {
+			if err := os.Chtimes(journal, weekAgo, weekAgo); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := Prune(dir, 24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []bool{false, true, false} {
+		if _, err := os.Stat(copies[i]); (err == nil) != want {
+			t.Errorf("expected copy %d to be kept: %t, got %v", i, want, err)
+		}
+	}
+	if journals, _ := filepath.Glob(filepath.Join(dir, "*.jsonl")); len(journals) != 1 || filepath.Base(journals[0]) != "1-1.jsonl" {
+		t.Errorf("expected only the recent journal to be kept, got %v", journals)
+	}
+}
@@ -0,0 +1,58 @@
+package snap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// invisibleNames names the characters that change how source code reads without being visible:
+// the bidirectional controls, which can reorder the text around them on screen like in Trojan
+// Source attacks, and the zero-width characters that aren't needed to write any language.
+var invisibleNames = map[rune]string{
+	'\u061c': "arabic letter mark",
+	'\u200b': "zero width space",
+	'\u200e': "left-to-right mark",
+	'\u200f': "right-to-left mark",
+	'\u202a': "left-to-right embedding",
+	'\u202b': "right-to-left embedding",
+	'\u202c': "pop directional formatting",
+	'\u202d': "left-to-right override",
+	'\u202e': "right-to-left override",
+	'\u2060': "word joiner",
+	'\u2066': "left-to-right isolate",
+	'\u2067': "right-to-left isolate",
+	'\u2068': "first strong isolate",
+	'\u2069': "pop directional isolate",
+	'\ufeff': "zero width no-break space",
+}
+
+// isInvisible reports whether r is a bidirectional control or zero-width character of
+// invisibleNames.
+func isInvisible(r rune) bool {
+	_, ok := invisibleNames[r]
+	return ok
+}
+
+// containsInvisible reports whether text contains characters for which isInvisible is true.
+func containsInvisible(text string) bool {
+	return strings.IndexFunc(text, isInvisible) >= 0
+}
+
+// invisibleWarnings returns a warning for each line of text with bidirectional control or
+// zero-width characters, which snapshots escape when writing them to source files.
+func invisibleWarnings(text string) []string {
+	var warnings []string
+	for i, line := range strings.Split(text, "\n") {
+		var found []string
+		for _, r := range line {
+			if isInvisible(r) {
+				found = append(found, fmt.Sprintf("%U (%s)", r, invisibleNames[r]))
+			}
+		}
+		if len(found) > 0 {
+			warnings = append(warnings, fmt.Sprintf("line %d of the snapshot contains %s, written escaped",
+				i+1, strings.Join(found, ", ")))
+		}
+	}
+	return warnings
+}
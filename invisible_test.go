@@ -0,0 +1,38 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpdateEscapesInvisibleCharacters(t *testing.T) {
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `old\nlines`).Diff(got)\n}\n", "old\nlines")
+	// The override displays the rest of the line reversed, as "access ddenied".
+	s.Diff("ok\naccess \u202edeinedd\u202c\u200b")
+
+	src := readFile(t, path)
+	if !strings.Contains(src, `snap.Snap(t, "ok\naccess \u202edeinedd\u202c\u200b")`) {
+		t.Errorf("expected the invisible characters to be escaped, got:\n%s", src)
+	}
+	if !containsLog(ft, "snap: Warning: line 2 of the snapshot contains U+202E (right-to-left override), "+
+		"U+202C (pop directional formatting), U+200B (zero width space), written escaped") {
+		t.Errorf("expected a warning for the invisible characters, got %q", ft.logs)
+	}
+}
+
+func TestQuoteLiteralInvisible(t *testing.T) {
+	if got, want := quoteLiteral("a\nb", true), "`a\nb`"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if got, want := quoteLiteral("a\n\ufeffb", true), `"a\n\ufeffb"`; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	// Joiners are part of emoji and of some scripts, and are kept.
+	if got, want := quoteLiteral("\U0001f468\u200d\U0001f469\n", true), "`\U0001f468\u200d\U0001f469\n`"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if warnings := invisibleWarnings("\U0001f468\u200d\U0001f469"); len(warnings) != 0 {
+		t.Errorf("expected no warnings for a joiner, got %q", warnings)
+	}
+}
@@ -0,0 +1,85 @@
+package snap
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledBuffer is the capacity above which buffers aren't reused, unless [SetBufferSize] sets a
+// larger one, so that one huge document doesn't keep its buffer alive for the rest of the run.
+const maxPooledBuffer = 1 << 20
+
+// bufferSize is the initial capacity of the buffers values are serialized into, set with
+// [SetBufferSize].
+var bufferSize atomic.Int64
+
+// jsonEncoders are the encoders values are serialized with, reused across snapshots to spare the
+// garbage collector of suites snapshotting thousands of JSON documents.
+var jsonEncoders = sync.Pool{New: func() any {
+	e := &jsonEncoder{}
+	e.buf.Grow(int(bufferSize.Load()))
+	e.enc = json.NewEncoder(&e.buf)
+	e.enc.SetEscapeHTML(false)
+	return e
+}}
+
+// SetBufferSize sets the initial capacity in bytes of the buffers values are serialized into by
+// [Snapshot.DiffJSON], [Snapshot.DiffJSONSemantic], [Snapshot.DiffYAML] and [JSONPart]. Buffers
+// are reused from one value to the next, so that they grow once to the size of the documents, but
+// suites snapshotting documents larger than a few kilobytes can set the size of a typical one to
+// not grow them at all. Buffers grown past both 1 MiB and size aren't reused.
+//
+// Typically called from TestMain.
+func SetBufferSize(size int) {
+	bufferSize.Store(int64(size))
+}
+
+// jsonEncoder is a JSON encoder writing into its own buffer, without escaping HTML characters.
+type jsonEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+// getJSONEncoder returns an encoder from the pool, indenting with indent. It must be released
+// once its output isn't used anymore.
+func getJSONEncoder(indent string) *jsonEncoder {
+	e := jsonEncoders.Get().(*jsonEncoder)
+	e.enc.SetIndent("", indent)
+	return e
+}
+
+// encode returns the JSON encoding of value, without the trailing newline. The result is only
+// valid until the next call or until e is released.
+func (e *jsonEncoder) encode(value any) ([]byte, error) {
+	e.buf.Reset()
+	if err := e.enc.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(e.buf.Bytes(), []byte("\n")), nil
+}
+
+// release puts e back in the pool.
+func (e *jsonEncoder) release() {
+	if e.buf.Cap() > max(maxPooledBuffer, int(bufferSize.Load())) {
+		return
+	}
+	jsonEncoders.Put(e)
+}
+
+// encodeJSON returns the JSON encoding of value, indented with indent, like [Snapshot.DiffJSON]
+// writes it. If the encoding is equal to known, typically the snapshot, known is returned rather
+// than a copy of the encoding.
+func encodeJSON(value any, indent string, known string) (string, error) {
+	e := getJSONEncoder(indent)
+	defer e.release()
+	data, err := e.encode(value)
+	if err != nil {
+		return "", err
+	}
+	if string(data) == known {
+		return known, nil
+	}
+	return string(data), nil
+}
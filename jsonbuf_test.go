@@ -0,0 +1,62 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestEncodeJSON(t *testing.T) {
+	first, err := encodeJSON(map[string]string{"a": "<b>"}, "  ", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := encodeJSON(make(chan int), "  ", ""); err == nil {
+		t.Error("expected an error for a value that can't be encoded")
+	}
+	// The encoders are reused, but their results aren't.
+	second, err := encodeJSON([]int{1, 2}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != "{\n  \"a\": \"<b>\"\n}" || second != "[1,2]" {
+		t.Errorf("unexpected encodings %q and %q", first, second)
+	}
+
+	known := strings.Clone("[1,2]")
+	if got, _ := encodeJSON([]int{1, 2}, "", known); unsafe.StringData(got) != unsafe.StringData(known) {
+		t.Errorf("expected the known encoding, got %q", got)
+	}
+}
+
+func TestSetBufferSize(t *testing.T) {
+	SetBufferSize(64 << 10)
+	defer SetBufferSize(0)
+	if e := jsonEncoders.New().(*jsonEncoder); e.buf.Cap() < 64<<10 {
+		t.Errorf("expected a buffer of at least 64 KiB, got %d bytes", e.buf.Cap())
+	}
+}
+
+func BenchmarkDiffJSON(b *testing.B) {
+	type item struct {
+		ID    int      `json:"id"`
+		Name  string   `json:"name"`
+		Tags  []string `json:"tags"`
+		Price float64  `json:"price"`
+	}
+	items := make([]item, 100)
+	for i := range items {
+		items[i] = item{ID: i, Name: "item", Tags: []string{"a", "b"}, Price: 9.99}
+	}
+	want, err := encodeJSON(items, "  ", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	s := snapNoUpdate(b, want)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.DiffJSON(items, "  ")
+	}
+}
@@ -0,0 +1,115 @@
+package snap
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxChangedPaths limits the paths listed by changedJSONPaths.
+const maxChangedPaths = 20
+
+// changedJSONPaths lists the JSON pointers of the values that differ between the JSON documents
+// want and got, like "snap: Changed: /items/3/price, /meta/count\n", to find the changes of large
+// documents faster than in the textual diff. It returns an empty string when want or got isn't
+// valid JSON, like snapshots with markers.
+func changedJSONPaths(want string, got string) string {
+	w, ok := decodeJSON(want)
+	if !ok {
+		return ""
+	}
+	g, ok := decodeJSON(got)
+	if !ok {
+		return ""
+	}
+
+	paths := jsonChanges(nil, "", w, g)
+	if len(paths) == 0 {
+		return ""
+	}
+	for i, p := range paths {
+		if p == "" {
+			paths[i] = "(root)"
+		}
+	}
+	if len(paths) > maxChangedPaths {
+		paths = append(paths[:maxChangedPaths], fmt.Sprintf("and %d more", len(paths)-maxChangedPaths))
+	}
+	return "snap: Changed: " + strings.Join(paths, ", ") + "\n"
+}
+
+// decodeJSON decodes the JSON document text, keeping numbers as written.
+func decodeJSON(text string) (any, bool) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return nil, false
+	}
+	return v, true
+}
+
+// jsonChanges appends the JSON pointers of the values that differ between want and got, at path,
+// to paths.
+func jsonChanges(paths []string, path string, want any, got any) []string {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := path + "/" + escapeJSONPointer(k)
+			wv, inWant := w[k]
+			gv, inGot := g[k]
+			if !inWant || !inGot {
+				paths = append(paths, p)
+				continue
+			}
+			paths = jsonChanges(paths, p, wv, gv)
+		}
+		return paths
+	case []any:
+		g, ok := got.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < max(len(w), len(g)); i++ {
+			p := path + "/" + strconv.Itoa(i)
+			if i >= len(w) || i >= len(g) {
+				paths = append(paths, p)
+				continue
+			}
+			paths = jsonChanges(paths, p, w[i], g[i])
+		}
+		return paths
+	case json.Number:
+		// Numbers written differently, like 1 and 1.0, are equal.
+		if g, ok := got.(json.Number); ok && compareJSON(json.RawMessage(w), json.RawMessage(g)) == 0 {
+			return paths
+		}
+		return append(paths, path)
+	}
+	if !reflect.DeepEqual(want, got) {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// escapeJSONPointer escapes a key for a JSON pointer, as RFC 6901 specifies.
+func escapeJSONPointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
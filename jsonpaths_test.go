@@ -0,0 +1,54 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChangedJSONPaths(t *testing.T) {
+	cases := []struct {
+		want, got string
+		changed   string
+	}{
+		{
+			want:    `{"items": [{"price": 1}, {"price": 2.0}], "meta": {"count": 2, "a/b~": true}}`,
+			got:     `{"items": [{"price": 1}, {"price": 2}, {"price": 3}], "meta": {"count": 3, "a/b~": false}}`,
+			changed: "snap: Changed: /items/2, /meta/a~1b~0, /meta/count\n",
+		},
+		{want: `{"a": 1}`, got: `{"b": 1}`, changed: "snap: Changed: /a, /b\n"},
+		{want: `{"a": [1]}`, got: `{"a": {"0": 1}}`, changed: "snap: Changed: /a\n"},
+		{want: `1`, got: `"1"`, changed: "snap: Changed: (root)\n"},
+		{want: `{"a": 1}`, got: `{"a": 1}`},
+		{want: `{"a": <snap:ignore>}`, got: `{"a": 1}`},
+	}
+	for _, tc := range cases {
+		if changed := changedJSONPaths(tc.want, tc.got); changed != tc.changed {
+			t.Errorf("changedJSONPaths(%s, %s) = %q, want %q", tc.want, tc.got, changed, tc.changed)
+		}
+	}
+
+	var want, got strings.Builder
+	want.WriteString("[")
+	got.WriteString("[")
+	for i := 0; i < maxChangedPaths+5; i++ {
+		if i > 0 {
+			want.WriteString(",")
+			got.WriteString(",")
+		}
+		want.WriteString("0")
+		got.WriteString("1")
+	}
+	want.WriteString("]")
+	got.WriteString("]")
+	if changed := changedJSONPaths(want.String(), got.String()); !strings.HasSuffix(changed, "/19, and 5 more\n") {
+		t.Errorf("expected the paths to be limited, got %q", changed)
+	}
+}
+
+func TestDiffJSONChangedPaths(t *testing.T) {
+	ft := newFakeT(t)
+	snapNoUpdate(ft, `{"count":1,"name":"a"}`).DiffJSON(map[string]any{"count": 2, "name": "a"}, "")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "snap: Changed: /count\n") {
+		t.Errorf("expected the changed paths, got %q", ft.errors)
+	}
+}
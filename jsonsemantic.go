@@ -0,0 +1,81 @@
+package snap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DiffJSONSemantic compares the snapshot with the JSON document data as parsed JSON rather than as
+// text, for documents from encoders whose key order and whitespace aren't stable, like upstream
+// services. Both are written in a canonical form, with sorted keys, two-space indentation and
+// numbers in their shortest form, before they're compared, so that the diff only shows the values
+// that changed, and the snapshot is updated in the canonical form. Markers in string values, like
+// `"id": "<snap:uuid>"`, still match.
+// It calls [testing.T.Error] when the snapshot is not equal to the document or when data isn't
+// valid JSON.
+func (s *Snapshot) DiffJSONSemantic(data []byte) {
+	s.t.Helper()
+	got, err := canonicalJSON(data)
+	if err != nil {
+		s.t.Errorf("snap: Invalid JSON: %v", err)
+		return
+	}
+	c := *s
+	c.isJSON = true
+	// Snapshots that aren't valid JSON, like empty ones or ones with markers outside of strings, are
+	// compared as they are.
+	if want, err := canonicalJSON([]byte(c.text)); err == nil {
+		c.text = want
+	}
+	c.Diff(got)
+}
+
+// canonicalJSON returns the JSON document data with sorted keys, two-space indentation and numbers
+// in their shortest form.
+func canonicalJSON(data []byte) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return "", err
+	}
+	if _, err := dec.Token(); err == nil {
+		return "", fmt.Errorf("unexpected data after the document at offset %d", dec.InputOffset())
+	}
+
+	return encodeJSON(canonicalNumbers(v), "  ", "")
+}
+
+// canonicalNumbers rewrites the numbers of the decoded JSON value v in their shortest form, so that
+// 1.0, 1 and 1e0 are equal. Integers are kept as written, since they may not fit in a float64, so
+// integral values below 1e21 are written as integers, like 100000000 for 1e8, and larger ones in
+// exponent form, like JavaScript does.
+func canonicalNumbers(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = canonicalNumbers(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = canonicalNumbers(e)
+		}
+	case json.Number:
+		if strings.ContainsAny(string(v), ".eE") {
+			if f, err := v.Float64(); err == nil {
+				if f == 0 {
+					f = 0 // Negative zero is zero.
+				}
+				if f == math.Trunc(f) && math.Abs(f) < 1e21 {
+					return json.Number(strconv.FormatFloat(f, 'f', -1, 64))
+				}
+				return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+			}
+		}
+	}
+	return v
+}
@@ -0,0 +1,34 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffJSONSemantic(t *testing.T) {
+	// Key order, whitespace and number forms don't matter.
+	Snap(t, `{"b": [1, 2.50], "a": {"y": true, "x": null}}`).DiffJSONSemantic([]byte(`{"a":{"x":null,"y":true},"b":[1.0,2.5]}`))
+	Snap(t, `{"n": 100000000, "m": 1e21, "z": -0.0}`).DiffJSONSemantic([]byte(`{"n":1e8,"m":1e+21,"z":0}`))
+	Snap(t, `[100000000.0, 1.5e3, 0.25]`).DiffJSONSemantic([]byte(`[1e8, 1500, 2.5e-1]`))
+	Snap(t, `{"id": "<snap:ignore>", "name": "alice"}`).DiffJSONSemantic([]byte(`{"name":"alice","id":"8f2c"}`))
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, `{"b": 2, "a": 1}`).DiffJSONSemantic([]byte(`{"a":1,"b":3}`))
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `\"a\": 1,\n  \"b\": 2`) || !strings.Contains(ft.errors[0], "snap: Changed: /b") {
+		t.Errorf("expected a diff of the canonical forms, got %q", ft.errors)
+	}
+
+	ft = newFakeT(t)
+	snapNoUpdate(ft, `{}`).DiffJSONSemantic([]byte(`{} {}`))
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "snap: Invalid JSON: unexpected data after the document") {
+		t.Errorf("expected an invalid JSON error, got %q", ft.errors)
+	}
+
+	// Updates write the canonical form.
+	ft = newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `{\"b\":1}`).DiffJSONSemantic(got)\n}\n", `{"b":1}`)
+	s.DiffJSONSemantic([]byte(`{"b":1,"a":[]}`))
+	if got := readFile(t, path); !strings.Contains(got, "snap.Snap(t, `{\n  \"a\": [],\n  \"b\": 1\n}`)") {
+		t.Errorf("expected the snapshot to be updated in canonical form, got:\n%s", got)
+	}
+}
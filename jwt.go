@@ -0,0 +1,97 @@
+package snap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// jwtText matches JSON Web Tokens, whose header and claims start with `{"`, encoded as "eyJ".
+var jwtText = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*`)
+
+// jwtTimeClaims are the claims holding times, in seconds since the Unix epoch.
+var jwtTimeClaims = []string{"iat", "exp", "nbf", "auth_time"}
+
+// JWTs is a [Normalizer] rewriting the JSON Web Tokens of the value as their decoded header and
+// claims, with sorted keys, and a placeholder for their signature, so that snapshots of auth
+// flows show what the tokens say rather than ignoring them:
+//
+//	JWT{"alg":"HS256","typ":"JWT"}.{"exp":"<iat+1h0m0s>","iat":"<iat>","sub":"42"}.<signature>
+//
+// The time claims differ on every run: the issue time, iat, is replaced by <iat>, and the expiry
+// and other times by their offset from it, which is the lifetime the test checks, or by <exp>,
+// <nbf> and <auth_time> in tokens without an issue time. Tokens that can't be decoded are left as
+// they are.
+func JWTs(got string) string {
+	return jwtText.ReplaceAllStringFunc(got, func(token string) string {
+		parts := strings.Split(token, ".")
+		header, ok := decodeJWTPart(parts[0])
+		if !ok {
+			return token
+		}
+		claims, ok := decodeJWTPart(parts[1])
+		if !ok {
+			return token
+		}
+		bucketJWTTimes(claims)
+		signature := "<signature>"
+		if parts[2] == "" {
+			signature = "" // Unsecured tokens, with the "none" algorithm, have no signature.
+		}
+		return "JWT" + encodeJWTPart(header) + "." + encodeJWTPart(claims) + "." + signature
+	})
+}
+
+// decodeJWTPart decodes the header or claims part of a JWT, reporting whether it's a JSON object.
+func decodeJWTPart(part string) (map[string]any, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var object map[string]any
+	if err := dec.Decode(&object); err != nil || object == nil {
+		return nil, false
+	}
+	return object, true
+}
+
+// encodeJWTPart returns the JSON encoding of a part of a JWT, with sorted keys.
+func encodeJWTPart(object map[string]any) string {
+	data, _ := encodeJSON(object, "", "") // The object was decoded from JSON, so it can be encoded.
+	return data
+}
+
+// bucketJWTTimes replaces the time claims of claims by their offset from the issue time, or by
+// markers.
+func bucketJWTTimes(claims map[string]any) {
+	issued, hasIssued := jwtTime(claims["iat"])
+	for _, name := range jwtTimeClaims {
+		t, ok := jwtTime(claims[name])
+		switch {
+		case !ok:
+			continue
+		case name != "iat" && hasIssued:
+			claims[name] = "<iat+" + t.Sub(issued).String() + ">"
+		default:
+			claims[name] = "<" + name + ">"
+		}
+	}
+}
+
+// jwtTime returns the time of a claim, in seconds since the Unix epoch.
+func jwtTime(value any) (time.Time, bool) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), true
+}
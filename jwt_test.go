@@ -0,0 +1,42 @@
+package snap
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+// jwt returns a token with the header and claims, and a fake signature.
+func jwt(header string, claims string, signature string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(header)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(claims)) + "." + signature
+}
+
+func TestJWTs(t *testing.T) {
+	header := `{"typ":"JWT","alg":"HS256"}`
+	cases := []struct {
+		got  string
+		want string
+	}{
+		{
+			got:  "Authorization: Bearer " + jwt(header, `{"sub":"42","iat":1715680800,"exp":1715684400,"roles":["admin"]}`, "c2lnbmF0dXJl"),
+			want: `Authorization: Bearer JWT{"alg":"HS256","typ":"JWT"}.{"exp":"<iat+1h0m0s>","iat":"<iat>","roles":["admin"],"sub":"42"}.<signature>`,
+		},
+		{
+			got:  `{"token":"` + jwt(header, `{"sub":"42","exp":1715684400}`, "c2ln") + `"}`,
+			want: `{"token":"JWT{"alg":"HS256","typ":"JWT"}.{"exp":"<exp>","sub":"42"}.<signature>"}`,
+		},
+		{
+			got:  jwt(`{"alg":"none"}`, `{"sub":"42"}`, ""),
+			want: `JWT{"alg":"none"}.{"sub":"42"}.`,
+		},
+		{
+			got:  "eyJub3Q.eyJqc29u.c2ln",
+			want: "eyJub3Q.eyJqc29u.c2ln",
+		},
+	}
+	for _, tc := range cases {
+		if got := JWTs(tc.got); got != tc.want {
+			t.Errorf("JWTs(%q):\nexpected %s\ngot      %s", tc.got, tc.want, got)
+		}
+	}
+}
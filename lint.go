@@ -0,0 +1,197 @@
+package snap
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// lintRules are the rules of SNAP_LINT, keeping snapshots consistent with the style tooling of the
+// repository, like editors and pre-commit hooks stripping trailing whitespace.
+type lintRules struct {
+	trailingSpace bool   // Strip trailing spaces and tabs from lines.
+	indent        string // "spaces" or "tabs" to convert the indentation of lines to, or "".
+	tabWidth      int    // Spaces per tab for indent.
+	maxLine       int    // Warn about updated snapshot lines longer than this, if not 0.
+	absPaths      string // "warn" or "fail" for updated snapshots with absolute paths of the machine, or "".
+}
+
+// parseLintRules parses the value of the SNAP_LINT environment variable: a comma-separated list of
+// trailing-space, indent=spaces, indent=tabs, tabwidth=N, max-line=N and abs-paths, which is
+// abs-paths=warn, or abs-paths=fail.
+func parseLintRules(s string) (lintRules, error) {
+	rules := lintRules{tabWidth: 4}
+	if s == "" {
+		return rules, nil
+	}
+	for _, rule := range strings.Split(s, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		var err error
+		switch name {
+		case "trailing-space":
+			rules.trailingSpace = true
+		case "indent":
+			if value != "spaces" && value != "tabs" {
+				return lintRules{}, fmt.Errorf("unknown SNAP_LINT indent %q, expected spaces or tabs", value)
+			}
+			rules.indent = value
+		case "tabwidth":
+			rules.tabWidth, err = strconv.Atoi(value)
+			if err == nil && rules.tabWidth <= 0 {
+				err = fmt.Errorf("not positive")
+			}
+		case "max-line":
+			rules.maxLine, err = strconv.Atoi(value)
+		case "abs-paths":
+			if value == "" {
+				value = "warn"
+			}
+			if value != "warn" && value != "fail" {
+				return lintRules{}, fmt.Errorf("unknown SNAP_LINT abs-paths %q, expected warn or fail", value)
+			}
+			rules.absPaths = value
+		default:
+			return lintRules{}, fmt.Errorf("unknown SNAP_LINT rule %q, expected trailing-space, indent, tabwidth, max-line or abs-paths", name)
+		}
+		if err != nil {
+			return lintRules{}, fmt.Errorf("invalid SNAP_LINT %s %q: %w", name, value, err)
+		}
+	}
+	return rules, nil
+}
+
+// fix applies the rules that can be fixed to the lines of text. It's applied to values before
+// they're compared, so that the fixed snapshots they update to still match them.
+func (r lintRules) fix(text string) string {
+	if !r.trailingSpace && r.indent == "" {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if r.trailingSpace {
+			line = strings.TrimRight(line, " \t")
+		}
+		if r.indent != "" {
+			rest := strings.TrimLeft(line, " \t")
+			line = r.fixIndent(line[:len(line)-len(rest)]) + rest
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fixIndent converts the indentation indent to spaces or tabs.
+func (r lintRules) fixIndent(indent string) string {
+	width := 0
+	for _, c := range indent {
+		if c == '\t' {
+			width += r.tabWidth - width%r.tabWidth
+		} else {
+			width++
+		}
+	}
+	if r.indent == "spaces" {
+		return strings.Repeat(" ", width)
+	}
+	return strings.Repeat("\t", width/r.tabWidth) + strings.Repeat(" ", width%r.tabWidth)
+}
+
+// check returns a warning for each line of text wider than the maximum line length, in columns,
+// and with abs-paths=warn, for each absolute path of the machine in text.
+func (r lintRules) check(text string) []string {
+	var warnings []string
+	if r.maxLine > 0 {
+		for i, line := range strings.Split(text, "\n") {
+			if n := displayWidth(line); n > r.maxLine {
+				warnings = append(warnings, fmt.Sprintf("line %d of the snapshot is %d columns wide, more than %d", i+1, n, r.maxLine))
+			}
+		}
+	}
+	if r.absPaths == "warn" {
+		warnings = append(warnings, machinePaths(text)...)
+	}
+	return warnings
+}
+
+// homePath matches paths in the home directories of users, on Linux, macOS and Windows.
+var homePath = regexp.MustCompile(`(/home/|/Users/|[A-Za-z]:\\Users\\)[^/\\\s"'` + "`" + `]+`)
+
+// machinePaths returns a warning for each line of text containing an absolute path specific to
+// the machine running the tests: in the module root, the home directory of the user, or the home
+// directory of any user. Such snapshots fail on other machines, which a scrubber replacing the
+// paths, like a [Normalizer], avoids.
+func machinePaths(text string) []string {
+	var prefixes []string
+	if root := moduleRoot(); len(root) > 1 {
+		prefixes = append(prefixes, root)
+	}
+	if home, err := os.UserHomeDir(); err == nil && len(home) > 1 {
+		prefixes = append(prefixes, home)
+	}
+	var warnings []string
+	for i, line := range strings.Split(text, "\n") {
+		path := homePath.FindString(line)
+		for _, prefix := range prefixes {
+			if containsPath(line, prefix) {
+				path = prefix
+				break
+			}
+		}
+		if path != "" {
+			warnings = append(warnings, fmt.Sprintf("line %d of the snapshot contains the absolute path %s of this machine", i+1, path))
+		}
+	}
+	return warnings
+}
+
+// containsPath reports whether line contains the path dir, and not only a path starting with
+// the same characters, like /home/bob for /home/bo.
+func containsPath(line string, dir string) bool {
+	for i := strings.Index(line, dir); i >= 0; {
+		end := i + len(dir)
+		if end == len(line) || !isPathChar(line[end]) {
+			return true
+		}
+		next := strings.Index(line[end:], dir)
+		if next < 0 {
+			return false
+		}
+		i = end + next
+	}
+	return false
+}
+
+// isPathChar reports whether c can continue the name of a file.
+func isPathChar(c byte) bool {
+	return c == '-' || c == '_' || c == '.' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// checkPaths fails the test if SNAP_LINT has abs-paths=fail and the snapshot is about to be
+// updated to text containing absolute paths of the machine, and reports whether it didn't.
+func (s *Snapshot) checkPaths(text string) bool {
+	s.t.Helper()
+	rules, _ := parseLintRules(os.Getenv("SNAP_LINT"))
+	if rules.absPaths != "fail" {
+		return true
+	}
+	warnings := machinePaths(text)
+	if len(warnings) == 0 {
+		return true
+	}
+	s.t.Errorf("snap: Not updating the snapshot at %s, %s, scrub it or remove abs-paths=fail from SNAP_LINT",
+		s.findLiteral().pos, strings.Join(warnings, ", "))
+	return false
+}
+
+// lint returns the rules of SNAP_LINT, failing the test if they're invalid.
+func (s *Snapshot) lint() (lintRules, bool) {
+	s.t.Helper()
+	rules, err := parseLintRules(os.Getenv("SNAP_LINT"))
+	if err != nil {
+		s.t.Errorf("snap: %s", err)
+		return lintRules{}, false
+	}
+	return rules, true
+}
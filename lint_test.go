@@ -0,0 +1,88 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintFix(t *testing.T) {
+	cases := []struct {
+		rules string
+		text  string
+		want  string
+	}{
+		{rules: "", text: "a \n\tb\t", want: "a \n\tb\t"},
+		{rules: "trailing-space", text: "a \n\tb\t\n", want: "a\n\tb\n"},
+		{rules: "indent=spaces", text: "\ta\n  \tb\n", want: "    a\n    b\n"},
+		{rules: "indent=tabs,tabwidth=2", text: "    a\n   b \n", want: "\t\ta\n\t b \n"},
+	}
+	for _, tc := range cases {
+		rules, err := parseLintRules(tc.rules)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rules.fix(tc.text); got != tc.want {
+			t.Errorf("SNAP_LINT=%s: fix(%q) = %q, want %q", tc.rules, tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestParseLintRulesErrors(t *testing.T) {
+	for _, rules := range []string{"unknown", "indent=both", "tabwidth=0", "max-line=x", "abs-paths=maybe"} {
+		if _, err := parseLintRules(rules); err == nil {
+			t.Errorf("expected SNAP_LINT=%s to be invalid", rules)
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	t.Setenv("SNAP_LINT", "trailing-space,max-line=5")
+	Snap(t, "a\nb").Diff("a  \nb\t")
+
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, \"old\").Diff(got)\n}\n", "old")
+	s.Diff("too long line   ")
+	if !strings.Contains(readFile(t, path), `snap.Snap(t, "too long line")`) {
+		t.Errorf("expected the trailing spaces to be stripped, got:\n%s", readFile(t, path))
+	}
+	if !containsLog(ft, "snap: Warning: line 1 of the snapshot is 13 columns wide, more than 5") {
+		t.Errorf("expected a warning for the long line, got %q", ft.logs)
+	}
+
+	t.Setenv("SNAP_LINT", "unknown")
+	ft = newFakeT(t)
+	snapNoUpdate(ft, "a").Diff("a")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `unknown SNAP_LINT rule "unknown"`) {
+		t.Errorf("expected an invalid SNAP_LINT error, got %q", ft.errors)
+	}
+}
+
+func TestLintAbsolutePaths(t *testing.T) {
+	root := moduleRoot()
+	if got := machinePaths("ok: /usr/bin/go\nbad: " + root + "/testdata/a.txt\nopened C:\\Users\\bob\\go\nhome: /home/bob/src"); len(got) != 3 ||
+		got[0] != "line 2 of the snapshot contains the absolute path "+root+" of this machine" ||
+		got[1] != `line 3 of the snapshot contains the absolute path C:\Users\bob of this machine` ||
+		got[2] != "line 4 of the snapshot contains the absolute path /home/bob of this machine" {
+		t.Errorf("unexpected warnings: %q", got)
+	}
+	if containsPath("/home/bob/a", "/home/bo") || !containsPath("/home/bo /home/bo/a", "/home/bo") {
+		t.Errorf("expected containsPath to only match whole file names")
+	}
+
+	t.Setenv("SNAP_LINT", "abs-paths")
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, \"old\").Diff(got)\n}\n", "old")
+	s.Diff("/home/bob/a.txt")
+	if !strings.Contains(readFile(t, path), `"/home/bob/a.txt"`) || !containsLog(ft, "snap: Warning: line 1 of the snapshot contains the absolute path /home/bob") {
+		t.Errorf("expected the snapshot to be updated with a warning, got %q", ft.logs)
+	}
+
+	t.Setenv("SNAP_LINT", "abs-paths=fail")
+	ft = newFakeT(t)
+	s, path = snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, \"old\").Diff(got)\n}\n", "old")
+	s.Diff("/home/bob/a.txt")
+	if strings.Contains(readFile(t, path), "/home/bob") || len(ft.errors) != 2 ||
+		!strings.Contains(ft.errors[1], "Not updating the snapshot at "+path+":6:15, line 1 of the snapshot contains the absolute path /home/bob") {
+		t.Errorf("expected the update to fail, got %q", ft.errors)
+	}
+}
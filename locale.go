@@ -0,0 +1,52 @@
+package snap
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// LocalizedNumbers returns a [Normalizer] rewriting the numbers of the value formatted with the
+// group and decimal separators of a locale to a canonical form, without grouping and with a '.'
+// decimal separator, so that snapshots pass on machines with a different LC_NUMERIC. For example,
+// LocalizedNumbers('.', ',') rewrites "1.234.567,89" to "1234567.89". Pass 0 as group for locales
+// whose numbers are not grouped.
+//
+// All the numbers of the value are rewritten, so with ',' as decimal separator a list like "1,2"
+// becomes "1.2".
+func LocalizedNumbers(group rune, decimal rune) Normalizer {
+	d := regexp.QuoteMeta(string(decimal))
+	pattern := `\b\d+` + d + `\d+\b`
+	if group != 0 {
+		g := regexp.QuoteMeta(string(group))
+		pattern = `\b\d{1,3}(?:` + g + `\d{3})+(?:` + d + `\d+)?\b|` + pattern
+	}
+	re := regexp.MustCompile(pattern)
+	return func(got string) string {
+		return re.ReplaceAllStringFunc(got, func(num string) string {
+			if group != 0 {
+				num = strings.ReplaceAll(num, string(group), "")
+			}
+			return strings.Replace(num, string(decimal), ".", 1)
+		})
+	}
+}
+
+// localizedNumber matches numbers that are only formatted like this by some locales: grouped with
+// spaces or apostrophes, grouped with one separator and a decimal part with the other, or grouped
+// more than once.
+var localizedNumber = regexp.MustCompile(`\b\d{1,3}(?:(?:[\x{a0}\x{202f}']\d{3})+|(?:\.\d{3})+,\d+|(?:,\d{3})+\.\d+|(?:\.\d{3}){2,}|(?:,\d{3}){2,})\b`)
+
+// LocaleIndependent is a [Validator] failing when the text contains numbers formatted for a
+// locale, like "1.234,5" or "1 234 567", to catch output that depends on the LC_NUMERIC of the
+// machine running the tests. Use it with [Snapshot.Schema]:
+//
+//	snap.Snap(t, want).Schema(snap.LocaleIndependent).Diff(report)
+var LocaleIndependent Validator = ValidatorFunc(func(text string) error {
+	var errs []error
+	for _, num := range localizedNumber.FindAllString(text, -1) {
+		errs = append(errs, fmt.Errorf("%q is formatted for a locale", num))
+	}
+	return errors.Join(errs...)
+})
@@ -0,0 +1,36 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLocalizedNumbers(t *testing.T) {
+	cases := []struct {
+		group, decimal rune
+		got, want      string
+	}{
+		{'.', ',', "total 1.234.567,89 EUR, 3,5%, 12", "total 1234567.89 EUR, 3.5%, 12"},
+		{',', '.', "1,234 items at 1,000.50", "1234 items at 1000.50"},
+		{' ', ',', "1 234,5 and 12,25", "1234.5 and 12.25"},
+		{0, ',', "pi is 3,14 and 1.234", "pi is 3.14 and 1.234"},
+	}
+	for _, tc := range cases {
+		if got := LocalizedNumbers(tc.group, tc.decimal)(tc.got); got != tc.want {
+			t.Errorf("LocalizedNumbers(%q, %q)(%q) = %q, want %q", tc.group, tc.decimal, tc.got, got, tc.want)
+		}
+	}
+}
+
+func TestLocaleIndependent(t *testing.T) {
+	for _, text := range []string{"3.14 and 1,5", "1,234", "v1.2.3", "at 12:00"} {
+		if err := LocaleIndependent.Validate(text); err != nil {
+			t.Errorf("Validate(%q): unexpected error %v", text, err)
+		}
+	}
+	for _, text := range []string{"1.234,5", "1,234.5", "1.234.567", "1,234,567", "1\u00a0234", "1\u202f234", "1'234"} {
+		if err := LocaleIndependent.Validate(text); err == nil || !strings.Contains(err.Error(), "is formatted for a locale") {
+			t.Errorf("Validate(%q): expected an error, got %v", text, err)
+		}
+	}
+}
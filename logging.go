@@ -0,0 +1,58 @@
+package snap
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// logger is the logger set with [SetLogger].
+var logger atomic.Pointer[slog.Logger]
+
+// SetLogger routes the messages of snap that don't fail tests, like update notices, warnings and
+// hints, to l instead of the log of the test, with the test's name as the "test" attribute, so that
+// large suites can redirect them, like to a file, or filter them by level. Hints, like the one to
+// rerun the tests with SNAP_UPDATE=1, are at the debug level, notices, like updates, at the info
+// level, and warnings and failures to write reports at the warn level. A nil l restores the log
+// of the test.
+//
+// Typically called from TestMain.
+func SetLogger(l *slog.Logger) {
+	logger.Store(l)
+}
+
+// logLevel returns the lowest level of the messages logged to the log of the test, set with
+// SNAP_LOG_LEVEL. All messages are logged by default.
+func logLevel() (slog.Level, error) {
+	value := os.Getenv("SNAP_LOG_LEVEL")
+	if value == "" {
+		return slog.LevelDebug, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("invalid SNAP_LOG_LEVEL %q, expected debug, info, warn or error", value)
+	}
+	return level, nil
+}
+
+// logf logs the message at level to the logger set with [SetLogger], or else to the log of the
+// test, if the level is at least SNAP_LOG_LEVEL.
+func (s *Snapshot) logf(level slog.Level, format string, args ...any) {
+	s.t.Helper()
+	msg := fmt.Sprintf(format, args...)
+	if l := logger.Load(); l != nil {
+		l.Log(context.Background(), level, strings.TrimSuffix(msg, "\n"), "test", s.testName())
+		return
+	}
+	min, err := logLevel()
+	if err != nil {
+		s.t.Errorf("snap: %s", err)
+		return
+	}
+	if level >= min {
+		s.t.Log("snap: " + msg)
+	}
+}
@@ -0,0 +1,50 @@
+package snap
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogLevel(t *testing.T) {
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "want").Diff("got")
+	if !containsLog(ft, "Rerun with SNAP_UPDATE=1") {
+		t.Errorf("want the rerun hint logged by default, got %q", ft.logs)
+	}
+
+	t.Setenv("SNAP_LOG_LEVEL", "info")
+	ft = newFakeT(t)
+	snapNoUpdate(ft, "want").Diff("got")
+	if containsLog(ft, "Rerun with SNAP_UPDATE=1") {
+		t.Errorf("want the rerun hint dropped with SNAP_LOG_LEVEL=info, got %q", ft.logs)
+	}
+	if len(ft.errors) != 1 {
+		t.Errorf("want the mismatch reported, got %q", ft.errors)
+	}
+
+	t.Setenv("SNAP_LOG_LEVEL", "loud")
+	ft = newFakeT(t)
+	snapNoUpdate(ft, "want").Diff("got")
+	if !strings.Contains(strings.Join(ft.errors, "\n"), `invalid SNAP_LOG_LEVEL "loud"`) {
+		t.Errorf("want an invalid SNAP_LOG_LEVEL error, got %q", ft.errors)
+	}
+}
+
+func TestSetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { SetLogger(nil) })
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "want").Diff("got")
+	if len(ft.logs) != 0 {
+		t.Errorf("want nothing logged to the test, got %q", ft.logs)
+	}
+	got := buf.String()
+	want := `level=DEBUG msg="Rerun with SNAP_UPDATE=1 environmental variable to update the snapshot." test=` + t.Name()
+	if !strings.Contains(got, want) {
+		t.Errorf("want log containing %q, got %q", want, got)
+	}
+}
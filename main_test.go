@@ -0,0 +1,20 @@
+package snap
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the undo journals of the snapshots updated by the tests out of the build cache.
+	dir, err := os.MkdirTemp("", "snap-undo-*")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Setenv("SNAP_UNDO_DIR", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -0,0 +1,184 @@
+package snap
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// splitAtMarkers splits got around the markers of snapshot, like <snap:ignore>: the parts of
+// snapshot between markers must appear in got as is, and like with [matchIgnored] each marker
+// matches a non-empty part of a single line, or of several lines for <snap:ignore-lines>, of the
+// shape required by typed markers like <snap:uuid>. It returns the parts of got matched by the
+// markers, preferring the shortest ones from the first marker on, or the longest ones if longest
+// is set.
+func splitAtMarkers(got string, snapshot string, longest bool) (matched []string, ok bool) {
+	specs, err := markerSpecs(snapshot)
+	if err != nil {
+		return nil, false
+	}
+	literals := matchingMarker.Split(snapshot, -1)
+	rest, ok := strings.CutPrefix(got, literals[0])
+	if !ok {
+		return nil, false
+	}
+	if len(literals) == 1 {
+		return nil, rest == ""
+	}
+
+	// failed records the markers and positions in got from which no split was found, so that each
+	// is only searched once.
+	failed := make(map[[2]int]bool)
+	var split func(marker int, pos int) bool
+	split = func(marker int, pos int) bool {
+		if failed[[2]int{marker, pos}] {
+			return false
+		}
+		spec := specs[marker]
+		end := len(got)
+		if i := strings.IndexByte(got[pos:], '\n'); i >= 0 && !spec.multiline {
+			end = pos + i
+		}
+		next := literals[marker+1]
+		last := marker+1 == len(literals)-1
+		for n := 1; n <= end-pos; n++ {
+			e := pos + n
+			if longest {
+				e = end + 1 - n
+			}
+			// Don't split runes between two markers in a row.
+			if e < len(got) && !utf8.RuneStart(got[e]) {
+				continue
+			}
+			if !strings.HasPrefix(got[e:], next) || (last && e+len(next) != len(got)) {
+				continue
+			}
+			if spec.accept != nil && !spec.accept(got[pos:e]) {
+				continue
+			}
+			if last || split(marker+1, e+len(next)) {
+				matched = append(matched, got[pos:e])
+				return true
+			}
+		}
+		failed[[2]int{marker, pos}] = true
+		return false
+	}
+	if !split(0, len(got)-len(rest)) {
+		return nil, false
+	}
+	// The parts were appended from the last marker.
+	slices.Reverse(matched)
+	return matched, true
+}
+
+// validateMarkers reports the markers of snapshot that can't match, like two <snap:ignore> markers
+// in a row, whose text can't be told apart. <snap:ignore> isn't allowed as a prefix or suffix, as
+// that makes it easy to miss leading or trailing data; the other markers check what they match.
+func validateMarkers(snapshot string) error {
+	if strings.HasPrefix(snapshot, ignoreFmt) || strings.HasSuffix(snapshot, ignoreFmt) {
+		return fmt.Errorf("%s is not allowed as a prefix or suffix", ignoreFmt)
+	}
+	if strings.Contains(snapshot, ignoreFmt+ignoreFmt) {
+		return fmt.Errorf("%s is not allowed twice in a row", ignoreFmt)
+	}
+	return nil
+}
+
+type matchMode int
+
+const (
+	matchLegacy matchMode = iota
+	matchStrict
+)
+
+// parseMatchMode parses the value of the SNAP_MATCH environment variable.
+func parseMatchMode(s string) (matchMode, error) {
+	switch s {
+	case "", "legacy":
+		return matchLegacy, nil
+	case "strict":
+		return matchStrict, nil
+	default:
+		return matchLegacy, fmt.Errorf("unknown SNAP_MATCH %q, expected legacy or strict", s)
+	}
+}
+
+// matchSegments is the strict matching of [matchMarkers]. The whole split of got around the markers
+// is verified at once, so a marker can match past an occurrence of the text following it when the
+// rest of got requires it.
+func matchSegments(got string, snapshot string) (matched []string, ok bool) {
+	return splitAtMarkers(got, snapshot, false)
+}
+
+// equal reports whether got matches want, using the matching selected by SNAP_MATCH. Until strict
+// matching becomes the default, it logs the snapshots that match differently with it.
+func (s *Snapshot) equal(got string, want string) bool {
+	s.t.Helper()
+	value, set := os.LookupEnv("SNAP_MATCH")
+	if _, err := parseMatchMode(value); err != nil {
+		s.t.Errorf("snap: %s", err)
+		return false
+	}
+
+	equal := equalExcludingIgnored(got, want)
+	if set || !matchingMarker.MatchString(want) {
+		return equal
+	}
+	_, strict := matchSegments(got, want)
+	if _, _, ambiguous := ambiguousMatch(got, want); ambiguous {
+		strict = false
+	}
+	if strict != equal {
+		s.logf(slog.LevelWarn, "Snapshot at %s matches differently with SNAP_MATCH=strict, which will become the default. "+
+			"Set SNAP_MATCH=legacy to keep the current behavior.", s.findLiteral())
+	}
+	return equal
+}
+
+// countMarkers reports whether ambiguous matches fail, because SNAP_COUNT_MARKERS is set or
+// SNAP_MATCH is strict.
+func countMarkers() bool {
+	if _, ok := os.LookupEnv("SNAP_COUNT_MARKERS"); ok {
+		return true
+	}
+	mode, _ := parseMatchMode(os.Getenv("SNAP_MATCH"))
+	return mode == matchStrict
+}
+
+// ambiguousMatch reports whether got can be split around the markers of snapshot in more than one
+// way. This happens when the literal text around the markers repeats in got, like for
+// `(x=<snap:ignore>, y=<snap:ignore>)` and `(x=1, y=2, y=3)`: the markers could hide a missing or
+// extra value, so the number of values in got doesn't necessarily match the number of markers. It
+// returns the parts of got matched by the markers in the shortest and longest splits.
+func ambiguousMatch(got string, snapshot string) (shortest []string, longest []string, ambiguous bool) {
+	if !matchingMarker.MatchString(snapshot) {
+		return nil, nil, false
+	}
+
+	shortest, ok := splitAtMarkers(got, snapshot, false)
+	if !ok {
+		return nil, nil, false
+	}
+	longest, _ = splitAtMarkers(got, snapshot, true)
+	return shortest, longest, !slices.Equal(shortest, longest)
+}
+
+// checkMarkerCount fails the test when SNAP_COUNT_MARKERS is set and got matches want in more
+// than one way. got must match want.
+func (s *Snapshot) checkMarkerCount(got string, want string) bool {
+	s.t.Helper()
+	if !countMarkers() {
+		return true
+	}
+	shortest, longest, ambiguous := ambiguousMatch(got, want)
+	if !ambiguous {
+		return true
+	}
+	s.mismatch(want, got, "snap: Snapshot at %s matches in more than one way, its markers may hide missing or extra values:\n\t%q\n\t%q",
+		s.findLiteral(), shortest, longest)
+	return false
+}
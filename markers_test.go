@@ -0,0 +1,70 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAmbiguousMatch(t *testing.T) {
+	cases := []struct {
+		got, snapshot string
+		ambiguous     bool
+	}{
+		{got: "(x=1, y=2)", snapshot: "(x=<snap:ignore>, y=<snap:ignore>)"},
+		{got: "(x=1, y=2, y=3)", snapshot: "(x=<snap:ignore>, y=<snap:ignore>)", ambiguous: true},
+		{got: "id 1.2.3.", snapshot: "id <snap:check:version>."},
+		{got: "a 1 b 2 b\nc", snapshot: "a <snap:ignore> b\nc"},
+		{got: "no markers", snapshot: "no markers"},
+	}
+
+	for _, tc := range cases {
+		if _, _, ambiguous := ambiguousMatch(tc.got, tc.snapshot); ambiguous != tc.ambiguous {
+			t.Errorf("ambiguousMatch(%q, %q): expected %v, got %v", tc.got, tc.snapshot, tc.ambiguous, ambiguous)
+		}
+	}
+}
+
+func TestCountMarkers(t *testing.T) {
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "(x=<snap:ignore>, y=<snap:ignore>)").Diff("(x=1, y=2, y=3)")
+	if len(ft.errors) != 0 {
+		t.Errorf("expected no errors without SNAP_COUNT_MARKERS, got %q", ft.errors)
+	}
+
+	t.Setenv("SNAP_COUNT_MARKERS", "1")
+	snapNoUpdate(ft, "(x=<snap:ignore>, y=<snap:ignore>)").Diff("(x=1, y=2, y=3)")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "matches in more than one way") {
+		t.Errorf("expected an ambiguous match error, got %q", ft.errors)
+	}
+}
+
+func TestStrictMatching(t *testing.T) {
+	// The legacy matching cuts "a-b-b" at the first "b", and doesn't find the rest.
+	ft := newFakeT(t)
+	if snapNoUpdate(ft, "a<snap:ignore>b").equal("a-b-b", "a<snap:ignore>b") {
+		t.Errorf("expected legacy matching to fail")
+	}
+	if !containsLog(ft, "matches differently with SNAP_MATCH=strict") {
+		t.Errorf("expected a deprecation log, got %q", ft.logs)
+	}
+
+	t.Setenv("SNAP_MATCH", "strict")
+	ft = newFakeT(t)
+	snapTB(ft, "a<snap:ignore>b").Diff("a-b-b")
+	snapNoUpdate(ft, "(x=<snap:ignore>, y=<snap:ignore>)").Diff("(x=1, y=2, y=3)")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "matches in more than one way") {
+		t.Errorf("expected only an ambiguous match error, got %q", ft.errors)
+	}
+	if len(ft.logs) != 0 {
+		t.Errorf("expected no deprecation logs with SNAP_MATCH set, got %q", ft.logs)
+	}
+}
+
+func TestMatchModeInvalid(t *testing.T) {
+	t.Setenv("SNAP_MATCH", "fuzzy")
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "a").Diff("a")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `unknown SNAP_MATCH "fuzzy"`) {
+		t.Errorf("expected an unknown SNAP_MATCH error, got %q", ft.errors)
+	}
+}
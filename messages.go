@@ -0,0 +1,141 @@
+package snap
+
+import (
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// MessageData is the data of the templates set with [SetFailureTemplate] and [SetHintTemplate].
+type MessageData struct {
+	Test     string // Name of the test, as reported by [testing.TB.Name].
+	Location string // Position of the snapshot, like "pkg/x_test.go:12:3", relative to the module root.
+	// Message is the message snap reports without a template, like "snap: Snapshot at
+	// pkg/x_test.go:12:3 differs, ...".
+	Message string
+	// Diff is the human readable diff between the snapshot and the value, or the chunks that
+	// differ for large values. It's empty for hints.
+	Diff string
+	// UpdateEnv is the environment variable updating the snapshot, "SNAP_UPDATE=1".
+	UpdateEnv string
+	// Rerun is the command rerunning the test and updating its snapshots, like
+	// "SNAP_UPDATE=1 go test -run '^TestX$' ./pkg".
+	Rerun string
+	// Owner of the snapshot, set with [Snapshot.Owner].
+	Owner string
+}
+
+var (
+	templatesMu     sync.Mutex
+	failureTemplate *template.Template
+	hintTemplate    *template.Template
+)
+
+// SetFailureTemplate replaces the messages reporting mismatching snapshots, including failed
+// checks and schemas, with the output of tmpl executed with a [MessageData], so that
+// organizations can add links to their documentation to the failures:
+//
+//	snap.SetFailureTemplate(template.Must(template.New("failure").Parse(
+//		"{{.Message}}\nSee https://wiki.acme.com/snapshots to update snapshots at ACME.")))
+//
+// When tmpl fails, the message is reported with the error. A nil tmpl restores the messages.
+//
+// Typically called from TestMain.
+func SetFailureTemplate(tmpl *template.Template) {
+	templatesMu.Lock()
+	defer templatesMu.Unlock()
+	failureTemplate = tmpl
+}
+
+// SetHintTemplate replaces the hint logged after a mismatch to rerun the test with SNAP_UPDATE=1
+// with the output of tmpl executed with a [MessageData], like [SetFailureTemplate]. A nil tmpl
+// restores the hint.
+//
+// Typically called from TestMain.
+func SetHintTemplate(tmpl *template.Template) {
+	templatesMu.Lock()
+	defer templatesMu.Unlock()
+	hintTemplate = tmpl
+}
+
+// failureMessage returns the message reporting that got doesn't match the snapshot text want: the
+// output of the failure template, or else message.
+func (s *Snapshot) failureMessage(want string, got string, message string) string {
+	templatesMu.Lock()
+	tmpl := failureTemplate
+	templatesMu.Unlock()
+	if tmpl == nil {
+		return message
+	}
+	diff := message
+	if !isLarge(want, got) {
+		diff = cmp.Diff(want, got)
+	}
+	out, err := s.executeTemplate(tmpl, message, diff)
+	if err != nil {
+		return message + "\nsnap: Failed to execute the failure template: " + err.Error()
+	}
+	return out
+}
+
+// hint logs the hint to rerun the test with SNAP_UPDATE=1.
+func (s *Snapshot) hint() {
+	s.t.Helper()
+	const message = "Rerun with SNAP_UPDATE=1 environmental variable to update the snapshot."
+	templatesMu.Lock()
+	tmpl := hintTemplate
+	templatesMu.Unlock()
+	if tmpl == nil {
+		s.logf(slog.LevelDebug, message)
+		return
+	}
+	out, err := s.executeTemplate(tmpl, "snap: "+message, "")
+	if err != nil {
+		s.logf(slog.LevelWarn, "Failed to execute the hint template: %s", err)
+		s.logf(slog.LevelDebug, message)
+		return
+	}
+	// The log of the test adds the prefix back.
+	s.logf(slog.LevelDebug, "%s", strings.TrimPrefix(out, "snap: "))
+}
+
+// executeTemplate returns the output of tmpl executed with the data of the snapshot.
+func (s *Snapshot) executeTemplate(tmpl *template.Template, message string, diff string) (string, error) {
+	data := MessageData{
+		Test:      s.t.Name(),
+		Location:  s.findLiteral().String(),
+		Message:   message,
+		Diff:      diff,
+		UpdateEnv: "SNAP_UPDATE=1",
+		Rerun:     s.rerunCommand(),
+		Owner:     s.owner,
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// rerunCommand returns the command rerunning the test with SNAP_UPDATE=1, in the package of the
+// source file of the snapshot.
+func (s *Snapshot) rerunCommand() string {
+	parts := strings.Split(s.t.Name(), "/")
+	for i, p := range parts {
+		parts[i] = "^" + regexp.QuoteMeta(p) + "$"
+	}
+	cmd := "SNAP_UPDATE=1 go test -run '" + strings.Join(parts, "/") + "'"
+	if s.location.file == "" {
+		return cmd
+	}
+	dir := filepath.Dir(relativePath(s.location.file))
+	if !filepath.IsAbs(dir) && dir != "." {
+		dir = "./" + filepath.ToSlash(dir)
+	}
+	return cmd + " " + dir
+}
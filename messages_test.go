@@ -0,0 +1,66 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// setTemplate sets the failure or hint template to text until the test t ends.
+func setTemplate(t *testing.T, set func(*template.Template), text string) {
+	t.Helper()
+	set(template.Must(template.New(t.Name()).Parse(text)))
+	t.Cleanup(func() { set(nil) })
+}
+
+func TestFailureTemplate(t *testing.T) {
+	setTemplate(t, SetFailureTemplate, "{{.Location}} of {{.Test}} differs:\n{{.Diff}}See https://wiki.acme.com/snap, or run: {{.Rerun}}")
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "want").Diff("got")
+	if len(ft.errors) != 1 {
+		t.Fatalf("want one error, got %q", ft.errors)
+	}
+	got := ft.errors[0]
+	for _, want := range []string{
+		"messages_test.go:",
+		" of TestFailureTemplate differs:\n",
+		`"want"`,
+		"See https://wiki.acme.com/snap, or run: SNAP_UPDATE=1 go test -run '^TestFailureTemplate$' .",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want failure containing %q, got %q", want, got)
+		}
+	}
+}
+
+func TestFailureTemplateError(t *testing.T) {
+	setTemplate(t, SetFailureTemplate, "{{.Missing}}")
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "want").Diff("got")
+	if len(ft.errors) != 1 || !strings.HasPrefix(ft.errors[0], "snap: Snapshot at ") ||
+		!strings.Contains(ft.errors[0], "snap: Failed to execute the failure template: ") {
+		t.Errorf("want the default failure with the template error, got %q", ft.errors)
+	}
+}
+
+func TestHintTemplate(t *testing.T) {
+	setTemplate(t, SetHintTemplate, "Update with {{.UpdateEnv}}, see https://wiki.acme.com/snap")
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "want").Diff("got")
+	if !containsLog(ft, "snap: Update with SNAP_UPDATE=1, see https://wiki.acme.com/snap") {
+		t.Errorf("want the hint of the template, got %q", ft.logs)
+	}
+	if containsLog(ft, "Rerun with SNAP_UPDATE=1") {
+		t.Errorf("want the default hint replaced, got %q", ft.logs)
+	}
+}
+
+func TestRerunCommand(t *testing.T) {
+	t.Run("sub test.x", func(t *testing.T) {
+		got := snapNoUpdate(t, "").rerunCommand()
+		want := `SNAP_UPDATE=1 go test -run '^TestRerunCommand$/^sub_test\.x$' .`
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+}
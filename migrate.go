@@ -0,0 +1,77 @@
+package snap
+
+import (
+	"fmt"
+	"sync"
+)
+
+type migration struct {
+	to int
+	fn func(string) string
+}
+
+var (
+	migrationsMu sync.Mutex
+	// migrations maps the version a migration starts from to the migration.
+	migrations = make(map[int]migration)
+)
+
+// Migrate registers fn to migrate snapshots stored in format version from to format version to.
+// Typically called from TestMain or an init function, when a renderer changes its output format.
+//
+// Snapshots declare the version they are stored in with [Snapshot.Version], snapshots without a
+// version are at version 0. Before diffing, the registered migrations are chained to bring the
+// snapshot to the latest version (the highest to of all migrations). A snapshot that matches after
+// migration passes, and with SNAP_UPDATE=1 the migrated text and latest version are written back
+// to the source code.
+//
+// Migrate panics if to is not greater than from, or if a migration from the same version is
+// already registered.
+func Migrate(from int, to int, fn func(string) string) {
+	if to <= from {
+		panic(fmt.Sprintf("snap: migration from version %d to %d must increase the version", from, to))
+	}
+
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+	if _, ok := migrations[from]; ok {
+		panic(fmt.Sprintf("snap: migration from version %d is already registered", from))
+	}
+	migrations[from] = migration{to: to, fn: fn}
+}
+
+// Version declares the format version the snapshot text is stored in. See [Migrate].
+func (s *Snapshot) Version(v int) *Snapshot {
+	c := *s
+	c.version = v
+	c.versioned = true
+	return &c
+}
+
+// latestVersion returns the highest version any registered migration migrates to.
+func latestVersion() int {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+
+	latest := 0
+	for _, m := range migrations {
+		latest = max(latest, m.to)
+	}
+	return latest
+}
+
+// migrate applies the registered migrations to the snapshot text, starting at the version of the
+// snapshot. It reports false if no migration applies.
+func (s *Snapshot) migrate() (text string, version int, ok bool) {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+
+	text, version = s.text, s.version
+	for {
+		m, found := migrations[version]
+		if !found {
+			return text, version, version != s.version
+		}
+		text, version = m.fn(text), m.to
+	}
+}
@@ -0,0 +1,101 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func registerMigration(t *testing.T, from int, to int, fn func(string) string) {
+	t.Helper()
+	Migrate(from, to, fn)
+	t.Cleanup(func() {
+		migrationsMu.Lock()
+		defer migrationsMu.Unlock()
+		delete(migrations, from)
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	registerMigration(t, 0, 1, strings.ToUpper)
+	registerMigration(t, 1, 3, func(s string) string { return s + "!" })
+
+	t.Run("matches after migration", func(t *testing.T) {
+		ft := newFakeT(t)
+		snapNoUpdate(ft, "hello").Diff("HELLO!")
+		if len(ft.errors) != 0 {
+			t.Errorf("expected no errors, got %q", ft.errors)
+		}
+		if !containsLog(ft, "migrating it from version 0 to 3") {
+			t.Errorf("expected migration to be logged, got %q", ft.logs)
+		}
+	})
+
+	t.Run("starts at snapshot version", func(t *testing.T) {
+		ft := newFakeT(t)
+		snapNoUpdate(ft, "hello").Version(1).Diff("hello!")
+		if len(ft.errors) != 0 {
+			t.Errorf("expected no errors, got %q", ft.errors)
+		}
+	})
+
+	t.Run("latest version is not migrated", func(t *testing.T) {
+		ft := newFakeT(t)
+		snapNoUpdate(ft, "hello").Version(3).Diff("hello")
+		if len(ft.errors) != 0 || len(ft.logs) != 0 {
+			t.Errorf("expected no errors or logs, got %q, %q", ft.errors, ft.logs)
+		}
+	})
+
+	t.Run("differs after migration", func(t *testing.T) {
+		ft := newFakeT(t)
+		snapNoUpdate(ft, "hello").Diff("HELLO?")
+		if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `"HELLO!"`) {
+			t.Errorf("expected a diff against the migrated snapshot, got %q", ft.errors)
+		}
+	})
+
+	t.Run("update adds version", func(t *testing.T) {
+		ft := newFakeT(t)
+		s, path := snapInFile(t, ft, `package example
+
+import "github.com/KasonBraley/snap"
+
+func TestExample(t *testing.T) {
+	snap.Snap(t, "hello").Diff("HELLO!")
+}
+`, "hello")
+		s.Diff("HELLO!")
+
+		want := `snap.Snap(t, "HELLO!").Version(3).Diff("HELLO!")`
+		if got := readFile(t, path); !strings.Contains(got, want) {
+			t.Errorf("expected source to contain %s, got:\n%s", want, got)
+		}
+	})
+
+	t.Run("update rewrites version", func(t *testing.T) {
+		ft := newFakeT(t)
+		s, path := snapInFile(t, ft, `package example
+
+import "github.com/KasonBraley/snap"
+
+func TestExample(t *testing.T) {
+	snap.Snap(t, "hello").Version(1).Diff("hello!")
+}
+`, "hello")
+		s.Version(1).Diff("hello!")
+
+		want := `snap.Snap(t, "hello!").Version(3).Diff("hello!")`
+		if got := readFile(t, path); !strings.Contains(got, want) {
+			t.Errorf("expected source to contain %s, got:\n%s", want, got)
+		}
+	})
+}
+
+func TestMigratePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for a migration that doesn't increase the version")
+		}
+	}()
+	Migrate(2, 2, strings.ToUpper)
+}
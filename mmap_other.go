@@ -0,0 +1,21 @@
+//go:build !unix
+
+package snap
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads the first size bytes of the file, as memory mapping isn't supported on this platform.
+func mapFile(f *os.File, size int64) ([]byte, error) {
+	data := make([]byte, size)
+	_, err := io.ReadFull(f, data)
+	return data, err
+}
+
+// isMapped reports whether the file at path was mapped into memory, which it never is on this
+// platform.
+func isMapped(path string) bool {
+	return false
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package snap
+
+import (
+	"os"
+	"sync"
+	"syscall"
+)
+
+var (
+	mappedMu sync.Mutex
+	// mappedFiles holds the paths of the files mapped by [mapFile].
+	mappedFiles = make(map[string]bool)
+)
+
+// mapFile maps the first size bytes of the file into memory, read-only. Its pages are read from
+// the file as they're accessed and can be evicted again, so a large file doesn't take its size in
+// memory. The mapping is never released: the strings pointing into it may outlive the snapshot.
+// Rewriting the file must replace it with a new one rather than change it, so that the mapping
+// keeps its content: [writeFile] never writes a mapped file in place.
+func mapFile(f *os.File, size int64) ([]byte, error) {
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err == nil {
+		mappedMu.Lock()
+		mappedFiles[f.Name()] = true
+		mappedMu.Unlock()
+	}
+	return data, err
+}
+
+// isMapped reports whether the file at path was mapped by [mapFile]. Truncating it would make
+// reading the strings pointing into the mapping fault.
+func isMapped(path string) bool {
+	mappedMu.Lock()
+	defer mappedMu.Unlock()
+	return mappedFiles[path]
+}
@@ -0,0 +1,93 @@
+package snap
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Normalizer rewrites a value before it is compared with the snapshot, removing the parts of it
+// that are not part of the contract. The normalized value is also what updates write to the
+// snapshot.
+type Normalizer func(got string) string
+
+// Normalize adds normalizers to the snapshot, applied to the value in order before comparing it.
+//
+//	snap.Snap(t, want).Normalize(snap.DropLines(regexp.MustCompile(`^DEBUG `))).Diff(output)
+func (s *Snapshot) Normalize(normalizers ...Normalizer) *Snapshot {
+	c := *s
+	c.normalizers = append(s.normalizers[:len(s.normalizers):len(s.normalizers)], normalizers...)
+	return &c
+}
+
+// normalize applies the normalizers of the snapshot to got.
+func (s *Snapshot) normalize(got string) string {
+	for _, n := range s.normalizers {
+		got = n(got)
+	}
+	return got
+}
+
+// KeepLines returns a [Normalizer] keeping only the lines of the value matching re.
+func KeepLines(re *regexp.Regexp) Normalizer {
+	return func(got string) string {
+		return filterLines(got, re.MatchString)
+	}
+}
+
+// DropLines returns a [Normalizer] removing the lines of the value matching re, like progress
+// output or debug logs interleaved with the output under test.
+func DropLines(re *regexp.Regexp) Normalizer {
+	return func(got string) string {
+		return filterLines(got, func(line string) bool { return !re.MatchString(line) })
+	}
+}
+
+// CollapseRepeatedLines is a [Normalizer] collapsing runs of identical lines into one line
+// followed by the number of repeats, like `retrying (x3)`, to keep the content of retry loops and
+// polling logs short in the snapshot. Blank lines are left alone. The number of repeats is part of
+// the value, so a snapshot of a count that varies must ignore it, like `retrying (x<snap:ignore>)`.
+func CollapseRepeatedLines(got string) string {
+	var sb strings.Builder
+	lines := strings.SplitAfter(got, "\n")
+	for i := 0; i < len(lines); {
+		line, ending := cutLineEnding(lines[i])
+		n := 1
+		for ; line != "" && i+n < len(lines) && lines[i+n] != ""; n++ {
+			next, nextEnding := cutLineEnding(lines[i+n])
+			if next != line {
+				break
+			}
+			ending = nextEnding
+		}
+		sb.WriteString(line)
+		if n > 1 {
+			fmt.Fprintf(&sb, " (x%d)", n)
+		}
+		sb.WriteString(ending)
+		i += n
+	}
+	return sb.String()
+}
+
+// cutLineEnding splits line into its content and its line ending.
+func cutLineEnding(line string) (content string, ending string) {
+	content = strings.TrimSuffix(line, "\n")
+	content = strings.TrimSuffix(content, "\r")
+	return content, line[len(content):]
+}
+
+// filterLines keeps the lines of text for which keep returns true. The lines are passed to keep
+// without their line ending.
+func filterLines(text string, keep func(line string) bool) string {
+	var sb strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line == "" {
+			continue
+		}
+		if content, _ := cutLineEnding(line); keep(content) {
+			sb.WriteString(line)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,48 @@
+package snap
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFilterLines(t *testing.T) {
+	output := "start\nDEBUG retrying\r\nprogress 50%\ndone"
+
+	Snap(t, "start\ndone").Normalize(DropLines(regexp.MustCompile(`^(DEBUG|progress) `))).Diff(output)
+	Snap(t, "DEBUG retrying\r\n").Normalize(KeepLines(regexp.MustCompile(`retrying$`))).Diff(output)
+	Snap(t, "").Normalize(KeepLines(regexp.MustCompile(`^error`))).Diff(output)
+}
+
+func TestNormalizeOrder(t *testing.T) {
+	lower := regexp.MustCompile(`[a-z]`)
+	mask := func(got string) string { return lower.ReplaceAllString(got, "x") }
+	keep := KeepLines(regexp.MustCompile(`^h`))
+
+	Snap(t, "xxxxx\n").Normalize(keep, mask).Diff("hello\nHELLO\n")
+	Snap(t, "").Normalize(mask).Normalize(keep).Diff("hello\nHELLO\n")
+}
+
+func TestCollapseRepeatedLines(t *testing.T) {
+	cases := []struct {
+		got  string
+		want string
+	}{
+		{got: "", want: ""},
+		{got: "a\n", want: "a\n"},
+		{got: "a\na\na\nb\na\n", want: "a (x3)\nb\na\n"},
+		{got: "a\na", want: "a (x2)"},
+		{got: "a\r\na\r\n\n\n", want: "a (x2)\r\n\n\n"},
+	}
+	for _, tc := range cases {
+		if got := CollapseRepeatedLines(tc.got); got != tc.want {
+			t.Errorf("CollapseRepeatedLines(%q) = %q, want %q", tc.got, got, tc.want)
+		}
+	}
+
+	Snap(t, `connecting
+retrying (x3)
+connected`).Normalize(CollapseRepeatedLines).Diff("connecting\nretrying\nretrying\nretrying\nconnected")
+	Snap(t, `connecting
+retrying (x<snap:ignore>)
+connected`).Normalize(CollapseRepeatedLines).Diff("connecting\nretrying\nretrying\nconnected")
+}
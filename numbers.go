@@ -0,0 +1,131 @@
+package snap
+
+import (
+	"strconv"
+	"strings"
+)
+
+// NumberFormat controls how [Snapshot.DiffJSON] writes numbers.
+type NumberFormat int
+
+const (
+	// NumbersAsEncoded writes numbers as encoding/json does, the default.
+	NumbersAsEncoded NumberFormat = iota
+	// NumbersFixed writes numbers without exponent, like 1000000000000000000000 instead of 1e+21.
+	NumbersFixed
+	// LargeNumbersAsStrings writes the numbers that a float64 can't represent exactly, like
+	// int64 values beyond 2^53 or big.Int values, as strings in fixed form. Other numbers are
+	// written in fixed form.
+	LargeNumbersAsStrings
+	// NumbersAsStrings writes all numbers as strings in fixed form.
+	NumbersAsStrings
+)
+
+// maxSafeInteger is the largest integer that a float64, and so most JSON parsers, represent exactly.
+const maxSafeInteger = 1<<53 - 1
+
+// JSONNumbers sets how [Snapshot.DiffJSON] writes numbers, so that snapshots of big.Int values,
+// large int64 values and floats are stable across marshalers and JSON consumers. Numbers that the
+// value encodes as strings, like big.Float and most decimal types do, are kept as they are.
+//
+//	snap.Snap(t, `{"balance":"12345678901234567890"}`).JSONNumbers(snap.LargeNumbersAsStrings).DiffJSON(account, "")
+func (s *Snapshot) JSONNumbers(format NumberFormat) *Snapshot {
+	c := *s
+	c.numbers = format
+	return &c
+}
+
+// formatJSONNumbers rewrites the numbers in the encoded JSON value data with format.
+func formatJSONNumbers(data string, format NumberFormat) string {
+	if format == NumbersAsEncoded {
+		return data
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(data); {
+		switch c := data[i]; {
+		case c == '"':
+			j := i + 1
+			for j < len(data) && data[j] != '"' {
+				if data[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			sb.WriteString(data[i:min(j+1, len(data))])
+			i = j + 1
+		case c == '-' || c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(data) && strings.IndexByte("+-.0123456789eE", data[j]) >= 0 {
+				j++
+			}
+			sb.WriteString(formatJSONNumber(data[i:j], format))
+			i = j
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// formatJSONNumber formats the JSON number num with format.
+func formatJSONNumber(num string, format NumberFormat) string {
+	fixed := fixedNumber(num)
+	switch format {
+	case LargeNumbersAsStrings:
+		if !exactFloat(fixed) {
+			return strconv.Quote(fixed)
+		}
+	case NumbersAsStrings:
+		return strconv.Quote(fixed)
+	}
+	return fixed
+}
+
+// fixedNumber returns the JSON number num without exponent.
+func fixedNumber(num string) string {
+	mantissa, exp, ok := strings.Cut(strings.ToLower(num), "e")
+	if !ok {
+		return num
+	}
+	shift, err := strconv.Atoi(exp)
+	if err != nil {
+		return num
+	}
+	sign := ""
+	if strings.HasPrefix(mantissa, "-") {
+		sign, mantissa = "-", mantissa[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(mantissa, ".")
+	digits := intPart + fracPart
+	point := len(intPart) + shift
+	if point < 0 {
+		digits = strings.Repeat("0", -point) + digits
+		point = 0
+	}
+	if point > len(digits) {
+		digits += strings.Repeat("0", point-len(digits))
+	}
+
+	intPart = strings.TrimLeft(digits[:point], "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	fracPart = strings.TrimRight(digits[point:], "0")
+	if fracPart == "" {
+		return sign + intPart
+	}
+	return sign + intPart + "." + fracPart
+}
+
+// exactFloat reports whether the number in fixed form num is represented exactly by a float64,
+// or, for fractions, survives a round trip through one.
+func exactFloat(num string) bool {
+	if !strings.Contains(num, ".") {
+		n, err := strconv.ParseInt(num, 10, 64)
+		return err == nil && -maxSafeInteger <= n && n <= maxSafeInteger
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	return err == nil && strconv.FormatFloat(f, 'f', -1, 64) == num
+}
@@ -0,0 +1,46 @@
+package snap
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestFixedNumber(t *testing.T) {
+	cases := map[string]string{
+		"12":       "12",
+		"-1.5":     "-1.5",
+		"1e+21":    "1000000000000000000000",
+		"1.5E-7":   "0.00000015",
+		"-2.5e1":   "-25",
+		"1.25e1":   "12.5",
+		"0.0e0":    "0",
+		"123.4e-1": "12.34",
+	}
+	for num, want := range cases {
+		if got := fixedNumber(num); got != want {
+			t.Errorf("fixedNumber(%q) = %q, want %q", num, got, want)
+		}
+	}
+}
+
+func TestJSONNumbers(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	value := map[string]any{
+		"big":   huge,
+		"float": 1e21,
+		"int":   int64(math.MaxInt64),
+		"small": 42,
+		"tiny":  1.5e-7,
+		"text":  "-1e3 \" 2",
+	}
+
+	Snap(t, `{"big":123456789012345678901234567890,"float":1e+21,"int":9223372036854775807,"small":42,"text":"-1e3 \" 2","tiny":1.5e-7}`).
+		DiffJSON(value, "")
+	Snap(t, `{"big":123456789012345678901234567890,"float":1000000000000000000000,"int":9223372036854775807,"small":42,"text":"-1e3 \" 2","tiny":0.00000015}`).
+		JSONNumbers(NumbersFixed).DiffJSON(value, "")
+	Snap(t, `{"big":"123456789012345678901234567890","float":"1000000000000000000000","int":"9223372036854775807","small":42,"text":"-1e3 \" 2","tiny":0.00000015}`).
+		JSONNumbers(LargeNumbersAsStrings).DiffJSON(value, "")
+	Snap(t, `{"big":"123456789012345678901234567890","float":"1000000000000000000000","int":"9223372036854775807","small":"42","text":"-1e3 \" 2","tiny":"0.00000015"}`).
+		JSONNumbers(NumbersAsStrings).DiffJSON(value, "")
+}
@@ -0,0 +1,134 @@
+package snap
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Overlay makes a [SnapFile] snapshot the file with the patch stored in the file at path applied,
+// so that tests of near-identical large values share one file and only store their differences:
+//
+//	snap.SnapFile(t, "testdata/page.golden").Overlay("testdata/page_admin.patch").Diff(page)
+//
+// The patch is a unified diff, as written by [ComputeDiff] with [ExactLines]. With SNAP_UPDATE=1,
+// only the patch is rewritten, with the difference between the shared file and the value, and it's
+// removed when there is none. A missing patch file is an empty patch.
+func (s *Snapshot) Overlay(path string) *Snapshot {
+	s.t.Helper()
+	c := *s
+	if s.file == "" {
+		c.t.Errorf("snap: Overlay is only supported for snapshots created with SnapFile")
+		return &c
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		c.t.Errorf("snap: %s", err)
+		return &c
+	}
+	c.overlay = abs
+	c.base = s.text
+	if s.missingFile {
+		return &c
+	}
+
+	patch, err := os.ReadFile(abs)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		c.t.Errorf("snap: Failed to read overlay: %s", err)
+		return &c
+	}
+	if c.text, err = applyPatch(s.text, string(patch)); err != nil {
+		c.t.Errorf("snap: Overlay %s doesn't apply to %s: %s, rerun with SNAP_UPDATE=1 to recreate it",
+			relativePath(abs), relativePath(s.file), err)
+		c.text = s.text
+	}
+	return &c
+}
+
+// updateOverlay writes the patch from the shared file of the snapshot to text to its overlay.
+func (s *Snapshot) updateOverlay(text string) {
+	s.t.Helper()
+	patch := ComputeDiff(s.base, text, ExactLines()).Text
+	var err error
+	switch _, statErr := os.Stat(s.overlay); {
+	case patch == "" && statErr == nil:
+		err = os.Remove(s.overlay)
+	case patch == "":
+	case errors.Is(statErr, fs.ErrNotExist):
+		if err = os.MkdirAll(filepath.Dir(s.overlay), 0755); err == nil {
+			err = os.WriteFile(s.overlay, []byte(patch), 0644)
+		}
+	default:
+		err = writeFile(s.overlay, []byte(patch))
+	}
+	if err != nil {
+		s.t.Errorf("snap: Failed to write overlay %q: %s", relativePath(s.overlay), err)
+		return
+	}
+	s.logf(slog.LevelInfo, "Updated %s\n", relativePath(s.overlay))
+	s.emitUpdated()
+}
+
+// applyPatch applies the unified diff patch, without file headers, to text. The unchanged and
+// removed lines of each hunk must be at their position in text.
+func applyPatch(text string, patch string) (string, error) {
+	if patch == "" {
+		return text, nil
+	}
+	lines := strings.Split(text, "\n")
+	patchLines := strings.Split(strings.TrimSuffix(patch, "\n"), "\n")
+
+	var result []string
+	next := 0 // Index of the first line of text not copied to result yet.
+	for i := 0; i < len(patchLines); {
+		start, count, err := hunkHeader(patchLines[i])
+		if err != nil {
+			return "", fmt.Errorf("line %d: %w", i+1, err)
+		}
+		// Like diff -u, an empty old side starts at the line before it.
+		if count > 0 {
+			start--
+		}
+		if start < next || start > len(lines) {
+			return "", fmt.Errorf("line %d: hunk out of order", i+1)
+		}
+		result = append(result, lines[next:start]...)
+		next = start
+		for i++; i < len(patchLines) && !strings.HasPrefix(patchLines[i], "@@"); i++ {
+			l := patchLines[i]
+			if l == "" {
+				return "", fmt.Errorf("line %d: empty line without prefix", i+1)
+			}
+			switch l[0] {
+			case ' ', '-':
+				if next >= len(lines) || lines[next] != l[1:] {
+					return "", fmt.Errorf("line %d: %q not found at line %d", i+1, l[1:], next+1)
+				}
+				if l[0] == ' ' {
+					result = append(result, lines[next])
+				}
+				next++
+			case '+':
+				result = append(result, l[1:])
+			default:
+				return "", fmt.Errorf("line %d: unexpected prefix %q", i+1, l[0])
+			}
+		}
+	}
+	result = append(result, lines[next:]...)
+	return strings.Join(result, "\n"), nil
+}
+
+// hunkHeader returns the line and the number of lines of the old side of the hunk header line,
+// like "@@ -3,4 +3,5 @@".
+func hunkHeader(line string) (start int, count int, err error) {
+	var newStart, newCount int
+	if _, err := fmt.Sscanf(line, "@@ -%d,%d +%d,%d @@", &start, &count, &newStart, &newCount); err != nil {
+		return 0, 0, fmt.Errorf("invalid hunk header %q", line)
+	}
+	return start, count, nil
+}
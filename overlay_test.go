@@ -0,0 +1,67 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	base := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	for _, got := range []string{
+		"a\nB\nc\nd\ne\nf\ng\nh\ni\nJ\n",
+		"new\na\nb\nc\nd\ne\nf\ng\nh\ni\nj\n",
+		"a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n",
+		"c\nd\ne\n",
+		"",
+		base,
+	} {
+		patch := ComputeDiff(base, got, ExactLines()).Text
+		applied, err := applyPatch(base, patch)
+		if err != nil || applied != got {
+			t.Errorf("applyPatch(%q, %q) = %q, %v, want %q", base, patch, applied, err, got)
+		}
+	}
+
+	if _, err := applyPatch("x\ny\n", "@@ -1,1 +1,1 @@\n-a\n+b\n"); err == nil || !strings.Contains(err.Error(), `"a" not found at line 1`) {
+		t.Errorf("expected a patch that doesn't apply to fail, got %v", err)
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "page.golden")
+	patch := filepath.Join(dir, "page_admin.patch")
+	if err := os.WriteFile(base, []byte("<html>\n<h1>Home</h1>\n<p>updated <snap:ignore></p>\n</html>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	admin := "<html>\n<h1>Home</h1>\n<a>Admin</a>\n<p>updated 12:00</p>\n</html>\n"
+
+	// A missing patch is empty.
+	ft := newFakeT(t)
+	SnapFile(ft, base).Overlay(patch).Diff(admin)
+	if len(ft.errors) != 1 {
+		t.Errorf("expected the value to differ from the shared file, got %q", ft.errors)
+	}
+
+	t.Setenv("SNAP_UPDATE", "1")
+	SnapFile(newFakeT(t), base).Overlay(patch).Diff(admin)
+	if got, want := readFile(t, patch), "@@ -1,5 +1,6 @@\n <html>\n <h1>Home</h1>\n+<a>Admin</a>\n <p>updated <snap:ignore></p>\n </html>\n \n"; got != want {
+		t.Errorf("expected the patch to be written keeping the marker, got:\n%s", got)
+	}
+	if !strings.Contains(readFile(t, base), "<p>updated <snap:ignore></p>\n</html>\n") || strings.Contains(readFile(t, base), "Admin") {
+		t.Errorf("expected the shared file not to change, got:\n%s", readFile(t, base))
+	}
+	os.Unsetenv("SNAP_UPDATE")
+
+	SnapFile(t, base).Overlay(patch).Diff(strings.Replace(admin, "12:00", "12:05", 1))
+	SnapFile(t, base).Diff("<html>\n<h1>Home</h1>\n<p>updated 12:05</p>\n</html>\n")
+
+	// Updating to the shared file removes the patch.
+	t.Setenv("SNAP_UPDATE", "1")
+	SnapFile(newFakeT(t), base).Overlay(patch).Diff("<html>\n<h1>Home</h1>\n<p>updated 12:05</p>\n</html>\n")
+	if _, err := os.Stat(patch); !os.IsNotExist(err) {
+		t.Errorf("expected the empty patch to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package snap
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// ParallelDiffs renders the values of independent snapshots concurrently, and compares them with
+// their snapshots in the order they were added. It's created with [Parallel].
+type ParallelDiffs struct {
+	t       testing.TB
+	workers chan struct{} // Holds a token for each running render.
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	pending []*parallelDiff
+}
+
+// parallelDiff is a snapshot added to ParallelDiffs, with its rendered value once it's rendered.
+type parallelDiff struct {
+	s   *Snapshot
+	got string
+	err error
+}
+
+// Parallel creates a ParallelDiffs rendering at most workers values at a time, or GOMAXPROCS if
+// workers is less than 1. It cuts the wall time of tests comparing many large values, like the
+// sections of a transcript, whose rendering is expensive:
+//
+//	p := snap.Parallel(t, 4)
+//	p.DiffFunc(snap.Snap(t, `...`), func() string { return render(dump.Users) })
+//	p.DiffFunc(snap.Snap(t, `...`), func() string { return render(dump.Orders) })
+//	p.Wait()
+//
+// The values are compared once [ParallelDiffs.Wait] is called, or when the test finishes.
+func Parallel(t testing.TB, workers int) *ParallelDiffs {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	p := &ParallelDiffs{t: t, workers: make(chan struct{}, workers)}
+	t.Cleanup(p.Wait)
+	return p
+}
+
+// DiffFunc renders the value of the snapshot s with render, on another goroutine, to compare it with
+// the snapshot like [Snapshot.DiffFunc] does. render must not call methods of the test, like
+// [testing.T.Fatal].
+func (p *ParallelDiffs) DiffFunc(s *Snapshot, render func() string) {
+	d := &parallelDiff{s: s}
+	p.mu.Lock()
+	p.pending = append(p.pending, d)
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.workers <- struct{}{}
+		defer func() { <-p.workers }()
+		defer func() {
+			if r := recover(); r != nil {
+				d.err = fmt.Errorf("Rendering the value at %s panicked: %v", s.findLiteral(), r)
+			}
+		}()
+		d.got, d.err = s.renderValue(func() (string, error) { return render(), nil })
+	}()
+}
+
+// Wait waits for the values added since the last call to be rendered, and compares them with their
+// snapshots, in the order they were added, so that failures are reported deterministically.
+func (p *ParallelDiffs) Wait() {
+	p.t.Helper()
+	p.wg.Wait()
+	p.mu.Lock()
+	pending := p.pending
+	p.pending = nil
+	p.mu.Unlock()
+
+	for _, d := range pending {
+		if d.err != nil {
+			d.s.t.Errorf("snap: %v", d.err)
+			continue
+		}
+		d.s.Diff(d.got)
+	}
+}
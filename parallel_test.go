@@ -0,0 +1,60 @@
+package snap
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallel(t *testing.T) {
+	ft := newFakeT(t)
+	p := Parallel(ft, 2)
+
+	// The first render waits for the second one, which needs them to run concurrently, but the
+	// failures are still reported in the order the snapshots were added.
+	second := make(chan struct{})
+	p.DiffFunc(snapNoUpdate(ft, "a"), func() string {
+		<-second
+		return "first"
+	})
+	p.DiffFunc(snapNoUpdate(ft, "b"), func() string {
+		defer close(second)
+		return "second"
+	})
+	p.DiffFunc(snapNoUpdate(ft, "c"), func() string { return "c" })
+	p.Wait()
+	if len(ft.errors) != 2 || !strings.Contains(ft.errors[0], `"first"`) || !strings.Contains(ft.errors[1], `"second"`) {
+		t.Errorf("expected the errors of the first and second snapshots, in order, got %q", ft.errors)
+	}
+}
+
+func TestParallelWorkers(t *testing.T) {
+	ft := newFakeT(t)
+	p := Parallel(ft, 2)
+	var running, most atomic.Int32
+	for i := 0; i < 6; i++ {
+		p.DiffFunc(snapNoUpdate(ft, "ok"), func() string {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for m := most.Load(); n > m && !most.CompareAndSwap(m, n); m = most.Load() {
+			}
+			time.Sleep(10 * time.Millisecond)
+			return "ok"
+		})
+	}
+	p.Wait()
+	if len(ft.errors) != 0 || most.Load() > 2 {
+		t.Errorf("expected at most 2 renders at a time and no errors, got %d and %q", most.Load(), ft.errors)
+	}
+}
+
+func TestParallelPanic(t *testing.T) {
+	ft := newFakeT(t)
+	p := Parallel(ft, 0)
+	p.DiffFunc(snapNoUpdate(ft, "a"), func() string { panic("boom") })
+	p.Wait()
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "panicked: boom") {
+		t.Errorf("expected an error for the panic, got %q", ft.errors)
+	}
+}
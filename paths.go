@@ -0,0 +1,83 @@
+package snap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// sourcePath returns the path of the source file of the snapshot, to read and update.
+//
+// Tests can run from a sandbox, where the source file is a symlink into a read-only tree or a copy
+// of the file in the workspace. Symlinks are resolved, so updates write to the file they point to
+// instead of replacing the link, and the "from=to" path prefixes of SNAP_PATH_MAP (separated like
+// the entries of PATH) map the sandbox to the writable workspace.
+func (s *Snapshot) sourcePath() (string, error) {
+	pathMap := os.Getenv("SNAP_PATH_MAP")
+	path, err := mapPath(s.location.file, pathMap)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return mapPath(path, pathMap)
+}
+
+// mapPath replaces the prefix of path by the first entry of pathMap matching it.
+func mapPath(path string, pathMap string) (string, error) {
+	if pathMap == "" {
+		return path, nil
+	}
+	for _, entry := range filepath.SplitList(pathMap) {
+		from, to, ok := strings.Cut(entry, "=")
+		if !ok || from == "" || to == "" {
+			return "", fmt.Errorf("invalid SNAP_PATH_MAP entry %q, expected from=to", entry)
+		}
+		from = filepath.Clean(from)
+		if path == from {
+			return filepath.Clean(to), nil
+		}
+		if rest, ok := strings.CutPrefix(path, from+string(filepath.Separator)); ok {
+			return filepath.Join(to, rest), nil
+		}
+	}
+	return path, nil
+}
+
+// moduleRoot returns the directory containing the go.mod file of the tested module, or "" if
+// there is none. It's looked up once from the working directory, which go test sets to the
+// directory of the tested package.
+var moduleRoot = sync.OnceValue(func() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+})
+
+// relativePath returns path relative to the module root with forward slashes, so that logs and
+// machine output don't depend on where the module is checked out. Paths outside the module root
+// are returned as is.
+func relativePath(path string) string {
+	root := moduleRoot()
+	if root == "" || !filepath.IsAbs(path) {
+		return path
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,78 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMapPath(t *testing.T) {
+	sep := string(filepath.ListSeparator)
+	cases := []struct {
+		path, pathMap, want string
+	}{
+		{path: "/sandbox/pkg/a_test.go", pathMap: "", want: "/sandbox/pkg/a_test.go"},
+		{path: "/sandbox/pkg/a_test.go", pathMap: "/sandbox=/home/user/ws", want: "/home/user/ws/pkg/a_test.go"},
+		{path: "/sandbox/pkg/a_test.go", pathMap: "/other=/x" + sep + "/sandbox/=/ws", want: "/ws/pkg/a_test.go"},
+		{path: "/sandboxed/a_test.go", pathMap: "/sandbox=/ws", want: "/sandboxed/a_test.go"},
+	}
+	for _, tc := range cases {
+		tc.path, tc.want = filepath.FromSlash(tc.path), filepath.FromSlash(tc.want)
+		if got, err := mapPath(tc.path, filepath.FromSlash(tc.pathMap)); err != nil || got != tc.want {
+			t.Errorf("mapPath(%q, %q) = %q, %v, want %q", tc.path, tc.pathMap, got, err, tc.want)
+		}
+	}
+
+	if _, err := mapPath("/a", "/a"); err == nil {
+		t.Errorf("expected an error for an entry without =")
+	}
+}
+
+func TestUpdateThroughSymlink(t *testing.T) {
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `old`).Diff(got)\n}\n", "old")
+	link := filepath.Join(t.TempDir(), "link_test.go")
+	if err := os.Symlink(path, link); err != nil {
+		t.Skip("symlinks are not supported:", err)
+	}
+	s.location.file = link
+	s.Diff("new")
+
+	if fi, err := os.Lstat(link); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("expected the symlink to be kept, got %v, %v", fi, err)
+	}
+	if got := readFile(t, path); !strings.Contains(got, "snap.Snap(t, `new`)") {
+		t.Errorf("expected the symlink target to be updated, got:\n%s", got)
+	}
+}
+
+func TestUpdateMappedPath(t *testing.T) {
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `old`).Diff(got)\n}\n", "old")
+	// A file is only updated once per test binary, so every run, like with -count=2, maps its own
+	// sandbox path.
+	sandbox := filepath.Join(t.TempDir(), "sandbox")
+	s.location.file = filepath.Join(sandbox, filepath.Base(path))
+	t.Setenv("SNAP_PATH_MAP", sandbox+"="+filepath.Dir(path))
+	s.Diff("new")
+
+	if got := readFile(t, path); !strings.Contains(got, "snap.Snap(t, `new`)") {
+		t.Errorf("expected the mapped file to be updated, got:\n%s", got)
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	abs, err := filepath.Abs(filepath.Join("internal", "diff", "diff.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := relativePath(abs); got != "internal/diff/diff.go" {
+		t.Errorf("expected a path relative to the module root, got %s", got)
+	}
+
+	outside := filepath.Join(filepath.Dir(moduleRoot()), "other", "a_test.go")
+	if got := relativePath(outside); got != outside {
+		t.Errorf("expected a path outside of the module to be kept, got %s", got)
+	}
+}
@@ -0,0 +1,150 @@
+package snap
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/KasonBraley/snap/internal/changes"
+)
+
+// pendingMode reports whether SNAP_PENDING is set: mismatching snapshots are then recorded as
+// pending changes, reviewed and applied with `snap review`, instead of being updated.
+func pendingMode() bool {
+	_, ok := os.LookupEnv("SNAP_PENDING")
+	return ok
+}
+
+// seedMode reports whether SNAP_SEED is set: the updates of the snapshots not written yet are then
+// recorded as pending changes, for `snap seed` to fill them in.
+func seedMode() bool {
+	_, ok := os.LookupEnv("SNAP_SEED")
+	return ok
+}
+
+// unseeded reports whether the snapshot wasn't written yet: it's empty or reads TODO.
+func (s *Snapshot) unseeded() bool {
+	text := strings.TrimSpace(s.text)
+	return text == "" || text == "TODO"
+}
+
+// writePending records the update of the snapshot to got and version as a pending change.
+func (s *Snapshot) writePending(want string, got string, version int) {
+	s.t.Helper()
+	if s.frozen() {
+		// Accepting the change would update the frozen file.
+		return
+	}
+	c, err := s.pendingChange(want, got, version)
+	if err == nil {
+		err = changes.Write(moduleRoot(), c)
+	}
+	if err != nil {
+		s.t.Errorf("snap: Failed to record pending change: %s", err)
+		return
+	}
+	s.logf(slog.LevelInfo, "Recorded a pending change of %s:%d, review it with `go run github.com/KasonBraley/snap/cmd/snap review`",
+		c.File, c.Line)
+}
+
+// pendingChange returns the pending change updating the snapshot to got and version: the edit of
+// its source file, or of its file for [SnapFile] snapshots.
+func (s *Snapshot) pendingChange(want string, got string, version int) (changes.Change, error) {
+	root := moduleRoot()
+	if root == "" {
+		return changes.Change{}, errors.New("the module root was not found")
+	}
+	text := s.mergeUpdate(got)
+	c := changes.Change{Test: s.testName(), Line: s.location.line, Diff: ComputeDiff(want, got).Text}
+
+	var path string
+	var src, updated []byte
+	if s.file != "" {
+		path, c.Line = s.file, 1
+		updated = []byte(text)
+		if s.snapFormat {
+			content, err := s.snapFileContent(text)
+			if err != nil {
+				return changes.Change{}, err
+			}
+			updated = []byte(content)
+		}
+		if s.overlay != "" && !s.missingFile {
+			path = s.overlay
+			updated = []byte(ComputeDiff(s.base, text, ExactLines()).Text)
+		}
+		var err error
+		if src, err = os.ReadFile(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return changes.Change{}, err
+		}
+	} else {
+		var err error
+		if path, err = s.sourcePath(); err != nil {
+			return changes.Change{}, err
+		}
+		if src, err = os.ReadFile(path); err != nil {
+			return changes.Change{}, err
+		}
+		r, err := s.newRewrite(text, version)
+		if err != nil {
+			return changes.Change{}, err
+		}
+		out, err := r.apply(path, src)
+		if err != nil {
+			return changes.Change{}, err
+		}
+		if updated, err = finalizeSource(path, src, out.src); err != nil {
+			return changes.Change{}, err
+		}
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return changes.Change{}, fmt.Errorf("%s is outside of the module at %s", path, root)
+	}
+	c.File = filepath.ToSlash(rel)
+	if src != nil {
+		c.SHA256 = changes.Hash(src)
+	}
+
+	// Record only the changed bytes, so that the changes of several snapshots of a file apply
+	// together.
+	prefix := 0
+	for prefix < len(src) && prefix < len(updated) && src[prefix] == updated[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(src)-prefix && suffix < len(updated)-prefix && src[len(src)-1-suffix] == updated[len(updated)-1-suffix] {
+		suffix++
+	}
+	c.Start, c.End = prefix, len(src)-suffix
+	c.New = string(updated[prefix : len(updated)-suffix])
+	return c, nil
+}
+
+// clearPending removes the pending change of the snapshot when it matches again.
+func (s *Snapshot) clearPending() {
+	s.t.Helper()
+	root := moduleRoot()
+	path, line := s.file, 1
+	if s.overlay != "" {
+		path = s.overlay
+	} else if s.file == "" {
+		var err error
+		if path, err = s.sourcePath(); err != nil {
+			return
+		}
+		line = s.location.line
+	}
+	rel, err := filepath.Rel(root, path)
+	if root == "" || err != nil || !filepath.IsLocal(rel) {
+		return
+	}
+	if err := changes.Remove(root, filepath.ToSlash(rel), line); err != nil {
+		s.logf(slog.LevelWarn, "Failed to remove pending change: %s", err)
+	}
+}
@@ -0,0 +1,124 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KasonBraley/snap/internal/changes"
+)
+
+func TestPending(t *testing.T) {
+	t.Setenv("SNAP_PENDING", "1")
+	t.Setenv("SNAP_UPDATE", "")
+	os.Unsetenv("SNAP_UPDATE")
+
+	// Pending changes are recorded relative to the module root, so the snapshots must be inside it.
+	root := moduleRoot()
+	dir, err := os.MkdirTemp(filepath.Join(root, "testdata"), "pending")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	src := "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, \"old\").Diff(got)\n}\n"
+	path := filepath.Join(dir, "example_test.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join(dir, "help.golden")
+	rel := func(path string) string {
+		r, _ := filepath.Rel(root, path)
+		return filepath.ToSlash(r)
+	}
+	t.Cleanup(func() {
+		changes.Remove(root, rel(path), 6)
+		changes.Remove(root, rel(golden), 1)
+		os.Remove(changes.Dir(root))
+		os.Remove(filepath.Dir(changes.Dir(root)))
+	})
+
+	ft := newFakeT(t)
+	s := At(ft, path, 6, "old")
+	s.Diff("new")
+	SnapFile(ft, golden).Diff("usage: tool\n")
+	if len(ft.errors) != 2 || !containsLog(ft, "snap: Recorded a pending change of "+rel(path)+":6") {
+		t.Fatalf("expected the mismatches to be recorded as pending changes, got errors %q and logs %q", ft.errors, ft.logs)
+	}
+	if readFile(t, path) != src {
+		t.Errorf("expected the source not to be updated, got:\n%s", readFile(t, path))
+	}
+
+	pending, err := changes.Read(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mine []changes.Change
+	for _, c := range pending {
+		if strings.HasPrefix(c.File, rel(dir)+"/") {
+			mine = append(mine, c)
+		}
+	}
+	if len(mine) != 2 || mine[0].Test != "TestPending" || !strings.Contains(mine[0].Diff, "+new") {
+		t.Fatalf("unexpected pending changes %+v", mine)
+	}
+	if err := changes.Apply(root, mine); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readFile(t, path), strings.Replace(src, `"old"`, `"new"`, 1); got != want {
+		t.Errorf("expected the change to update the source to:\n%s\ngot:\n%s", want, got)
+	}
+	if got := readFile(t, golden); got != "usage: tool\n" {
+		t.Errorf("expected the change to create the golden file, got %q", got)
+	}
+
+	// A matching snapshot removes its pending change.
+	if err := changes.Write(root, changes.Change{File: rel(path), Line: 6}); err != nil {
+		t.Fatal(err)
+	}
+	s = At(t, path, 6, "new")
+	s.Diff("new")
+	pending, err = changes.Read(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range pending {
+		if c.File == rel(path) {
+			t.Errorf("expected the pending change of the matching snapshot to be removed, got %+v", c)
+		}
+	}
+}
+
+func TestSeedMode(t *testing.T) {
+	t.Setenv("SNAP_SEED", "1")
+	t.Setenv("SNAP_UPDATE", "")
+	os.Unsetenv("SNAP_UPDATE")
+
+	root := moduleRoot()
+	dir, err := os.MkdirTemp(filepath.Join(root, "testdata"), "seed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	src := "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, \"TODO\").Diff(got)\n\tsnap.Snap(t, \"old\").Diff(got)\n}\n"
+	path := filepath.Join(dir, "example_test.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rel, _ := filepath.Rel(root, path)
+	rel = filepath.ToSlash(rel)
+	t.Cleanup(func() {
+		changes.Remove(root, rel, 6)
+		changes.Remove(root, rel, 7)
+		os.Remove(changes.Dir(root))
+		os.Remove(filepath.Dir(changes.Dir(root)))
+	})
+
+	ft := newFakeT(t)
+	At(ft, path, 6, "TODO").Diff("new")
+	At(ft, path, 7, "old").Diff("new")
+	if len(ft.errors) != 2 || !containsLog(ft, "snap: Recorded a pending change of "+rel+":6") ||
+		containsLog(ft, "snap: Recorded a pending change of "+rel+":7") {
+		t.Fatalf("expected only the TODO snapshot to be recorded as a pending change, got errors %q and logs %q", ft.errors, ft.logs)
+	}
+}
@@ -0,0 +1,104 @@
+package snap
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// PhaseRecorder records the phases of a test, like its setup, requests and teardown, and compares
+// their order with a snapshot when the test finishes. It's created with [Phases].
+type PhaseRecorder struct {
+	s       *Snapshot
+	mu      sync.Mutex
+	lines   []string
+	running []int // The lines of the phases started and not ended yet.
+	buckets []time.Duration
+}
+
+// Phases creates a PhaseRecorder comparing the phases of the test with the snapshot want, one per
+// line, once the test and the cleanup functions registered after it have finished. It catches the
+// lifecycle steps of complex integration tests that are accidentally removed or reordered:
+//
+//	phases := snap.Phases(t, `setup
+//	request
+//	  retry
+//	teardown`)
+//	end := phases.Start("setup")
+//	srv := startServer(t)
+//	end()
+//	t.Cleanup(phases.Start("teardown"))
+//
+// Phases started while others are running are indented below them. Durations are ignored, unless
+// [PhaseRecorder.DurationBuckets] is set.
+func Phases(t testing.TB, want string) *PhaseRecorder {
+	r := &PhaseRecorder{s: newSnapshot(t, want, 0)}
+	t.Cleanup(func() {
+		t.Helper()
+		r.s.Diff(r.String())
+	})
+	return r
+}
+
+// DurationBuckets adds the duration of each phase to its line, as the smallest of bounds it's
+// shorter than, like "request (<100ms)", or as ">=" the largest one, like "setup (>=1s)". Coarse
+// bounds keep the snapshot stable while catching a phase that got much slower.
+func (r *PhaseRecorder) DurationBuckets(bounds ...time.Duration) *PhaseRecorder {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buckets = append([]time.Duration(nil), bounds...)
+	sort.Slice(r.buckets, func(i, j int) bool { return r.buckets[i] < r.buckets[j] })
+	return r
+}
+
+// Start records the start of the phase name, and returns the function recording its end. Phases
+// that don't end before the snapshot is compared are marked as not ended.
+func (r *PhaseRecorder) Start(name string) (end func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	line := len(r.lines)
+	r.lines = append(r.lines, strings.Repeat("  ", len(r.running))+name)
+	r.running = append(r.running, line)
+	start := time.Now()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			elapsed := time.Since(start)
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			for i, l := range r.running {
+				if l == line {
+					r.running = append(r.running[:i], r.running[i+1:]...)
+					break
+				}
+			}
+			if len(r.buckets) > 0 {
+				r.lines[line] += " (" + r.bucket(elapsed) + ")"
+			}
+		})
+	}
+}
+
+// bucket returns the duration bucket of elapsed.
+func (r *PhaseRecorder) bucket(elapsed time.Duration) string {
+	for _, bound := range r.buckets {
+		if elapsed < bound {
+			return "<" + bound.String()
+		}
+	}
+	return ">=" + r.buckets[len(r.buckets)-1].String()
+}
+
+// String returns the recorded phases, one per line.
+func (r *PhaseRecorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lines := append([]string(nil), r.lines...)
+	for _, line := range r.running {
+		lines[line] += " (not ended)"
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,56 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPhases(t *testing.T) {
+	t.Run("order", func(t *testing.T) {
+		phases := Phases(t, `setup
+  migrate
+request
+teardown`)
+		end := phases.Start("setup")
+		phases.Start("migrate")()
+		end()
+		phases.Start("request")()
+		t.Cleanup(phases.Start("teardown"))
+	})
+
+	var ft *fakeT
+	t.Run("mismatch", func(t *testing.T) {
+		ft = newFakeT(t)
+		phases := Phases(ft, "")
+		phases.s = snapNoUpdate(ft, "setup\nrequest\nteardown")
+		phases.Start("setup")()
+		phases.Start("request")
+	})
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "request (not ended)") || !strings.Contains(ft.errors[0], "teardown") {
+		t.Errorf("expected the missing and unfinished phases in the diff, got %q", ft.errors)
+	}
+}
+
+func TestPhaseDurationBuckets(t *testing.T) {
+	r := &PhaseRecorder{}
+	r.DurationBuckets(time.Second, 10*time.Millisecond)
+	for _, tc := range []struct {
+		elapsed time.Duration
+		want    string
+	}{
+		{time.Millisecond, "<10ms"},
+		{10 * time.Millisecond, "<1s"},
+		{2 * time.Second, ">=1s"},
+	} {
+		if got := r.bucket(tc.elapsed); got != tc.want {
+			t.Errorf("bucket(%s): expected %q, got %q", tc.elapsed, tc.want, got)
+		}
+	}
+
+	r = (&PhaseRecorder{}).DurationBuckets(time.Minute)
+	r.Start("setup")()
+	if got := r.String(); got != "setup (<1m0s)" {
+		t.Errorf("expected the bucket of the phase, got %q", got)
+	}
+}
@@ -0,0 +1,162 @@
+package snap
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// policyFile is the name of the update policy file at the module root. SNAP_POLICY overrides its
+// path.
+const policyFile = ".snappolicy"
+
+// policyRule is a line of the update policy file, like `internal/legacy/** : frozen`.
+type policyRule struct {
+	pattern string
+	frozen  bool
+	line    int
+}
+
+// policy is a parsed update policy file. The rules map the source files of snapshots to whether
+// SNAP_UPDATE may update them, the last rule matching a file wins. Files matching no rule can be
+// updated.
+type policy struct {
+	path  string
+	rules []policyRule
+}
+
+var (
+	policiesMu sync.Mutex
+	// policies caches the parsed policies by path.
+	policies = make(map[string]*policy)
+)
+
+// loadPolicy returns the update policy of the module, or nil if there is none.
+func loadPolicy() (*policy, error) {
+	p := os.Getenv("SNAP_POLICY")
+	if p == "" {
+		if moduleRoot() == "" {
+			return nil, nil
+		}
+		p = filepath.Join(moduleRoot(), policyFile)
+	}
+
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	if cached, ok := policies[p]; ok {
+		return cached, nil
+	}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) && os.Getenv("SNAP_POLICY") == "" {
+		policies[p] = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parsePolicy(p, b)
+	if err != nil {
+		return nil, err
+	}
+	policies[p] = parsed
+	return parsed, nil
+}
+
+// parsePolicy parses an update policy file. Each line is a pattern of source files relative to the
+// module root, where ** matches any number of directories, and a permission, frozen or update,
+// separated by a colon. Empty lines and lines starting with # are ignored.
+func parsePolicy(filename string, b []byte) (*policy, error) {
+	p := &policy{path: filename}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern, permission, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected pattern : permission, got %q", filename, n, line)
+		}
+		rule := policyRule{pattern: strings.TrimSpace(pattern), line: n}
+		switch strings.TrimSpace(permission) {
+		case "frozen":
+			rule.frozen = true
+		case "update":
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown permission %q, expected frozen or update", filename, n, strings.TrimSpace(permission))
+		}
+		if _, err := path.Match(strings.ReplaceAll(rule.pattern, "**", "*"), ""); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid pattern %q: %s", filename, n, rule.pattern, err)
+		}
+		p.rules = append(p.rules, rule)
+	}
+	return p, scanner.Err()
+}
+
+// frozenBy returns the rule freezing file, a path relative to the module root, if any.
+func (p *policy) frozenBy(file string) (policyRule, bool) {
+	var match *policyRule
+	for i, rule := range p.rules {
+		if matchPattern(strings.Split(rule.pattern, "/"), strings.Split(file, "/")) {
+			match = &p.rules[i]
+		}
+	}
+	if match == nil || !match.frozen {
+		return policyRule{}, false
+	}
+	return *match, true
+}
+
+// matchPattern reports whether the path elements match the pattern elements, where ** matches any
+// number of elements and the other elements are matched with [path.Match].
+func matchPattern(pattern []string, elems []string) bool {
+	if len(pattern) == 0 {
+		return len(elems) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(elems); i++ {
+			if matchPattern(pattern[1:], elems[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(elems) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], elems[0])
+	return ok && matchPattern(pattern[1:], elems[1:])
+}
+
+// frozen reports whether the update policy forbids SNAP_UPDATE to update the snapshot, or to record
+// a pending change or a quick fix of it, logging why once.
+func (s *Snapshot) frozen() bool {
+	s.t.Helper()
+	p, err := loadPolicy()
+	if err != nil {
+		s.t.Errorf("snap: Failed to read the update policy: %s", err)
+		return true
+	}
+	if p == nil {
+		return false
+	}
+	file, err := s.sourcePath()
+	if err != nil {
+		return false
+	}
+	rule, frozen := p.frozenBy(relativePath(file))
+	if frozen && !s.frozenLogged {
+		s.frozenLogged = true
+		s.logf(slog.LevelInfo, "Not updating the snapshot at %s, it is frozen by %s:%d (%s). Use Snapshot.Update to update it anyway.",
+			s.findLiteral(), relativePath(p.path), rule.line, rule.pattern)
+	}
+	return frozen
+}
@@ -0,0 +1,99 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	p, err := parsePolicy(".snappolicy", []byte("# Contracts.\ninternal/legacy/** : frozen\ninternal/legacy/scratch_test.go: update\n\n*.go : frozen\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]bool{
+		"internal/legacy/a_test.go":       true,
+		"internal/legacy/deep/b_test.go":  true,
+		"internal/legacy/scratch_test.go": false,
+		"internal/other/a_test.go":        false,
+		"root_test.go":                    true,
+	}
+	for file, want := range cases {
+		if _, got := p.frozenBy(file); got != want {
+			t.Errorf("frozenBy(%s): expected %v, got %v", file, want, got)
+		}
+	}
+}
+
+func TestPolicyInvalid(t *testing.T) {
+	for _, src := range []string{"internal/**\n", "internal/** : readonly\n", "[ : frozen\n"} {
+		if _, err := parsePolicy(".snappolicy", []byte(src)); err == nil {
+			t.Errorf("expected an error for %q", src)
+		}
+	}
+}
+
+func TestUpdateFrozen(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), ".snappolicy")
+	if err := os.WriteFile(policyPath, []byte("** : frozen\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SNAP_POLICY", policyPath)
+	t.Setenv("SNAP_UPDATE", "1")
+
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `old`).Diff(got)\n}\n", "old")
+	s.updateThis = false
+	s.Diff("new")
+
+	if got := readFile(t, path); !strings.Contains(got, "`old`") {
+		t.Errorf("expected the frozen snapshot not to be updated, got:\n%s", got)
+	}
+	if n := countLogs(ft, "it is frozen by"); n != 1 {
+		t.Errorf("expected one frozen log, got %q", ft.logs)
+	}
+
+	// Update overrides the policy.
+	s.Update().Diff("new")
+	if got := readFile(t, path); !strings.Contains(got, "`new`") {
+		t.Errorf("expected Update to update the frozen snapshot, got:\n%s", got)
+	}
+}
+
+func TestPendingFrozen(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), ".snappolicy")
+	if err := os.WriteFile(policyPath, []byte("** : frozen\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SNAP_POLICY", policyPath)
+	t.Setenv("SNAP_PENDING", "1")
+	t.Setenv("SNAP_FIXES", "1")
+	os.Unsetenv("SNAP_UPDATE")
+
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `old`).Diff(got)\n}\n", "old")
+	s.updateThis = false
+	s.Diff("new")
+
+	if containsLog(ft, "Recorded a pending change") {
+		t.Errorf("expected no pending change for the frozen snapshot, got %q", ft.logs)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), fixesFile)); !os.IsNotExist(err) {
+		t.Errorf("expected no quick fix for the frozen snapshot, got %v", err)
+	}
+	if n := countLogs(ft, "it is frozen by"); n != 1 {
+		t.Errorf("expected one frozen log, got %q", ft.logs)
+	}
+}
+
+func countLogs(ft *fakeT, substr string) int {
+	n := 0
+	for _, l := range ft.logs {
+		if strings.Contains(l, substr) {
+			n++
+		}
+	}
+	return n
+}
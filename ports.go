@@ -0,0 +1,45 @@
+package snap
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// localAddr matches the addresses of local listeners, like the servers of net/http/httptest, with
+// the host as the first submatch and the port as the second.
+var localAddr = regexp.MustCompile(`(\b127\.\d{1,3}\.\d{1,3}\.\d{1,3}|\blocalhost|\b0\.0\.0\.0|\[::1?\]):(\d{1,5})\b`)
+
+// EphemeralPorts is a [Normalizer] replacing the ports of local addresses, like the
+// "127.0.0.1:54321" of an httptest server, which the system picks on every run, by placeholders
+// numbered in order of appearance, like "127.0.0.1:<port1>". The same port always gets the same
+// placeholder within a value, so the snapshot still shows which connections go to the same
+// listener. Addresses on 127.0.0.0/8, localhost, 0.0.0.0, [::1] and [::] are local.
+//
+//	snap.Snap(t, "GET http://127.0.0.1:<port1>/users").Normalize(snap.EphemeralPorts).Diff(log)
+func EphemeralPorts(got string) string {
+	ports := make(map[string]int)
+	return localAddr.ReplaceAllStringFunc(got, func(addr string) string {
+		m := localAddr.FindStringSubmatch(addr)
+		n, ok := ports[m[2]]
+		if !ok {
+			n = len(ports) + 1
+			ports[m[2]] = n
+		}
+		return fmt.Sprintf("%s:<port%d>", m[1], n)
+	})
+}
+
+// EphemeralAddrs is a [Normalizer] replacing local addresses entirely, like [EphemeralPorts]
+// replaces their ports, by placeholders like "<addr1>", for values whose listeners may be on any
+// local host, like 127.0.0.1 or [::1] depending on the machine.
+func EphemeralAddrs(got string) string {
+	addrs := make(map[string]int)
+	return localAddr.ReplaceAllStringFunc(got, func(addr string) string {
+		n, ok := addrs[addr]
+		if !ok {
+			n = len(addrs) + 1
+			addrs[addr] = n
+		}
+		return fmt.Sprintf("<addr%d>", n)
+	})
+}
@@ -0,0 +1,18 @@
+package snap
+
+import "testing"
+
+func TestEphemeralPorts(t *testing.T) {
+	got := "GET http://127.0.0.1:54321/users\nproxy [::1]:40001 -> 127.0.0.1:54321\nlistening on localhost:40001, 0.0.0.0:8080\n" +
+		"at 12:30:05, example.com:443, 10.0.0.1:5432"
+
+	Snap(t, `GET http://127.0.0.1:<port1>/users
+proxy [::1]:<port2> -> 127.0.0.1:<port1>
+listening on localhost:<port2>, 0.0.0.0:<port3>
+at 12:30:05, example.com:443, 10.0.0.1:5432`).Normalize(EphemeralPorts).Diff(got)
+
+	Snap(t, `GET http://<addr1>/users
+proxy <addr2> -> <addr1>
+listening on <addr3>, <addr4>
+at 12:30:05, example.com:443, 10.0.0.1:5432`).Normalize(EphemeralAddrs).Diff(got)
+}
@@ -0,0 +1,42 @@
+package snap
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+)
+
+// email matches email addresses.
+var email = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+// Pseudonymize returns a [Normalizer] replacing the matches of re, like names or IDs, with fake
+// values derived from seed and the match, like "user-3f2a9c1b" for the prefix "user". The same
+// match always gets the same fake value, so related snapshots redacted with the same seed stay
+// consistent with each other, unlike with <snap:ignore>. Use one seed for a whole suite:
+//
+//	var redact = snap.Pseudonymize("my-suite", regexp.MustCompile(`\bcus_[0-9A-Za-z]+`), "customer")
+//
+// The fake values are not secret: anyone with the seed can check a guess of the original value.
+func Pseudonymize(seed string, re *regexp.Regexp, prefix string) Normalizer {
+	return func(got string) string {
+		return re.ReplaceAllStringFunc(got, func(match string) string {
+			return prefix + "-" + pseudonym(seed, match)
+		})
+	}
+}
+
+// PseudonymizeEmails returns a [Normalizer] replacing email addresses with fake addresses derived
+// from seed and the address, like "user-3f2a9c1b@example.com", as [Pseudonymize] does.
+func PseudonymizeEmails(seed string) Normalizer {
+	return func(got string) string {
+		return email.ReplaceAllStringFunc(got, func(match string) string {
+			return "user-" + pseudonym(seed, match) + "@example.com"
+		})
+	}
+}
+
+// pseudonym derives a short fake value from seed and value.
+func pseudonym(seed string, value string) string {
+	sum := sha256.Sum256([]byte(seed + "\x00" + value))
+	return hex.EncodeToString(sum[:4])
+}
@@ -0,0 +1,21 @@
+package snap
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestPseudonymize(t *testing.T) {
+	emails := PseudonymizeEmails("suite")
+	customers := Pseudonymize("suite", regexp.MustCompile(`\bcus_[0-9A-Za-z]+`), "customer")
+
+	order := "order by ada@example.org for cus_42"
+	invoice := "invoice for cus_42, cc ada@example.org and bob@example.org"
+	Snap(t, "order by user-d7f27d99@example.com for customer-af3702d0").Normalize(emails, customers).Diff(order)
+	Snap(t, "invoice for customer-af3702d0, cc user-d7f27d99@example.com and user-4e7b4c8e@example.com").
+		Normalize(emails, customers).Diff(invoice)
+
+	if PseudonymizeEmails("other")(order) == emails(order) {
+		t.Error("expected another seed to give other fake values")
+	}
+}
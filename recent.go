@@ -0,0 +1,70 @@
+package snap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	testStartsMu sync.Mutex
+	// testStarts records when each running test created its first snapshot.
+	testStarts = make(map[testing.TB]time.Time)
+)
+
+// testStart returns the start time of the test t, approximated by the creation of its first
+// snapshot.
+func testStart(t testing.TB) time.Time {
+	testStartsMu.Lock()
+	defer testStartsMu.Unlock()
+	if start, ok := testStarts[t]; ok {
+		return start
+	}
+	start := time.Now()
+	testStarts[t] = start
+	t.Cleanup(func() {
+		testStartsMu.Lock()
+		delete(testStarts, t)
+		testStartsMu.Unlock()
+	})
+	return start
+}
+
+// recentLayouts are the timestamp layouts accepted by <snap:recent:duration> markers, besides Unix
+// times in seconds and milliseconds.
+var recentLayouts = []string{time.RFC3339Nano, time.DateTime, time.RFC1123, time.RFC1123Z, time.UnixDate}
+
+// checkRecent returns an error unless text is a timestamp within d of start. Timestamps without a
+// time zone are in local time.
+func checkRecent(text string, d time.Duration, start time.Time) error {
+	ts, ok := parseTimestamp(text)
+	if !ok {
+		return fmt.Errorf("not a timestamp")
+	}
+	if diff := ts.Sub(start); diff < -d || diff > d {
+		return fmt.Errorf("%s from the start of the test, more than %s", diff.Round(time.Millisecond), d)
+	}
+	return nil
+}
+
+// parseTimestamp parses text as a timestamp in one of recentLayouts, or as Unix seconds or
+// milliseconds.
+func parseTimestamp(text string) (time.Time, bool) {
+	for _, layout := range recentLayouts {
+		if ts, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			return ts, true
+		}
+	}
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil && !strings.HasPrefix(text, "-") {
+		switch len(text) {
+		case 10:
+			return time.Unix(n, 0), true
+		case 13:
+			return time.UnixMilli(n), true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,34 @@
+package snap
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecentMarker(t *testing.T) {
+	now := time.Now()
+	Snap(t, "created <snap:recent:1m> by <snap:ignore>.").Diff(fmt.Sprintf("created %s by ci.", now.Format(time.RFC3339)))
+	Snap(t, "at <snap:recent:1m>.").Diff(fmt.Sprintf("at %d.", now.UnixMilli()))
+	Snap(t, "at <snap:recent:1m>.").Diff("at " + now.Format(time.DateTime) + ".")
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "created <snap:recent:5s>.").Diff(fmt.Sprintf("created %s.", now.Add(-time.Hour).Format(time.RFC3339)))
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "snap: Check <snap:recent:5s> failed for ") ||
+		!strings.Contains(ft.errors[0], "from the start of the test, more than 5s") {
+		t.Errorf("expected the stale timestamp to fail, got %q", ft.errors)
+	}
+
+	ft = newFakeT(t)
+	snapNoUpdate(ft, "created <snap:recent:5s>.").Diff("created yesterday.")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `failed for "yesterday": not a timestamp`) {
+		t.Errorf("expected a non-timestamp to fail, got %q", ft.errors)
+	}
+
+	ft = newFakeT(t)
+	snapNoUpdate(ft, "created <snap:recent:5parsecs>.").Diff("created 1700000000.")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "snap: Invalid duration in <snap:recent:5parsecs>") {
+		t.Errorf("expected an invalid duration error, got %q", ft.errors)
+	}
+}
@@ -0,0 +1,30 @@
+package snap
+
+import (
+	"fmt"
+	"os"
+)
+
+// recordOnly reports whether SNAP_RECORD is set: mismatches are then logged instead of failing the
+// tests, and snapshots are never updated. This gives a complete inventory of the mismatches, for
+// example when running the tests against a new backend for the first time.
+func recordOnly() bool {
+	_, ok := os.LookupEnv("SNAP_RECORD")
+	return ok
+}
+
+// mismatch reports that got doesn't match the snapshot text want. It fails the test, unless
+// SNAP_RECORD is set, in which case it's only logged, and calls the functions registered with
+// [OnFailure].
+func (s *Snapshot) mismatch(want string, got string, format string, args ...any) {
+	s.t.Helper()
+	message := s.failureMessage(want, got, fmt.Sprintf(format, args...))
+	s.mismatched = true
+	if recordOnly() {
+		s.t.Log(message)
+	} else {
+		s.t.Error(message)
+	}
+	s.runFailureHooks(want, got, message)
+	s.emitEvent(Event{Kind: EventMismatched, Size: len(got)})
+}
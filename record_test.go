@@ -0,0 +1,25 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecordOnly(t *testing.T) {
+	t.Setenv("SNAP_RECORD", "1")
+	t.Setenv("SNAP_UPDATE", "1")
+
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `old`).Diff(got)\n}\n", "old")
+	s.Diff("new")
+
+	if len(ft.errors) != 0 {
+		t.Errorf("expected no errors, got %q", ft.errors)
+	}
+	if !containsLog(ft, "(-want +got)") {
+		t.Errorf("expected the mismatch to be logged, got %q", ft.logs)
+	}
+	if got := readFile(t, path); !strings.Contains(got, "`old`") {
+		t.Errorf("expected the snapshot not to be updated, got:\n%s", got)
+	}
+}
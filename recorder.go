@@ -0,0 +1,58 @@
+package snap
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Recorder accumulates a numbered transcript of the evolution of a system over a test, to compare
+// the whole transcript with one snapshot, instead of asserting on each step:
+//
+//	var rec snap.Recorder
+//	rec.Step("start", machine.State())
+//	machine.Apply(event)
+//	rec.Step("after event", machine.State())
+//	snap.Snap(t, want).Diff(rec.String())
+//
+// The zero value is an empty Recorder, safe for concurrent use.
+type Recorder struct {
+	mu    sync.Mutex
+	sb    strings.Builder
+	steps int
+}
+
+// Step appends state to the transcript, numbered and labeled with label. Strings and values
+// implementing [fmt.Stringer] are written as they are, other values with the %+v verb of the fmt
+// package. States spanning several lines are written below the label, indented.
+func (r *Recorder) Step(label string, state any) {
+	var text string
+	switch v := state.(type) {
+	case string:
+		text = v
+	case fmt.Stringer:
+		text = v.String()
+	default:
+		text = fmt.Sprintf("%+v", v)
+	}
+	text = strings.TrimSuffix(text, "\n")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.steps++
+	if !strings.Contains(text, "\n") {
+		fmt.Fprintf(&r.sb, "%d. %s: %s\n", r.steps, label, text)
+		return
+	}
+	fmt.Fprintf(&r.sb, "%d. %s:\n", r.steps, label)
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(&r.sb, "    %s\n", line)
+	}
+}
+
+// String returns the transcript.
+func (r *Recorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sb.String()
+}
@@ -0,0 +1,27 @@
+package snap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	type account struct {
+		ID      int
+		Balance int
+	}
+
+	var rec Recorder
+	rec.Step("open", account{ID: 1})
+	rec.Step("deposit", account{ID: 1, Balance: 100})
+	rec.Step("ledger", "deposit 100\nbalance 100\n")
+	rec.Step("timeout", 2*time.Second)
+
+	Snap(t, `1. open: {ID:1 Balance:0}
+2. deposit: {ID:1 Balance:100}
+3. ledger:
+    deposit 100
+    balance 100
+4. timeout: 2s
+`).Diff(rec.String())
+}
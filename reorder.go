@@ -0,0 +1,58 @@
+package snap
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/KasonBraley/snap/internal/diff"
+)
+
+// IgnoreOrder makes the snapshot match values with the same lines in another order, for output
+// whose order is not deterministic, like the iteration of a map. Updates still rewrite the
+// snapshot of a mismatching value in the order of the value.
+func (s *Snapshot) IgnoreOrder() *Snapshot {
+	c := *s
+	c.ignoreOrder = true
+	return &c
+}
+
+// sameLines reports whether want and got have the same lines, in any order.
+func sameLines(want string, got string) bool {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	if len(wantLines) != len(gotLines) {
+		return false
+	}
+	slices.Sort(wantLines)
+	slices.Sort(gotLines)
+	return slices.Equal(wantLines, gotLines)
+}
+
+// movedLines lists the lines that moved from want to got, which have the same lines in another
+// order, like "\t"b" moved from line 2 to 1\n". This is far shorter to read than a diff adding
+// and removing the lines.
+func movedLines(want string, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+
+	// The lines removed from want are the ones inserted elsewhere in got, pair them in order.
+	inserted := make(map[string][]int)
+	edits := diff.Lines(wantLines, gotLines, nil)
+	for _, e := range edits {
+		if e.Op == diff.Insert {
+			inserted[gotLines[e.B]] = append(inserted[gotLines[e.B]], e.B)
+		}
+	}
+	var sb strings.Builder
+	for _, e := range edits {
+		if e.Op != diff.Delete {
+			continue
+		}
+		line := wantLines[e.A]
+		to := inserted[line][0]
+		inserted[line] = inserted[line][1:]
+		fmt.Fprintf(&sb, "\t%q moved from line %d to %d\n", line, e.A+1, to+1)
+	}
+	return sb.String()
+}
@@ -0,0 +1,30 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReordered(t *testing.T) {
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "a\nb\nc\nd").Diff("b\nc\nd\na")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "differs only in the order of its lines:\n\t\"a\" moved from line 1 to 4\n") {
+		t.Errorf("expected a reordering failure, got %q", ft.errors)
+	}
+
+	ft = newFakeT(t)
+	snapNoUpdate(ft, "a\nb").Diff("b\nb")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "(-want +got)") {
+		t.Errorf("expected a diff for other lines, got %q", ft.errors)
+	}
+}
+
+func TestIgnoreOrder(t *testing.T) {
+	Snap(t, "a\nb\nb\nc").IgnoreOrder().Diff("c\nb\na\nb")
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "a\nb").IgnoreOrder().Diff("b\nb")
+	if len(ft.errors) != 1 {
+		t.Errorf("expected other lines to differ, got %q", ft.errors)
+	}
+}
@@ -0,0 +1,83 @@
+package snap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/KasonBraley/snap/internal/diff"
+)
+
+// anchoredLines lists the changed lines of a multi-line snapshot, each prefixed with the file:line
+// of that line inside the snapshot literal, so editors and CI can link straight to it. text is the
+// text of the literal, and want the snapshot as compared, without its critical section tags and
+// soft wraps.
+//
+// It returns an empty string for single-line snapshots, snapshots that aren't raw string literals,
+// whose lines don't correspond to source lines, and snapshots whose lines don't correspond to the
+// lines of the literal, like when a template parameter expands to several lines.
+func anchoredLines(lit literal, text string, want string, got string) string {
+	if !lit.found || !lit.raw || !strings.Contains(want, "\n") {
+		return ""
+	}
+
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	textLines := literalLines(text)
+	if len(textLines) != len(wantLines) {
+		return ""
+	}
+	// The first line of the literal starts on the line of the literal. Lines added to got are
+	// anchored at the snapshot line they're inserted before.
+	line := func(wantLine int) int {
+		return lit.pos.Line + textLines[min(wantLine, len(textLines)-1)]
+	}
+
+	var sb strings.Builder
+	sb.WriteString("snap: Changed snapshot lines:\n")
+	wantLine := 0
+	for _, e := range diff.Lines(wantLines, gotLines, lineMatches) {
+		switch e.Op {
+		case diff.Equal:
+			wantLine++
+		case diff.Delete:
+			fmt.Fprintf(&sb, "\t%s:%d: -%s\n", lit.pos.Filename, line(wantLine), wantLines[e.A])
+			wantLine++
+		case diff.Insert:
+			fmt.Fprintf(&sb, "\t%s:%d: +%s\n", lit.pos.Filename, line(wantLine), gotLines[e.B])
+		}
+	}
+	return sb.String()
+}
+
+// literalLines returns, for each line of the snapshot text as compared, the index of the line of
+// the literal text it starts on: the critical section tags are cut, and the lines soft wrapped
+// with <snap:wrap> markers joined.
+func literalLines(text string) []int {
+	var lines []int
+	wrapped := false
+	for i, line := range strings.Split(text, "\n") {
+		if t := strings.TrimSpace(line); !wrapped && (t == criticalStart || t == criticalEnd) {
+			continue
+		}
+		if !wrapped {
+			lines = append(lines, i)
+		}
+		wrapped = strings.HasSuffix(line, wrapMarker)
+	}
+	return lines
+}
+
+// diffStats summarizes the line changes from want to got in one line, like "2 lines added,
+// 1 removed, 3 ignored regions matched", so large mismatches can be triaged at a glance. Ignored
+// regions are the markers on lines that didn't change.
+func diffStats(want string, got string) string {
+	return ComputeDiff(want, got).Stats.String()
+}
+
+// plural returns singular if n is 1, and plural otherwise.
+func plural(n int, singular string, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
@@ -0,0 +1,53 @@
+package snap
+
+import (
+	"go/token"
+	"testing"
+)
+
+func TestAnchoredLines(t *testing.T) {
+	lit := literal{pos: token.Position{Filename: "x_test.go", Line: 10, Column: 15}, raw: true, found: true}
+
+	got := anchoredLines(lit, "a\nb <snap:ignore> b\nc", "a\nb <snap:ignore> b\nc", "a\nb 1 b\nC\nd")
+	want := "snap: Changed snapshot lines:\n" +
+		"\tx_test.go:12: -c\n" +
+		"\tx_test.go:12: +C\n" +
+		"\tx_test.go:12: +d\n"
+	if got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+
+	if got := anchoredLines(lit, "a", "a", "b"); got != "" {
+		t.Errorf("expected no anchored lines for a single-line snapshot, got %q", got)
+	}
+
+	// The lines of the literal include the critical section tags and soft wrapped lines.
+	text := "<snap:critical>\na\n</snap:critical>\nb<snap:wrap>\nb\nc"
+	got = anchoredLines(lit, text, "a\nbb\nc", "a\nbb\nC")
+	want = "snap: Changed snapshot lines:\n" +
+		"\tx_test.go:15: -c\n" +
+		"\tx_test.go:15: +C\n"
+	if got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+
+	lit.raw = false
+	if got := anchoredLines(lit, "a\nb", "a\nb", "a\nc"); got != "" {
+		t.Errorf("expected no anchored lines for an interpreted string literal, got %q", got)
+	}
+}
+
+func TestDiffStats(t *testing.T) {
+	cases := []struct {
+		want, got, stats string
+	}{
+		{want: "a\nb", got: "a\nc\nd", stats: "2 lines added, 1 removed, 0 ignored regions matched"},
+		{want: "id <snap:ignore>\nt <snap:check:x> <snap:ignore>\nold", got: "id 1\nt 2 3", stats: "0 lines added, 1 removed, 3 ignored regions matched"},
+		{want: "<snap:ignore> a", got: "x b", stats: "1 line added, 1 removed, 0 ignored regions matched"},
+	}
+	for _, tc := range cases {
+		if got := diffStats(tc.want, tc.got); got != tc.stats {
+			t.Errorf("diffStats(%q, %q): expected %q, got %q", tc.want, tc.got, tc.stats, got)
+		}
+	}
+}
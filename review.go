@@ -0,0 +1,84 @@
+package snap
+
+import (
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// Review describes a mismatching snapshot handed to a [Reviewer].
+type Review struct {
+	Test string // Name of the test, as reported by [testing.TB.Name].
+	File string // Source file containing the snapshot, relative to the module root.
+	Line int    // Line of the [Snap] call in File.
+	Want string // The snapshot text.
+	Got  string // The value the snapshot was compared against.
+	Diff string // Human readable diff between Want and Got.
+	// Owner of the snapshot, set with [Snapshot.Owner].
+	Owner string
+	// Notes attached to the snapshot with [Snapshot.Note].
+	Notes []string
+}
+
+// Reviewer is implemented by remote snapshot review services.
+//
+// Review uploads the mismatching snapshot and returns a URL where it can be reviewed.
+type Reviewer interface {
+	Review(r Review) (url string, err error)
+}
+
+// The ReviewerFunc type is an adapter to allow the use of ordinary functions as a [Reviewer].
+type ReviewerFunc func(r Review) (url string, err error)
+
+// Review calls f(r).
+func (f ReviewerFunc) Review(r Review) (string, error) {
+	return f(r)
+}
+
+var (
+	reviewerMu sync.Mutex
+	reviewer   Reviewer
+)
+
+// SetReviewer registers r to receive every mismatching snapshot when running in CI, which is
+// detected by a non-empty CI environment variable. The returned review URL is logged
+// alongside the diff. Passing nil removes the registered Reviewer.
+//
+// Typically called from TestMain.
+func SetReviewer(r Reviewer) {
+	reviewerMu.Lock()
+	defer reviewerMu.Unlock()
+	reviewer = r
+}
+
+func (s *Snapshot) submitReview(want string, got string, diff string) {
+	s.t.Helper()
+
+	reviewerMu.Lock()
+	r := reviewer
+	reviewerMu.Unlock()
+
+	if r == nil {
+		return
+	}
+	if os.Getenv("CI") == "" {
+		return
+	}
+
+	url, err := r.Review(Review{
+		Test: s.testName(),
+		File: relativePath(s.location.file),
+		Line: s.location.line,
+		Want: want,
+		Got:  got,
+		Diff: diff,
+
+		Owner: s.owner,
+		Notes: s.notes,
+	})
+	if err != nil {
+		s.logf(slog.LevelWarn, "Failed to submit snapshot for review: %s", err)
+		return
+	}
+	s.logf(slog.LevelInfo, "Review the snapshot at %s", url)
+}
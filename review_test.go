@@ -0,0 +1,70 @@
+package snap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReviewer(t *testing.T) {
+	var reviews []Review
+	SetReviewer(ReviewerFunc(func(r Review) (string, error) {
+		reviews = append(reviews, r)
+		return "https://review.example.com/1", nil
+	}))
+	t.Cleanup(func() { SetReviewer(nil) })
+
+	t.Run("outside CI", func(t *testing.T) {
+		t.Setenv("CI", "")
+		reviews = nil
+		ft := newFakeT(t)
+		snapNoUpdate(ft, "want").Diff("got")
+		if len(reviews) != 0 {
+			t.Errorf("expected no reviews outside of CI, got %d", len(reviews))
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		t.Setenv("CI", "true")
+		reviews = nil
+		ft := newFakeT(t)
+		snapNoUpdate(ft, "want").Diff("got")
+		if len(reviews) != 1 {
+			t.Fatalf("expected 1 review, got %d", len(reviews))
+		}
+		r := reviews[0]
+		if r.Want != "want" || r.Got != "got" || r.Diff == "" || r.Test != t.Name() {
+			t.Errorf("unexpected review: %+v", r)
+		}
+		if !strings.HasSuffix(r.File, "review_test.go") {
+			t.Errorf("unexpected review file: %q", r.File)
+		}
+		if !containsLog(ft, "https://review.example.com/1") {
+			t.Errorf("review URL not logged: %q", ft.logs)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Setenv("CI", "true")
+		SetReviewer(ReviewerFunc(func(r Review) (string, error) {
+			return "", errors.New("service unavailable")
+		}))
+		ft := newFakeT(t)
+		snapNoUpdate(ft, "want").Diff("got")
+		if len(ft.errors) != 1 {
+			t.Errorf("a failed review must not add errors, got %q", ft.errors)
+		}
+		if !containsLog(ft, "service unavailable") {
+			t.Errorf("review error not logged: %q", ft.logs)
+		}
+	})
+}
+
+func containsLog(ft *fakeT, substr string) bool {
+	for _, l := range ft.logs {
+		if strings.Contains(l, substr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestRewriteGolden applies rewrites to the files in testdata/rewrite/*.input and compares the
+// result with the corresponding .golden file byte-for-byte. The inputs contain comments,
+// directives and formatting gofmt would change, all of which must survive an update untouched.
+func TestRewriteGolden(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string    // Defaults to name.
+		rewrites []rewrite // Applied in order.
+	}{
+		{name: "comments", rewrites: []rewrite{{line: 18, text: "new"}}},
+		{name: "formatting", rewrites: []rewrite{{line: 11, text: "new"}}},
+		{name: "multiline", rewrites: []rewrite{{line: 6, text: "{\n  \"id\": 1,\n  \"time\": \"<snap:ignore>\",\n  \"name\": \"new\"\n}"}}},
+		{name: "escaping", rewrites: []rewrite{{line: 6, text: "say \"hi\"\n\ttab `quoted`"}}},
+		{name: "raw_backquote", rewrites: []rewrite{{line: 6, text: "a `b`\nc"}}},
+		{name: "version", rewrites: []rewrite{{line: 6, text: "new", setVersion: true, addVersion: true, version: 2}}},
+		{name: "sprintf", rewrites: []rewrite{{line: 6, text: "user doug has 4 items", collapseSprintf: true}}},
+		{name: "sprintf_import", rewrites: []rewrite{{line: 11, text: "user doug has 4 items", collapseSprintf: true}}},
+		{name: "concatenation", rewrites: []rewrite{
+			{line: 6, text: "line 1\nline 2\nline three\nline 4"},
+			{line: 10, text: "ac"},
+		}},
+		{name: "fold", rewrites: []rewrite{
+			{line: 6, text: "line 1\nline 2\nline 3", foldLines: 3},
+			{line: 13, text: "line 1\nline 2\nline 3", foldLines: 3},
+			{line: 18, text: "still short", foldLines: 3},
+			{line: 21, text: "line 1\nline 2\nline 3", foldLines: 3},
+		}},
+		{name: "format_func", input: "format", rewrites: []rewrite{{line: 10, text: "new", format: formatDecl}}},
+		{name: "format_file", input: "format", rewrites: []rewrite{{line: 10, text: "new", format: formatFile}}},
+		{name: "call_forms", rewrites: []rewrite{
+			{line: 6, text: "new"},
+			{line: 9, text: "new"},
+			{line: 12, text: "new"},
+			{line: 14, text: "new"},
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			input := tc.input
+			if input == "" {
+				input = tc.name
+			}
+			inputPath := filepath.Join("testdata", "rewrite", input+".input")
+			src, err := os.ReadFile(inputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(filepath.Join("testdata", "rewrite", tc.name+".golden"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := src
+			for _, r := range tc.rewrites {
+				result, err := r.apply(inputPath, got)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = result.src
+			}
+			if diff := cmp.Diff(string(want), string(got)); diff != "" {
+				t.Errorf("rewritten source differs from golden file: (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRewriteFormatResult(t *testing.T) {
+	src := []byte("package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestResult(t *testing.T)   {\n\tsnap.Snap(t,\"a\")\n\tsnap.Snap( t, \"old\").Diff(got)\n}\n")
+	r := rewrite{line: 7, text: "newer", format: formatFile}
+	result, err := r.apply("example_test.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := result.pos.String(), "example_test.go:7:15"; got != want {
+		t.Errorf("expected position %s, got %s", want, got)
+	}
+	if got, want := string(result.src[result.start:result.end]), `"newer"`; got != want {
+		t.Errorf("expected byte range to cover %s, got %s", want, got)
+	}
+}
+
+func TestRewriteCRLF(t *testing.T) {
+	src := []byte("package example\r\n\r\nimport \"github.com/KasonBraley/snap\"\r\n\r\nfunc TestCRLF(t *testing.T) {\r\n\tsnap.Snap(t, `a\r\nb`).Diff(got)\r\n}\r\n")
+	for _, format := range []formatMode{formatNone, formatFile} {
+		r := rewrite{line: 6, text: "a\nb\nc", format: format}
+		result, err := r.apply("example_test.go", src)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := "package example\r\n\r\nimport \"github.com/KasonBraley/snap\"\r\n\r\nfunc TestCRLF(t *testing.T) {\r\n\tsnap.Snap(t, `a\r\nb\r\nc`).Diff(got)\r\n}\r\n"
+		if got := string(result.src); got != want {
+			t.Errorf("format %d: expected %q, got %q", format, want, got)
+		}
+	}
+}
+
+func TestParseFormatMode(t *testing.T) {
+	for s, want := range map[string]formatMode{"": formatNone, "none": formatNone, "func": formatDecl, "file": formatFile} {
+		if got, err := parseFormatMode(s); err != nil || got != want {
+			t.Errorf("parseFormatMode(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	if _, err := parseFormatMode("gofumpt"); err == nil {
+		t.Errorf("expected an error for an unknown mode")
+	}
+}
+
+func TestRewriteResult(t *testing.T) {
+	src := []byte("package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestResult(t *testing.T) {\n\tsnap.Snap(t, \"old\").Diff(got)\n}\n")
+	r := rewrite{line: 6, text: "newer"}
+	result, err := r.apply("example_test.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := result.pos.String(), "example_test.go:6:15"; got != want {
+		t.Errorf("expected position %s, got %s", want, got)
+	}
+	if got, want := string(result.src[result.start:result.end]), `"newer"`; got != want {
+		t.Errorf("expected byte range to cover %s, got %s", want, got)
+	}
+}
+
+func TestRewriteUnsupported(t *testing.T) {
+	src := []byte(`package example
+
+import "github.com/KasonBraley/snap"
+
+func TestUnsupported(t *testing.T) {
+	snap.Snap(t, want).Diff(got)
+	notSnap(t, "old").Diff(got)
+	snap.Snap(t, fmt.Sprintf("a %s", "b")).Diff(got)
+	snap.Snap(t, fmt.Sprintf("a %s", b)).Diff(got)
+	snap.Snap(t, "a"+b).Diff(got)
+}
+`)
+
+	cases := []struct {
+		line            int
+		collapseSprintf bool
+		err             string
+	}{
+		{line: 6, err: "example_test.go:6:15: the snapshot is the identifier want, only string literals can be updated"},
+		{line: 7, err: "example_test.go:7: no Snap call found, the call form is not supported"},
+		{line: 8, err: "example_test.go:8:15: the snapshot is built with fmt.Sprintf, rerun with SNAP_COLLAPSE_SPRINTF=1 to replace it with a string literal"},
+		{line: 9, collapseSprintf: true, err: "example_test.go:9:35: the snapshot is built with fmt.Sprintf from the identifier b, update it by hand"},
+		{line: 10, err: "example_test.go:10:15: the snapshot is a binary expression, only string literals can be updated"},
+	}
+	for _, tc := range cases {
+		r := rewrite{line: tc.line, text: "new", collapseSprintf: tc.collapseSprintf}
+		_, err := r.apply("example_test.go", src)
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("line %d: expected error %q, got %v", tc.line, tc.err, err)
+		}
+	}
+}
+
+func TestRewriteInvalidSource(t *testing.T) {
+	r := rewrite{line: 1, text: "new"}
+	if _, err := r.apply("broken.go", []byte("package example\nfunc {")); err == nil {
+		t.Errorf("expected an error for invalid source")
+	}
+}
@@ -0,0 +1,156 @@
+package snap
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Validator validates the shape of a snapshot, like a schema does.
+type Validator interface {
+	Validate(text string) error
+}
+
+// ValidatorFunc is an adapter to allow the use of ordinary functions as [Validator].
+type ValidatorFunc func(text string) error
+
+// Validate calls f(text).
+func (f ValidatorFunc) Validate(text string) error {
+	return f(text)
+}
+
+// Schema attaches v to the snapshot: both the snapshot and the value must satisfy it, in addition
+// to matching. This catches snapshots edited by hand into an invalid shape. Snapshots containing
+// markers like <snap:ignore> are not validated, only the value is.
+//
+//	snap.Snap(t, `{"id": 1}`).Schema(snap.JSONSchema(`{"required": ["id"]}`)).Diff(got)
+func (s *Snapshot) Schema(v Validator) *Snapshot {
+	c := *s
+	c.schema = v
+	return &c
+}
+
+// validate validates want and got with the schema of the snapshot, reporting an error for each
+// that doesn't satisfy it. It reports whether got satisfies it, since a snapshot can't be updated,
+// recorded as pending or fixed to a value that doesn't.
+func (s *Snapshot) validate(want string, got string) bool {
+	s.t.Helper()
+	if s.schema == nil {
+		return true
+	}
+	if !matchingMarker.MatchString(want) {
+		if err := s.schema.Validate(want); err != nil {
+			s.mismatch(want, got, "snap: Snapshot at %s doesn't satisfy its schema: %s", s.findLiteral(), err)
+		}
+	}
+	if err := s.schema.Validate(got); err != nil {
+		s.mismatch(want, got, "snap: Value doesn't satisfy the schema of the snapshot at %s: %s", s.findLiteral(), err)
+		return false
+	}
+	return true
+}
+
+// JSONSchema returns a [Validator] for JSON documents, supporting this subset of JSON Schema: the
+// type, enum, properties, required, additionalProperties (as a boolean) and items keywords. It
+// panics if schema is not valid JSON.
+func JSONSchema(schema string) Validator {
+	var s jsonSchema
+	if err := json.Unmarshal([]byte(schema), &s); err != nil {
+		panic(fmt.Sprintf("snap: invalid JSON schema: %s", err))
+	}
+	return ValidatorFunc(func(text string) error {
+		var v any
+		if err := json.Unmarshal([]byte(text), &v); err != nil {
+			return fmt.Errorf("not valid JSON: %w", err)
+		}
+		return s.validate("$", v)
+	})
+}
+
+type jsonSchema struct {
+	Type                 jsonTypes              `json:"type"`
+	Enum                 []any                  `json:"enum"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+}
+
+// jsonTypes is the value of the type keyword, a single type or a list of them.
+type jsonTypes []string
+
+func (t *jsonTypes) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*t = jsonTypes{single}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(t))
+}
+
+// validate validates the decoded value v at path.
+func (s *jsonSchema) validate(path string, v any) error {
+	if len(s.Type) > 0 && !s.hasType(v) {
+		return fmt.Errorf("%s is %s, expected %s", path, jsonType(v), strings.Join(s.Type, " or "))
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			found = found || reflect.DeepEqual(e, v)
+		}
+		if !found {
+			return fmt.Errorf("%s is not one of the values of its enum", path)
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s.%s is required", path, name)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			prop, ok := s.Properties[key]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s.%s is not allowed", path, key)
+				}
+				continue
+			}
+			if err := prop.validate(path+"."+key, v[key]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hasType reports whether v is of one of the types of s.
+func (s *jsonSchema) hasType(v any) bool {
+	for _, t := range s.Type {
+		if t == jsonType(v) {
+			return true
+		}
+		if n, ok := v.(float64); ok && t == "integer" && n == math.Trunc(n) {
+			return true
+		}
+	}
+	return false
+}
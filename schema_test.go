@@ -0,0 +1,74 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	schema := JSONSchema(`{
+		"type": "object",
+		"required": ["id"],
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": "integer"},
+			"role": {"enum": ["admin", "user"]},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"note": {"type": ["string", "null"]}
+		}
+	}`)
+
+	cases := []struct {
+		text string
+		err  string
+	}{
+		{text: `{"id": 1, "role": "admin", "tags": ["a"], "note": null}`},
+		{text: `{"role": "user"}`, err: "$.id is required"},
+		{text: `{"id": 1.5}`, err: "$.id is number, expected integer"},
+		{text: `{"id": 1, "role": "root"}`, err: "$.role is not one of the values of its enum"},
+		{text: `{"id": 1, "tags": [1]}`, err: "$.tags[0] is number, expected string"},
+		{text: `{"id": 1, "name": "gopher"}`, err: "$.name is not allowed"},
+		{text: `[]`, err: "$ is array, expected object"},
+		{text: `{`, err: "not valid JSON"},
+	}
+	for _, tc := range cases {
+		err := schema.Validate(tc.text)
+		if tc.err == "" && err != nil {
+			t.Errorf("Validate(%s): unexpected error %v", tc.text, err)
+		}
+		if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("Validate(%s): expected error %q, got %v", tc.text, tc.err, err)
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	schema := JSONSchema(`{"required": ["id"]}`)
+	Snap(t, `{"id": 1}`).Schema(schema).Diff(`{"id": 1}`)
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, `{"name": "gopher"}`).Schema(schema).Diff(`{"name": "gopher"}`)
+	if len(ft.errors) != 2 || !strings.Contains(ft.errors[0], "doesn't satisfy its schema: $.id is required") {
+		t.Errorf("expected the snapshot and the value to fail validation, got %q", ft.errors)
+	}
+
+	// Snapshots with markers are not validated.
+	ft = newFakeT(t)
+	snapNoUpdate(ft, `{"id": <snap:ignore>}`).Schema(schema).Diff(`{"id": 2}`)
+	if len(ft.errors) != 0 {
+		t.Errorf("expected no errors, got %q", ft.errors)
+	}
+}
+
+func TestSchemaInvalidValueNotUpdated(t *testing.T) {
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package x\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestX(t *testing.T) {\n\tsnap.Snap(t, `{\"id\": 1}`)\n}\n", `{"id": 1}`)
+	before := readFile(t, path)
+	s.Schema(JSONSchema(`{"required": ["id"]}`)).Diff(`{"name": "x"}`)
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "Value doesn't satisfy the schema") {
+		t.Errorf("expected the value to fail validation, got %q", ft.errors)
+	}
+	if got := readFile(t, path); got != before {
+		t.Errorf("expected the snapshot not to be updated to an invalid value, got:\n%s", got)
+	}
+}
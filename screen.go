@@ -0,0 +1,149 @@
+package snap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Screen is the view of a terminal UI, as a grid of cells, for [Snapshot.DiffScreen]. Frameworks
+// are adapted by implementing it on their view models or cell buffers, like [CellBuffer] does.
+type Screen interface {
+	// Size returns the number of columns and rows of the screen.
+	Size() (width int, height int)
+	// Cell returns the character at column x of row y, or 0 for an empty cell.
+	Cell(x int, y int) rune
+}
+
+// WidgetScreen is a [Screen] that also reports the widgets laid out on it, which
+// [Snapshot.DiffScreen] lists below the grid.
+type WidgetScreen interface {
+	Screen
+	Widgets() []Widget
+}
+
+// Widget is a named rectangle of a [WidgetScreen], like a list or a status bar.
+type Widget struct {
+	Name          string
+	X, Y          int
+	Width, Height int
+}
+
+// DiffScreen compares the snapshot with the layout of the terminal UI screen, its grid of cells
+// framed by a border, so that the width of the screen and trailing spaces are visible:
+//
+//	snap.Snap(t, `+------------+
+//	|Inbox (2)   |
+//	|> Welcome   |
+//	|  Invoice   |
+//	+------------+
+//	list 0,1 12x2`).DiffScreen(app.View())
+//
+// The widgets of a [WidgetScreen] are listed below the grid with their position and size, by
+// row and then column, so that layout changes show even when the text doesn't.
+// It calls [testing.T.Error] when the snapshot is not equal to the layout.
+func (s *Snapshot) DiffScreen(screen Screen) {
+	s.t.Helper()
+	s.Diff(renderScreen(screen))
+}
+
+// renderScreen returns the layout of screen, as [Snapshot.DiffScreen] compares it.
+func renderScreen(screen Screen) string {
+	width, height := screen.Size()
+	border := "+" + strings.Repeat("-", width) + "+"
+	var sb strings.Builder
+	sb.WriteString(border + "\n")
+	for y := 0; y < height; y++ {
+		sb.WriteString("|")
+		for x := 0; x < width; x++ {
+			r := screen.Cell(x, y)
+			if r == 0 {
+				r = ' '
+			}
+			sb.WriteRune(r)
+		}
+		sb.WriteString("|\n")
+	}
+	sb.WriteString(border)
+
+	if ws, ok := screen.(WidgetScreen); ok {
+		widgets := append([]Widget(nil), ws.Widgets()...)
+		sort.SliceStable(widgets, func(i, j int) bool {
+			a, b := widgets[i], widgets[j]
+			if a.Y != b.Y {
+				return a.Y < b.Y
+			}
+			if a.X != b.X {
+				return a.X < b.X
+			}
+			return a.Name < b.Name
+		})
+		for _, w := range widgets {
+			fmt.Fprintf(&sb, "\n%s %d,%d %dx%d", w.Name, w.X, w.Y, w.Width, w.Height)
+		}
+	}
+	return sb.String()
+}
+
+// CellBuffer is a minimal [WidgetScreen]: a grid of cells that views draw text into, and the
+// reference for adapting the cell buffers of terminal UI frameworks to [Snapshot.DiffScreen].
+//
+//	buf := snap.NewCellBuffer(12, 3)
+//	buf.Draw(snap.Widget{Name: "list", Y: 1, Width: 12, Height: 2}, "> Welcome\n  Invoice")
+//	snap.Snap(t, want).DiffScreen(buf)
+type CellBuffer struct {
+	width, height int
+	cells         []rune
+	widgets       []Widget
+}
+
+// NewCellBuffer returns an empty CellBuffer of width columns and height rows.
+func NewCellBuffer(width int, height int) *CellBuffer {
+	return &CellBuffer{width: width, height: height, cells: make([]rune, width*height)}
+}
+
+// Size returns the number of columns and rows of the buffer.
+func (b *CellBuffer) Size() (width int, height int) {
+	return b.width, b.height
+}
+
+// Cell returns the character at column x of row y, or 0 for an empty cell or a cell outside of the
+// buffer.
+func (b *CellBuffer) Cell(x int, y int) rune {
+	if x < 0 || y < 0 || x >= b.width || y >= b.height {
+		return 0
+	}
+	return b.cells[y*b.width+x]
+}
+
+// SetString writes the lines of text into the buffer from column x of row y, one character per
+// cell, clipped to the buffer.
+func (b *CellBuffer) SetString(x int, y int, text string) {
+	for i, line := range strings.Split(text, "\n") {
+		col := x
+		for _, r := range line {
+			if col >= 0 && col < b.width && y+i >= 0 && y+i < b.height {
+				b.cells[(y+i)*b.width+col] = r
+			}
+			col++
+		}
+	}
+}
+
+// Draw records the widget and writes the lines of text into its rectangle, clipped to it.
+func (b *CellBuffer) Draw(w Widget, text string) {
+	b.widgets = append(b.widgets, w)
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines) && i < w.Height; i++ {
+		line := []rune(lines[i])
+		if len(line) > w.Width {
+			line = line[:w.Width]
+		}
+		b.SetString(w.X, w.Y+i, string(line))
+	}
+}
+
+// Widgets returns the widgets drawn into the buffer, in the order they were drawn.
+func (b *CellBuffer) Widgets() []Widget {
+	return b.widgets
+}
@@ -0,0 +1,28 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffScreen(t *testing.T) {
+	buf := NewCellBuffer(12, 4)
+	buf.Draw(Widget{Name: "status", Y: 3, Width: 12, Height: 1}, "2 unread, 1 flagged")
+	buf.Draw(Widget{Name: "list", Y: 1, Width: 12, Height: 2}, "> Welcome\n  Invoice\n  Hidden")
+	buf.SetString(0, 0, "Inbox (2)")
+
+	Snap(t, `+------------+
+|Inbox (2)   |
+|> Welcome   |
+|  Invoice   |
+|2 unread, 1 |
++------------+
+list 0,1 12x2
+status 0,3 12x1`).DiffScreen(buf)
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "").DiffScreen(NewCellBuffer(2, 1))
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `+--+\n|  |\n+--+`) {
+		t.Errorf("expected the empty screen in the diff, got %q", ft.errors)
+	}
+}
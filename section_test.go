@@ -0,0 +1,16 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffSectionNotFound(t *testing.T) {
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "").DiffSection("a b c", "x", "")
+	snapNoUpdate(ft, "").DiffSection("a b c", "b", "a")
+	if len(ft.errors) != 2 || !strings.Contains(ft.errors[0], `Section start "x" not found`) ||
+		!strings.Contains(ft.errors[1], `Section end "a" not found after "b"`) {
+		t.Errorf("expected errors for the missing start and end, got %q", ft.errors)
+	}
+}
@@ -0,0 +1,141 @@
+package snap
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KasonBraley/snap/internal/source"
+)
+
+// TestSelf updates the out of date snapshots of the synthetic test packages in testdata/selftest
+// by running their tests with SNAP_UPDATE, and checks that they then pass, and that nothing but
+// the snapshots changed.
+func TestSelf(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+
+	dirs, err := filepath.Glob(filepath.Join("testdata", "selftest", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range dirs {
+		dir := dir
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			t.Parallel()
+			selfTest(t, dir)
+		})
+	}
+}
+
+// selfTest runs the self-test of the test package in dir.
+func selfTest(t *testing.T, dir string) {
+	t.Helper()
+	root, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmp := t.TempDir()
+	originals := make(map[string][]byte)
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		originals[filepath.Base(file)] = b
+		if err := os.WriteFile(filepath.Join(tmp, filepath.Base(file)), b, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	goMod := "module example.com/selftest\n\ngo 1.21\n\n" +
+		"require (\n\tgithub.com/KasonBraley/snap v0.0.0\n\tgithub.com/google/go-cmp v0.6.0\n)\n\n" +
+		"replace github.com/KasonBraley/snap => " + root + "\n"
+	goSum, err := os.ReadFile("go.sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "go.mod"), []byte(goMod), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "go.sum"), goSum, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	goTest := func(update bool) (string, error) {
+		cmd := exec.Command("go", "test", "-count=1", "./...")
+		cmd.Dir = tmp
+		// Don't let the environment of this test change the behavior of the self-test.
+		for _, kv := range os.Environ() {
+			if !strings.HasPrefix(kv, "SNAP_") && !strings.HasPrefix(kv, "CI=") {
+				cmd.Env = append(cmd.Env, kv)
+			}
+		}
+		cmd.Env = append(cmd.Env, "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off")
+		if update {
+			cmd.Env = append(cmd.Env, "SNAP_UPDATE=1")
+		}
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+
+	if out, err := goTest(true); err == nil {
+		t.Fatalf("expected the out of date snapshots to fail with SNAP_UPDATE, got:\n%s", out)
+	}
+	if out, err := goTest(false); err != nil {
+		t.Fatalf("expected the updated snapshots to pass, got %s:\n%s", err, out)
+	}
+
+	for name, original := range originals {
+		updated, err := os.ReadFile(filepath.Join(tmp, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(updated, original) {
+			t.Errorf("%s: expected snapshots to be updated", name)
+		}
+		before, after := withoutSnapshots(t, name, original), withoutSnapshots(t, name, updated)
+		if before != after {
+			t.Errorf("%s: expected only snapshots to change, got:\n%s", name, after)
+		}
+	}
+}
+
+// withoutSnapshots returns src with the snapshot arguments of its Snap calls replaced by SNAPSHOT.
+func withoutSnapshots(t *testing.T, filename string, src []byte) string {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := source.NewMatcher(f)
+	var b strings.Builder
+	last := 0
+	ast.Inspect(f, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && m.IsSnapCall(call) {
+			b.Write(src[last:fset.Position(call.Args[1].Pos()).Offset])
+			b.WriteString("SNAPSHOT")
+			last = fset.Position(call.Args[1].End()).Offset
+		}
+		return true
+	})
+	b.Write(src[last:])
+	return b.String()
+}
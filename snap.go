@@ -17,7 +17,7 @@
 // Running that test will fail, printing the diff between the actual result (`4`) and what is specified
 // in the source code:
 //
-//	    snap_test.go:34: snap: Snapshot differs: (-want +got):
+//	    snap_test.go:34: snap: Snapshot at snap_test.go:34:37 differs, 1 line added, 1 removed, 0 ignored regions matched: (-want +got):
 //	          string(
 //	        -       "8",
 //	        +       "4",
@@ -27,7 +27,31 @@
 //
 // Re-running the test with SNAP_UPDATE=1 environmental variable will update the
 // source code in-place to say "4". Alternatively, you can use [Snapshot.Update] to auto-update
-// just a single test.
+// just a single test. Only snapshots written as string literals (or concatenations of them, which
+// are replaced by a single literal) can be updated; a snapshot built with fmt.Sprintf from
+// constant arguments is replaced by a string literal when SNAP_COLLAPSE_SPRINTF=1 is set as well.
+//
+// Updates are written right away, one at a time for each file, and later updates of a file
+// account for the lines moved by the earlier ones, also when parallel tests update snapshots of
+// the same file. Calling [Run] from TestMain instead delays all updates until the tests have
+// finished, which makes them independent of the scheduling of parallel tests.
+//
+// Setting SNAP_BASELINE=/path/to/baseline.jsonl records mismatching snapshots to that file instead
+// of failing the tests. Baselines recorded on two branches can then be compared with
+// `go run github.com/KasonBraley/snap/cmd/snap baseline old.jsonl new.jsonl`, to audit all
+// snapshot changes of a large refactor at once.
+//
+// Setting SNAP_PRUNE=1 when the tests run with [Run] removes the files of the [SnapTree]
+// directories that no test used, after a complete run that passed. Only the files of the top-level
+// tests that used a directory are removed, so that skipped tests keep theirs.
+//
+// Setting SNAP_PENDING=1 records the update of each mismatching snapshot as a pending change in
+// the .snap/pending directory of the module instead. Running
+// `go run github.com/KasonBraley/snap/cmd/snap review` then shows the diff of each change to accept
+// or reject it, and `snap accept-all` and `snap reject-all` apply or discard all of them.
+// Setting SNAP_SEED=1 instead only records the updates of the snapshots not written yet: empty
+// ones, ones reading TODO and missing [SnapFile] files. `snap seed` runs the tests with it to fill
+// in all of them at once, when adopting snapshot tests across an existing codebase.
 //
 // Snapshots can use the `<snap:ignore>` marker to ignore part of input. This is helpful when dealing
 // with values that change between test runs, like timestamps:
@@ -38,6 +62,116 @@
 //		snap.Snap(t, "Unix time is <snap:ignore> ms").Diff(timestampStr)
 //	}
 //
+// A `<snap:check:name>` marker ignores part of the input like `<snap:ignore>`, but the ignored
+// text is still validated by the function registered with [RegisterCheck] under that name. A
+// `<snap:recent:duration>` marker, like `<snap:recent:5s>`, only matches a timestamp within that
+// duration of the start of the test, approximated by its first snapshot, to check that timestamps
+// are fresh rather than ignoring them. Timestamps in RFC 3339, "2006-01-02 15:04:05", RFC 1123 and
+// Unix date formats, and Unix times in seconds or milliseconds are recognized. `<snap:seq>`
+// markers match numbers that must be strictly increasing through the snapshot, like IDs or
+// offsets, keeping their order without fixing their values.
+//
+// Snapshots taken inside testing/synctest bubbles see the fake clock of the bubble, which starts
+// at [SynctestEpoch]. The [SynctestTimes] normalizer rewrites its timestamps as offsets from the
+// epoch, which don't depend on the time zone of the machine and tell them apart from wall-clock
+// timestamps, and `<snap:recent:duration>` markers are checked against the fake clock when the
+// snapshot is created in the bubble.
+//
+// Typed markers only match text of a given shape: `<snap:uuid>` matches a UUID,
+// `<snap:timestamp>` a timestamp in one of the formats above, `<snap:url>` an absolute URL, with a
+// scheme and a host, and `<snap:regexp:pattern>` text matched entirely by the regular expression
+// pattern, which can't contain '>'. Like `<snap:ignore>`, they match part of a single line, while
+// `<snap:ignore-lines>` matches any text spanning one or more lines. Snapshots with typed markers
+// are always matched like with SNAP_MATCH=strict.
+//
+// Lines between `<snap:critical>` and `</snap:critical>` lines form a critical section of the
+// snapshot. When a snapshot has critical sections, only changes in them fail the test, and the
+// changes of the other lines, which give context, are logged as a warning.
+//
+// Snapshots created with [Template] can also use `<snap:param:name>` markers, which are replaced by
+// the values bound with [Snapshot.Bind]. This lets table tests share one snapshot pattern.
+//
+// Snapshots updated by a test binary pass when their tests run again, like with go test -count=2.
+// go test caches passing results by test binary, environment variables and files read by the
+// tests: editing a snapshot rebuilds the binary, and the environment variables that decide whether
+// a snapshot passes are read on every comparison, so a cached pass never hides a mismatch. Use
+// -count=1 to run the tests regardless.
+//
+// The behavior of the package can be adjusted with these environment variables:
+//
+//   - SNAP_UPDATE: update mismatching snapshots in the source code.
+//   - SNAP_BASELINE: record mismatching snapshots to a baseline file instead of failing.
+//   - SNAP_RECORD: log mismatching snapshots, and write the reports configured below, without
+//     failing the tests or updating anything, to take an inventory of all mismatches. Only
+//     configuration errors, like an unknown SNAP_FORMAT, still fail.
+//   - SNAP_COUNT_MARKERS: fail when a value matches a snapshot with markers in more than one way,
+//     like `(x=1, y=2, y=3)` does `(x=<snap:ignore>, y=<snap:ignore>)`, as the markers may hide
+//     missing or extra values.
+//   - SNAP_MATCH: how snapshots with markers are matched. "legacy" (the default) cuts the value at
+//     the first occurrence of the text following each marker. "strict" verifies the whole split of
+//     the value around the markers at once, and requires it to be unambiguous, like with
+//     SNAP_COUNT_MARKERS. Strict matching will become the default in a future release.
+//   - SNAP_COLLAPSE_SPRINTF: replace snapshots built with fmt.Sprintf by string literals on update.
+//   - SNAP_DIFF_TOOL: an external diff tool, like "delta" or "difft", run on the want and got
+//     values of mismatching snapshots. Its output is logged.
+//   - SNAP_HTML_REPORT: a directory to write side-by-side HTML diffs of mismatching snapshots to.
+//   - SNAP_ARTIFACTS_DIR: a directory to write the want.txt, got.txt and diff.patch files of
+//     mismatching snapshots to, for CI systems to upload. Set but empty, the files are written
+//     under the test's temporary directory, and their path is logged.
+//   - SNAP_FOLD_LINES: surround updated snapshots of at least this many lines with //snap:begin
+//     and //snap:end comments, which editors can fold.
+//   - SNAP_WRAP_LINES: wrap the lines of updated snapshots wider than this many columns, like
+//     minified code or tokens, ending each wrapped line with a <snap:wrap> marker. A line ending
+//     with the marker continues on the next line, without a line break, in any snapshot.
+//   - SNAP_PATH_MAP: "from=to" path prefixes, separated like the entries of PATH, mapping the
+//     source files seen by tests running in a sandbox to the writable workspace to update.
+//   - SNAP_POLICY: the update policy file, .snappolicy at the module root by default. Its lines map
+//     source files to whether SNAP_UPDATE may update their snapshots, like
+//     `internal/legacy/** : frozen`, to protect golden contracts from blanket updates. Frozen
+//     snapshots get no pending changes or quick fixes either.
+//   - SNAP_BACKUP: back up source files to file.go.orig before their snapshots are first updated,
+//     and sync the rewritten files to disk.
+//   - SNAP_FORMATTER: a formatter command, like "gofumpt", that rewritten source files are piped
+//     through before they're written. Independently, files formatted with gofmt are never
+//     written unformatted.
+//   - SNAP_MAX_WRITES: the number of files a test binary writes at the same time, 8 by default,
+//     to bound the open files and the load on network file systems of large updates. Updates of
+//     the same source file are always written one at a time, and [Run] writes each file once.
+//   - SNAP_TRACE: trace the work of snap: comparing values, parsing source files and writing
+//     files are regions of the execution trace written by go test -trace, call the functions
+//     registered with [OnSpan], and [Run] prints the time spent in each after the tests.
+//   - SNAP_VERIFY_DETERMINISM: the number of times values are rendered by [Snapshot.DiffJSON],
+//     [Snapshot.DiffValue], [Snapshot.DiffYAML] and [Snapshot.DiffFunc], failing if the renders
+//     differ, to catch nondeterministic serialization before it makes a snapshot flaky.
+//   - SNAP_FLAKES: record whether each snapshot matched, and the commit it ran at, in the
+//     snap-flakes directory of the build cache. `go run github.com/KasonBraley/snap/cmd/snap
+//     flakes` then lists the snapshots that both matched and mismatched at the same commit.
+//   - SNAP_UNDO_DIR: where to record the original content of the source files rewritten by
+//     updates, so that the last run can be rolled back with `go run
+//     github.com/KasonBraley/snap/cmd/snap undo`. The snap-undo directory of the build cache by
+//     default. The records of runs older than a week are removed.
+//   - SNAP_LINT: comma-separated rules keeping snapshots consistent with the style tooling of the
+//     repository. "trailing-space" strips trailing whitespace from the lines of values,
+//     "indent=spaces" or "indent=tabs" converts their indentation, with "tabwidth=N" spaces per
+//     tab (4 by default), before comparing and updating. "max-line=N" logs a warning when an
+//     updated snapshot has lines wider than N columns, counting wide characters like CJK
+//     ideographs and emoji as two. "abs-paths" or "abs-paths=warn" logs a warning when an updated
+//     snapshot contains absolute paths of the machine, in the module or a home directory, which
+//     usually means a scrubber is missing, and "abs-paths=fail" fails the test instead of updating
+//     it.
+//   - SNAP_FIXES: write the updates of mismatching snapshots to the .snap-fixes.json file of
+//     their package, as an LSP WorkspaceEdit of the text edits of their source files, for editor
+//     plugins to apply them without running the tests again. The file is rewritten by each run.
+//   - SNAP_EVENTS: write the lifecycle events of the snapshots, created, matched, mismatched and
+//     updated, as JSON lines to this file, or to the unix socket at the path following "unix:",
+//     for test runners and dashboards. See [Event].
+//   - SNAP_LOG_LEVEL: only log the messages of snap at this level or above, "debug" (the default),
+//     "info", "warn" or "error", like "info" to silence the hints to rerun with SNAP_UPDATE=1.
+//     See [SetLogger] to send them to a [slog.Logger] instead.
+//   - SNAP_FORMAT: what to format after updating a snapshot: "none" (the default) only replaces
+//     the literal, "func" formats the function containing the snapshot, and "file" the whole file.
+//     With "none", the function is still formatted in files formatted with gofmt, to keep them so.
+//
 // Main idea and influence came from these articles:
 //
 //   - https://tigerbeetle.com/blog/2024-05-14-snapshot-testing-for-the-masses
@@ -47,18 +181,14 @@
 package snap
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"go/ast"
-	"go/format"
-	"go/parser"
-	"go/token"
-	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -72,8 +202,39 @@ type Snapshot struct {
 	location            sourceLocation
 	text                string
 	updateThis          bool
-	t                   *testing.T
+	t                   testing.TB
 	foundCallerLocation bool
+	version             int
+	versioned           bool               // Whether version was set with [Snapshot.Version].
+	params              map[string]string  // Parameters bound with [Snapshot.Bind].
+	compatible          CompatibilityCheck // Set by [Snapshot.Compatible].
+	comparer            Comparer           // Set by [Snapshot.WithComparer].
+	cmpOptions          cmp.Options        // Set by [Snapshot.WithCmpOptions].
+	schema              Validator          // Set by [Snapshot.Schema].
+	owner               string             // Set by [Snapshot.Owner].
+	notes               []string           // Added by [Snapshot.Note].
+	seed                any                // Set by [Snapshot.Seed].
+	ignoreOrder         bool               // Set by [Snapshot.IgnoreOrder].
+	normalizers         []Normalizer       // Added by [Snapshot.Normalize].
+	numbers             NumberFormat       // Set by [Snapshot.JSONNumbers].
+	variant             string             // The Go release whose [Snapshot.GoVersion] text is used.
+	isJSON              bool               // Whether the value is JSON rendered by [Snapshot.DiffJSON].
+	base64              bool               // Set by [Snapshot.Base64].
+	decompress          bool               // Set by [Snapshot.Decompress].
+	started             time.Time          // Start of the test, for <snap:recent:duration> markers.
+	allowedLines        int                // Set by [Snapshot.AllowDiffLines].
+	file                string             // The absolute path of the file of a [SnapFile] snapshot.
+	missingFile         bool               // Whether the file of a [SnapFile] snapshot, or its section, doesn't exist.
+	snapFormat          bool               // Whether the file is in the format of snap, see [SnapFile].
+	section             string             // Section of the file of a [FileSection] snapshot.
+	stamp               snapStamp          // Stamp of the .snap file when it was read.
+	wrapped             bool               // Whether the snapshot is created by a helper, see [SnapHelper].
+	overlay             string             // The absolute path of the patch set by [Snapshot.Overlay].
+	base                string             // The text of the file patched by the overlay.
+	candidates          []string           // The snapshots of [AnyOf], any of which the value can match.
+	history             int                // Set by [Snapshot.KeepHistory].
+	mismatched          bool               // Whether a mismatch was reported, for SNAP_FLAKES.
+	frozenLogged        bool               // Whether [Snapshot.frozen] logged why it isn't updated.
 }
 
 // Creates a new Snapshot.
@@ -81,26 +242,80 @@ type Snapshot struct {
 // Set SNAP_UPDATE=1 environment variable or call the [Snapshot.Update] method to automagically update
 // the test value.
 func Snap(t *testing.T, text string) *Snapshot {
-	_, file, line, ok := runtime.Caller(1)
+	return newSnapshot(t, text, 0)
+}
+
+// SnapHelper creates a snapshot from a test helper wrapping [Snap], skip frames below the test, so
+// that the snapshot is located at the call of the helper in the test:
+//
+//	func checkOutput(t *testing.T, got string, want string) {
+//		t.Helper()
+//		snap.SnapHelper(t, want, 1).Diff(got)
+//	}
+//
+// The snapshot is updated in the argument of the helper call that is a string literal equal to the
+// snapshot, which must be the only one. Its [Snapshot.Version] isn't updated. Snap can also be
+// imported under another name, or assigned to a variable, without a helper.
+func SnapHelper(t testing.TB, text string, skip int) *Snapshot {
+	s := newSnapshot(t, text, skip)
+	s.wrapped = skip > 0
+	return s
+}
+
+// At creates a snapshot located at the Snap call on line of file, instead of at its caller, for the
+// snapshots of code that tests compile and run from another location, like packages under
+// testdata copied by analyzer or generator tests: the test running the copied code passes the
+// location of the call in the original file, which is the one updated. It also lets frameworks
+// built on snap, and tests of snapshot updates, choose exactly which literal is rewritten. file is
+// relative to the directory of the package.
+//
+//	snap.At(t, "testdata/src/a/a_test.go", 12, want).Diff(got)
+func At(t testing.TB, file string, line int, text string) *Snapshot {
+	s := newSnapshot(t, text, 0)
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		t.Errorf("snap: %s", err)
+		abs = file
+	}
+	s.location = sourceLocation{file: abs, line: line}
+	s.foundCallerLocation = true
+	return s
+}
+
+// newSnapshot creates a Snapshot located at the caller of its caller, or skip frames above it.
+func newSnapshot(t testing.TB, text string, skip int) *Snapshot {
+	_, file, line, ok := runtime.Caller(2 + skip)
 	if !ok {
 		t.Errorf("snap: unable to retrieve caller location")
 	}
 
 	return &Snapshot{
-		location:            sourceLocation{file: file, line: line},
+		// runtime.Caller reports paths with forward slashes, also on Windows.
+		location:            sourceLocation{file: filepath.FromSlash(file), line: line},
 		text:                text,
 		t:                   t,
 		foundCallerLocation: ok,
+		started:             testStart(t),
 	}
 }
 
+// String returns the text of the snapshot, to use the expected value when arranging a test, like
+// the body served by a fake server that the test expects back, without repeating the literal.
+// Bound template parameters are replaced, and markers are kept.
+//
+//	want := snap.Snap(t, `{"status":"ok"}`)
+//	server := fakeServer(want.String())
+//	want.Diff(fetch(server.URL))
+func (s *Snapshot) String() string {
+	c := s.current()
+	return c.expandLine(unwrapSoft(c.text))
+}
+
 // Update allows updating just this particular snapshot.
 func (s *Snapshot) Update() *Snapshot {
-	return &Snapshot{
-		location:   sourceLocation{file: s.location.file, line: s.location.line},
-		text:       s.text,
-		updateThis: true,
-	}
+	c := *s
+	c.updateThis = true
+	return &c
 }
 
 // Diff compares the snapshot with a given string.
@@ -108,80 +323,225 @@ func (s *Snapshot) Update() *Snapshot {
 // elsewhere.
 func (s *Snapshot) Diff(got string) {
 	s.t.Helper()
-	if equalExcludingIgnored(got, s.text) {
+	defer startSpan("diff", s.t.Name())()
+	s = s.current()
+	if s.candidates != nil {
+		s.diffAnyOf(got)
+		return
+	}
+	want, got, critical, ok := s.prepare(got)
+	if !ok {
 		return
 	}
 
-	if diff := cmp.Diff(s.text, got); diff != "" {
-		s.t.Errorf("snap: Snapshot differs: (-want +got):\n%s", diff)
+	if !s.validate(want, got) {
+		return
 	}
 
-	if !s.shouldUpdate() {
-		s.t.Log("snap: Rerun with SNAP_UPDATE=1 environmental variable to update the snapshot.")
+	if s.missingFile {
+		if s.shouldUpdate() {
+			s.update(got, s.version)
+			return
+		}
+		if s.section != "" {
+			s.mismatch(want, got, "snap: Snapshot file %s has no section %q, rerun with SNAP_UPDATE=1 to create it.", relativePath(s.file), s.section)
+		} else {
+			s.mismatch(want, got, "snap: Snapshot file %s does not exist, rerun with SNAP_UPDATE=1 to create it.", relativePath(s.file))
+		}
+		if pendingMode() || seedMode() {
+			s.writePending(want, got, s.version)
+		}
 		return
 	}
 
-	fset := token.NewFileSet()
+	if path, ok := baselinePath(); ok {
+		s.recordBaseline(path, want, got)
+		return
+	}
+	if !s.shouldUpdate() {
+		defer s.recordResult()
+	}
+	defer func() { s.emitResult(want, got) }() // want may be migrated.
 
-	f, err := parser.ParseFile(fset, s.location.file, nil, parser.ParseComments)
-	if err != nil {
-		s.t.Errorf("snap: %v", err)
+	equal, explanation := s.compare(got, want)
+	if equal {
+		if s.checkMarkerCount(got, want) {
+			s.runChecks(got, want)
+		}
+		if pendingMode() {
+			s.clearPending()
+		}
+		return
+	}
+	if s.ignoreOrder && sameLines(want, got) {
+		return
+	}
+	if s.withinDiffBudget(want, got) {
+		return
+	}
+	if critical != nil && !criticalChanged(want, critical, got) {
+		s.logf(slog.LevelWarn, "Warning: Snapshot at %s differs outside of its critical sections: (-want +got):\n%s",
+			s.findLiteral(), cmp.Diff(want, got))
+		if s.shouldUpdate() && s.variant == "" {
+			// The update keeps the tags of the sections.
+			s.update(got, max(s.version, latestVersion()))
+		}
 		return
 	}
 
-	// Traverse the AST and find snap.Snap function calls.
-	ast.Inspect(f, func(n ast.Node) bool {
-		// Check for function call expressions.
-		if callExpr, ok := n.(*ast.CallExpr); ok {
-			if s.location.line != fset.Position(callExpr.Pos()).Line {
-				return true
+	if migrated, version, ok := s.migrate(); ok {
+		var err error
+		if want, err = s.expand(unwrapSoft(migrated)); err != nil {
+			s.t.Errorf("snap: %s", err)
+			return
+		}
+		if want, _, err = cutCritical(want); err != nil {
+			s.t.Errorf("snap: %s", err)
+			return
+		}
+		if equal, explanation = s.compare(got, want); equal {
+			if s.checkMarkerCount(got, want) {
+				s.runChecks(got, want)
+			}
+			s.logf(slog.LevelInfo, "Snapshot matches after migrating it from version %d to %d.", s.version, version)
+			if s.shouldUpdate() {
+				s.update(migrated, version)
 			}
-			// Check if the function being called is "Snap".
-			if selExpr, ok := callExpr.Fun.(*ast.SelectorExpr); ok {
-				if _, ok := selExpr.X.(*ast.Ident); ok {
-					if selExpr.Sel.Name == "Snap" {
-						// Check if the __second__ argument is a string literal, the first argument
-						// is for *testing.T.
-						if len(callExpr.Args) > 0 {
-							if strLit, ok := callExpr.Args[1].(*ast.BasicLit); ok && strLit.Kind == token.STRING {
-								// TODO: handle overwriting of <snap:ignore>.
-								// Check for raw string literal.
-								if len(strLit.Value) >= 2 && strLit.Value[0] == '`' && strLit.Value[len(strLit.Value)-1] == '`' {
-									strLit.Value = "`" + got + "`"
-								} else {
-									strLit.Value = `"` + got + `"`
-								}
-							}
-						}
-					}
+			return
+		}
+	}
+
+	if isLarge(want, got) {
+		s.mismatch(want, got, "snap: Snapshot at %s differs, compared in chunks of %d bytes as the value has %d bytes and the snapshot %d:\n%s%s",
+			s.findLiteral(), diffChunkSize, len(got), len(want), differingChunks(want, got), s.annotations())
+	} else if diff := cmp.Diff(want, got); diff != "" {
+		lit := s.findLiteral()
+		if s.compatible != nil {
+			err := s.compatible(want, got)
+			if err == nil {
+				s.logf(slog.LevelInfo, "Snapshot at %s changed compatibly: (-want +got):\n%s", lit, diff)
+				if s.shouldUpdate() {
+					s.update(got, max(s.version, latestVersion()))
 				}
+				return
 			}
+			// Incompatible changes are never updated, which would approve them.
+			s.mismatch(want, got, "snap: Snapshot at %s changed incompatibly: %s: (-want +got):\n%s%s",
+				lit, err, diff, s.annotations())
+			return
 		}
-		return true
-	})
+		var changed string
+		if s.isJSON {
+			changed = changedJSONPaths(want, got)
+		} else if s.base64 {
+			changed = changedBytes(want, got)
+		} else {
+			changed = changedParts(want, got)
+		}
+		annotations := s.annotations()
+		if explanation != "" {
+			annotations = fmt.Sprintf("snap: Comparer: %s\n", explanation) + annotations
+		}
+		if sameLines(want, got) {
+			s.mismatch(want, got, "snap: Snapshot at %s differs only in the order of its lines:\n%s%s",
+				lit, movedLines(want, got), annotations)
+		} else {
+			s.mismatch(want, got, "snap: Snapshot at %s differs, %s: (-want +got):\n%s%s%s%s",
+				lit, diffStats(want, got), diff, anchoredLines(lit, s.text, want, got), changed, annotations)
+		}
+		s.runDiffTool(want, got)
+		s.writeHTMLReport(lit, want, got)
+		s.writeArtifacts(lit, want, got)
+		s.submitReview(want, got, diff)
+	} else if s.comparer != nil {
+		// The comparer rejects a value equal to the snapshot, which an update can't fix.
+		s.t.Errorf("snap: Snapshot at %s is equal to the value, but its comparer doesn't match them: %s\n%s",
+			s.findLiteral(), explanation, s.annotations())
+		return
+	}
 
-	// Format the modified AST to a buffer first to avoid writing garbage(or nothing at all) back
-	// to the source file. Only if this succeeds, we then flush the buffer to the source file.
-	var buf bytes.Buffer
-	if err := format.Node(&buf, fset, f); err != nil {
-		s.t.Errorf("snap: Failed to format modified AST, aborting: %s", err)
+	if s.variant != "" {
+		s.logf(slog.LevelDebug, "Snapshot for %s is not updated automatically, edit it by hand.", s.variant)
+		return
+	}
+	if !s.shouldUpdate() {
+		if fixesMode() && s.foundCallerLocation && !recordOnly() {
+			s.writeFix(want, got, max(s.version, latestVersion()))
+		}
+		if (pendingMode() || seedMode() && s.unseeded()) && s.foundCallerLocation && !recordOnly() {
+			s.writePending(want, got, max(s.version, latestVersion()))
+			return
+		}
+		if _, hasEnv := os.LookupEnv("SNAP_UPDATE"); !hasEnv && !recordOnly() {
+			s.hint()
+		}
 		return
 	}
 
-	outFile, err := os.OpenFile(s.location.file, os.O_WRONLY|os.O_TRUNC, 0644)
+	s.update(got, max(s.version, latestVersion()))
+}
+
+// prepare returns the snapshot text and got as they're compared: got decompressed, normalized
+// and encoded as set on the snapshot and fixed by SNAP_LINT, and the snapshot with its template
+// parameters expanded and its critical section tags cut, along with the lines they mark. It
+// reports errors to the test and returns false if they can't be compared.
+func (s *Snapshot) prepare(got string) (want string, prepared string, critical []bool, ok bool) {
+	s.t.Helper()
+	rules, ok := s.lint()
+	if !ok {
+		return "", "", nil, false
+	}
+	if s.decompress {
+		var err error
+		if got, err = decompress(got); err != nil {
+			s.t.Errorf("snap: %s", err)
+			return "", "", nil, false
+		}
+	}
+	got = s.normalize(got)
+	if s.base64 {
+		got = encodeBase64(got)
+	}
+	got = rules.fix(got)
+	want, err := s.expand(unwrapSoft(s.text))
 	if err != nil {
-		s.t.Errorf("snap: Failed to open source file %q for writing to: %s", s.location.file, err)
-		return
+		s.t.Errorf("snap: %s", err)
+		return "", "", nil, false
+	}
+	if _, err := markerSpecs(want); err != nil {
+		s.t.Errorf("snap: %s", err)
+		return "", "", nil, false
+	}
+	if err := validateMarkers(want); err != nil {
+		s.t.Errorf("snap: %s", err)
+		return "", "", nil, false
 	}
-	defer outFile.Close()
+	want, critical, err = cutCritical(want)
+	if err != nil {
+		s.t.Errorf("snap: %s", err)
+		return "", "", nil, false
+	}
+	return want, got, critical, true
+}
 
-	// Write the modified(and formatted) AST in the buffer back to the original source file.
-	if _, err := io.Copy(outFile, &buf); err != nil {
-		s.t.Errorf("snap: Failed to write modified AST to source file %q: %s", s.location.file, err)
+// DiffNot checks that got doesn't match the snapshot, like an output that must have changed after
+// a migration. got is normalized like with [Snapshot.Diff], and markers match like with it. The
+// snapshot is never updated.
+// It calls [testing.T.Error] when the snapshot matches the value.
+func (s *Snapshot) DiffNot(got string) {
+	s.t.Helper()
+	s = s.current()
+	want, got, _, ok := s.prepare(got)
+	if !ok {
 		return
 	}
-
-	s.t.Logf("snap: Updated %s\n", s.location.file)
+	equal := equalExcludingIgnored(got, want)
+	if s.comparer != nil {
+		equal, _ = s.comparer(want, got)
+	}
+	if equal || (s.ignoreOrder && sameLines(want, got)) {
+		s.t.Errorf("snap: Value matches the snapshot at %s, expected it to differ:\n%s", s.findLiteral(), got)
+	}
 }
 
 // DiffJSON compares the snapshot with the json serialization of a value.
@@ -190,40 +550,106 @@ func (s *Snapshot) Diff(got string) {
 func (s *Snapshot) DiffJSON(value any, indent string) {
 	s.t.Helper()
 
-	var buf bytes.Buffer
-	enc := json.NewEncoder(&buf)
-	enc.SetEscapeHTML(false)
-	enc.SetIndent("", indent)
-	if err := enc.Encode(&value); err != nil {
-		s.t.Errorf("snap: %v", err)
+	got, ok := s.render(func() (string, error) {
+		// The value usually matches the snapshot: reuse the snapshot rather than copying the value.
+		return encodeJSON(value, indent, s.text)
+	})
+	if !ok {
 		return
 	}
-	s.Diff(strings.TrimSuffix(buf.String(), "\n")) // Trim the trailing newline that *json.Encoder.Encode adds.
+	c := *s
+	c.isJSON = true
+	c.Diff(formatJSONNumbers(got, s.numbers))
+}
+
+// DiffSection compares the snapshot with the section of got that starts at the first occurrence of
+// start and ends right before the following occurrence of end, or at the end of got if end is
+// empty. This pins only the relevant part of a large output, like one section of a help screen,
+// while the snapshot can still be updated.
+// It calls [testing.T.Error] when start or end are not found in got.
+func (s *Snapshot) DiffSection(got string, start string, end string) {
+	s.t.Helper()
+
+	i := strings.Index(got, start)
+	if i < 0 {
+		s.t.Errorf("snap: Section start %q not found in:\n%s", start, got)
+		return
+	}
+	section := got[i:]
+	if end != "" {
+		j := strings.Index(section[len(start):], end)
+		if j < 0 {
+			s.t.Errorf("snap: Section end %q not found after %q in:\n%s", end, start, got)
+			return
+		}
+		section = section[:len(start)+j]
+	}
+	s.Diff(section)
 }
 
 func (s *Snapshot) shouldUpdate() bool {
+	s.t.Helper()
 	if !s.foundCallerLocation {
 		// If for some reason runtime.Caller failed in [Snap], don't try to update the snapshot.
 		return false
 	}
+	if recordOnly() || s.variant != "" {
+		return false
+	}
 
 	if s.updateThis {
 		return true
 	}
 	_, hasEnv := os.LookupEnv("SNAP_UPDATE")
-	return hasEnv
+	return hasEnv && !s.frozen()
 }
 
+const (
+	// markerPrefix starts all markers.
+	markerPrefix = "<snap:"
+	ignoreFmt    = "<snap:ignore>"
+)
+
 func equalExcludingIgnored(got string, snapshot string) bool {
-	var gotRest = got
-	var snapshotRest = snapshot
-	const ignoreFmt = "<snap:ignore>"
+	if !strings.Contains(snapshot, markerPrefix) {
+		return got == snapshot // Without copying large snapshots, like the markers are replaced.
+	}
+	if validateMarkers(snapshot) != nil {
+		return false
+	}
+	return matchIgnored(got, snapshot)
+}
+
+// matchIgnored is [equalExcludingIgnored] without the check for leading and trailing markers.
+func matchIgnored(got string, snapshot string) bool {
+	_, ok := matchMarkers(got, snapshot)
+	return ok
+}
 
-	// Don't allow ignoring suffixes and prefixes, as that makes it easy to miss trailing or leading
-	// data.
-	if strings.HasPrefix(snapshot, ignoreFmt) || strings.HasSuffix(snapshot, ignoreFmt) {
-		panic(fmt.Sprintf("%q is not allowed as a prefix or suffix", ignoreFmt))
+// matchMarkers matches got against snapshot like [matchIgnored], and returns the parts of got
+// matched by the markers of snapshot, in order. <snap:check:name> markers match like <snap:ignore>.
+// Snapshots with the markers of typedMarker always use the strict matching, which checks the
+// shape of the text they match, and so do snapshots starting or ending with a marker, which the
+// legacy matching can't match.
+func matchMarkers(got string, snapshot string) (matched []string, ok bool) {
+	mode, _ := parseMatchMode(os.Getenv("SNAP_MATCH"))
+	if mode == matchStrict || typedMarker.MatchString(snapshot) || edgeMarker(snapshot) {
+		return matchSegments(got, snapshot)
 	}
+	return matchCut(got, snapshot)
+}
+
+// edgeMarker reports whether snapshot starts or ends with a marker matching part of the input.
+func edgeMarker(snapshot string) bool {
+	loc := matchingMarker.FindAllStringIndex(snapshot, -1)
+	return len(loc) > 0 && (loc[0][0] == 0 || loc[len(loc)-1][1] == len(snapshot))
+}
+
+// matchCut is the legacy matching of [matchMarkers]. It cuts got at the first occurrence of each
+// part of the snapshot between markers, and never reconsiders that choice.
+func matchCut(got string, snapshot string) (matched []string, ok bool) {
+	var gotRest = got
+	var snapshotRest = checkMarker.ReplaceAllString(snapshot, ignoreFmt)
 
 	for {
 		// First, check the snapshot for the ignore marker.
@@ -241,7 +667,7 @@ func equalExcludingIgnored(got string, snapshot string) bool {
 
 		// There should be nothing in this prefix if the values are indeed equal.
 		if len(gotPrefix) != 0 {
-			return false
+			return nil, false
 		}
 
 		gotRest = gotSuffix
@@ -254,8 +680,9 @@ func equalExcludingIgnored(got string, snapshot string) bool {
 			nextMatchPrefix = snapshotRest
 		}
 
+		// Two markers in a row can't be told apart.
 		if len(nextMatchPrefix) == 0 {
-			panic("nextMatchPrefix should be greater than 0")
+			return nil, false
 		}
 
 		_, snapshotRestSuffix, snapshotRestFound := strings.Cut(snapshotRest, nextMatchPrefix)
@@ -265,17 +692,21 @@ func equalExcludingIgnored(got string, snapshot string) bool {
 
 		gotCutNextPrefix, gotCutNextSuffix, gotCutNextFound := strings.Cut(gotRest, nextMatchPrefix)
 		if !gotCutNextFound {
-			return false
+			return nil, false
 		}
 
 		ignored := gotCutNextPrefix
 		// If <snap:ignore> matched an empty string, or several lines, report it as an error.
 		if len(ignored) == 0 || strings.Contains(ignored, "\n") {
-			return false
+			return nil, false
 		}
+		matched = append(matched, ignored)
 
 		gotRest = gotCutNextSuffix
 	}
 
-	return gotRest == snapshotRest
+	if gotRest != snapshotRest {
+		return nil, false
+	}
+	return matched, true
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/KasonBraley/snap"
+	"github.com/KasonBraley/snap/snaptest"
 )
 
 func TestSnapDiff(t *testing.T) {
@@ -74,3 +75,57 @@ func TestSnapJSONWithIgnore(t *testing.T) {
   "timestamp": "<snap:ignore>"
 }`))
 }
+
+func TestSnapDiffSection(t *testing.T) {
+	help := "Usage: app [flags]\n\nFlags:\n  -v  verbose\n  -q  quiet\n\nSee the docs for more.\n"
+
+	snap.Snap(t, `Flags:
+  -v  verbose
+  -q  quiet`).DiffSection(help, "Flags:", "\n\n")
+	snap.Snap(t, "See the docs for more.\n").DiffSection(help, "See", "")
+}
+
+func TestGoStringLiteral(t *testing.T) {
+	cases := []struct {
+		text string
+		want string
+	}{
+		{text: "one line", want: `"one line"`},
+		{text: "two\nlines", want: "`two\nlines`"},
+		{text: "back`quote\nline", want: `"back` + "`" + `quote\nline"`},
+		{text: "crlf\r\nline", want: `"crlf\r\nline"`},
+		{text: "tab\there", want: `"tab\there"`},
+		{text: "nul\x00\nline", want: `"nul\x00\nline"`},
+		{text: "invalid\xff\nutf-8", want: `"invalid\xff\nutf-8"`},
+	}
+	for _, tc := range cases {
+		if got := snap.GoStringLiteral(tc.text); got != tc.want {
+			t.Errorf("GoStringLiteral(%q) = %s, want %s", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestSnapshotString(t *testing.T) {
+	want := snap.Snap(t, `{"status":"ok"}`)
+	body := want.String()
+	want.Diff(body)
+
+	tmpl := snap.Template(t, "user <snap:param:name> at <snap:ignore>").Bind("name", "alice")
+	if got := tmpl.String(); got != "user alice at <snap:ignore>" {
+		t.Errorf("unexpected text %q", got)
+	}
+}
+
+func TestUpdateRewrites(t *testing.T) {
+	const header = "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n"
+	cases := []struct{ src, got, want string }{
+		{src: "\tsnap.Snap(t, \"old\").Diff(got)\n", got: "new", want: "\tsnap.Snap(t, \"new\").Diff(got)\n"},
+		{src: "\tsnap.Snap(t, \"old\").Diff(got)\n", got: "a\nb", want: "\tsnap.Snap(t, \"a\\nb\").Diff(got)\n"},
+		{src: "\tsnap.Snap(t, `a\nb`).Diff(got)\n", got: "a\nc", want: "\tsnap.Snap(t, `a\nc`).Diff(got)\n"},
+		{src: "\tsnap.Snap(t, \"a\\n\" +\n\t\t\"b\").Diff(got)\n", got: "a\nc", want: "\tsnap.Snap(t, `a\nc`).Diff(got)\n"},
+		{src: "\tsnap.Snap(t, \"took <snap:ignore> ms\").Diff(got)\n", got: "took 3 s", want: "\tsnap.Snap(t, \"took 3 s\").Diff(got)\n"},
+	}
+	for _, tc := range cases {
+		snaptest.RequireUpdateResult(t, header+tc.src+"}\n", tc.got, header+tc.want+"}\n")
+	}
+}
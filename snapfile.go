@@ -0,0 +1,257 @@
+package snap
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/KasonBraley/snap/internal/diff"
+)
+
+// snapFileExt is the extension of the [SnapFile] files in the format of snap.
+const snapFileExt = ".snap"
+
+// isSnapFile reports whether the [SnapFile] file at path is in the format of snap.
+func isSnapFile(path string) bool {
+	return filepath.Ext(path) == snapFileExt
+}
+
+// rendererFormat is the version of the output of the renderers, like [formatValue] and the
+// encoders of [Snapshot.DiffJSON], stamped in the .snap files. It's bumped when they render the same
+// values differently, so that snapshots written before are reported as such when they differ.
+const rendererFormat = 1
+
+// libraryPath is the module path of snap.
+const libraryPath = "github.com/KasonBraley/snap"
+
+// libraryVersion returns the version of snap the test binary is built with, or an empty string if
+// it isn't known.
+var libraryVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if info.Main.Path == libraryPath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == libraryPath {
+			return dep.Version
+		}
+	}
+	return ""
+})
+
+// stampPrefix starts the comment line stamped at the top of the .snap files, recording the
+// version of snap and the renderer format that last wrote them:
+//
+//	#snap version=v1.4.0 format=1
+const stampPrefix = "#snap "
+
+// snapStamp is the stamp of a .snap file.
+type snapStamp struct {
+	version string
+	format  int // 0 if the file has no stamp.
+}
+
+// currentStamp returns the stamp of the files written by this version of snap.
+func currentStamp() snapStamp {
+	return snapStamp{version: libraryVersion(), format: rendererFormat}
+}
+
+// String returns the stamp line.
+func (st snapStamp) String() string {
+	line := stampPrefix
+	if st.version != "" {
+		line += "version=" + st.version + " "
+	}
+	return line + "format=" + strconv.Itoa(st.format)
+}
+
+// stamp returns the stamp of the file, the zero stamp if it has none.
+func (f snapFile) stamp() snapStamp {
+	var st snapStamp
+	if len(f.lines) == 0 || !strings.HasPrefix(f.lines[0], stampPrefix) {
+		return st
+	}
+	for _, field := range strings.Fields(strings.TrimPrefix(f.lines[0], stampPrefix)) {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "version":
+			st.version = value
+		case "format":
+			st.format, _ = strconv.Atoi(value)
+		}
+	}
+	return st
+}
+
+// setStamp stamps the file with st, replacing its stamp if it has one.
+func (f *snapFile) setStamp(st snapStamp) {
+	if len(f.lines) > 0 && strings.HasPrefix(f.lines[0], stampPrefix) {
+		f.lines[0] = st.String()
+		return
+	}
+	f.lines = append([]string{st.String()}, f.lines...)
+}
+
+// stampWarning returns the warning shown when the snapshot differs from a value, if its .snap file
+// was written with another renderer format, whose output may differ for the same value.
+func (s *Snapshot) stampWarning() string {
+	if s.stamp.format == 0 || s.stamp.format == rendererFormat {
+		return ""
+	}
+	by := "another version of snap"
+	if s.stamp.version != "" {
+		by = "snap " + s.stamp.version
+	}
+	return fmt.Sprintf("snap: Warning: %s was written by %s with renderer format %d, and this version renders format %d, "+
+		"rerun with SNAP_UPDATE=1 to migrate it.\n", relativePath(s.file), by, s.stamp.format, rendererFormat)
+}
+
+// snapFile is the content of a snapshot file in the format of snap: lines ending with a newline,
+// which are comments if they start with #, section headers if they read -- name --, and otherwise
+// the lines of the snapshots, escaped with a backslash if they start with # or a backslash or read
+// as a header. The lines before the first header are the snapshot of [SnapFile], and those after
+// each header the snapshot of its [FileSection].
+type snapFile struct {
+	lines []string
+}
+
+// parseSnapFile parses the content of a snapshot file in the format of snap.
+func parseSnapFile(data string) snapFile {
+	if data == "" {
+		return snapFile{}
+	}
+	return snapFile{lines: strings.Split(strings.TrimSuffix(data, "\n"), "\n")}
+}
+
+// String returns the content of the file.
+func (f snapFile) String() string {
+	var sb strings.Builder
+	for _, line := range f.lines {
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// isComment reports whether the line of a snapshot file is a comment.
+func isComment(line string) bool {
+	return strings.HasPrefix(line, "#")
+}
+
+// sectionName returns the name of the section whose header is line, if it's one.
+func sectionName(line string) (string, bool) {
+	name, ok := strings.CutPrefix(line, "-- ")
+	if !ok {
+		return "", false
+	}
+	name, ok = strings.CutSuffix(name, " --")
+	name = strings.TrimSpace(name)
+	return name, ok && name != ""
+}
+
+// section returns the lines of the section name, or of the lines before the first header if name
+// is empty, as the range [start, end) of f.lines. ok is false if the file has no such section.
+func (f snapFile) section(name string) (start int, end int, ok bool) {
+	start, ok = 0, name == ""
+	for i, line := range f.lines {
+		header, isHeader := sectionName(line)
+		if !isHeader {
+			continue
+		}
+		if ok {
+			return start, i, true
+		}
+		if header == name {
+			start, ok = i+1, true
+		}
+	}
+	if !ok {
+		return len(f.lines), len(f.lines), false
+	}
+	return start, len(f.lines), true
+}
+
+// text returns the snapshot stored in the section name of the file, without its comments, and
+// whether the file has the section.
+func (f snapFile) text(name string) (string, bool) {
+	start, end, ok := f.section(name)
+	var lines []string
+	for _, line := range f.lines[start:end] {
+		if !isComment(line) {
+			lines = append(lines, unescapeLine(line))
+		}
+	}
+	return strings.Join(lines, "\n"), ok
+}
+
+// setText replaces the snapshot stored in the section name of the file with text, adding the
+// section at the end if the file doesn't have it. The comments are kept before the same lines of
+// the snapshot, or where they were if their lines were removed.
+func (f *snapFile) setText(name string, text string) {
+	start, end, ok := f.section(name)
+	if !ok {
+		f.lines = append(f.lines, "-- "+name+" --")
+		start, end = len(f.lines), len(f.lines)
+	}
+
+	// comments[i] are the comment lines before the i-th line of the snapshot.
+	var lines []string
+	comments := [][]string{nil}
+	for _, line := range f.lines[start:end] {
+		if isComment(line) {
+			comments[len(lines)] = append(comments[len(lines)], line)
+			continue
+		}
+		lines = append(lines, unescapeLine(line))
+		comments = append(comments, nil)
+	}
+
+	textLines := strings.Split(text, "\n")
+	result := append([]string{}, f.lines[:start]...)
+	for _, e := range diff.Lines(lines, textLines, nil) {
+		if e.Op != diff.Insert {
+			result = append(result, comments[e.A]...)
+		}
+		if e.Op != diff.Delete {
+			result = append(result, escapeLine(textLines[e.B]))
+		}
+	}
+	result = append(result, comments[len(lines)]...)
+	f.lines = append(result, f.lines[end:]...)
+}
+
+// escapeLine escapes the line of a snapshot that would read as a comment, a section header or an
+// escaped line.
+func escapeLine(line string) string {
+	if _, ok := sectionName(line); ok || strings.HasPrefix(line, "#") || strings.HasPrefix(line, `\`) {
+		return `\` + line
+	}
+	return line
+}
+
+// unescapeLine returns the line of a snapshot escaped by [escapeLine].
+func unescapeLine(line string) string {
+	return strings.TrimPrefix(line, `\`)
+}
+
+// snapFileContent returns the content of the snapshot file in the format of snap of s, with the
+// snapshot of its section replaced by text.
+func (s *Snapshot) snapFileContent(text string) (string, error) {
+	data, err := os.ReadFile(s.file)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	f := parseSnapFile(string(data))
+	f.setText(s.section, text)
+	f.setStamp(currentStamp())
+	return f.String(), nil
+}
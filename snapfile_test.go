@@ -0,0 +1,110 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSnapFileComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "help.snap")
+	content := "# The flags are sorted.\nusage: tool [flags]\n  -a  all\n# -b is deprecated.\n  -b  brief\n\\# not a comment\n# End.\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	SnapFile(t, path).Diff("usage: tool [flags]\n  -a  all\n  -b  brief\n# not a comment")
+
+	// The comments are kept before the same lines, or where their lines were removed.
+	ft := newFakeT(t)
+	SnapFile(ft, path).Update().Diff("usage: tool [flags]\n  -a  all\n  -c  color\n\\ and # text")
+	want := currentStamp().String() + "\n# The flags are sorted.\nusage: tool [flags]\n  -a  all\n# -b is deprecated.\n  -c  color\n\\\\ and # text\n# End.\n"
+	if got := readFile(t, path); got != want {
+		t.Errorf("expected the comments to be kept, want:\n%s\ngot:\n%s", want, got)
+	}
+	SnapFile(t, path).Diff("usage: tool [flags]\n  -a  all\n  -c  color\n\\ and # text")
+
+	// New files are created in the format.
+	created := filepath.Join(t.TempDir(), "new.snap")
+	t.Setenv("SNAP_UPDATE", "1")
+	ft = newFakeT(t)
+	SnapFile(ft, created).Diff("#1\nline")
+	if got := readFile(t, created); got != currentStamp().String()+"\n\\#1\nline\n" {
+		t.Errorf("expected the new file to be escaped, got %q", got)
+	}
+}
+
+func TestSnapFileText(t *testing.T) {
+	for _, text := range []string{"", "\n", "a", "a\n", "#", "\\", "\\#", "a\n\n# b\n", "-- a --\n--  --"} {
+		var f snapFile
+		f.setText("", text)
+		if got, _ := parseSnapFile(f.String()).text(""); got != text {
+			t.Errorf("expected %q to be read back, got %q from %q", text, got, f.String())
+		}
+		if strings.Contains(f.String(), "\n#") || strings.HasPrefix(f.String(), "#") {
+			t.Errorf("expected no comments in %q", f.String())
+		}
+	}
+}
+
+func TestFileSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.snap")
+	content := "# API responses.\n-- login --\n# The token is fake.\n{\"token\": \"x\"}\n-- logout --\n{}\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	FileSection(t, path, "login").Diff(`{"token": "x"}`)
+	FileSection(t, path, "logout").Diff("{}")
+	SnapFile(t, path).Diff("")
+
+	// Only the section is rewritten.
+	ft := newFakeT(t)
+	FileSection(ft, path, "login").Update().Diff("{\"token\": \"y\"}\n-- not a header --")
+	want := currentStamp().String() + "\n# API responses.\n-- login --\n# The token is fake.\n{\"token\": \"y\"}\n\\-- not a header --\n-- logout --\n{}\n"
+	if got := readFile(t, path); got != want {
+		t.Errorf("expected only the section to be rewritten, want:\n%s\ngot:\n%s", want, got)
+	}
+	FileSection(t, path, "login").Diff("{\"token\": \"y\"}\n-- not a header --")
+
+	// Missing sections fail, and are added at the end.
+	ft = newFakeT(t)
+	FileSection(ft, path, "refresh").Diff("{}")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `has no section "refresh"`) {
+		t.Errorf("expected the missing section to be reported, got %q", ft.errors)
+	}
+	ft = newFakeT(t)
+	FileSection(ft, path, "refresh").Update().Diff("{}")
+	if got := readFile(t, path); got != want+"-- refresh --\n{}\n" {
+		t.Errorf("expected the section to be added, got:\n%s", got)
+	}
+
+	ft = newFakeT(t)
+	FileSection(ft, filepath.Join(t.TempDir(), "api.golden"), "login")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "needs a .snap file") {
+		t.Errorf("expected other files to be rejected, got %q", ft.errors)
+	}
+}
+
+func TestSnapFileStamp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "help.snap")
+	if err := os.WriteFile(path, []byte("#snap version=v9.0.0 format=2\n# Help.\nusage: old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ft := newFakeT(t)
+	SnapFile(ft, path).Diff("usage: new")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "snap: Warning: "+relativePath(path)+" was written by snap v9.0.0 with renderer format 2") {
+		t.Errorf("expected a warning about the renderer format, got %q", ft.errors)
+	}
+
+	// The stamp is replaced on update.
+	ft = newFakeT(t)
+	SnapFile(ft, path).Update().Diff("usage: new")
+	if got, want := readFile(t, path), currentStamp().String()+"\n# Help.\nusage: new\n"; got != want {
+		t.Errorf("expected the stamp to be replaced, want:\n%s\ngot:\n%s", want, got)
+	}
+	ft = newFakeT(t)
+	SnapFile(ft, path).Diff("usage: newer")
+	if len(ft.errors) != 1 || strings.Contains(ft.errors[0], "Warning") {
+		t.Errorf("expected no warning for the current format, got %q", ft.errors)
+	}
+}
@@ -0,0 +1,66 @@
+// Package snaptest helps test code built around the snap package, like test helpers wrapping
+// [snap.Snap] or tools generating snapshot tests, by showing how snapshots would be updated
+// without rewriting any file.
+//
+//	func TestUpdateKeepsMarkers(t *testing.T) {
+//		snaptest.RequireUpdateResult(t,
+//			"package p\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestX(t *testing.T) {\n\tsnap.Snap(t, `id: <snap:ignore>\nold`).Diff(got)\n}\n",
+//			"id: 42\nnew",
+//			"package p\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestX(t *testing.T) {\n\tsnap.Snap(t, `id: <snap:ignore>\nnew`).Diff(got)\n}\n")
+//	}
+//
+// Updates are configured by the same environment variables as SNAP_UPDATE=1 runs, like
+// SNAP_FORMAT and SNAP_FOLD_LINES.
+package snaptest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/KasonBraley/snap"
+	"github.com/KasonBraley/snap/internal/rewriter"
+	"github.com/KasonBraley/snap/internal/source"
+	"github.com/google/go-cmp/cmp"
+)
+
+// filename is the name of the snapshot sources in errors.
+const filename = "snapshot_test.go"
+
+// The snap package registers its rewriter when it's initialized.
+var _ = snap.Snap
+
+// UpdateResult returns snapshotSource, the source of a Go file with a single [snap.Snap] call,
+// rewritten as SNAP_UPDATE=1 would when the snapshot of that call is compared with the value got.
+// The snapshot must be a string literal, or a concatenation of them, and its markers are kept
+// like in real updates.
+func UpdateResult(snapshotSource string, got string) (string, error) {
+	snapshots, err := source.Find(filename, []byte(snapshotSource))
+	if err != nil {
+		return "", err
+	}
+	if len(snapshots) != 1 {
+		return "", fmt.Errorf("expected one Snap call in the source, found %d", len(snapshots))
+	}
+	s := snapshots[0]
+	if !s.Constant {
+		return "", fmt.Errorf("%s: the snapshot is not a string literal", s.Pos)
+	}
+	rewritten, err := rewriter.Source(filename, []byte(snapshotSource), s.Line, s.Text, got)
+	if err != nil {
+		return "", err
+	}
+	return string(rewritten), nil
+}
+
+// RequireUpdateResult fails the test right away if updating the snapshot of snapshotSource to the
+// value got, as described for [UpdateResult], doesn't rewrite the source to wantRewrittenSource.
+func RequireUpdateResult(t testing.TB, snapshotSource string, got string, wantRewrittenSource string) {
+	t.Helper()
+	rewritten, err := UpdateResult(snapshotSource, got)
+	if err != nil {
+		t.Fatalf("snaptest: Failed to update the snapshot: %s", err)
+	}
+	if diff := cmp.Diff(wantRewrittenSource, rewritten); diff != "" {
+		t.Fatalf("snaptest: Rewritten source differs: (-want +got):\n%s", diff)
+	}
+}
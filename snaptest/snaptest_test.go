@@ -0,0 +1,24 @@
+package snaptest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpdateResult(t *testing.T) {
+	RequireUpdateResult(t,
+		"package p\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestX(t *testing.T) {\n\tsnap.Snap(t, `id: <snap:ignore>\nold`).Diff(got)\n}\n",
+		"id: 42\nnew",
+		"package p\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestX(t *testing.T) {\n\tsnap.Snap(t, `id: <snap:ignore>\nnew`).Diff(got)\n}\n")
+
+	for _, tc := range []struct{ src, err string }{
+		{src: "package p\n", err: "expected one Snap call in the source, found 0"},
+		{src: "package p\n\nimport \"github.com/KasonBraley/snap\"\n\nvar x = snap.Snap(t, want)\n", err: "snapshot_test.go:5:22: the snapshot is not a string literal"},
+		{src: "package p\n\nimport \"github.com/KasonBraley/snap\"\n\nvar x = snap.Snap(t, fmt.Sprintf(\"%d\", n))\n", err: "not a string literal"},
+		{src: "package p\n\nimport \"github.com/KasonBraley/snap\"\n\nvar x = snap.Snap(t, \"old\"", err: "missing ','"},
+	} {
+		if _, err := UpdateResult(tc.src, "new"); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("UpdateResult(%q): expected error %q, got %v", tc.src, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package snap
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// wrapMarker ends a line of a snapshot that continues on the next line, written by
+// SNAP_WRAP_LINES. The marker and the line break after it aren't part of the snapshot.
+const wrapMarker = "<snap:wrap>"
+
+// unwrapSoft joins the lines of text continued with a <snap:wrap> marker.
+func unwrapSoft(text string) string {
+	return strings.ReplaceAll(text, wrapMarker+"\n", "")
+}
+
+// softWrapWidth returns the column lines of updated snapshots are wrapped at, set by
+// SNAP_WRAP_LINES, or 0 to not wrap them.
+func softWrapWidth() int {
+	n, err := strconv.Atoi(os.Getenv("SNAP_WRAP_LINES"))
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return n
+}
+
+// softWrap wraps the lines of text wider than width columns, like minified code or tokens,
+// continuing them on the next line after a <snap:wrap> marker. Runes and markers, like
+// <snap:ignore>, are never split, so a line may be wider than width if one of them is.
+func softWrap(text string, width int) string {
+	lines := strings.Split(text, "\n")
+	var sb strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		col := 0
+		for line != "" {
+			r, size := utf8.DecodeRuneInString(line)
+			w := runeWidth(r)
+			if strings.HasPrefix(line, "<snap:") {
+				if end := strings.IndexByte(line, '>'); end > 0 {
+					size = end + 1
+					w = displayWidth(line[:size])
+				}
+			}
+			if col > 0 && col+w > width {
+				sb.WriteString(wrapMarker + "\n")
+				col = 0
+			}
+			sb.WriteString(line[:size])
+			line = line[size:]
+			col += w
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,54 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSoftWrap(t *testing.T) {
+	cases := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{"short\nlines", 10, "short\nlines"},
+		{"abcdefghij", 4, "abcd<snap:wrap>\nefgh<snap:wrap>\nij"},
+		{"abcd\nabcdefgh\n", 4, "abcd\nabcd<snap:wrap>\nefgh\n"},
+		{"trailing  ", 8, "trailing<snap:wrap>\n  "},
+		{"日本語の", 5, "日本<snap:wrap>\n語の"},
+		{"id: <snap:uuid>", 6, "id: <snap:wrap>\n<snap:uuid>"},
+	}
+	for _, tc := range cases {
+		got := softWrap(tc.text, tc.width)
+		if got != tc.want {
+			t.Errorf("softWrap(%q, %d): expected %q, got %q", tc.text, tc.width, tc.want, got)
+		}
+		if unwrapped := unwrapSoft(got); unwrapped != tc.text {
+			t.Errorf("unwrapSoft(%q): expected %q, got %q", got, tc.text, unwrapped)
+		}
+	}
+}
+
+func TestDiffSoftWrapped(t *testing.T) {
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "token: eyJhbG<snap:wrap>\nciOiJIUzI1<snap:wrap>\nNiJ9\nend").Diff("token: eyJhbGciOiJIUzI1NiJ9\nend")
+	if len(ft.errors) != 0 {
+		t.Errorf("expected wrapped snapshot to match, got %q", ft.errors)
+	}
+
+	ft = newFakeT(t)
+	snapNoUpdate(ft, "eyJhbG<snap:wrap>\nciOiJ").Diff("eyJhbG\nciOiJ")
+	if len(ft.errors) == 0 {
+		t.Errorf("expected the line break of a wrapped line not to match")
+	}
+}
+
+func TestUpdateSoftWraps(t *testing.T) {
+	t.Setenv("SNAP_WRAP_LINES", "10")
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `x`).Diff(got)\n}\n", "x")
+	s.Diff("head\n0123456789abcdef <snap:ignore>")
+	if src := readFile(t, path); !strings.Contains(src, "snap.Snap(t, `head\n0123456789<snap:wrap>\nabcdef <snap:wrap>\n<snap:ignore>`)") {
+		t.Errorf("expected long line to be wrapped, got:\n%s", src)
+	}
+}
@@ -0,0 +1,162 @@
+package snap
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// SortJSONArray returns a [Normalizer] sorting the array of objects at path in a JSON value by
+// their field, for arrays whose content is deterministic but whose order is not, like the rows of a
+// query without ORDER BY.
+//
+//	snap.Snap(t, want).Normalize(snap.SortJSONArray("data.users", "id")).DiffJSON(resp, "  ")
+//
+// The path is a list of object keys separated by dots, empty for the top-level value. Numbers are
+// sorted numerically, strings lexically, and elements without the field come first. Only the order
+// of the elements changes, the formatting of the value is kept. Values that aren't JSON, or without
+// an array at path, are left unchanged.
+func SortJSONArray(path string, field string) Normalizer {
+	var keys []string
+	if path != "" {
+		keys = strings.Split(path, ".")
+	}
+	return func(got string) string {
+		data := []byte(got)
+		start, ok := findJSONValue(data, 0, keys)
+		if !ok {
+			return got
+		}
+		sorted, ok := sortJSONArray(data[start:], field)
+		if !ok {
+			return got
+		}
+		return got[:start] + sorted
+	}
+}
+
+// findJSONValue returns the offset in data of the value at the path of object keys, starting with
+// the value at off.
+func findJSONValue(data []byte, off int, keys []string) (int, bool) {
+	off = skipJSONSpace(data, off)
+	if len(keys) == 0 {
+		return off, true
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data[off:]))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return 0, false
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return 0, false
+		}
+		if key == keys[0] {
+			// Skip the colon after the key, which the decoder hasn't read yet.
+			valueOff := skipJSONSpace(data, off+int(dec.InputOffset()))
+			if valueOff >= len(data) || data[valueOff] != ':' {
+				return 0, false
+			}
+			return findJSONValue(data, valueOff+1, keys[1:])
+		}
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// sortJSONArray sorts the array of objects at the start of data by field, keeping the text between
+// its elements. It returns data with the sorted array.
+func sortJSONArray(data []byte, field string) (string, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return "", false
+	}
+
+	type element struct {
+		start, end int
+		key        json.RawMessage
+	}
+	var elements []element
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return "", false
+		}
+		end := int(dec.InputOffset())
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return "", false
+		}
+		elements = append(elements, element{start: end - len(raw), end: end, key: obj[field]})
+	}
+	if _, err := dec.Token(); err != nil {
+		return "", false
+	}
+
+	sorted := make([]element, len(elements))
+	copy(sorted, elements)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return compareJSON(sorted[i].key, sorted[j].key) < 0
+	})
+
+	var sb strings.Builder
+	last := 0
+	for i, e := range elements {
+		sb.Write(data[last:e.start])
+		sb.Write(data[sorted[i].start:sorted[i].end])
+		last = e.end
+	}
+	sb.Write(data[last:])
+	return sb.String(), true
+}
+
+// compareJSON compares two JSON values: missing values first, then numbers by value, then strings,
+// then any other value by its text.
+func compareJSON(a json.RawMessage, b json.RawMessage) int {
+	if ra, rb := jsonRank(a), jsonRank(b); ra != rb {
+		return ra - rb
+	}
+	switch jsonRank(a) {
+	case 1:
+		x, _, _ := big.ParseFloat(string(a), 10, 256, big.ToNearestEven)
+		y, _, _ := big.ParseFloat(string(b), 10, 256, big.ToNearestEven)
+		if x != nil && y != nil {
+			return x.Cmp(y)
+		}
+	case 2:
+		var x, y string
+		if json.Unmarshal(a, &x) == nil && json.Unmarshal(b, &y) == nil {
+			return strings.Compare(x, y)
+		}
+	}
+	return bytes.Compare(a, b)
+}
+
+// jsonRank orders the kinds of JSON values for compareJSON.
+func jsonRank(v json.RawMessage) int {
+	switch {
+	case len(v) == 0:
+		return 0
+	case v[0] == '-' || v[0] >= '0' && v[0] <= '9':
+		return 1
+	case v[0] == '"':
+		return 2
+	default:
+		return 3
+	}
+}
+
+// skipJSONSpace returns the offset of the first byte at or after off in data that isn't
+// whitespace.
+func skipJSONSpace(data []byte, off int) int {
+	for off < len(data) && strings.IndexByte(" \t\r\n", data[off]) >= 0 {
+		off++
+	}
+	return off
+}
@@ -0,0 +1,36 @@
+package snap
+
+import "testing"
+
+func TestSortJSONArray(t *testing.T) {
+	cases := []struct {
+		path  string
+		field string
+		got   string
+		want  string
+	}{
+		{field: "id", got: `[{"id": 10}, {"id": 9}, {}]`, want: `[{}, {"id": 9}, {"id": 10}]`},
+		{field: "name", got: `[{"name": "b"},{"name": "a"}]`, want: `[{"name": "a"},{"name": "b"}]`},
+		{
+			path:  "data.users",
+			field: "id",
+			got:   "{\n  \"data\" : {\n    \"users\": [\n      {\"id\": 2, \"n\": 1},\n      {\"id\": 1, \"n\": 2}\n    ]\n  }\n}",
+			want:  "{\n  \"data\" : {\n    \"users\": [\n      {\"id\": 1, \"n\": 2},\n      {\"id\": 2, \"n\": 1}\n    ]\n  }\n}",
+		},
+		{path: "users", field: "id", got: `{"other": [{"id": 2}, {"id": 1}]}`, want: `{"other": [{"id": 2}, {"id": 1}]}`},
+		{field: "id", got: `[1, 2]`, want: `[1, 2]`},
+		{field: "id", got: `not json`, want: `not json`},
+	}
+	for _, tc := range cases {
+		if got := SortJSONArray(tc.path, tc.field)(tc.got); got != tc.want {
+			t.Errorf("SortJSONArray(%q, %q)(%q) = %q, want %q", tc.path, tc.field, tc.got, got, tc.want)
+		}
+	}
+
+	type user struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	rows := map[string]any{"users": []user{{2, "b"}, {1, "a"}}}
+	Snap(t, `{"users":[{"id":1,"name":"a"},{"id":2,"name":"b"}]}`).Normalize(SortJSONArray("users", "id")).DiffJSON(rows, "")
+}
@@ -0,0 +1,56 @@
+package snap
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SynctestEpoch is the time the fake clock of testing/synctest bubbles starts at: midnight UTC on
+// January 1st, 2000.
+var SynctestEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// synctestTimestamp matches the timestamps rewritten by [SynctestTimes]: RFC 3339 timestamps,
+// "2006-01-02 15:04:05" timestamps, and timestamps printed by [time.Time.String].
+var synctestTimestamp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2}| [+-]\d{4}( [A-Za-z]+| [+-]\d+)?)?`)
+
+// synctestLayouts are the layouts of the timestamps matched by synctestTimestamp, once the zone
+// abbreviations of [time.Time.String] are removed, as the offset before them is enough.
+var synctestLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// SynctestTimes is a [Normalizer] rewriting the timestamps of the fake clock of testing/synctest
+// bubbles as their offset from [SynctestEpoch], like `<synctest+1m30s>`:
+//
+//	synctest.Test(t, func(t *testing.T) {
+//		time.Sleep(90 * time.Second)
+//		snap.Snap(t, "done at <synctest+1m30s>").Normalize(snap.SynctestTimes).Diff(log.String())
+//	})
+//
+// Times inside a bubble are deterministic, but they're printed in the local time zone of the
+// machine, which this removes, and the offsets read as the time spent in the test. Timestamps of
+// the wall clock, from code running outside the bubble, are left as they are, so they stand out
+// in the diff. Timestamps without a time zone are in local time.
+func SynctestTimes(got string) string {
+	return synctestTimestamp.ReplaceAllStringFunc(got, func(text string) string {
+		trimmed := text
+		if fields := strings.Fields(text); len(fields) == 4 {
+			trimmed = strings.Join(fields[:3], " ")
+		}
+		for _, layout := range synctestLayouts {
+			ts, err := time.ParseInLocation(layout, trimmed, time.Local)
+			if err != nil {
+				continue
+			}
+			// Bubbles rarely advance their clock by a year, and the wall clock is long past it.
+			if ts.Before(SynctestEpoch) || !ts.Before(SynctestEpoch.AddDate(1, 0, 0)) {
+				return text
+			}
+			return "<synctest+" + ts.Sub(SynctestEpoch).String() + ">"
+		}
+		return text
+	})
+}
@@ -0,0 +1,21 @@
+package snap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSynctestTimes(t *testing.T) {
+	bubble := SynctestEpoch.Add(90 * time.Second)
+	zone := time.FixedZone("", 7*60*60)
+	got := "started " + SynctestEpoch.Format(time.RFC3339) + "\n" +
+		"done " + bubble.In(zone).Format(time.RFC3339Nano) + "\n" +
+		"logged " + bubble.Add(time.Millisecond).In(zone).String() + "\n" +
+		"local " + bubble.Local().Format(time.DateTime) + "\n" +
+		"wall clock 2024-05-14T09:30:00Z"
+	Snap(t, `started <synctest+0s>
+done <synctest+1m30s>
+logged <synctest+1m30.001s>
+local <synctest+1m30s>
+wall clock 2024-05-14T09:30:00Z`).Normalize(SynctestTimes).Diff(got)
+}
@@ -0,0 +1,81 @@
+package snap
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// paramMarker matches the <snap:param:name> markers of template snapshots.
+var paramMarker = regexp.MustCompile(`<snap:param:([A-Za-z0-9_]+)>`)
+
+// Template creates a new Snapshot whose text can contain `<snap:param:name>` markers. Each
+// marker is replaced by the value bound to name with [Snapshot.Bind] before comparing, so one
+// snapshot pattern can serve parameterized table tests:
+//
+//	snap.Template(t, "user <snap:param:name> created at <snap:ignore> UTC").Bind("name", username).Diff(got)
+//
+// When the snapshot is updated, the markers are kept on the lines that still match.
+func Template(t testing.TB, text string) *Snapshot {
+	return newSnapshot(t, text, 0)
+}
+
+// TemplateFile creates a [Template] snapshot stored in the file at path, like [SnapFile], for
+// expected values too large to keep in the source that also depend on the test, like a response
+// with the port of a test server or the inputs of a table test:
+//
+//	snap.TemplateFile(t, "testdata/welcome.golden").Bind("port", port).Bind("user", tc.user).Diff(got)
+//
+// The bound values are substituted into the text of the file before comparing, and the file keeps
+// its `<snap:param:name>` markers when it's updated, on the lines that still match.
+func TemplateFile(t testing.TB, path string) *Snapshot {
+	s := newSnapshot(t, "", 0)
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		t.Errorf("snap: %s", err)
+		abs = path
+	}
+	s.openFile(abs)
+	return s
+}
+
+// Bind binds value to the <snap:param:name> markers of a [Template] snapshot.
+func (s *Snapshot) Bind(name string, value string) *Snapshot {
+	c := *s
+	c.params = make(map[string]string, len(s.params)+1)
+	for k, v := range s.params {
+		c.params[k] = v
+	}
+	c.params[name] = value
+	return &c
+}
+
+// expand substitutes the bound parameters into text.
+func (s *Snapshot) expand(text string) (string, error) {
+	if !strings.Contains(text, "<snap:param:") {
+		return text, nil
+	}
+	var err error
+	expanded := paramMarker.ReplaceAllStringFunc(text, func(marker string) string {
+		name := paramMarker.FindStringSubmatch(marker)[1]
+		value, ok := s.params[name]
+		if !ok && err == nil {
+			err = fmt.Errorf("template parameter %q is not bound", name)
+		}
+		return value
+	})
+	return expanded, err
+}
+
+// expandLine is like [Snapshot.expand], but leaves unbound parameters in place.
+func (s *Snapshot) expandLine(line string) string {
+	return paramMarker.ReplaceAllStringFunc(line, func(marker string) string {
+		value, ok := s.params[paramMarker.FindStringSubmatch(marker)[1]]
+		if !ok {
+			return marker
+		}
+		return value
+	})
+}
@@ -0,0 +1,68 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	Template(t, "user <snap:param:name> created at <snap:ignore> UTC").Bind("name", "gopher").Diff("user gopher created at 12:00 UTC")
+
+	ft := newFakeT(t)
+	s := snapNoUpdate(ft, "user <snap:param:name> (<snap:param:id>)").Bind("name", "gopher").Bind("id", "1")
+	s.Diff("user gopher (2)")
+	if len(ft.errors) != 1 {
+		t.Fatalf("expected 1 error, got %q", ft.errors)
+	}
+	if !strings.Contains(ft.errors[0], "user gopher (1)") {
+		t.Errorf("expected the diff to show the expanded template, got %q", ft.errors[0])
+	}
+}
+
+func TestTemplateUnboundParameter(t *testing.T) {
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "user <snap:param:name>").Diff("user gopher")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `template parameter "name" is not bound`) {
+		t.Errorf("expected an unbound parameter error, got %q", ft.errors)
+	}
+}
+
+func TestBindCopies(t *testing.T) {
+	s := snapNoUpdate(t, "<snap:param:a>")
+	a := s.Bind("a", "1")
+	b := a.Bind("a", "2")
+	if got, _ := a.expand(a.text); got != "1" {
+		t.Errorf("expected Bind to leave the receiver unchanged, got %q", got)
+	}
+	if got, _ := b.expand(b.text); got != "2" {
+		t.Errorf("expected 2, got %q", got)
+	}
+}
+
+func TestUpdatePreservesTemplateParameters(t *testing.T) {
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Template(t, `user <snap:param:name>\nid: 1`).Bind(\"name\", name).Diff(got)\n}\n", "user <snap:param:name>\nid: 1")
+	s.Bind("name", "gopher").Diff("user gopher\nid: 2")
+
+	want := "snap.Template(t, `user <snap:param:name>\nid: 2`)"
+	if got := readFile(t, path); !strings.Contains(got, want) {
+		t.Errorf("expected source to contain %s, got:\n%s", want, got)
+	}
+}
+
+func TestTemplateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "welcome.golden")
+	if err := os.WriteFile(path, []byte("Welcome, <snap:param:user>!\nListening on 127.0.0.1:<snap:param:port>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	TemplateFile(t, path).Bind("user", "ada").Bind("port", "8080").Diff("Welcome, ada!\nListening on 127.0.0.1:8080\n")
+
+	t.Setenv("SNAP_UPDATE", "1")
+	ft := newFakeT(t)
+	TemplateFile(ft, path).Bind("user", "ada").Bind("port", "9090").Diff("Welcome back, ada!\nListening on 127.0.0.1:9090\n")
+	if got, want := readFile(t, path), "Welcome back, ada!\nListening on 127.0.0.1:<snap:param:port>\n"; got != want {
+		t.Errorf("expected the file to be updated keeping the markers of the matching lines to %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,73 @@
+package snap
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Columns sets the COLUMNS environment variable to columns for the duration of the test, so that
+// programs wrapping their output to the width of the terminal, like help screens, render the same
+// output on every machine. As it uses [testing.T.Setenv], it can't be used in parallel tests.
+//
+//	snap.Columns(t, 80)
+//	snap.Snap(t, want).Diff(runHelp(t))
+func Columns(t testing.TB, columns int) {
+	t.Helper()
+	t.Setenv("COLUMNS", strconv.Itoa(columns))
+}
+
+// WrapLines returns a [Normalizer] wrapping the lines of the value wider than width columns at
+// spaces, continuing them with the indentation of the line. Wide characters, like CJK ideographs
+// and emoji, take two columns, and combining marks none. Words longer than width are not split.
+// Together with [Columns], this keeps snapshots of help text independent of the terminal of the
+// machine running the tests.
+func WrapLines(width int) Normalizer {
+	return func(got string) string {
+		var sb strings.Builder
+		for _, line := range strings.SplitAfter(got, "\n") {
+			content, ending := cutLineEnding(line)
+			wrapLine(&sb, content, width)
+			sb.WriteString(ending)
+		}
+		return sb.String()
+	}
+}
+
+// wrapLine writes line to sb, wrapped at width columns.
+func wrapLine(sb *strings.Builder, line string, width int) {
+	if displayWidth(line) <= width {
+		sb.WriteString(line)
+		return
+	}
+
+	rest := strings.TrimLeft(line, " \t")
+	indent := line[:len(line)-len(rest)]
+	indentLen := displayWidth(indent)
+	sb.WriteString(indent)
+	n := indentLen // Columns of the current line.
+	for first := true; rest != ""; first = false {
+		// Split the next word with the spaces before it, which are kept unless the line wraps.
+		word := strings.TrimLeft(rest, " \t")
+		space := rest[:len(rest)-len(word)]
+		if i := strings.IndexAny(word, " \t"); i >= 0 {
+			word = word[:i]
+		}
+		rest = rest[len(space)+len(word):]
+		if word == "" {
+			break // Drop trailing spaces.
+		}
+
+		wordLen := displayWidth(word)
+		if !first && n+displayWidth(space)+wordLen > width {
+			sb.WriteString("\n")
+			sb.WriteString(indent)
+			n = indentLen
+		} else {
+			sb.WriteString(space)
+			n += displayWidth(space)
+		}
+		sb.WriteString(word)
+		n += wordLen
+	}
+}
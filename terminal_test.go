@@ -0,0 +1,25 @@
+package snap
+
+import (
+	"os"
+	"testing"
+)
+
+func TestColumns(t *testing.T) {
+	Columns(t, 42)
+	if got := os.Getenv("COLUMNS"); got != "42" {
+		t.Errorf("COLUMNS = %q, want 42", got)
+	}
+}
+
+func TestWrapLines(t *testing.T) {
+	help := "Usage: app [flags]\n  -v  print every step of the build, with timings\n\n" +
+		"A very-long-word-that-does-not-fit\r\n"
+
+	Snap(t, `Usage: app [flags]
+  -v  print every step of
+  the build, with timings
+
+A
+very-long-word-that-does-not-fit`+"\r\n").Normalize(WrapLines(28)).Diff(help)
+}
@@ -0,0 +1,38 @@
+// Package api is a package whose API is snapshotted by TestPackageAPI.
+package api
+
+import "io"
+
+// Version is documented.
+const Version, build = "1.0", 3
+
+var (
+	Default = New()
+	cache   map[string]int
+)
+
+// Client is exported.
+type Client struct {
+	Name string // Comment.
+	io.Reader
+	token string
+	a, B  int
+	*options
+}
+
+type options struct{}
+
+type Handler interface {
+	Handle(string) error
+	close()
+}
+
+func New() *Client { return &Client{} }
+
+func (c *Client) Do(req string) (string, error) { return req, nil }
+
+func (c *Client) retry() {}
+
+func (o options) Set() {}
+
+func helper() {}
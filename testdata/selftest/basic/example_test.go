@@ -0,0 +1,41 @@
+// Package example is a synthetic test package, whose snapshots are all out of date.
+package example
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/KasonBraley/snap"
+)
+
+// greet is not a snapshot, and must not be touched by updates.
+func greet(name string) string {
+	return fmt.Sprintf("Hello, %s!", name)
+}
+
+func TestGreet(t *testing.T) {
+	snap.Snap(t, "Hello, world").Diff(greet("gopher"))
+
+	check := func(name string, want *snap.Snapshot) {
+		t.Helper()
+		want.Diff(greet(name))
+	}
+	check("a", snap.Snap(t, "Hi, a!"))
+	check("b", snap.Snap(t, "Hello, b!")) // Up to date.
+}
+
+func TestLines(t *testing.T) {
+	lines := []string{"one", "two", "three"}
+	snap.Snap(t, `
+one
+two
+four`).Diff("\n" + strings.Join(lines, "\n"))
+
+	snap.Snap(t, "id 1, created at <snap:ignore> UTC").Diff("id 2, created at 12:00 UTC")
+}
+
+// TestGrow updates a snapshot to more lines, so it is the last of the file.
+func TestGrow(t *testing.T) {
+	snap.Snap(t, "").Diff("a\nb\nc")
+}
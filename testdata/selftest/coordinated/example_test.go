@@ -0,0 +1,38 @@
+// Package example is a synthetic test package updated by snap.Run, whose snapshots grow by several
+// lines, and are updated by parallel subtests.
+package example
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/KasonBraley/snap"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(snap.Run(m))
+}
+
+func TestGrow(t *testing.T) {
+	snap.Snap(t, "one").Diff("one\ntwo\nthree")
+	snap.Snap(t, "four").Diff("four\nfive")
+}
+
+func TestParallel(t *testing.T) {
+	cases := []struct {
+		n    int
+		want *snap.Snapshot
+	}{
+		{n: 1, want: snap.Snap(t, "")},
+		{n: 2, want: snap.Snap(t, "")},
+		{n: 3, want: snap.Snap(t, "")},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run("", func(t *testing.T) {
+			t.Parallel()
+			tc.want.Diff(strings.Repeat("x\n", tc.n))
+		})
+	}
+}
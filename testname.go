@@ -0,0 +1,71 @@
+package snap
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	testNamesMu sync.Mutex
+	// testNames maps the names derived for anonymous subtests to the names the testing package gave
+	// them, to detect two subtests deriving the same name.
+	testNames = make(map[string]string)
+	// derivedNames maps the names the testing package gave to anonymous subtests to the names
+	// derived for them, so that all the snapshots of a subtest use the name derived from the first.
+	derivedNames = make(map[string]string)
+)
+
+// anonymousSubtest matches the names the testing package gives to subtests run with an empty name,
+// like "#00".
+var anonymousSubtest = regexp.MustCompile(`^#\d+$`)
+
+// testName returns the name identifying the test of the snapshot in baselines, reports and failure
+// hooks. Subtests run with t.Run("", ...) are named by their index, like "TestTable/#03", which
+// changes when cases are added before them, so their name is derived from the first line of the
+// first snapshot of the subtest instead, or from the line of the call if the snapshot is empty.
+// Subtests deriving the same name get a numbered suffix, like "TestTable/ok#2", in the order they
+// run.
+func (s *Snapshot) testName() string {
+	name := s.t.Name()
+	parent, last := "", name
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		parent, last = name[:i+1], name[i+1:]
+	}
+	if !anonymousSubtest.MatchString(last) {
+		return name
+	}
+
+	testNamesMu.Lock()
+	defer testNamesMu.Unlock()
+	if derived, ok := derivedNames[name]; ok {
+		return derived
+	}
+	derived := parent + subtestName(s.text, s.location.line)
+	candidate := derived
+	for n := 2; testNames[candidate] != ""; n++ {
+		candidate = fmt.Sprintf("%s#%d", derived, n)
+	}
+	testNames[candidate] = name
+	derivedNames[name] = candidate
+	return candidate
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// subtestName derives a subtest name from the first non-blank line of the snapshot text, like the
+// testing package does from names with spaces, or from the line of the call if there is none.
+func subtestName(text string, line int) string {
+	for _, l := range strings.Split(text, "\n") {
+		l = strings.Trim(unsafeNameChars.ReplaceAllString(l, "_"), "_")
+		if l == "" {
+			continue
+		}
+		if len(l) > 40 {
+			l = strings.TrimRight(l[:40], "_")
+		}
+		return l
+	}
+	return fmt.Sprintf("line_%d", line)
+}
@@ -0,0 +1,38 @@
+package snap
+
+import (
+	"testing"
+)
+
+func TestTestNameAnonymousSubtests(t *testing.T) {
+	var names []string
+	for _, text := range []string{"ok: 1 item", "", "ok: 1 item", "ok: 2 items"} {
+		t.Run("", func(t *testing.T) {
+			names = append(names, Snap(t, text).testName())
+			// Later snapshots of the subtest keep its name.
+			if name := Snap(t, "other").testName(); name != names[len(names)-1] {
+				t.Errorf("expected %s for the second snapshot, got %s", names[len(names)-1], name)
+			}
+		})
+	}
+	t.Run("named", func(t *testing.T) {
+		names = append(names, Snap(t, "ok").testName())
+	})
+
+	want := []string{
+		"TestTestNameAnonymousSubtests/ok_1_item",
+		"TestTestNameAnonymousSubtests/line_11",
+		"TestTestNameAnonymousSubtests/ok_1_item#2",
+		"TestTestNameAnonymousSubtests/ok_2_items",
+		"TestTestNameAnonymousSubtests/named",
+	}
+	if len(names) != len(want) {
+		t.Fatalf("expected %q, got %q", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("expected %q, got %q", want, names)
+			break
+		}
+	}
+}
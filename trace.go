@@ -0,0 +1,98 @@
+package snap
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime/trace"
+	"sort"
+	"sync"
+	"time"
+)
+
+// spanTotal is the time spent in the spans of a phase.
+type spanTotal struct {
+	count    int
+	duration time.Duration
+}
+
+var (
+	spansMu sync.Mutex
+	// spanHooks holds the functions registered with [OnSpan].
+	spanHooks []func(name string, test string) (end func())
+	// spanTotals holds the time spent in each phase by the test binary, by name.
+	spanTotals = make(map[string]spanTotal)
+)
+
+// OnSpan registers start to be called when a phase of the work of snap begins, with SNAP_TRACE
+// set, to forward it to a tracing system like OpenTelemetry. The phases are "diff", comparing a
+// value with a snapshot, "parse", parsing the source file of a snapshot to update it, and "write",
+// writing a file. start is called with the name of the phase and of the test, and returns the
+// function called when the phase ends:
+//
+//	snap.OnSpan(func(name string, test string) func() {
+//		_, span := tracer.Start(ctx, "snap."+name, trace.WithAttributes(attribute.String("test", test)))
+//		return func() { span.End() }
+//	})
+//
+// Typically called from TestMain.
+func OnSpan(start func(name string, test string) (end func())) {
+	spansMu.Lock()
+	defer spansMu.Unlock()
+	spanHooks = append(spanHooks, start)
+}
+
+// traceMode reports whether SNAP_TRACE is set.
+func traceMode() bool {
+	_, ok := os.LookupEnv("SNAP_TRACE")
+	return ok
+}
+
+// startSpan starts the phase name of the work for test, when SNAP_TRACE is set, and returns the
+// function ending it. The phase is a region of the execution trace, written by go test -trace,
+// calls the functions registered with [OnSpan], and is added to the time spent reported by [Run].
+func startSpan(name string, test string) (end func()) {
+	if !traceMode() {
+		return func() {}
+	}
+	spansMu.Lock()
+	hooks := spanHooks
+	spansMu.Unlock()
+
+	start := time.Now()
+	region := trace.StartRegion(context.Background(), "snap."+name)
+	ends := make([]func(), len(hooks))
+	for i, hook := range hooks {
+		ends[i] = hook(name, test)
+	}
+	return func() {
+		for i := len(ends) - 1; i >= 0; i-- {
+			if ends[i] != nil {
+				ends[i]()
+			}
+		}
+		region.End()
+		spansMu.Lock()
+		defer spansMu.Unlock()
+		total := spanTotals[name]
+		total.count++
+		total.duration += time.Since(start)
+		spanTotals[name] = total
+	}
+}
+
+// printSpans prints the time spent in each phase to w, if any.
+func printSpans(w io.Writer) {
+	spansMu.Lock()
+	defer spansMu.Unlock()
+	names := make([]string, 0, len(spanTotals))
+	for name := range spanTotals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		total := spanTotals[name]
+		fmt.Fprintf(w, "snap: %s: %d spans, %s\n", name, total.count, total.duration)
+	}
+}
@@ -0,0 +1,46 @@
+package snap
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestOnSpan(t *testing.T) {
+	var mu sync.Mutex
+	var spans []string
+	OnSpan(func(name string, test string) func() {
+		mu.Lock()
+		spans = append(spans, "start "+name+" "+test)
+		mu.Unlock()
+		return func() {
+			mu.Lock()
+			spans = append(spans, "end "+name)
+			mu.Unlock()
+		}
+	})
+	t.Cleanup(func() {
+		spansMu.Lock()
+		defer spansMu.Unlock()
+		spanHooks = spanHooks[:len(spanHooks)-1]
+	})
+
+	// Spans are only started with SNAP_TRACE set.
+	Snap(t, "a").Diff("a")
+	if len(spans) != 0 {
+		t.Fatalf("expected no spans without SNAP_TRACE, got %q", spans)
+	}
+
+	t.Setenv("SNAP_TRACE", "1")
+	Snap(t, "a").Diff("a")
+	if got, want := strings.Join(spans, "\n"), "start diff TestOnSpan\nend diff"; got != want {
+		t.Errorf("expected a diff span, got %q", got)
+	}
+
+	var buf bytes.Buffer
+	printSpans(&buf)
+	if !strings.Contains(buf.String(), "snap: diff: ") {
+		t.Errorf("expected the time spent comparing to be printed, got %q", buf.String())
+	}
+}
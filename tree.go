@@ -0,0 +1,192 @@
+package snap
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var (
+	treeMu sync.Mutex
+	// treeCount counts the snapshots of each running test created with [SnapTree].
+	treeCount = make(map[testing.TB]int)
+	// treeUsed records the files of the snapshots created with [SnapTree], by tree directory, to
+	// prune the others.
+	treeUsed = make(map[string]map[string]bool)
+	// treeTests records the top-level tests that created snapshots with [SnapTree], by tree
+	// directory. Only their files are pruned: the others may have been skipped before SnapTree.
+	treeTests = make(map[string]map[string]bool)
+)
+
+// SnapTree creates a snapshot stored in a file of the directory dir, like [SnapFile], named after
+// the test: the snapshot of the subtest TestAPI/login/success is stored in
+// dir/TestAPI/login/success.snap, so that the files of large nested suites mirror their tests.
+// Further snapshots of a test are numbered, like success.2.snap. Unlike the .snap files of
+// [SnapFile], the files are compared byte for byte.
+//
+//	snap.SnapTree(t, "testdata/snapshots").Diff(response)
+//
+// With SNAP_PRUNE=1 and the tests run with [Run], the files of the directory that no test used are
+// removed after a complete run that passed, along with the directories left empty. Only the files
+// of the top-level tests that used the directory are pruned, so that skipped tests keep theirs; the
+// files of removed top-level tests are left to remove by hand, and so are those of subtests
+// skipped before SnapTree.
+func SnapTree(t testing.TB, dir string) *Snapshot {
+	s := newSnapshot(t, "", 0)
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		t.Errorf("snap: %s", err)
+		abs = dir
+	}
+
+	treeMu.Lock()
+	if treeCount[t] == 0 {
+		t.Cleanup(func() {
+			treeMu.Lock()
+			delete(treeCount, t)
+			treeMu.Unlock()
+		})
+	}
+	treeCount[t]++
+	path := filepath.Join(abs, treePath(t.Name(), treeCount[t]))
+	if treeUsed[abs] == nil {
+		treeUsed[abs] = make(map[string]bool)
+	}
+	treeUsed[abs][path] = true
+	if treeTests[abs] == nil {
+		treeTests[abs] = make(map[string]bool)
+	}
+	treeTests[abs][treeTest(treePath(t.Name(), 1))] = true
+	treeMu.Unlock()
+
+	s.openFile(path)
+	return s
+}
+
+// treePath returns the path of the index-th snapshot of the test named name in a tree directory.
+func treePath(name string, index int) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		parts[i] = treeSegment(part)
+	}
+	if index > 1 {
+		parts[len(parts)-1] += "." + strconv.Itoa(index)
+	}
+	return filepath.Join(parts...) + ".snap"
+}
+
+// treeTest returns the name of the top-level test owning the file of a tree directory at the
+// relative path rel, as named in the path.
+func treeTest(rel string) string {
+	top, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	// Top-level tests are named after functions, so the name ends at the extension or the index.
+	name, _, _ := strings.Cut(top, ".")
+	return name
+}
+
+// treeSegment returns the name of a test turned into a file name, replacing the characters that
+// aren't allowed in file names on some systems.
+func treeSegment(name string) string {
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r < ' ' || strings.ContainsRune(`<>:"\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// pruneMode reports whether SNAP_PRUNE is set.
+func pruneMode() bool {
+	_, ok := os.LookupEnv("SNAP_PRUNE")
+	return ok
+}
+
+// completeRun reports whether all the tests of the package ran, that is they weren't selected with
+// -run or -skip, and -short wasn't set.
+func completeRun() bool {
+	for _, name := range []string{"test.run", "test.skip"} {
+		if f := flag.Lookup(name); f != nil && f.Value.String() != "" {
+			return false
+		}
+	}
+	return !testing.Short()
+}
+
+// pruneTrees removes the snapshot files of the [SnapTree] directories that weren't used by the
+// run, and the directories left empty, and prints the removed files to w.
+func pruneTrees(w io.Writer) error {
+	treeMu.Lock()
+	dirs := make([]string, 0, len(treeUsed))
+	for dir := range treeUsed {
+		dirs = append(dirs, dir)
+	}
+	treeMu.Unlock()
+	sort.Strings(dirs)
+
+	var errs []error
+	for _, dir := range dirs {
+		if err := pruneTree(dir, w); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// pruneTree removes the unused snapshot files of the tree directory dir belonging to the top-level
+// tests that used it, then its empty directories, from the deepest up. dir itself is kept.
+func pruneTree(dir string, w io.Writer) error {
+	var dirs []string
+	var errs []error
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		treeMu.Lock()
+		keep := treeUsed[dir][path] || !treeTests[dir][treeTest(rel)]
+		treeMu.Unlock()
+		if keep || filepath.Ext(path) != ".snap" {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		fmt.Fprintf(w, "snap: Pruned %s\n", relativePath(path))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		entries, err := os.ReadDir(dirs[i])
+		if err == nil && len(entries) == 0 {
+			err = os.Remove(dirs[i])
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
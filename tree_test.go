@@ -0,0 +1,106 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTreePath(t *testing.T) {
+	cases := []struct {
+		name  string
+		index int
+		want  string
+	}{
+		{name: "TestAPI/login/success", index: 1, want: "TestAPI/login/success.snap"},
+		{name: "TestAPI/login/success", index: 2, want: "TestAPI/login/success.2.snap"},
+		{name: "TestAPI/a:b?/..", index: 1, want: "TestAPI/a_b_/_.snap"},
+	}
+	for _, tc := range cases {
+		if got := treePath(tc.name, tc.index); got != filepath.FromSlash(tc.want) {
+			t.Errorf("treePath(%q, %d) = %q, want %q", tc.name, tc.index, got, tc.want)
+		}
+	}
+}
+
+func TestSnapTree(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SNAP_UPDATE", "1")
+	t.Run("login", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			ft := newFakeT(t)
+			SnapTree(ft, dir).Diff("200 OK")
+			SnapTree(ft, dir).Diff("welcome")
+			if len(ft.errors) != 0 {
+				t.Errorf("unexpected errors %q", ft.errors)
+			}
+		})
+	})
+	for file, want := range map[string]string{
+		"TestSnapTree/login/success.snap":   "200 OK",
+		"TestSnapTree/login/success.2.snap": "welcome",
+	} {
+		if got := readFile(t, filepath.Join(dir, filepath.FromSlash(file))); got != want {
+			t.Errorf("expected %s to contain %q, got %q", file, want, got)
+		}
+	}
+	os.Unsetenv("SNAP_UPDATE")
+
+	ft := &namedT{fakeT: newFakeT(t), name: "TestSnapTree/login/success"}
+	SnapTree(ft, dir).Diff("200 OK")
+	SnapTree(ft, dir).Diff("goodbye")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "success.2.snap") {
+		t.Errorf("expected only the second snapshot to differ, got %q", ft.errors)
+	}
+}
+
+// namedT is a fakeT reporting the test name name, to create the snapshots of a test again.
+type namedT struct {
+	*fakeT
+	name string
+}
+
+func (n *namedT) Name() string { return n.name }
+
+func TestPruneTree(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{"TestA/kept.snap", "TestA/old.snap", "TestB/sub/old.snap", "TestB/notes.txt", "TestB.2.snap", "TestC/skipped.snap"} {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	treeMu.Lock()
+	treeUsed[dir] = map[string]bool{filepath.Join(dir, "TestA", "kept.snap"): true}
+	// TestC didn't use the tree, it may have been skipped.
+	treeTests[dir] = map[string]bool{"TestA": true, "TestB": true}
+	treeMu.Unlock()
+	t.Cleanup(func() {
+		treeMu.Lock()
+		delete(treeUsed, dir)
+		delete(treeTests, dir)
+		treeMu.Unlock()
+	})
+
+	var out strings.Builder
+	if err := pruneTree(dir, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "TestA/old.snap") || strings.Contains(out.String(), "kept.snap") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+
+	var left []string
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		rel, _ := filepath.Rel(dir, path)
+		left = append(left, filepath.ToSlash(rel))
+		return nil
+	})
+	if got, want := strings.Join(left, " "), ". TestA TestA/kept.snap TestB TestB/notes.txt TestC TestC/skipped.snap"; got != want {
+		t.Errorf("expected %q to be left, got %q", want, got)
+	}
+}
@@ -0,0 +1,49 @@
+package snap
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// typedMarker matches the markers that only match text of a given shape: <snap:uuid>,
+// <snap:timestamp>, <snap:url>, <snap:regexp:pattern>, and <snap:ignore-lines>, which spans lines.
+var typedMarker = regexp.MustCompile(`<snap:(?:uuid|timestamp|url|ignore-lines|regexp:[^>\n]+)>`)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// markerSpec is what a marker matches in got.
+type markerSpec struct {
+	// accept reports whether the marker matches text, or is nil if it matches any text.
+	accept func(text string) bool
+	// multiline is set if the marker matches text spanning several lines.
+	multiline bool
+}
+
+// markerSpecs returns the specs of the markers of snapshot matching part of the input, in order.
+func markerSpecs(snapshot string) ([]markerSpec, error) {
+	var specs []markerSpec
+	for _, m := range matchingMarker.FindAllStringSubmatch(snapshot, -1) {
+		var spec markerSpec
+		switch {
+		case m[4] == "uuid":
+			spec.accept = uuidPattern.MatchString
+		case m[4] == "timestamp":
+			spec.accept = func(text string) bool {
+				_, ok := parseTimestamp(text)
+				return ok
+			}
+		case m[4] == "url":
+			spec.accept = isURL
+		case m[4] == "ignore-lines":
+			spec.multiline = true
+		case m[5] != "":
+			re, err := regexp.Compile(`^(?:` + m[5] + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern in %s: %w", m[0], err)
+			}
+			spec.accept = re.MatchString
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
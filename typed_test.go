@@ -0,0 +1,46 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTypedMarkers(t *testing.T) {
+	Snap(t, "id <snap:uuid> created <snap:timestamp>.").Diff("id 123e4567-e89b-12d3-a456-426614174000 created 2024-05-14T10:00:00Z.")
+	Snap(t, "commit <snap:regexp:[0-9a-f]{7}> on main").Diff("commit 4e17a7b on main")
+	Snap(t, "redirect to <snap:url>.").Diff("redirect to https://example.com/login?next=%2F.")
+	Snap(t, "header\n<snap:ignore-lines>\nfooter").Diff("header\nline 1\nline 2\nline 3\nfooter")
+
+	cases := []struct {
+		snapshot string
+		got      string
+	}{
+		{snapshot: "id <snap:uuid>.", got: "id 123e4567."},
+		{snapshot: "at <snap:timestamp>.", got: "at noon."},
+		{snapshot: "commit <snap:regexp:[0-9a-f]{7}>.", got: "commit 4e17a7bc."},
+		{snapshot: "redirect to <snap:url>.", got: "redirect to /login."},
+		{snapshot: "a <snap:ignore>\nb", got: "a 1\n2\nb"},
+	}
+	for _, tc := range cases {
+		ft := newFakeT(t)
+		snapNoUpdate(ft, tc.snapshot).Diff(tc.got)
+		if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "differs") {
+			t.Errorf("expected %q not to match %q, got %q", tc.got, tc.snapshot, ft.errors)
+		}
+	}
+
+	ft := newFakeT(t)
+	snapNoUpdate(ft, "commit <snap:regexp:[0-9a-f>.").Diff("commit 1.")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "snap: invalid pattern in <snap:regexp:[0-9a-f>: ") {
+		t.Errorf("expected an invalid pattern error, got %q", ft.errors)
+	}
+}
+
+func TestTypedMarkersPreserved(t *testing.T) {
+	snapshot := "id <snap:uuid>\n<snap:ignore-lines>\nname: old"
+	got := "id 123e4567-e89b-12d3-a456-426614174000\ndetails\nname: new"
+	want := "id <snap:uuid>\n<snap:ignore-lines>\nname: new"
+	if updated := preserveMarkers(snapshot, got, func(line string) string { return line }); updated != want {
+		t.Errorf("expected the markers to be preserved in %q, got %q", want, updated)
+	}
+}
@@ -0,0 +1,62 @@
+package snap
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/KasonBraley/snap/internal/undo"
+)
+
+// journalMaxAge is how long the undo journals of runs are kept, see [undo.Prune].
+const journalMaxAge = 7 * 24 * time.Hour
+
+var (
+	journalMu sync.Mutex
+	// journaled holds the files rewritten by this test binary, recorded in its undo journal for
+	// `snap undo`.
+	journaled = make(map[string]bool)
+	pruneOnce sync.Once
+)
+
+// readOriginal returns the content of the file at path before it's first rewritten by this test
+// binary, to record it in the undo journal, or nil if it was already rewritten.
+func readOriginal(path string) ([]byte, error) {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+	if journaled[path] {
+		return nil, nil
+	}
+	return os.ReadFile(path)
+}
+
+// recordUndo records in the undo journal that the file at path, whose content was original
+// before this test binary first rewrote it, now contains data. The journals of old runs are pruned
+// when this test binary first records one.
+func recordUndo(path string, original []byte, data []byte) error {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	dir, err := undo.Dir()
+	if err != nil {
+		return err
+	}
+	pruneOnce.Do(func() {
+		// A journal that can't be pruned now can be the next time.
+		_ = undo.Prune(dir, journalMaxAge)
+	})
+	f := undo.File{Path: path, SHA256: undo.Hash(data)}
+	if !journaled[path] {
+		if f.Copy, err = undo.SaveCopy(dir, original); err != nil {
+			return err
+		}
+	}
+	// The test binaries of one go test invocation are started by the same go command.
+	run := fmt.Sprint(os.Getppid())
+	if err := undo.Append(dir, fmt.Sprintf("%s-%d.jsonl", run, os.Getpid()), moduleRoot(), run, f); err != nil {
+		return err
+	}
+	journaled[path] = true
+	return nil
+}
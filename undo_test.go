@@ -0,0 +1,48 @@
+package snap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KasonBraley/snap/internal/undo"
+)
+
+func TestUndoJournal(t *testing.T) {
+	t.Setenv("SNAP_UNDO_DIR", t.TempDir())
+	journalMu.Lock()
+	saved := journaled
+	journaled = make(map[string]bool)
+	journalMu.Unlock()
+	t.Cleanup(func() {
+		journalMu.Lock()
+		journaled = saved
+		journalMu.Unlock()
+	})
+
+	path := filepath.Join(t.TempDir(), "example_test.go")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range []string{"first", "second"} {
+		if err := writeFile(path, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir, _ := undo.Dir()
+	journals, _, err := undo.Last(dir, moduleRoot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(journals) != 1 || len(journals[0].Files) != 1 {
+		t.Fatalf("expected one journal with one file, got %+v", journals)
+	}
+	f := journals[0].Files[0]
+	if f.Path != path || f.SHA256 != undo.Hash([]byte("second")) {
+		t.Errorf("expected the hash of the last write, got %+v", f)
+	}
+	if b, err := os.ReadFile(f.Copy); err != nil || string(b) != "original" {
+		t.Errorf("expected a copy of the original content, got %q, %v", b, err)
+	}
+}
@@ -0,0 +1,925 @@
+package snap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/KasonBraley/snap/internal/diff"
+	"github.com/KasonBraley/snap/internal/imports"
+	"github.com/KasonBraley/snap/internal/rewriter"
+	"github.com/KasonBraley/snap/internal/source"
+)
+
+// update rewrites the snapshot in the source code to text. If version differs from the version of
+// the snapshot, the [Snapshot.Version] call is rewritten (or added) as well.
+func (s *Snapshot) update(text string, version int) {
+	s.t.Helper()
+	text = s.mergeUpdate(text)
+	if !s.checkPaths(text) {
+		return
+	}
+	if s.file != "" {
+		s.updateFile(text)
+		return
+	}
+
+	path, err := s.sourcePath()
+	if err != nil {
+		s.t.Errorf("snap: %s", err)
+		return
+	}
+	unlock := lockFile(path)
+	defer unlock()
+	src, err := os.ReadFile(path)
+	if err != nil {
+		s.t.Errorf("snap: Failed to read source file %q: %s", path, err)
+		return
+	}
+	r, err := s.newRewrite(text, version)
+	if err != nil {
+		s.t.Errorf("snap: %s", err)
+		return
+	}
+
+	// Rewrite into a buffer first to avoid writing garbage(or nothing at all) back to the source
+	// file. Only if this succeeds, we then flush it to the source file.
+	out, err := r.apply(path, src)
+	if err != nil {
+		s.t.Errorf("snap: Failed to rewrite snapshot, aborting: %s", err)
+		return
+	}
+
+	out.pos.Filename = relativePath(out.pos.Filename)
+	if queueUpdate(path, r, s.testName()) {
+		s.updated(text, version)
+		s.logf(slog.LevelInfo, "Updating %s when the tests finish\n", out.pos)
+		s.emitUpdated()
+		s.lintUpdated(text)
+		return
+	}
+
+	final, err := finalizeSource(path, src, out.src)
+	if err != nil {
+		s.t.Errorf("snap: Failed to rewrite snapshot, aborting: %s", err)
+		return
+	}
+	if err := writeFile(path, final); err != nil {
+		s.t.Errorf("snap: Failed to write to source file %q: %s", path, err)
+		return
+	}
+	recordShift(path, s.location.line, src, final)
+	s.updated(text, version)
+	s.logf(slog.LevelInfo, "Updated %s (bytes %d-%d)\n", out.pos, out.start, out.end)
+	s.emitUpdated()
+	s.lintUpdated(text)
+}
+
+// mergeUpdate returns the text to update the snapshot to for the value text, keeping the markers
+// and critical sections of the snapshot. Its long lines are wrapped with SNAP_WRAP_LINES.
+func (s *Snapshot) mergeUpdate(text string) string {
+	old := unwrapSoft(s.text)
+	text = restoreCritical(old, preserveMarkers(old, text, s.expandLine))
+	if width := softWrapWidth(); width > 0 {
+		text = softWrap(text, width)
+	}
+	return text
+}
+
+// sourceLine returns the current line of the Snap call in its source file, which updates of the
+// snapshots above it may have moved.
+func (s *Snapshot) sourceLine() int {
+	path, err := s.sourcePath()
+	if err != nil {
+		return s.location.line
+	}
+	return shiftedLine(path, s.location.line)
+}
+
+// newRewrite returns the rewrite updating the snapshot to text and version, configured by the
+// environment variables.
+func (s *Snapshot) newRewrite(text string, version int) (rewrite, error) {
+	r := rewrite{line: s.sourceLine(), text: text, wrapped: s.wrapped}
+	if s.wrapped {
+		r.old = s.text
+	}
+	if err := r.configure(); err != nil {
+		return rewrite{}, err
+	}
+	if version != s.version && !s.wrapped {
+		r.version = version
+		r.setVersion = true
+		r.addVersion = !s.versioned
+	}
+	return r, nil
+}
+
+// configure sets the options of r from the environment variables.
+func (r *rewrite) configure() error {
+	_, r.collapseSprintf = os.LookupEnv("SNAP_COLLAPSE_SPRINTF")
+	if n, err := strconv.Atoi(os.Getenv("SNAP_FOLD_LINES")); err == nil {
+		r.foldLines = n
+	}
+	var err error
+	r.format, err = parseFormatMode(os.Getenv("SNAP_FORMAT"))
+	return err
+}
+
+func init() {
+	rewriter.Source = rewriteSource
+}
+
+// rewriteSource returns src, the source file filename, with the snapshot of the Snap call at line
+// updated from old to got, for the snaptest package. It's the source [Snapshot.Update] would
+// write, without reading or writing any file.
+func rewriteSource(filename string, src []byte, line int, old string, got string) ([]byte, error) {
+	s := &Snapshot{text: old}
+	r := rewrite{line: line, text: s.mergeUpdate(got)}
+	if err := r.configure(); err != nil {
+		return nil, err
+	}
+	out, err := r.apply(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	return finalizeSource(filename, src, out.src)
+}
+
+// lintUpdated logs a warning for each rule of SNAP_LINT that the updated snapshot text doesn't
+// follow and that can't be fixed, and for the invisible characters that were escaped in it.
+func (s *Snapshot) lintUpdated(text string) {
+	s.t.Helper()
+	rules, _ := parseLintRules(os.Getenv("SNAP_LINT"))
+	for _, w := range append(rules.check(text), invisibleWarnings(text)...) {
+		s.logf(slog.LevelWarn, "Warning: %s", w)
+	}
+}
+
+// updatedSnapshot is the text and version a snapshot was updated to.
+type updatedSnapshot struct {
+	text    string
+	version int
+}
+
+var (
+	updatedMu sync.Mutex
+	// updated holds the snapshots updated by this test binary. The binary still contains their old
+	// text, so when tests run again, like with go test -count=2, they compare with the updated
+	// text instead, as a rebuilt binary would.
+	updated = make(map[sourceLocation]updatedSnapshot)
+)
+
+// updated records that the snapshot was updated to text and version.
+func (s *Snapshot) updated(text string, version int) {
+	updatedMu.Lock()
+	defer updatedMu.Unlock()
+	updated[s.location] = updatedSnapshot{text: text, version: version}
+}
+
+// current returns the snapshot as it is in the source code, with the updates made by this test
+// binary.
+func (s *Snapshot) current() *Snapshot {
+	updatedMu.Lock()
+	u, ok := updated[s.location]
+	updatedMu.Unlock()
+	if !ok {
+		return s
+	}
+
+	c := *s
+	c.text = u.text
+	c.versioned = s.versioned || u.version != s.version
+	c.version = u.version
+	return &c
+}
+
+// literal is the snapshot literal in the source code.
+type literal struct {
+	pos   token.Position
+	raw   bool // Whether it's a raw string literal.
+	found bool
+}
+
+// String returns the position of the literal, or the position of the Snap call's line if the
+// literal wasn't found. The file is relative to the module root.
+func (l literal) String() string {
+	return l.pos.String()
+}
+
+// findLiteral finds the snapshot literal (the second argument of the Snap call) in the source, or
+// the file of a [SnapFile] snapshot.
+func (s *Snapshot) findLiteral() literal {
+	if s.file != "" {
+		// The lines of a snapshot file are the lines of the snapshot, like for raw string literals,
+		// unless it has comments.
+		return literal{pos: token.Position{Filename: relativePath(s.file), Line: 1}, raw: !s.snapFormat, found: true}
+	}
+	fallback := literal{pos: token.Position{Filename: relativePath(s.location.file), Line: s.location.line}}
+	if s.location.file == "" {
+		return fallback
+	}
+
+	path, err := s.sourcePath()
+	if err != nil {
+		return fallback
+	}
+	line := shiftedLine(path, s.location.line)
+	fset := token.NewFileSet()
+	endSpan := startSpan("parse", s.t.Name())
+	f, _ := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	endSpan()
+	if f == nil {
+		return fallback
+	}
+	m := source.NewMatcher(f)
+
+	var arg ast.Expr
+	ast.Inspect(f, func(n ast.Node) bool {
+		callExpr, ok := n.(*ast.CallExpr)
+		if !ok || arg != nil || line != fset.Position(callExpr.Pos()).Line {
+			return arg == nil
+		}
+		if m.IsSnapCall(callExpr) {
+			arg = callExpr.Args[1]
+		} else if s.wrapped {
+			arg = source.WrapperArg(callExpr, s.text)
+		}
+		return arg == nil
+	})
+	if arg == nil {
+		return fallback
+	}
+
+	lit, isLit := arg.(*ast.BasicLit)
+	pos := fset.Position(arg.Pos())
+	pos.Filename = relativePath(pos.Filename)
+	return literal{
+		pos:   pos,
+		raw:   isLit && isRawLiteral(lit.Value),
+		found: true,
+	}
+}
+
+// rewrite describes the source change of updating a single snapshot.
+type rewrite struct {
+	line int    // Line of the Snap call.
+	text string // New snapshot text.
+
+	// Whether a fmt.Sprintf call with only constant arguments is replaced by a string literal.
+	collapseSprintf bool
+
+	format formatMode
+
+	// Snapshots with at least this many lines get surrounded by //snap:begin and //snap:end
+	// comments, so editors can fold them. Zero disables folding.
+	foldLines int
+
+	// For snapshots created by a helper with [SnapHelper], the current snapshot text, which
+	// identifies the argument of the helper call to rewrite.
+	wrapped bool
+	old     string
+
+	setVersion bool // Whether to write version to the [Snapshot.Version] call.
+	addVersion bool // Whether the [Snapshot.Version] call needs to be added.
+	version    int
+}
+
+// replacement replaces src[start:end] with text.
+type replacement struct {
+	start, end int
+	text       string
+	snapshot   bool // Whether this replaces the snapshot literal.
+}
+
+// rewritten is the result of applying a rewrite.
+type rewritten struct {
+	src []byte
+	// pos is the position of the snapshot literal, and [start, end) its byte range in src.
+	pos        token.Position
+	start, end int
+	// call is the number of calls before the call holding the snapshot in the file, in the order of
+	// [ast.Inspect], and arg the index of the snapshot among its arguments. Rewrites and formatting
+	// keep both, so the snapshot is found again by them, see [rewrite.relocate].
+	call, arg int
+}
+
+// apply returns src with the rewrite applied.
+//
+// The bytes of the literals are spliced into src directly instead of printing the modified AST, so
+// that everything outside of the edited literals, like comments, directives, and formatting that
+// gofmt would change, is preserved byte-for-byte.
+//
+// A file that doesn't parse, because of a syntax newer than the toolchain or an edit in progress,
+// is still rewritten if the syntax errors are outside of the declaration containing the snapshot.
+// The rewrite must then leave the errors as they were, and the file isn't formatted.
+func (r rewrite) apply(filename string, src []byte) (rewritten, error) {
+	if line, ok := conflictMarker(src); ok {
+		return rewritten{}, fmt.Errorf("%s:%d: file has merge conflict markers, resolve the conflict before updating snapshots",
+			relativePath(filename), line)
+	}
+	fset := token.NewFileSet()
+	endSpan := startSpan("parse", "")
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	endSpan()
+	var syntaxErrs scanner.ErrorList
+	if err != nil && (f == nil || !errors.As(err, &syntaxErrs)) {
+		return rewritten{}, err
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	versionLit := &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(r.version)}
+	m := source.NewMatcher(f)
+
+	var result rewritten
+	var replacements []replacement
+	var unsupported ast.Expr
+	var sprintf *ast.CallExpr
+	// The top-level declaration containing the Snap call.
+	var decl ast.Node
+	collapsed := false
+	foundCall := false
+	calls := 0
+	// The path from the root of the file to the current node.
+	var stack []ast.Node
+	// Traverse the AST and find snap.Snap function calls.
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, n)
+
+		// Check for function call expressions.
+		callExpr, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		call := calls
+		calls++
+		if r.line != fset.Position(callExpr.Pos()).Line {
+			return true
+		}
+
+		if m.IsVersionCall(callExpr) && r.setVersion {
+			arg := callExpr.Args[0]
+			replacements = append(replacements, replacement{start: offset(arg.Pos()), end: offset(arg.End()), text: versionLit.Value})
+			return true
+		}
+
+		// Check if the __second__ argument is a string literal, the first argument
+		// is for *testing.T.
+		var arg ast.Expr
+		if m.IsSnapCall(callExpr) {
+			arg = callExpr.Args[1]
+		} else if r.wrapped {
+			arg = source.WrapperArg(callExpr, r.old)
+		}
+		if arg == nil {
+			return true
+		}
+		foundCall = true
+		decl = stack[1]
+
+		if format, ok := sprintfFormat(arg); ok {
+			if !r.collapseSprintf || nonConstantArg(arg.(*ast.CallExpr)) != nil {
+				sprintf = arg.(*ast.CallExpr)
+				return true
+			}
+			// Replace the whole fmt.Sprintf call with a plain literal.
+			replacements = append(replacements, replacement{
+				start:    offset(arg.Pos()),
+				end:      offset(arg.End()),
+				text:     quoteLiteral(r.text, isRawLiteral(format.Value)),
+				snapshot: true,
+			})
+			collapsed = true
+		} else if strLit, ok := arg.(*ast.BasicLit); ok && strLit.Kind == token.STRING {
+			replacements = append(replacements, replacement{
+				start:    offset(strLit.Pos()),
+				end:      offset(strLit.End()),
+				text:     quoteLiteral(r.text, isRawLiteral(strLit.Value)),
+				snapshot: true,
+			})
+		} else if isConcatenation(arg) {
+			// Replace the whole concatenation with a single literal. Concatenations are usually
+			// used to split up multi-line snapshots, so prefer a raw string literal for those.
+			replacements = append(replacements, replacement{
+				start:    offset(arg.Pos()),
+				end:      offset(arg.End()),
+				text:     GoStringLiteral(r.text),
+				snapshot: true,
+			})
+		} else {
+			unsupported = arg
+			return true
+		}
+		result.pos = fset.Position(arg.Pos())
+		result.call, result.arg = call, slices.Index(callExpr.Args, arg)
+
+		if r.setVersion && r.addVersion {
+			end := offset(callExpr.End())
+			replacements = append(replacements, replacement{start: end, end: end, text: ".Version(" + versionLit.Value + ")"})
+			// The added call encloses the Snap call, so it comes first.
+			result.call++
+		}
+		if r.foldLines > 0 && strings.Count(r.text, "\n")+1 >= r.foldLines {
+			if stmt := enclosingStmt(stack); stmt != nil {
+				replacements = append(replacements, foldMarkers(src, offset(stmt.Pos()), offset(stmt.End()))...)
+			}
+		}
+		return true
+	})
+
+	if len(syntaxErrs) > 0 {
+		if e := syntaxErrorIn(syntaxErrs, decl, fset); e != nil || !foundCall {
+			if e == nil {
+				e = syntaxErrs[0]
+			}
+			return rewritten{}, fmt.Errorf("%s: syntax error, not updating the snapshot at line %d: %s",
+				e.Pos, r.line, e.Msg)
+		}
+	}
+	if !foundCall {
+		return rewritten{}, fmt.Errorf("%s:%d: no Snap call found, the call form is not supported", filename, r.line)
+	}
+	if sprintf != nil {
+		if arg := nonConstantArg(sprintf); arg != nil {
+			return rewritten{}, fmt.Errorf("%s: the snapshot is built with fmt.Sprintf from %s, update it by hand",
+				fset.Position(arg.Pos()), exprKind(arg))
+		}
+		return rewritten{}, fmt.Errorf("%s: the snapshot is built with fmt.Sprintf, rerun with SNAP_COLLAPSE_SPRINTF=1 "+
+			"to replace it with a string literal", fset.Position(sprintf.Pos()))
+	}
+	if unsupported != nil {
+		return rewritten{}, fmt.Errorf("%s: the snapshot is %s, only string literals can be updated",
+			fset.Position(unsupported.Pos()), exprKind(unsupported))
+	}
+
+	sort.SliceStable(replacements, func(i, j int) bool { return replacements[i].start < replacements[j].start })
+
+	crlf := usesCRLF(src)
+	var buf bytes.Buffer
+	last := 0
+	for _, repl := range replacements {
+		buf.Write(src[last:repl.start])
+		text := repl.text
+		if crlf {
+			// Keep the line endings of the file. The compiler discards the carriage returns of raw
+			// string literals, so this doesn't change their value.
+			text = strings.ReplaceAll(text, "\n", "\r\n")
+		}
+		if repl.snapshot {
+			result.start = buf.Len()
+			result.end = buf.Len() + len(text)
+		}
+		buf.WriteString(text)
+		last = repl.end
+	}
+	buf.Write(src[last:])
+
+	// Make sure the result is still valid Go, or has the same syntax errors, before it's written
+	// anywhere.
+	if _, err := parser.ParseFile(token.NewFileSet(), filename, buf.Bytes(), parser.ParseComments); err != nil {
+		if !sameSyntaxErrors(err, syntaxErrs) {
+			return rewritten{}, err
+		}
+	}
+	result.src = buf.Bytes()
+	removed := 0
+	if collapsed {
+		// The collapsed call may have been the last use of fmt. Imports come before the snapshot, so
+		// removing one moves it back by the removed length.
+		src, err := imports.RemoveUnused(result.src, "fmt")
+		if err != nil {
+			return rewritten{}, err
+		}
+		removed = len(result.src) - len(src)
+		result.src, result.start, result.end = src, result.start-removed, result.end-removed
+	}
+
+	mode := r.format
+	if mode == formatNone && len(syntaxErrs) == 0 && gofmtClean(src) {
+		// Splicing in a literal of another length can misalign the comments and columns around it,
+		// which [finalizeSource] would refuse.
+		mode = formatDecl
+	}
+	switch {
+	case mode == formatNone || len(syntaxErrs) > 0:
+		if removed > 0 {
+			// The line of the snapshot moved too.
+			return r.relocate(filename, result)
+		}
+		return result, nil
+	case mode == formatFile:
+		result.src, err = format.Source(result.src)
+	case mode == formatDecl:
+		result.src, err = formatEnclosingDecl(filename, result.src, result.start)
+	}
+	if err != nil {
+		return rewritten{}, err
+	}
+	if crlf {
+		// The printer only writes newlines.
+		result.src = bytes.ReplaceAll(bytes.ReplaceAll(result.src, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n"))
+	}
+	// Formatting moves the literal, find it again.
+	return r.relocate(filename, result)
+}
+
+// syntaxErrorIn returns the first of the syntax errors errs within the top-level declaration decl,
+// or nil if there's none.
+func syntaxErrorIn(errs scanner.ErrorList, decl ast.Node, fset *token.FileSet) *scanner.Error {
+	if decl == nil {
+		return nil
+	}
+	start, end := fset.Position(decl.Pos()).Offset, fset.Position(decl.End()).Offset
+	for _, e := range errs {
+		if e.Pos.Offset >= start && e.Pos.Offset <= end {
+			return e
+		}
+	}
+	return nil
+}
+
+// sameSyntaxErrors reports whether the error of parsing a rewritten file is the syntax errors errs
+// of the original file. Their positions after the snapshot may have moved.
+func sameSyntaxErrors(err error, errs scanner.ErrorList) bool {
+	var got scanner.ErrorList
+	if len(errs) == 0 || !errors.As(err, &got) || len(got) != len(errs) {
+		return false
+	}
+	for i := range got {
+		if got[i].Msg != errs[i].Msg {
+			return false
+		}
+	}
+	return true
+}
+
+// formatMode controls how much of a file is formatted after a snapshot is updated.
+type formatMode int
+
+const (
+	formatNone formatMode = iota // Only splice in the literal.
+	formatDecl                   // Format the top-level declaration (usually the test function) containing the snapshot.
+	formatFile                   // Format the whole file, like gofmt.
+)
+
+// parseFormatMode parses the value of the SNAP_FORMAT environment variable.
+func parseFormatMode(s string) (formatMode, error) {
+	switch s {
+	case "", "none":
+		return formatNone, nil
+	case "func":
+		return formatDecl, nil
+	case "file":
+		return formatFile, nil
+	default:
+		return formatNone, fmt.Errorf("unknown SNAP_FORMAT %q, expected none, func or file", s)
+	}
+}
+
+// formatEnclosingDecl formats the top-level declaration of src containing offset.
+func formatEnclosingDecl(filename string, src []byte, offset int) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, decl := range f.Decls {
+		start, end := fset.Position(decl.Pos()).Offset, fset.Position(decl.End()).Offset
+		if offset < start || offset >= end {
+			continue
+		}
+
+		// The doc comment is outside of the declaration's range, don't print it twice.
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			d.Doc = nil
+		case *ast.GenDecl:
+			d.Doc = nil
+		}
+
+		var buf bytes.Buffer
+		buf.Write(src[:start])
+		if err := format.Node(&buf, fset, &printer.CommentedNode{Node: decl, Comments: f.Comments}); err != nil {
+			return nil, err
+		}
+		buf.Write(src[end:])
+		return buf.Bytes(), nil
+	}
+	return src, nil
+}
+
+// relocate updates the position of the snapshot literal in result, which is the result.arg'th
+// argument of the result.call'th call in the file.
+func (r rewrite) relocate(filename string, result rewritten) (rewritten, error) {
+	fset := token.NewFileSet()
+	// Files with syntax errors outside of the snapshot, see [rewrite.apply], are searched as far as
+	// they parse.
+	f, err := parser.ParseFile(fset, filename, result.src, parser.SkipObjectResolution)
+	if f == nil {
+		return rewritten{}, err
+	}
+
+	calls := 0
+	var arg ast.Expr
+	ast.Inspect(f, func(n ast.Node) bool {
+		callExpr, ok := n.(*ast.CallExpr)
+		if calls > result.call || !ok {
+			return calls <= result.call
+		}
+		if calls == result.call && result.arg >= 0 && result.arg < len(callExpr.Args) {
+			arg = callExpr.Args[result.arg]
+		}
+		calls++
+		return true
+	})
+	if arg == nil {
+		return rewritten{}, fmt.Errorf("%s: snapshot not found after formatting", filename)
+	}
+	result.pos = fset.Position(arg.Pos())
+	result.start, result.end = result.pos.Offset, fset.Position(arg.End()).Offset
+	return result, nil
+}
+
+// usesCRLF reports whether the lines of src end with "\r\n", judging by the first line.
+func usesCRLF(src []byte) bool {
+	i := bytes.IndexByte(src, '\n')
+	return i > 0 && src[i-1] == '\r'
+}
+
+// writeFile replaces the content of the file at path with data. It writes a temporary file next to
+// path first and renames it over path, so that a failed write never leaves a truncated source file
+// behind, and large snapshot files mapped into memory keep their content. With SNAP_BACKUP set,
+// path is backed up first and the write is synced to disk. The original content of path is
+// recorded in the undo journal, to roll back with `snap undo`. At most SNAP_MAX_WRITES files are
+// written at the same time.
+func writeFile(path string, data []byte) error {
+	release := acquireWrite()
+	defer release()
+	defer startSpan("write", "")()
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	original, err := readOriginal(path)
+	if err != nil {
+		return err
+	}
+	_, durable := os.LookupEnv("SNAP_BACKUP")
+	if durable {
+		if err := backupFile(path, perm); err != nil {
+			return fmt.Errorf("backing up: %w", err)
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".snap-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if durable {
+		if err := tmp.Sync(); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		if isMapped(path) {
+			return err
+		}
+		// On Windows, renaming over a file that another process (like an editor) holds open fails
+		// where writing to it may not.
+		if err := writeFileInPlace(path, data, perm, durable); err != nil {
+			return err
+		}
+	} else if durable {
+		syncDir(filepath.Dir(path))
+	}
+	if err := recordUndo(path, original, data); err != nil {
+		return fmt.Errorf("recording undo journal: %w", err)
+	}
+	return nil
+}
+
+// conflictMarker returns the line of the first merge conflict marker in src, like
+// "<<<<<<< HEAD". Rewriting a partially resolved file could otherwise succeed and hide the
+// conflict.
+func conflictMarker(src []byte) (line int, ok bool) {
+	for i, l := range bytes.Split(src, []byte("\n")) {
+		l = bytes.TrimSuffix(l, []byte("\r"))
+		for _, marker := range []string{"<<<<<<<", ">>>>>>>"} {
+			if rest, found := bytes.CutPrefix(l, []byte(marker)); found && (len(rest) == 0 || rest[0] == ' ') {
+				return i + 1, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// GoStringLiteral returns s as a Go string literal, written like snapshots are: a raw string
+// literal for text of several lines, so that it reads like the text itself, and an interpreted
+// string literal for text of a single line or that a raw string literal can't hold, like text
+// with backquotes, carriage returns, NUL bytes or invalid UTF-8. Bidirectional controls and
+// zero-width characters are always escaped, so that they can't hide in the source.
+func GoStringLiteral(s string) string {
+	return quoteLiteral(s, strings.Contains(s, "\n"))
+}
+
+// quoteLiteral returns text as a Go string literal, using a raw string literal if raw is set and
+// text can be represented as one.
+func quoteLiteral(text string, raw bool) string {
+	// Raw string literals can't contain backquotes, and carriage returns are discarded from them.
+	// Go source can't contain NUL bytes or invalid UTF-8. Bidirectional controls and zero-width
+	// characters are escaped so that they can't hide in the source.
+	if raw && !strings.ContainsAny(text, "`\r\x00") && utf8.ValidString(text) && !containsInvisible(text) {
+		return "`" + text + "`"
+	}
+	return strconv.Quote(text)
+}
+
+// isRawLiteral reports whether the string literal lit is a raw string literal.
+func isRawLiteral(lit string) bool {
+	return len(lit) >= 2 && lit[0] == '`' && lit[len(lit)-1] == '`'
+}
+
+// sprintfFormat returns the format string of expr if it's a fmt.Sprintf call.
+func sprintfFormat(expr ast.Expr) (format *ast.BasicLit, ok bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return nil, false
+	}
+	selExpr, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || selExpr.Sel.Name != "Sprintf" {
+		return nil, false
+	}
+	if pkg, ok := selExpr.X.(*ast.Ident); !ok || pkg.Name != "fmt" {
+		return nil, false
+	}
+	format, ok = call.Args[0].(*ast.BasicLit)
+	return format, ok && format.Kind == token.STRING
+}
+
+// enclosingStmt returns the innermost statement of a block in stack, the path to a node.
+func enclosingStmt(stack []ast.Node) ast.Stmt {
+	for i := len(stack) - 1; i > 0; i-- {
+		stmt, ok := stack[i].(ast.Stmt)
+		if !ok {
+			continue
+		}
+		switch stack[i-1].(type) {
+		case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+			return stmt
+		}
+	}
+	return nil
+}
+
+const (
+	foldBegin = "//snap:begin"
+	foldEnd   = "//snap:end"
+)
+
+// foldMarkers returns the replacements surrounding the statement at src[start:end] with the
+// //snap:begin and //snap:end comments that editors can fold. Nothing is returned if the statement
+// is already surrounded by them, or if it doesn't start on its own line.
+func foldMarkers(src []byte, start int, end int) []replacement {
+	lineStart := bytes.LastIndexByte(src[:start], '\n') + 1
+	indent := src[lineStart:start]
+	if len(bytes.TrimLeft(indent, " \t")) != 0 {
+		return nil
+	}
+
+	if lineStart > 0 {
+		prevLineStart := bytes.LastIndexByte(src[:lineStart-1], '\n') + 1
+		if string(bytes.TrimSpace(src[prevLineStart:lineStart])) == foldBegin {
+			return nil
+		}
+	}
+
+	return []replacement{
+		{start: lineStart, end: lineStart, text: string(indent) + foldBegin + "\n"},
+		{start: end, end: end, text: "\n" + string(indent) + foldEnd},
+	}
+}
+
+// isConcatenation reports whether expr is a concatenation of string literals, like "a\n" + "b\n".
+func isConcatenation(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return isConcatenation(e.X)
+	case *ast.BinaryExpr:
+		return e.Op == token.ADD && isStringOperand(e.X) && isStringOperand(e.Y)
+	default:
+		return false
+	}
+}
+
+func isStringOperand(expr ast.Expr) bool {
+	if lit, ok := expr.(*ast.BasicLit); ok {
+		return lit.Kind == token.STRING
+	}
+	return isConcatenation(expr)
+}
+
+// nonConstantArg returns the first argument after the format string of a fmt.Sprintf call which is
+// not a literal.
+func nonConstantArg(sprintf *ast.CallExpr) ast.Expr {
+	for _, arg := range sprintf.Args[1:] {
+		if _, ok := arg.(*ast.BasicLit); !ok {
+			return arg
+		}
+	}
+	return nil
+}
+
+// exprKind describes the kind of expression for diagnostics.
+func exprKind(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return fmt.Sprintf("the identifier %s", e.Name)
+	case *ast.CallExpr:
+		return "a function call"
+	case *ast.BinaryExpr:
+		return "a binary expression"
+	case *ast.BasicLit:
+		return "a " + strings.ToLower(e.Kind.String()) + " literal"
+	default:
+		return fmt.Sprintf("a %T", expr)
+	}
+}
+
+// preserveMarkers returns got, with every line that matches a line of the snapshot text (including
+// through markers like <snap:ignore>) replaced by that line of the snapshot. Lines of the
+// snapshot are passed through expand before matching, which substitutes template parameters.
+//
+// This keeps markers on lines that didn't change, and makes the update of a large snapshot a
+// minimal line-level edit instead of a rewrite of all of its lines.
+func preserveMarkers(snapshot string, got string, expand func(line string) string) string {
+	if !strings.Contains(snapshot, markerPrefix) {
+		return got
+	}
+
+	snapshotLines := strings.Split(snapshot, "\n")
+	gotLines := strings.Split(got, "\n")
+	expandedLines := make([]string, len(snapshotLines))
+	for i, line := range snapshotLines {
+		expandedLines[i] = expand(line)
+	}
+
+	var merged, expanded []string
+	for _, e := range diff.Lines(expandedLines, gotLines, lineMatches) {
+		switch e.Op {
+		case diff.Equal:
+			merged = append(merged, snapshotLines[e.A])
+			expanded = append(expanded, expandedLines[e.A])
+		case diff.Insert:
+			merged = append(merged, gotLines[e.B])
+			expanded = append(expanded, gotLines[e.B])
+		}
+	}
+
+	result := strings.Join(merged, "\n")
+	expandedResult := strings.Join(expanded, "\n")
+	// Markers can't be preserved at the very start or end of the snapshot, and matching line by
+	// line is not exactly the same as matching the whole text. Only keep the markers when the
+	// result is guaranteed to match.
+	normalized := checkMarker.ReplaceAllString(expandedResult, ignoreFmt)
+	if strings.HasPrefix(normalized, ignoreFmt) || strings.HasSuffix(normalized, ignoreFmt) ||
+		!matchIgnored(got, expandedResult) {
+		return got
+	}
+	return result
+}
+
+// lineMatches reports whether a single line of got matches a line of the snapshot text.
+func lineMatches(snapshotLine string, gotLine string) bool {
+	// Surround the lines with newlines, markers are allowed at the start and end of a line.
+	return matchIgnored("\n"+gotLine+"\n", "\n"+snapshotLine+"\n")
+}
@@ -0,0 +1,182 @@
+package snap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func identity(line string) string { return line }
+
+func TestPreserveMarkers(t *testing.T) {
+	cases := []struct {
+		snapshot, got, want string
+	}{
+		{snapshot: "a\nb", got: "a\nc", want: "a\nc"},
+		{
+			snapshot: "id: 1\ntime: <snap:ignore>\nname: foo",
+			got:      "id: 1\ntime: 12:00\nname: bar",
+			want:     "id: 1\ntime: <snap:ignore>\nname: bar",
+		},
+		{
+			snapshot: "{\n  \"time\": <snap:ignore>,\n  \"n\": 1\n}",
+			got:      "{\n  \"time\": 123,\n  \"extra\": true,\n  \"n\": 2\n}",
+			want:     "{\n  \"time\": <snap:ignore>,\n  \"extra\": true,\n  \"n\": 2\n}",
+		},
+		// Marker lines that no longer match are replaced.
+		{snapshot: "a\nt=<snap:ignore>\nb", got: "a\nx=1\nb!", want: "a\nx=1\nb!"},
+		// A marker can't end up at the start of the snapshot.
+		{snapshot: "a\n<snap:ignore> b\nc", got: "1 b\nd", want: "1 b\nd"},
+	}
+
+	for _, tc := range cases {
+		if got := preserveMarkers(tc.snapshot, tc.got, identity); got != tc.want {
+			t.Errorf("preserveMarkers(%q, %q):\nwant: %q\ngot:  %q", tc.snapshot, tc.got, tc.want, got)
+		}
+	}
+}
+
+func TestUpdatePreservesIgnoreMarkers(t *testing.T) {
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `id: 1\ntime: <snap:ignore> ms`).Diff(got)\n}\n", "id: 1\ntime: <snap:ignore> ms")
+	s.Diff("id: 2\ntime: 12 ms")
+
+	want := "snap.Snap(t, `id: 2\ntime: <snap:ignore> ms`)"
+	if got := readFile(t, path); !strings.Contains(got, want) {
+		t.Errorf("expected source to contain %s, got:\n%s", want, got)
+	}
+}
+
+func TestDiffReportsLiteralPosition(t *testing.T) {
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, \"want\").Diff(got)\n}\n", "want")
+	s.updateThis = false
+	s.Diff("got")
+
+	want := fmt.Sprintf("snap: Snapshot at %s:6:15 differs", path)
+	if len(ft.errors) != 1 || !strings.HasPrefix(ft.errors[0], want) {
+		t.Errorf("expected error starting with %q, got %q", want, ft.errors)
+	}
+}
+
+func TestUpdateRefusesConflicts(t *testing.T) {
+	ft := newFakeT(t)
+	src := "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, \"old\").Diff(got)\n}\n\n/*\n<<<<<<< HEAD\n=======\n>>>>>>> branch\n*/\n"
+	s, path := snapInFile(t, ft, src, "old")
+	s.Diff("new")
+
+	if len(ft.errors) != 2 || !strings.Contains(ft.errors[1], "example_test.go:10: file has merge conflict markers") {
+		t.Errorf("expected a conflict error, got %q", ft.errors)
+	}
+	if got := readFile(t, path); got != src {
+		t.Errorf("expected the file to be unchanged, got:\n%s", got)
+	}
+}
+
+func TestUpdateWithSyntaxErrors(t *testing.T) {
+	// A syntax error in another function doesn't prevent the update.
+	ft := newFakeT(t)
+	src := "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, \"old\").Diff(got)\n}\n\nfunc broken() {\n\tx := \n}\n"
+	s, path := snapInFile(t, ft, src, "old")
+	s.Diff("new")
+	if got, want := readFile(t, path), strings.Replace(src, `"old"`, `"new"`, 1); got != want {
+		t.Errorf("expected the snapshot to be updated, got:\n%s", got)
+	}
+
+	// A syntax error in the function of the snapshot does, and is reported.
+	ft = newFakeT(t)
+	src = "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, \"old\").Diff(got)\n\tx := \n}\n"
+	s, path = snapInFile(t, ft, src, "old")
+	s.Diff("new")
+	if len(ft.errors) != 2 || !strings.Contains(ft.errors[1], "example_test.go:8:1: syntax error, not updating the snapshot at line 6: expected operand") {
+		t.Errorf("expected a syntax error, got %q", ft.errors)
+	}
+	if got := readFile(t, path); got != src {
+		t.Errorf("expected the file to be unchanged, got:\n%s", got)
+	}
+}
+
+func TestUpdateLogsByteRange(t *testing.T) {
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, \"old\").Diff(got)\n}\n", "old")
+	s.Diff("new")
+
+	want := fmt.Sprintf("snap: Updated %s:6:15 (bytes 102-107)", path)
+	if !containsLog(ft, want) {
+		t.Errorf("expected log %q, got %q", want, ft.logs)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "example_test.go")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFile(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFile(t, path); got != "new" {
+		t.Errorf("expected new, got %q", got)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("expected the permissions to be kept, got %v", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("expected no temporary files to be left, got %v", entries)
+	}
+}
+
+func TestSnapLocationIsNative(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("paths only differ from runtime.Caller on Windows")
+	}
+	s := Snap(t, "")
+	if strings.Contains(s.location.file, "/") {
+		t.Errorf("expected a path with backslashes, got %s", s.location.file)
+	}
+}
+
+func TestUpdateTwice(t *testing.T) {
+	// Like go test -count=2, where the second run still has the old snapshot compiled in.
+	ft := newFakeT(t)
+	s, path := snapInFile(t, ft, "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `old\nsnapshot`).Version(1).Diff(got)\n}\n", "old\nsnapshot")
+	s = s.Version(1)
+	s.Diff("new")
+	s.Diff("new")
+
+	if len(ft.errors) != 1 {
+		t.Errorf("expected only the first comparison to fail, got %q", ft.errors)
+	}
+	want := "snap.Snap(t, `new`).Version(1)"
+	if got := readFile(t, path); !strings.Contains(got, want) {
+		t.Errorf("expected source to contain %s, got:\n%s", want, got)
+	}
+}
+
+func TestWriteFileBackup(t *testing.T) {
+	t.Setenv("SNAP_BACKUP", "1")
+	path := filepath.Join(t.TempDir(), "example_test.go")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range []string{"first", "second"} {
+		if err := writeFile(path, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := readFile(t, path); got != "second" {
+		t.Errorf("expected second, got %q", got)
+	}
+	if got := readFile(t, path+".orig"); got != "original" {
+		t.Errorf("expected the backup to hold the original source, got %q", got)
+	}
+}
@@ -0,0 +1,66 @@
+package snap
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// urlText matches absolute URLs, like "https://example.com/a?b=c", up to the first space or quote.
+var urlText = regexp.MustCompile(`\b[A-Za-z][A-Za-z0-9+.-]*://[^\s"'<>` + "`" + `]+`)
+
+// defaultPorts are the ports stripped from the URLs of their scheme by [URLs].
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+	"ftp":   "21",
+}
+
+// URLs is a [Normalizer] canonicalizing the absolute URLs of the value: their scheme and host are
+// lowercased, the default port of their scheme is stripped, and their query parameters are
+// sorted by name, keeping the order of repeated parameters. API clients often build query strings
+// from maps, in a different order on every run:
+//
+//	snap.Snap(t, "GET https://api.example.com/users?limit=10&page=2").Normalize(snap.URLs).Diff(log)
+//
+// The parameters are otherwise kept as they are, escaped the same way. Punctuation ending a
+// sentence after a URL is not part of it.
+func URLs(got string) string {
+	return urlText.ReplaceAllStringFunc(got, func(text string) string {
+		trimmed := strings.TrimRight(text, ".,;:!?)]")
+		return canonicalURL(trimmed) + text[len(trimmed):]
+	})
+}
+
+// canonicalURL returns the canonical form of the URL text, as [URLs] writes it, or text if it
+// isn't a valid absolute URL.
+func canonicalURL(text string) string {
+	u, err := url.Parse(text)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return text
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); port != "" && port == defaultPorts[u.Scheme] {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+	if u.RawQuery != "" {
+		params := strings.Split(u.RawQuery, "&")
+		sort.SliceStable(params, func(i, j int) bool {
+			nameI, _, _ := strings.Cut(params[i], "=")
+			nameJ, _, _ := strings.Cut(params[j], "=")
+			return nameI < nameJ
+		})
+		u.RawQuery = strings.Join(params, "&")
+	}
+	return u.String()
+}
+
+// isURL reports whether text is an absolute URL, with a scheme and a host, for <snap:url> markers.
+func isURL(text string) bool {
+	u, err := url.Parse(text)
+	return err == nil && u.Scheme != "" && u.Host != "" && !strings.ContainsAny(text, " \t\n")
+}
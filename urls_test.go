@@ -0,0 +1,22 @@
+package snap
+
+import "testing"
+
+func TestURLs(t *testing.T) {
+	cases := []struct {
+		got  string
+		want string
+	}{
+		{"GET https://API.Example.com:443/users?page=2&limit=10", "GET https://api.example.com/users?limit=10&page=2"},
+		{"http://example.com:8080/?b=1&a=2&b=0", "http://example.com:8080/?a=2&b=1&b=0"},
+		{"see HTTP://example.com:80/a%2Fb?q=a+b&next=%2F.", "see http://example.com/a%2Fb?next=%2F&q=a+b."},
+		{`"url": "wss://example.com:443/socket#Top"`, `"url": "wss://example.com/socket#Top"`},
+		{"(https://example.com/path)", "(https://example.com/path)"},
+		{"no urls in /users?b=1&a=2", "no urls in /users?b=1&a=2"},
+	}
+	for _, tc := range cases {
+		if got := URLs(tc.got); got != tc.want {
+			t.Errorf("URLs(%q): expected %q, got %q", tc.got, tc.want, got)
+		}
+	}
+}
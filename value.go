@@ -0,0 +1,229 @@
+package snap
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiffValue compares the snapshot with value pretty-printed in Go syntax, one field or element per
+// line, with map keys sorted and without pointer addresses, so that snapshots of structs and maps
+// don't change between runs:
+//
+//	snap.Snap(t, `snap_test.point{
+//	  X: 1,
+//	  Y: 2,
+//	}`).DiffValue(point{X: 1, Y: 2})
+//
+// Values implementing error or fmt.Stringer are printed with their Error or String method. With
+// [Snapshot.WithCmpOptions], the value is compared structurally with go-cmp instead.
+// It calls [testing.T.Error] when the snapshot is not equal to the value.
+func (s *Snapshot) DiffValue(value any) {
+	s.t.Helper()
+	got, ok := s.render(func() (string, error) { return formatValue(value), nil })
+	if !ok {
+		return
+	}
+	if s.cmpOptions != nil && s.matchesStructurally(value, got) {
+		return
+	}
+	s.Diff(got)
+}
+
+// formatValue pretty-prints value for [Snapshot.DiffValue].
+func formatValue(value any) string {
+	p := valuePrinter{visiting: make(map[visit]bool)}
+	p.print(reflect.ValueOf(value), 0, true)
+	return p.sb.String()
+}
+
+// valuePrinter pretty-prints values for [Snapshot.DiffValue].
+type valuePrinter struct {
+	sb strings.Builder
+	// visiting holds the pointers, maps and slices being printed, to print cycles once.
+	visiting map[visit]bool
+}
+
+// visit is a pointer, map or slice being printed. The type tells apart a pointer to a struct and a
+// pointer to its first field.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+var (
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// print prints v at the given depth of indentation. typed is whether the type of v must be
+// printed, which isn't the case for the elements of slices, arrays and maps, since their type is
+// the element type.
+func (p *valuePrinter) print(v reflect.Value, depth int, typed bool) {
+	if !v.IsValid() {
+		p.sb.WriteString("nil")
+		return
+	}
+	if v.CanInterface() && (v.Type().Implements(errorType) || v.Type().Implements(stringerType)) {
+		if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+			p.sb.WriteString("nil")
+			return
+		}
+		var text string
+		switch x := v.Interface().(type) {
+		case error:
+			text = x.Error()
+		case fmt.Stringer:
+			text = x.String()
+		}
+		fmt.Fprintf(&p.sb, "%s(%s)", v.Type(), strconv.Quote(text))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		p.scalar(v, typed, strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		p.scalar(v, typed, strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		p.scalar(v, typed, strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		p.scalar(v, typed, strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()))
+	case reflect.Complex64, reflect.Complex128:
+		p.scalar(v, typed, strconv.FormatComplex(v.Complex(), 'g', -1, v.Type().Bits()))
+	case reflect.String:
+		p.scalar(v, typed, strconv.Quote(v.String()))
+	case reflect.Pointer:
+		if v.IsNil() {
+			p.sb.WriteString("nil")
+			return
+		}
+		if !p.enter(v) {
+			fmt.Fprintf(&p.sb, "<cycle %s>", v.Type())
+			return
+		}
+		defer p.leave(v)
+		p.sb.WriteString("&")
+		p.print(v.Elem(), depth, true)
+	case reflect.Interface:
+		p.print(v.Elem(), depth, true)
+	case reflect.Struct:
+		p.sb.WriteString(v.Type().String())
+		p.block(depth, v.NumField(), func(i int) {
+			fmt.Fprintf(&p.sb, "%s: ", v.Type().Field(i).Name)
+			p.print(v.Field(i), depth+1, true)
+		})
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			p.sb.WriteString("nil")
+			return
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			fmt.Fprintf(&p.sb, "%s(%s)", v.Type(), strconv.Quote(string(v.Bytes())))
+			return
+		}
+		if v.Kind() == reflect.Slice && v.Len() > 0 {
+			if !p.enter(v) {
+				fmt.Fprintf(&p.sb, "<cycle %s>", v.Type())
+				return
+			}
+			defer p.leave(v)
+		}
+		p.sb.WriteString(v.Type().String())
+		p.block(depth, v.Len(), func(i int) {
+			p.print(v.Index(i), depth+1, v.Type().Elem().Kind() == reflect.Interface)
+		})
+	case reflect.Map:
+		if v.IsNil() {
+			p.sb.WriteString("nil")
+			return
+		}
+		if !p.enter(v) {
+			fmt.Fprintf(&p.sb, "<cycle %s>", v.Type())
+			return
+		}
+		defer p.leave(v)
+		// Sort the entries by key, numerically for numbers and by their printed keys otherwise.
+		type entry struct {
+			key     reflect.Value
+			printed string
+			value   reflect.Value
+		}
+		entries := make([]entry, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			kp := valuePrinter{visiting: p.visiting}
+			kp.print(iter.Key(), depth+1, v.Type().Key().Kind() == reflect.Interface)
+			entries = append(entries, entry{key: iter.Key(), printed: kp.sb.String(), value: iter.Value()})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			a, b := entries[i].key, entries[j].key
+			switch a.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				return a.Int() < b.Int()
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				return a.Uint() < b.Uint()
+			case reflect.Float32, reflect.Float64:
+				return a.Float() < b.Float()
+			}
+			return entries[i].printed < entries[j].printed
+		})
+		p.sb.WriteString(v.Type().String())
+		p.block(depth, len(entries), func(i int) {
+			p.sb.WriteString(entries[i].printed)
+			p.sb.WriteString(": ")
+			p.print(entries[i].value, depth+1, v.Type().Elem().Kind() == reflect.Interface)
+		})
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if v.IsNil() {
+			fmt.Fprintf(&p.sb, "%s(nil)", v.Type())
+		} else {
+			fmt.Fprintf(&p.sb, "%s(...)", v.Type())
+		}
+	default:
+		fmt.Fprintf(&p.sb, "<%s>", v.Type())
+	}
+}
+
+// scalar prints the literal text of the scalar v, converted to its type if it's a named type.
+func (p *valuePrinter) scalar(v reflect.Value, typed bool, text string) {
+	if typed && v.Type().PkgPath() != "" {
+		fmt.Fprintf(&p.sb, "%s(%s)", v.Type(), text)
+		return
+	}
+	p.sb.WriteString(text)
+}
+
+// block prints the n items of a composite value between braces, one per line.
+func (p *valuePrinter) block(depth int, n int, item func(i int)) {
+	if n == 0 {
+		p.sb.WriteString("{}")
+		return
+	}
+	p.sb.WriteString("{\n")
+	for i := 0; i < n; i++ {
+		p.sb.WriteString(strings.Repeat("  ", depth+1))
+		item(i)
+		p.sb.WriteString(",\n")
+	}
+	p.sb.WriteString(strings.Repeat("  ", depth))
+	p.sb.WriteString("}")
+}
+
+// enter records that the pointer, map or slice v is being printed, and reports whether it wasn't
+// already.
+func (p *valuePrinter) enter(v reflect.Value) bool {
+	key := visit{ptr: v.Pointer(), typ: v.Type()}
+	if p.visiting[key] {
+		return false
+	}
+	p.visiting[key] = true
+	return true
+}
+
+// leave records that v was printed.
+func (p *valuePrinter) leave(v reflect.Value) {
+	delete(p.visiting, visit{ptr: v.Pointer(), typ: v.Type()})
+}
@@ -0,0 +1,70 @@
+package snap
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type valueUser struct {
+	Name    string
+	Age     int
+	Admin   bool
+	Tags    []string
+	Scores  map[string]float64
+	Friend  *valueUser
+	Created time.Time
+	Err     error
+	Data    []byte
+	Any     any
+	Fn      func()
+	unused  map[int]string
+}
+
+func TestDiffValue(t *testing.T) {
+	u := &valueUser{
+		Name:    "alice",
+		Age:     30,
+		Tags:    []string{"a", "b"},
+		Scores:  map[string]float64{"math": 9.5, "art": 7},
+		Created: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Err:     errors.New("boom"),
+		Data:    []byte("hi"),
+		Any:     []any{1, "x", nil},
+		Fn:      func() {},
+		unused:  map[int]string{10: "ten", 9: "nine"},
+	}
+	u.Friend = u
+
+	Snap(t, `&snap.valueUser{
+  Name: "alice",
+  Age: 30,
+  Admin: false,
+  Tags: []string{
+    "a",
+    "b",
+  },
+  Scores: map[string]float64{
+    "art": 7,
+    "math": 9.5,
+  },
+  Friend: <cycle *snap.valueUser>,
+  Created: time.Time("2024-05-01 12:00:00 +0000 UTC"),
+  Err: error("boom"),
+  Data: []uint8("hi"),
+  Any: []interface {}{
+    1,
+    "x",
+    nil,
+  },
+  Fn: func()(...),
+  unused: map[int]string{
+    9: "nine",
+    10: "ten",
+  },
+}`).DiffValue(u)
+
+	Snap(t, `nil`).DiffValue(nil)
+	Snap(t, `time.Duration("1s")`).DiffValue(time.Second)
+	Snap(t, `map[string][]int{}`).DiffValue(map[string][]int{})
+}
@@ -0,0 +1,296 @@
+package snap
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// WithCmpOptions makes [Snapshot.DiffValue] compare the value with the snapshot structurally,
+// with go-cmp and opts, like cmpopts.EquateApproxTime, while the snapshot keeps the pretty-printed
+// form. The snapshot is read back into a value of the type of the value, and a value equal to it
+// with opts matches, even if it's printed differently, and isn't updated:
+//
+//	snap.Snap(t, `snap_test.event{
+//	  Name: "deploy",
+//	  At: time.Time("2024-05-01 12:00:00 +0000 UTC"),
+//	}`).WithCmpOptions(cmpopts.EquateApproxTime(time.Second)).DiffValue(got)
+//
+// Snapshots with markers, and snapshots that can't be read back, like values printed with their
+// Error or String method other than time.Time, are compared as text. So are mismatches, which are
+// reported and updated as usual.
+func (s *Snapshot) WithCmpOptions(opts ...cmp.Option) *Snapshot {
+	c := *s
+	c.cmpOptions = append(cmp.Options{}, opts...)
+	return &c
+}
+
+// matchesStructurally reports whether the snapshot, read back into a value of the type of value,
+// equals value with the options set by [Snapshot.WithCmpOptions]. got is the pretty-printed value.
+func (s *Snapshot) matchesStructurally(value any, got string) bool {
+	s.t.Helper()
+	text := s.String()
+	if text == got || strings.Contains(text, "<snap:") || value == nil {
+		return false
+	}
+	want, err := parseValue(text, reflect.ValueOf(value))
+	if err != nil {
+		s.logf(slog.LevelDebug, "Comparing the snapshot at %s as text: %s", s.findLiteral(), err)
+		return false
+	}
+	if !cmpEqual(want.Interface(), value, s.cmpOptions) {
+		return false
+	}
+	s.logf(slog.LevelDebug, "Snapshot at %s matches the value with its cmp options: (-want +got):\n%s",
+		s.findLiteral(), cmp.Diff(text, got))
+	return true
+}
+
+// cmpEqual is [cmp.Equal], reporting false for values cmp.Equal panics on, like structs with
+// unexported fields that opts don't handle.
+func cmpEqual(x any, y any, opts cmp.Options) (equal bool) {
+	defer func() {
+		if recover() != nil {
+			equal = false
+		}
+	}()
+	return cmp.Equal(x, y, opts)
+}
+
+// parseValue reads text, printed by [formatValue], back into a value of the type of like. The
+// dynamic types of interfaces are those of the values at the same places in like.
+func parseValue(text string, like reflect.Value) (reflect.Value, error) {
+	fset := token.NewFileSet()
+	expr, err := parser.ParseExprFrom(fset, "", text, 0)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	p := valueParser{fset: fset, text: text}
+	return p.parse(expr, like.Type(), like)
+}
+
+// valueParser reads the values printed by [formatValue] back.
+type valueParser struct {
+	fset *token.FileSet
+	text string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// source returns the text of e.
+func (p *valueParser) source(e ast.Node) string {
+	return p.text[p.fset.Position(e.Pos()).Offset:p.fset.Position(e.End()).Offset]
+}
+
+// parse returns the value of type typ printed as e. like is the value of the same place in the
+// value compared with the snapshot, if any, for the dynamic types of interfaces.
+func (p *valueParser) parse(e ast.Expr, typ reflect.Type, like reflect.Value) (reflect.Value, error) {
+	v := reflect.New(typ).Elem()
+	if id, ok := e.(*ast.Ident); ok && id.Name == "nil" {
+		switch typ.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+			return v, nil
+		}
+	}
+	if typ == timeType {
+		s, err := p.stringArg(e, typ)
+		if err != nil {
+			return v, err
+		}
+		// The monotonic clock reading isn't part of the time.
+		s, _, _ = strings.Cut(s, " m=")
+		t, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", s)
+		if err != nil {
+			return v, err
+		}
+		v.Set(reflect.ValueOf(t))
+		return v, nil
+	}
+	if typ.Implements(errorType) || typ.Implements(stringerType) {
+		return v, fmt.Errorf("%s is printed with its Error or String method", typ)
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(p.source(p.unconvert(e, typ)))
+		v.SetBool(b)
+		return v, err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(p.source(p.unconvert(e, typ)), 10, typ.Bits())
+		v.SetInt(n)
+		return v, err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(p.source(p.unconvert(e, typ)), 10, typ.Bits())
+		v.SetUint(n)
+		return v, err
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(p.source(p.unconvert(e, typ)), typ.Bits())
+		v.SetFloat(f)
+		return v, err
+	case reflect.Complex64, reflect.Complex128:
+		c, err := strconv.ParseComplex(p.source(p.unconvert(e, typ)), typ.Bits())
+		v.SetComplex(c)
+		return v, err
+	case reflect.String:
+		s, err := strconv.Unquote(p.source(p.unconvert(e, typ)))
+		v.SetString(s)
+		return v, err
+	case reflect.Pointer:
+		u, ok := e.(*ast.UnaryExpr)
+		if !ok || u.Op != token.AND {
+			return v, fmt.Errorf("expected a pointer to %s, got %s", typ.Elem(), p.source(e))
+		}
+		if like.IsValid() && !like.IsNil() {
+			like = like.Elem()
+		} else {
+			like = reflect.Value{}
+		}
+		elem, err := p.parse(u.X, typ.Elem(), like)
+		if err != nil {
+			return v, err
+		}
+		v.Set(reflect.New(typ.Elem()))
+		v.Elem().Set(elem)
+		return v, nil
+	case reflect.Interface:
+		if !like.IsValid() || like.IsNil() {
+			return v, fmt.Errorf("no value to take the dynamic type of %s from", p.source(e))
+		}
+		elem, err := p.parse(e, like.Elem().Type(), like.Elem())
+		if err != nil {
+			return v, err
+		}
+		v.Set(elem)
+		return v, nil
+	case reflect.Struct:
+		lit, err := p.compositeLit(e, typ)
+		if err != nil {
+			return v, err
+		}
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				return v, fmt.Errorf("expected a field of %s, got %s", typ, p.source(elt))
+			}
+			field, ok := typ.FieldByName(p.source(kv.Key))
+			if !ok || len(field.Index) != 1 {
+				return v, fmt.Errorf("%s has no field %s", typ, p.source(kv.Key))
+			}
+			var likeField reflect.Value
+			if like.IsValid() {
+				likeField = like.Field(field.Index[0])
+			}
+			f, err := p.parse(kv.Value, field.Type, likeField)
+			if err != nil {
+				return v, err
+			}
+			// Unexported fields are set too, the value is only compared.
+			dst := v.Field(field.Index[0])
+			reflect.NewAt(dst.Type(), unsafe.Pointer(dst.UnsafeAddr())).Elem().Set(f)
+		}
+		return v, nil
+	case reflect.Slice, reflect.Array:
+		if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+			s, err := p.stringArg(e, typ)
+			if err != nil {
+				return v, err
+			}
+			v.SetBytes([]byte(s))
+			return v, nil
+		}
+		lit, err := p.compositeLit(e, typ)
+		if err != nil {
+			return v, err
+		}
+		if typ.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(typ, len(lit.Elts), len(lit.Elts)))
+		} else if len(lit.Elts) != typ.Len() {
+			return v, fmt.Errorf("expected %d elements of %s, got %d", typ.Len(), typ, len(lit.Elts))
+		}
+		for i, elt := range lit.Elts {
+			var likeElem reflect.Value
+			if like.IsValid() && i < like.Len() {
+				likeElem = like.Index(i)
+			}
+			elem, err := p.parse(elt, typ.Elem(), likeElem)
+			if err != nil {
+				return v, err
+			}
+			v.Index(i).Set(elem)
+		}
+		return v, nil
+	case reflect.Map:
+		lit, err := p.compositeLit(e, typ)
+		if err != nil {
+			return v, err
+		}
+		v.Set(reflect.MakeMapWithSize(typ, len(lit.Elts)))
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				return v, fmt.Errorf("expected an entry of %s, got %s", typ, p.source(elt))
+			}
+			key, err := p.parse(kv.Key, typ.Key(), reflect.Value{})
+			if err != nil {
+				return v, err
+			}
+			var likeElem reflect.Value
+			if like.IsValid() && !like.IsNil() {
+				likeElem = like.MapIndex(key)
+			}
+			elem, err := p.parse(kv.Value, typ.Elem(), likeElem)
+			if err != nil {
+				return v, err
+			}
+			v.SetMapIndex(key, elem)
+		}
+		return v, nil
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		// Compared as text, since func() (nil) parses as a function type.
+		if p.source(e) == typ.String()+"(nil)" {
+			return v, nil
+		}
+	}
+	return v, fmt.Errorf("values of type %s can't be read back", typ)
+}
+
+// unconvert returns the operand of e if it's a conversion to typ, like the named scalars printed
+// by [formatValue], and e otherwise.
+func (p *valueParser) unconvert(e ast.Expr, typ reflect.Type) ast.Expr {
+	if call, ok := e.(*ast.CallExpr); ok && len(call.Args) == 1 && p.source(call.Fun) == typ.String() {
+		return call.Args[0]
+	}
+	return e
+}
+
+// stringArg returns the string that e converts to typ, like the byte slices printed by
+// [formatValue].
+func (p *valueParser) stringArg(e ast.Expr, typ reflect.Type) (string, error) {
+	arg := p.unconvert(e, typ)
+	if arg == e {
+		return "", fmt.Errorf("expected a conversion to %s, got %s", typ, p.source(e))
+	}
+	return strconv.Unquote(p.source(arg))
+}
+
+// compositeLit returns e as a composite literal of type typ.
+func (p *valueParser) compositeLit(e ast.Expr, typ reflect.Type) (*ast.CompositeLit, error) {
+	lit, ok := e.(*ast.CompositeLit)
+	if !ok || lit.Type == nil {
+		return nil, errors.New("expected a composite literal, got " + p.source(e))
+	}
+	if got := p.source(lit.Type); got != typ.String() {
+		return nil, fmt.Errorf("expected a %s, got a %s", typ, got)
+	}
+	return lit, nil
+}
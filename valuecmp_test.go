@@ -0,0 +1,116 @@
+package snap
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+type cmpEvent struct {
+	Name   string
+	At     time.Time
+	Score  float64
+	Tags   map[string][]int
+	Parent *cmpEvent
+	Extra  any
+	Data   []byte
+	secret int
+}
+
+func TestWithCmpOptions(t *testing.T) {
+	snapshot := `&snap.cmpEvent{
+  Name: "deploy",
+  At: time.Time("2024-05-01 12:00:00 +0000 UTC"),
+  Score: 0.3,
+  Tags: map[string][]int{
+    "a": []int{
+      1,
+      -2,
+    },
+  },
+  Parent: nil,
+  Extra: []interface {}{
+    "x",
+  },
+  Data: []uint8("hi"),
+  secret: 7,
+}`
+	got := &cmpEvent{
+		Name:   "deploy",
+		At:     time.Date(2024, 5, 1, 12, 0, 0, 400_000_000, time.UTC),
+		Score:  0.30000000000000004,
+		Tags:   map[string][]int{"a": {1, -2}},
+		Extra:  []any{"x"},
+		Data:   []byte("hi"),
+		secret: 7,
+	}
+	opts := cmp.Options{cmpopts.EquateApproxTime(time.Second), cmpopts.EquateApprox(0, 1e-9), cmpopts.IgnoreUnexported(cmpEvent{})}
+
+	// The time and the float differ in their printed form, but not with the options.
+	ft := newFakeT(t)
+	snapNoUpdate(ft, snapshot).WithCmpOptions(opts).DiffValue(got)
+	if len(ft.errors) != 0 {
+		t.Errorf("expected the value to match structurally, got %q", ft.errors)
+	}
+
+	// Nor is it updated.
+	src := "package example\n\nimport \"github.com/KasonBraley/snap\"\n\nfunc TestExample(t *testing.T) {\n\tsnap.Snap(t, `" + snapshot + "`).DiffValue(got)\n}\n"
+	ft = newFakeT(t)
+	s, path := snapInFile(t, ft, src, snapshot)
+	s.WithCmpOptions(opts).DiffValue(got)
+	if readFile(t, path) != src {
+		t.Errorf("expected the snapshot not to be updated, got:\n%s", readFile(t, path))
+	}
+
+	// Without them, or beyond their tolerance, the printed forms are compared.
+	ft = newFakeT(t)
+	snapNoUpdate(ft, snapshot).DiffValue(got)
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "Score: 0.30000000000000004,") {
+		t.Errorf("expected a mismatch without options, got %q", ft.errors)
+	}
+	later := *got
+	later.At = later.At.Add(time.Minute)
+	ft = newFakeT(t)
+	snapNoUpdate(ft, snapshot).WithCmpOptions(opts).DiffValue(&later)
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `At: time.Time("2024-05-01 12:01:00.4 +0000 UTC"),`) {
+		t.Errorf("expected a mismatch beyond the tolerance, got %q", ft.errors)
+	}
+
+	// Unexported fields are compared too unless the options ignore them.
+	changed := *got
+	changed.secret = 8
+	ft = newFakeT(t)
+	snapNoUpdate(ft, snapshot).WithCmpOptions(opts[:2]...).DiffValue(&changed)
+	if len(ft.errors) != 1 {
+		t.Errorf("expected a mismatch of the unexported field, got %q", ft.errors)
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	values := []any{
+		&valueUser{Name: "bob", Tags: []string{}, Scores: map[string]float64{"x": -1.5}, Data: []byte("\x00")},
+		map[int]string{2: "b", 1: "a"},
+		[2]bool{true, false},
+		[]complex128{1 + 2i},
+		struct{ N uint8 }{N: 255},
+	}
+	for _, value := range values {
+		v, err := parseValue(formatValue(value), reflect.ValueOf(value))
+		if err != nil {
+			t.Errorf("parseValue(%s): %s", formatValue(value), err)
+			continue
+		}
+		if diff := cmp.Diff(value, v.Interface(), cmpopts.IgnoreUnexported(valueUser{})); diff != "" {
+			t.Errorf("parseValue(%s): (-want +got):\n%s", formatValue(value), diff)
+		}
+	}
+
+	// Values printed with their String method can't be read back.
+	if _, err := parseValue(`time.Duration("1s")`, reflect.ValueOf(time.Second)); err == nil {
+		t.Errorf("expected an error for a Stringer")
+	}
+}
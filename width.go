@@ -0,0 +1,58 @@
+package snap
+
+import (
+	"unicode"
+)
+
+// displayWidth returns the number of terminal columns text takes: 2 for wide characters, like CJK
+// ideographs and most emoji, 0 for combining marks, format characters like zero-width joiners and
+// variation selectors, and the characters an emoji sequence joins or modifies, and 1 for the rest.
+func displayWidth(text string) int {
+	width := 0
+	joined := false // The previous rune was a zero-width joiner.
+	for _, r := range text {
+		w := runeWidth(r)
+		if joined && w > 0 {
+			w = 0
+		}
+		joined = r == '\u200d'
+		width += w
+	}
+	return width
+}
+
+// runeWidth returns the number of terminal columns of r on its own.
+func runeWidth(r rune) int {
+	switch {
+	case r == '\t':
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf, unicode.Cc):
+		return 0
+	case r >= 0x1f3fb && r <= 0x1f3ff: // Emoji skin tone modifiers.
+		return 0
+	case wideRune(r):
+		return 2
+	}
+	return 1
+}
+
+// wideRune reports whether r is a wide character of the East Asian scripts or an emoji presented
+// as one by default.
+func wideRune(r rune) bool {
+	return r >= 0x1100 && r <= 0x115f || // Hangul Jamo initials.
+		r >= 0x2e80 && r <= 0x303e || // CJK radicals and punctuation.
+		r >= 0x3041 && r <= 0x33ff || // Kana, Bopomofo and CJK compatibility.
+		r >= 0x3400 && r <= 0x4dbf || // CJK extension A.
+		r >= 0x4e00 && r <= 0x9fff || // CJK unified ideographs.
+		r >= 0xa000 && r <= 0xa4cf || // Yi.
+		r >= 0xac00 && r <= 0xd7a3 || // Hangul syllables.
+		r >= 0xf900 && r <= 0xfaff || // CJK compatibility ideographs.
+		r >= 0xfe30 && r <= 0xfe4f || // CJK compatibility forms.
+		r >= 0xff00 && r <= 0xff60 || // Fullwidth forms.
+		r >= 0xffe0 && r <= 0xffe6 ||
+		r >= 0x1f300 && r <= 0x1f64f || // Pictographs and emoticons.
+		r >= 0x1f900 && r <= 0x1f9ff || // Supplemental pictographs.
+		r >= 0x1fa70 && r <= 0x1faff ||
+		r >= 0x1f680 && r <= 0x1f6ff || // Transport and map symbols.
+		r >= 0x20000 && r <= 0x3fffd // CJK extensions B and later.
+}
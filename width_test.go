@@ -0,0 +1,66 @@
+package snap
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	cases := []struct {
+		text  string
+		width int
+	}{
+		{text: "hello", width: 5},
+		{text: "日本語", width: 6},
+		{text: "한국어", width: 6},
+		{text: "ｆｕｌｌ", width: 8},
+		{text: "e\u0301te\u0301", width: 3},                            // Combining acute accents.
+		{text: "ok 👍", width: 5},                                       // Emoji.
+		{text: "\U0001f44d\U0001f3fd", width: 2},                       // Skin tone modifier.
+		{text: "\U0001f468\u200d\U0001f469\u200d\U0001f467", width: 2}, // Zero-width joiner sequence.
+		{text: "a\u200bb", width: 2},                                   // Zero-width space.
+		{text: "\u2764\ufe0f", width: 1},                               // Variation selector.
+		{text: "tab\there", width: 8},
+	}
+	for _, tc := range cases {
+		if got := displayWidth(tc.text); got != tc.width {
+			t.Errorf("expected %q to be %d columns wide, got %d", tc.text, tc.width, got)
+		}
+	}
+}
+
+func TestWrapLinesWide(t *testing.T) {
+	got := WrapLines(10)("  日本語 テキスト 👍👍 done")
+	want := "  日本語\n  テキスト\n  👍👍\n  done"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if displayWidth(line) > 10 {
+			t.Errorf("line %q is wider than 10 columns", line)
+		}
+	}
+}
+
+func TestComputeDiffUnicode(t *testing.T) {
+	want := "名前: 太郎\nstatus: 👍\nna\u00efve\nend"
+	got := "名前: 花子\nstatus: 👎\nnai\u0308ve\nend"
+
+	d := ComputeDiff(want, got, ContextLines(0))
+	if d.Stats.Added != 3 || d.Stats.Removed != 3 {
+		t.Errorf("expected 3 lines added and removed, got %s", d.Stats)
+	}
+	// "naïve" is spelled with a precomposed ï in want and a combining diaeresis in got, so it
+	// differs, but the diff keeps the combining mark with its letter.
+	for _, h := range d.Hunks {
+		for _, l := range h.Lines {
+			if !utf8.ValidString(l.Text) || strings.HasPrefix(l.Text, "\u0308") {
+				t.Errorf("diff line %q splits a character", l.Text)
+			}
+		}
+	}
+	if !utf8.ValidString(d.Text) || !strings.Contains(d.Text, "+status: 👎\n") {
+		t.Errorf("unexpected diff text:\n%s", d.Text)
+	}
+}
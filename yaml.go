@@ -0,0 +1,233 @@
+package snap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DiffYAML compares the snapshot with the YAML serialization of value, for configuration-like
+// values that are easier to read as YAML than as JSON:
+//
+//	snap.Snap(t, `name: api
+//	replicas: 3
+//	ports:
+//	  - 80
+//	  - 443`).DiffYAML(config)
+//
+// The value is first marshaled with encoding/json, so its json struct tags and MarshalJSON
+// methods apply, and struct fields keep their order while map keys are sorted. Strings are
+// quoted only when needed, and multi-line strings are written as literal blocks.
+// It calls [testing.T.Error] when the snapshot is not equal to the value or when an error is
+// encountered elsewhere.
+func (s *Snapshot) DiffYAML(value any) {
+	s.t.Helper()
+	got, ok := s.render(func() (string, error) { return marshalYAML(value) })
+	if !ok {
+		return
+	}
+	s.Diff(got)
+}
+
+// yamlNode is a JSON value, keeping the order of the keys of objects.
+type yamlNode struct {
+	scalar string // The YAML text of a scalar, empty for objects and arrays.
+	object bool
+	keys   []string
+	values []*yamlNode // The values of the keys of an object, or the elements of an array.
+}
+
+// marshalYAML returns the YAML serialization of value.
+func marshalYAML(value any) (string, error) {
+	e := getJSONEncoder("")
+	defer e.release()
+	data, err := e.encode(value)
+	if err != nil {
+		return "", err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	n, err := decodeYAMLNode(dec)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	writeYAML(&sb, n, 0)
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// decodeYAMLNode decodes the next JSON value of dec.
+func decodeYAMLNode(dec *json.Decoder) (*yamlNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		n := &yamlNode{object: tok == '{'}
+		for dec.More() {
+			if n.object {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key.(string))
+			}
+			v, err := decodeYAMLNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.values = append(n.values, v)
+		}
+		// Consume the closing delimiter.
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case string:
+		return &yamlNode{scalar: yamlString(tok)}, nil
+	case json.Number:
+		return &yamlNode{scalar: tok.String()}, nil
+	case bool:
+		return &yamlNode{scalar: strconv.FormatBool(tok)}, nil
+	case nil:
+		return &yamlNode{scalar: "null"}, nil
+	default:
+		return nil, fmt.Errorf("unexpected JSON token %v", tok)
+	}
+}
+
+// inline returns the text of n if it's written on the line of its key or dash: scalars, empty
+// objects and empty arrays.
+func (n *yamlNode) inline() (string, bool) {
+	switch {
+	case n.scalar != "":
+		return n.scalar, true
+	case len(n.values) > 0:
+		return "", false
+	case n.object:
+		return "{}", true
+	default:
+		return "[]", true
+	}
+}
+
+// writeYAML writes n with depth levels of indentation, followed by a newline.
+func writeYAML(sb *strings.Builder, n *yamlNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+	if text, ok := n.inline(); ok {
+		sb.WriteString(indent)
+		sb.WriteString(blockIndent(text, indent))
+		sb.WriteString("\n")
+		return
+	}
+	for i, v := range n.values {
+		var prefix string
+		if n.object {
+			prefix = yamlKey(n.keys[i]) + ":"
+		} else {
+			prefix = "-"
+		}
+		if text, ok := v.inline(); ok {
+			fmt.Fprintf(sb, "%s%s %s\n", indent, prefix, blockIndent(text, indent))
+			continue
+		}
+		if n.object {
+			fmt.Fprintf(sb, "%s%s\n", indent, prefix)
+			writeYAML(sb, v, depth+1)
+			continue
+		}
+		// Elements of arrays start on the line of their dash, which takes one level of indentation.
+		var elem strings.Builder
+		writeYAML(&elem, v, depth+1)
+		sb.WriteString(indent)
+		sb.WriteString("- ")
+		sb.WriteString(strings.TrimPrefix(elem.String(), indent+"  "))
+	}
+}
+
+// blockIndent indents the lines of a literal block scalar after the first one with indent, one
+// level deeper.
+func blockIndent(text string, indent string) string {
+	if !strings.HasPrefix(text, "|") {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + "  " + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// yamlKey returns the YAML text of the key s, plain or double-quoted.
+func yamlKey(s string) string {
+	if plainYAML(s) {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// yamlString returns the YAML text of the string s: plain when it can't be read as another value,
+// a literal block for multi-line strings, or double-quoted otherwise. Literal blocks start with
+// "|", and their lines after the first are not indented yet.
+func yamlString(s string) string {
+	if plainYAML(s) {
+		return s
+	}
+	if block, ok := literalBlock(s); ok {
+		return block
+	}
+	return strconv.Quote(s)
+}
+
+// plainYAML reports whether s can be written as a plain YAML scalar.
+func plainYAML(s string) bool {
+	if s == "" || s != strings.TrimSpace(s) || strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return false
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "null", "~", "yes", "no", "on", "off", "y", "n":
+		return false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil || strings.HasPrefix(s, ".") || unicode.IsDigit(rune(s[0])) {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// literalBlock returns s as a literal block scalar, "|" when s ends with one newline or "|-" when
+// it doesn't, if s can be written as one.
+func literalBlock(s string) (string, bool) {
+	body, ok := strings.CutSuffix(s, "\n")
+	header := "|"
+	if !ok {
+		header = "|-"
+	}
+	if !strings.Contains(s, "\n") || strings.HasSuffix(body, "\n") || strings.HasPrefix(body, " ") || strings.HasPrefix(body, "\n") {
+		return "", false
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if line != strings.TrimRight(line, " \t") {
+			return "", false
+		}
+		for _, r := range line {
+			if !unicode.IsPrint(r) && r != '\t' {
+				return "", false
+			}
+		}
+	}
+	return header + "\n" + body, true
+}
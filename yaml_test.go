@@ -0,0 +1,71 @@
+package snap
+
+import "testing"
+
+func TestDiffYAML(t *testing.T) {
+	type port struct {
+		Name string `json:"name"`
+		Port int    `json:"port"`
+	}
+	type config struct {
+		Service  string            `json:"service"`
+		Replicas int               `json:"replicas"`
+		Ports    []port            `json:"ports"`
+		Labels   map[string]string `json:"labels"`
+		Matrix   [][]int           `json:"matrix"`
+		Script   string            `json:"script"`
+		Env      []string          `json:"env"`
+		Extra    map[string]any    `json:"extra,omitempty"`
+	}
+	Snap(t, `service: api
+replicas: 3
+ports:
+  - name: http
+    port: 80
+  - name: https
+    port: 443
+labels:
+  "app: name": "true"
+  tier: web
+matrix:
+  - - 1
+    - 2
+  - []
+script: |
+  set -e
+  make test
+env: []`).DiffYAML(config{
+		Service:  "api",
+		Replicas: 3,
+		Ports:    []port{{"http", 80}, {"https", 443}},
+		Labels:   map[string]string{"tier": "web", "app: name": "true"},
+		Matrix:   [][]int{{1, 2}, {}},
+		Script:   "set -e\nmake test\n",
+		Env:      []string{},
+	})
+
+	Snap(t, `"42"`).DiffYAML("42")
+	Snap(t, `null`).DiffYAML(nil)
+}
+
+func TestYAMLString(t *testing.T) {
+	cases := map[string]string{
+		"plain":        "plain",
+		"":             `""`,
+		" padded":      `" padded"`,
+		"yes":          `"yes"`,
+		"1.5":          `"1.5"`,
+		"- item":       `"- item"`,
+		"a # comment":  `"a # comment"`,
+		"a\nb":         "|-\na\nb",
+		"a \nb":        `"a \nb"`,
+		"tab\there":    `"tab\there"`,
+		"with: colon":  `"with: colon"`,
+		"http://x.com": "http://x.com",
+	}
+	for s, want := range cases {
+		if got := yamlString(s); got != want {
+			t.Errorf("yamlString(%q) = %q, want %q", s, got, want)
+		}
+	}
+}